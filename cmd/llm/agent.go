@@ -0,0 +1,193 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/internal/history"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// Agent limits, unless agent_max_steps or agent_max_tokens are set
+const (
+	defaultAgentSteps  = 10
+	defaultAgentTokens = 100000
+)
+
+// Limits on each command the agent runs
+const (
+	agentCommandTimeout = 5 * time.Minute
+	agentOutputTokens   = 1000
+)
+
+const agentUsage = `usage: llm agent [--max-steps N] [--max-tokens N] "<goal>"`
+
+// runAgent works towards a goal by repeatedly asking the model for the next
+// command, running it once the user agrees, and showing the model what
+// happened. It stops when the model says the goal is met, the user declines
+// a command, or a step or token limit is reached. The run is saved to the
+// history however it ends.
+func runAgent(args []string) (err error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	opts := &options{mode: llm.AgentMode}
+	var maxSteps, maxTokens int
+	flagSet := flag.NewFlagSet("llm agent", flag.ContinueOnError)
+	flagSet.IntVar(&maxSteps, "max-steps", cmp.Or(cfg.Int("agent_max_steps"), defaultAgentSteps), "Stop after this many commands")
+	flagSet.IntVar(&maxTokens, "max-tokens", cmp.Or(cfg.Int("agent_max_tokens"), defaultAgentTokens), "Stop once about this many tokens have been sent and received")
+	flagSet.BoolVar(&opts.context, "context", cfg.Bool("context"), "Include project context in the prompt")
+	flagSet.BoolVar(&opts.noRedact, "no-redact", false, "Send command output without redacting secrets")
+	opts.log.register(flagSet)
+	flagSet.Usage = func() {
+		fmt.Fprintln(os.Stderr, agentUsage)
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	opts.query = expandAliases(strings.Join(flagSet.Args(), " "), cfg)
+	if opts.query == "" {
+		return usageError(agentUsage)
+	}
+
+	sys := llm.DetectSystem()
+	if opts.context {
+		if wd, err := os.Getwd(); err == nil {
+			project := llm.DetectProject(wd)
+			sys.Project = &project
+		}
+	}
+	if err := opts.log.setup(); err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+	client.Retries = unattendedRetries
+
+	ctx := context.Background()
+	start := time.Now()
+	var transcript strings.Builder
+	used := 0
+	outcome := ""
+	var stopErr error
+	// The whole run is kept as one history entry, including the commands
+	// that ran before an error
+	defer func() {
+		if outcome == "" && err != nil {
+			outcome = "Stopped: " + err.Error()
+		}
+		saveAgentRun(cfg, client, opts, start, transcript.String()+outcome)
+	}()
+	for step := 1; outcome == ""; step++ {
+		switch {
+		case step > maxSteps:
+			outcome = fmt.Sprintf("Stopped after %d steps (--max-steps)", maxSteps)
+			continue
+		case used >= maxTokens:
+			outcome = fmt.Sprintf("Stopped after about %d tokens (--max-tokens)", used)
+			continue
+		}
+
+		stepSys := sys
+		if transcript.Len() > 0 {
+			stepSys.Attachments = []llm.Attachment{{Title: "Steps so far", Content: transcript.String()}}
+		}
+		response, tokens, err := requery(ctx, cfg, client, opts, stepSys)
+		used += tokens
+		if errors.Is(err, errInterrupted) {
+			// Keep the steps so far, which may have changed things
+			outcome, stopErr = "Stopped: interrupted", err
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		next, err := llm.ParseAgentStep(response)
+		if err != nil {
+			return err
+		}
+		if next.Done {
+			outcome = next.Summary
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, "\n[%d/%d] %s\n$ %s\n", step, maxSteps, next.Thought, next.Command)
+		warnDangers(next.Command)
+		ok, err := confirm("Run it?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintf(&transcript, "$ %s\n(declined by the user)\n\n", next.Command)
+			outcome = "Stopped: the user declined a command"
+			continue
+		}
+
+		output, status := runAgentCommand(ctx, sys.Shell, next.Command)
+		fmt.Fprintf(&transcript, "$ %s\n%s\n(exit status %d)\n\n",
+			next.Command, llm.TruncateMiddle(strings.TrimRight(output, "\n"), agentOutputTokens), status)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Println(outcome)
+	return stopErr
+}
+
+// saveAgentRun saves an agent run, its commands and how it ended, to the
+// history
+func saveAgentRun(cfg *config.Config, client *llm.Client, opts *options, start time.Time, record string) {
+	goal := opts.query
+	if !opts.noRedact {
+		record, _ = llm.Redact(record)
+		goal, _ = llm.Redact(goal)
+	}
+	if err := saveHistory(cfg, history.Entry{
+		Time:     start,
+		Mode:     opts.mode.String(),
+		Provider: client.Provider.String(),
+		Model:    client.ModelName(),
+		Query:    goal,
+		Response: record,
+	}); err != nil {
+		slog.Warn("Failed to save history", "error", err)
+	}
+}
+
+// runAgentCommand runs command in shell, showing its output as it goes,
+// and returns the output and exit status
+func runAgentCommand(ctx context.Context, shell, command string) (string, int) {
+	ctx, cancel := context.WithTimeout(ctx, agentCommandTimeout)
+	defer cancel()
+
+	cmd := userShellCommand(ctx, shell, command)
+	var output strings.Builder
+	// Only the outcome goes to stdout
+	cmd.Stdout = io.MultiWriter(os.Stderr, &output)
+	cmd.Stderr = io.MultiWriter(os.Stderr, &output)
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return output.String(), 0
+	case ctx.Err() != nil:
+		return output.String() + fmt.Sprintf("\n(timed out after %v)", agentCommandTimeout), -1
+	case errors.As(err, &exitErr):
+		return output.String(), exitErr.ExitCode()
+	}
+	return output.String() + "\n" + err.Error(), -1
+}
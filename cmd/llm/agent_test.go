@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/internal/config"
+)
+
+func TestRunAgentCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	tests := []struct {
+		command string
+		output  string
+		status  int
+	}{
+		{"echo hello", "hello\n", 0},
+		{"echo oops >&2; exit 3", "oops\n", 3},
+		{"true", "", 0},
+	}
+	for _, tt := range tests {
+		output, status := runAgentCommand(context.Background(), "sh", tt.command)
+		if output != tt.output || status != tt.status {
+			t.Errorf("runAgentCommand(%q) = %q, %d, want %q, %d", tt.command, output, status, tt.output, tt.status)
+		}
+	}
+}
+
+func TestRunAgentCommandCanceled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	output, status := runAgentCommand(ctx, "sh", "sleep 5")
+	if status != -1 || !strings.Contains(output, "timed out") {
+		t.Errorf("runAgentCommand() = %q, %d, want a timeout", output, status)
+	}
+}
+
+func TestAgentSavesFailedRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"choices":[{"message":{"content":"I'm not sure"}}]}`)
+	}))
+	defer srv.Close()
+
+	home := t.TempDir()
+	if _, stderr, status := runLLMIn(t, home, srv.URL, "agent", "clean up"); status == exitOK {
+		t.Fatalf("an unparseable step succeeded; stderr:\n%s", stderr)
+	}
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	cfg, _ := config.Parse("")
+	store, err := openHistory(cfg, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := store.Entries()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("history = %+v, %v", entries, err)
+	}
+	if e := entries[0]; e.Mode != "agent" || e.Query != "clean up" || !strings.HasPrefix(e.Response, "Stopped: failed to parse agent step") {
+		t.Errorf("saved %+v", e)
+	}
+}
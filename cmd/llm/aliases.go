@@ -0,0 +1,35 @@
+package main
+
+import (
+	"log/slog"
+	"regexp"
+
+	"github.com/jamesob/llm-cli/internal/config"
+)
+
+// aliasRe matches @name at the start of a word, so email addresses and the
+// like aren't taken for aliases
+var aliasRe = regexp.MustCompile(`(^|[\s("'])@([A-Za-z][A-Za-z0-9_-]*)`)
+
+// expandAliases replaces each @name in query with its snippet from the
+// config file's [aliases] section, e.g. @prod with "on our Ubuntu 22.04
+// production servers". Words starting with @ that aren't aliases are left
+// as they are.
+func expandAliases(query string, cfg *config.Config) string {
+	aliases := cfg.Section("aliases")
+	if len(aliases) == 0 {
+		return query
+	}
+	expanded := aliasRe.ReplaceAllStringFunc(query, func(match string) string {
+		m := aliasRe.FindStringSubmatch(match)
+		snippet, ok := aliases[m[2]]
+		if !ok {
+			return match
+		}
+		return m[1] + snippet
+	})
+	if expanded != query {
+		slog.Debug("expanded aliases", "query", expanded)
+	}
+	return expanded
+}
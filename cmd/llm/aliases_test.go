@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/jamesob/llm-cli/internal/config"
+)
+
+func TestExpandAliases(t *testing.T) {
+	cfg, err := config.Parse(`
+[aliases]
+prod = "on our Ubuntu 22.04 production servers with systemd"
+k8s-dev = "in the dev cluster"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{ in, want string }{
+		{"restart nginx @prod", "restart nginx on our Ubuntu 22.04 production servers with systemd"},
+		{"@prod: list failed units", "on our Ubuntu 22.04 production servers with systemd: list failed units"},
+		{"list pods @k8s-dev.", "list pods in the dev cluster."},
+		{"mail ops@prod.example.com", "mail ops@prod.example.com"},
+		{"install @types/node", "install @types/node"},
+		{"restart @staging", "restart @staging"},
+	}
+	for _, tt := range tests {
+		if got := expandAliases(tt.in, cfg); got != tt.want {
+			t.Errorf("expandAliases(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := expandAliases("restart @prod", nil); got != "restart @prod" {
+		t.Errorf("without a config got %q", got)
+	}
+}
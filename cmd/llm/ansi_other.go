@@ -0,0 +1,7 @@
+//go:build !windows
+
+package main
+
+// enableANSI is only needed on Windows; other terminals handle escape
+// sequences already
+func enableANSI() {}
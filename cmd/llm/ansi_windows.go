@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+var (
+	kernel32           = syscall.NewLazyDLL("kernel32.dll")
+	procSetConsoleMode = kernel32.NewProc("SetConsoleMode")
+)
+
+// enableVirtualTerminalProcessing is ENABLE_VIRTUAL_TERMINAL_PROCESSING from
+// consoleapi.h
+const enableVirtualTerminalProcessing = 0x0004
+
+// enableANSI turns on escape sequence handling in the Windows console, so
+// that colors and bold text render instead of showing as codes. Older
+// consoles that can't do it are left as they are.
+func enableANSI() {
+	for _, f := range []*os.File{os.Stdout, os.Stderr} {
+		handle := syscall.Handle(f.Fd())
+		var mode uint32
+		if err := syscall.GetConsoleMode(handle, &mode); err != nil {
+			// Not a console, e.g. redirected to a file
+			continue
+		}
+		procSetConsoleMode.Call(uintptr(handle), uintptr(mode|enableVirtualTerminalProcessing))
+	}
+}
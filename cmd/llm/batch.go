@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// Defaults for llm batch, unless batch_concurrency or batch_rpm are set
+const (
+	defaultBatchConcurrency = 4
+	defaultBatchRPM         = 60
+)
+
+// queryModes are the modes that need nothing but a query, which llm batch
+// and llm compare can use
+var queryModes = []llm.Mode{
+	llm.CommandMode, llm.CodeMode, llm.ExplainMode, llm.RegexMode, llm.SQLMode,
+	llm.CronMode, llm.K8sMode, llm.SedMode, llm.AwkMode, llm.TLDRMode,
+	llm.AWSMode, llm.GCloudMode, llm.AzureMode,
+}
+
+const batchUsage = "usage: llm batch [--input FILE] [--mode MODE] [--concurrency N] [--rpm N] [--out FILE]"
+
+// batchResult is a line of llm batch output
+type batchResult struct {
+	Line     int    `json:"line"`
+	Prompt   string `json:"prompt"`
+	Response string `json:"response,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// runBatch answers each line of a file, or of stdin, as a separate query,
+// several at a time, and writes the answers as JSON lines in input order
+func runBatch(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	var input, output, modeName string
+	var concurrency, rpm int
+	opts := &options{}
+	flagSet := flag.NewFlagSet("llm batch", flag.ContinueOnError)
+	flagSet.StringVar(&input, "input", "-", "File with one prompt per line, or - for stdin")
+	flagSet.StringVar(&output, "out", "", "Write results to this file instead of stdout")
+	flagSet.StringVar(&modeName, "mode", "command", "Mode to answer in: "+queryModeNames())
+	flagSet.IntVar(&concurrency, "concurrency", cmp.Or(cfg.Int("batch_concurrency"), defaultBatchConcurrency), "Prompts to answer at once")
+	flagSet.IntVar(&rpm, "rpm", cmp.Or(cfg.Int("batch_rpm"), defaultBatchRPM), "Most requests to start per minute")
+	flagSet.BoolVar(&opts.context, "context", cfg.Bool("context"), "Include project context in each prompt")
+	flagSet.BoolVar(&opts.noRedact, "no-redact", false, "Send secrets in prompts without redacting them")
+	opts.log.register(flagSet)
+	flagSet.Usage = func() {
+		fmt.Fprintln(os.Stderr, batchUsage)
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if flagSet.NArg() > 0 || concurrency < 1 || rpm < 1 {
+		return usageError(batchUsage)
+	}
+	if opts.mode, err = queryMode(modeName); err != nil {
+		return err
+	}
+
+	prompts, err := readPrompts(input)
+	if err != nil {
+		return err
+	}
+	if len(prompts) == 0 {
+		return errors.New("no prompts to answer")
+	}
+
+	out := io.Writer(os.Stdout)
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	sys := llm.DetectSystem()
+	if opts.context {
+		if wd, err := os.Getwd(); err == nil {
+			project := llm.DetectProject(wd)
+			sys.Project = &project
+		}
+	}
+	if err := opts.log.setup(); err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+	client.Retries = unattendedRetries
+
+	ctx, stop := interruptible(context.Background())
+	results := answerBatch(ctx, cfg, client, opts, sys, prompts, concurrency, rpm)
+	stop()
+	failed := 0
+	enc := json.NewEncoder(out)
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	if err := interrupted(ctx, ctx.Err()); err != nil {
+		// The answers so far are written; the rest are marked interrupted
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d prompts failed", failed, len(results))
+	}
+	return nil
+}
+
+// queryMode returns the mode in queryModes called name
+func queryMode(name string) (llm.Mode, error) {
+	for _, m := range queryModes {
+		if m.String() == name {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown mode %q (expected one of %s)", name, queryModeNames())
+}
+
+// queryModeNames lists queryModes
+func queryModeNames() string {
+	var names []string
+	for _, m := range queryModes {
+		names = append(names, m.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// readPrompts returns the non-blank lines of path, or of stdin if path is
+// "-", with their line numbers
+func readPrompts(path string) ([]batchResult, error) {
+	r := io.Reader(os.Stdin)
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var prompts []batchResult
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxStdin)
+	for n := 1; scanner.Scan(); n++ {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			prompts = append(prompts, batchResult{Line: n, Prompt: line})
+		}
+	}
+	return prompts, scanner.Err()
+}
+
+// answerBatch answers prompts with up to concurrency requests in flight,
+// starting at most rpm a minute, and reports progress on stderr
+func answerBatch(ctx context.Context, cfg *config.Config, client *llm.Client, opts *options, sys llm.System, prompts []batchResult, concurrency, rpm int) []batchResult {
+	results := make([]batchResult, len(prompts))
+	copy(results, prompts)
+
+	ticker := time.NewTicker(time.Minute / time.Duration(rpm))
+	defer ticker.Stop()
+	start := make(chan int)
+	go func() {
+		defer close(start)
+		for i := range results {
+			if i > 0 {
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case start <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// finished marks the results that were answered or failed on their own,
+	// rather than being cut short or never started because of Ctrl-C
+	finished := make([]bool, len(results))
+	var mu sync.Mutex
+	done := 0
+	var wg sync.WaitGroup
+	for range min(concurrency, len(results)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range start {
+				prompt := llm.BuildPrompt(opts.mode, sys, results[i].Prompt)
+				if !opts.noRedact {
+					prompt, _ = llm.Redact(prompt)
+				}
+				var response string
+				err := moderate(ctx, cfg, prompt)
+				if err == nil {
+					response, err = client.Query(ctx, prompt)
+				}
+				if err = interrupted(ctx, err); err != nil {
+					results[i].Error = err.Error()
+				} else {
+					results[i].Response = response
+				}
+				finished[i] = err != errInterrupted
+
+				mu.Lock()
+				done++
+				fmt.Fprintf(notices, "\r[%d/%d]", done, len(results))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	fmt.Fprintln(notices)
+	if ctx.Err() != nil {
+		for i := range results {
+			if !finished[i] {
+				results[i].Error = interrupted(ctx, ctx.Err()).Error()
+			}
+		}
+	}
+	return results
+}
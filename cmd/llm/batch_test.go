@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestReadPrompts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompts.txt")
+	os.WriteFile(path, []byte("list files\n\n  disk usage  \n"), 0644)
+
+	got, err := readPrompts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []batchResult{{Line: 1, Prompt: "list files"}, {Line: 3, Prompt: "disk usage"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readPrompts() = %+v, want %+v", got, want)
+	}
+}
+
+func TestQueryMode(t *testing.T) {
+	if m, err := queryMode("code"); err != nil || m != llm.CodeMode {
+		t.Errorf("queryMode(code) = %v, %v", m, err)
+	}
+	if _, err := queryMode("commit"); err == nil {
+		t.Error("expected an error for a mode that needs more than a query")
+	}
+}
+
+func TestAnswerBatch(t *testing.T) {
+	// The provider echoes the query back, and fails on "bad"
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req llm.OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		query := req.Prompt[strings.LastIndex(req.Prompt, "User request: ")+len("User request: "):]
+		query = strings.SplitN(query, "\n", 2)[0]
+		if query == "bad" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"response": "answer to " + query})
+	}))
+	t.Cleanup(provider.Close)
+	client := &llm.Client{Provider: llm.Ollama, Model: "llama3", Endpoint: provider.URL}
+
+	prompts := []batchResult{{Line: 1, Prompt: "one"}, {Line: 2, Prompt: "bad"}, {Line: 4, Prompt: "three"}}
+	results := answerBatch(context.Background(), nil, client, &options{}, llm.System{OS: "linux", Shell: "bash"}, prompts, 2, 6000)
+
+	for i, r := range results {
+		if r.Line != prompts[i].Line || r.Prompt != prompts[i].Prompt {
+			t.Errorf("result %d is for %+v, want input order", i, r)
+		}
+	}
+	if results[0].Response != "answer to one" || results[2].Response != "answer to three" {
+		t.Errorf("unexpected responses %+v", results)
+	}
+	if results[1].Error == "" || results[1].Response != "" {
+		t.Errorf("expected an error for the failing prompt, got %+v", results[1])
+	}
+}
+
+func TestAnswerBatchInterrupted(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"response": "answer"})
+	}))
+	t.Cleanup(provider.Close)
+	client := &llm.Client{Provider: llm.Ollama, Model: "llama3", Endpoint: provider.URL}
+
+	// Ctrl-C before anything starts leaves every prompt marked, in order
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errInterrupted)
+	prompts := []batchResult{{Line: 1, Prompt: "one"}, {Line: 2, Prompt: "two"}, {Line: 3, Prompt: "three"}}
+	results := answerBatch(ctx, nil, client, &options{}, llm.System{OS: "linux", Shell: "bash"}, prompts, 2, 1)
+	if len(results) != len(prompts) {
+		t.Fatalf("got %d results, want %d", len(results), len(prompts))
+	}
+	for i, r := range results {
+		if r.Line != prompts[i].Line || r.Response != "" || r.Error != "interrupted" {
+			t.Errorf("result %d = %+v, want it marked interrupted", i, r)
+		}
+	}
+}
+
+func TestAnswerBatchInterruptedKeepsErrors(t *testing.T) {
+	// "bad" fails, then Ctrl-C arrives while "two" is being answered
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req llm.OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if strings.Contains(req.Prompt, "User request: bad") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		cancel(errInterrupted)
+		<-r.Context().Done()
+	}))
+	t.Cleanup(provider.Close)
+	client := &llm.Client{Provider: llm.Ollama, Model: "llama3", Endpoint: provider.URL}
+
+	prompts := []batchResult{{Line: 1, Prompt: "bad"}, {Line: 2, Prompt: "two"}, {Line: 3, Prompt: "three"}}
+	results := answerBatch(ctx, nil, client, &options{}, llm.System{OS: "linux", Shell: "bash"}, prompts, 1, 6000)
+	if r := results[0]; r.Error == "" || r.Error == "interrupted" {
+		t.Errorf("the failed prompt lost its error: %+v", r)
+	}
+	for _, r := range results[1:] {
+		if r.Error != "interrupted" {
+			t.Errorf("result %+v, want it marked interrupted", r)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// benchQueries are the prompts llm bench sends, typical of command mode
+var benchQueries = []string{
+	"list files in the current directory sorted by size",
+	"find all .log files older than 7 days and delete them",
+	"show which process is listening on port 8080",
+	"count lines of Go code in this repository, excluding vendor",
+	"compress the logs directory into a tar.gz with today's date in the name",
+	"show the 10 largest directories under /var",
+	"replace foo with bar in every .txt file recursively",
+	"print the last 50 lines of syslog and follow it",
+}
+
+const benchUsage = "usage: llm bench [-m MODEL ...] [--runs N]"
+
+// benchSample is the outcome of one benchmark query
+type benchSample struct {
+	elapsed time.Duration
+	tokens  int
+	err     error
+}
+
+// benchSummary sums up a model's benchmark samples
+type benchSummary struct {
+	model         string
+	runs, failed  int
+	p50, p90, p99 time.Duration
+	tokensPerSec  float64
+}
+
+// runBench sends a fixed set of queries to each model, one at a time, and
+// reports latency percentiles, answer speed and failures
+func runBench(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	var models []string
+	var runs int
+	var logs logFlags
+	flagSet := flag.NewFlagSet("llm bench", flag.ContinueOnError)
+	flagSet.Var((*stringList)(&models), "m", "Model to benchmark (repeatable); defaults to bench_models, or your usual model")
+	flagSet.Var((*stringList)(&models), "model", "Model to benchmark (long)")
+	flagSet.IntVar(&runs, "runs", 1, "Times to send each query")
+	logs.register(flagSet)
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if flagSet.NArg() > 0 || runs < 1 {
+		return usageError(benchUsage)
+	}
+	if err := logs.setup(); err != nil {
+		return err
+	}
+	if len(models) == 0 {
+		models = cfg.Strings("bench_models")
+	}
+
+	var clients []*llm.Client
+	for _, m := range models {
+		client, err := modelClient(cfg, m)
+		if err != nil {
+			return err
+		}
+		clients = append(clients, client)
+	}
+	if len(clients) == 0 {
+		client, err := newClient(cfg)
+		if err != nil {
+			return err
+		}
+		clients = append(clients, client)
+	}
+
+	sys := llm.DetectSystem()
+	var summaries []benchSummary
+	for _, client := range clients {
+		var samples []benchSample
+		for run := range runs {
+			for i, query := range benchQueries {
+				fmt.Fprintf(notices, "\r%s: %d/%d", client.ModelName(), run*len(benchQueries)+i+1, runs*len(benchQueries))
+				samples = append(samples, benchQuery(context.Background(), client, llm.BuildPrompt(llm.CommandMode, sys, query)))
+			}
+		}
+		fmt.Fprintln(notices)
+		summaries = append(summaries, summarizeBench(client.ModelName(), samples))
+	}
+	printBench(os.Stdout, summaries)
+	return nil
+}
+
+// benchQuery times a single query
+func benchQuery(ctx context.Context, client *llm.Client, prompt string) benchSample {
+	start := time.Now()
+	answer, err := client.Query(ctx, prompt)
+	return benchSample{elapsed: time.Since(start), tokens: llm.EstimateTokens(answer), err: err}
+}
+
+// summarizeBench computes latency percentiles and answer speed over the
+// samples that succeeded
+func summarizeBench(model string, samples []benchSample) benchSummary {
+	s := benchSummary{model: model, runs: len(samples)}
+	var latencies []time.Duration
+	var total time.Duration
+	tokens := 0
+	for _, sample := range samples {
+		if sample.err != nil {
+			s.failed++
+			continue
+		}
+		latencies = append(latencies, sample.elapsed)
+		total += sample.elapsed
+		tokens += sample.tokens
+	}
+	slices.Sort(latencies)
+	s.p50 = percentile(latencies, 50)
+	s.p90 = percentile(latencies, 90)
+	s.p99 = percentile(latencies, 99)
+	if total > 0 {
+		s.tokensPerSec = float64(tokens) / total.Seconds()
+	}
+	return s
+}
+
+// percentile returns the p-th percentile of sorted by the nearest-rank
+// method, or 0 if it's empty
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// printBench writes summaries as a table
+func printBench(w io.Writer, summaries []benchSummary) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODEL\tRUNS\tFAILED\tP50\tP90\tP99\tTOK/S")
+	for _, s := range summaries {
+		fmt.Fprintf(tw, "%s\t%d\t%.0f%%\t%.2fs\t%.2fs\t%.2fs\t%.1f\n", s.model, s.runs,
+			100*float64(s.failed)/float64(max(s.runs, 1)),
+			s.p50.Seconds(), s.p90.Seconds(), s.p99.Seconds(), s.tokensPerSec)
+	}
+	tw.Flush()
+}
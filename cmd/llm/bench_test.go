@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 10; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Second)
+	}
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{50, 5 * time.Second},
+		{90, 9 * time.Second},
+		{99, 10 * time.Second},
+		{0, 1 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile of nothing = %v", got)
+	}
+}
+
+func TestSummarizeBench(t *testing.T) {
+	s := summarizeBench("llama3", []benchSample{
+		{elapsed: 2 * time.Second, tokens: 10},
+		{elapsed: 1 * time.Second, tokens: 20},
+		{elapsed: 30 * time.Second, err: errors.New("timeout")},
+	})
+	if s.runs != 3 || s.failed != 1 || s.p50 != time.Second || s.p99 != 2*time.Second || s.tokensPerSec != 10 {
+		t.Errorf("unexpected summary %+v", s)
+	}
+
+	var b strings.Builder
+	printBench(&b, []benchSummary{s})
+	want := "MODEL   RUNS  FAILED  P50    P90    P99    TOK/S\n" +
+		"llama3  3     33%     1.00s  2.00s  2.00s  10.0\n"
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
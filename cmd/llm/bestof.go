@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// sampleTemperature is used for --best-of samples so that they differ
+const sampleTemperature = 0.8
+
+// bestOf sends prompt n times, to the models in best_of_models in turn or
+// to client, and has a judge pick or merge the best answer. The judge is
+// best_of_judge, or client.
+func bestOf(ctx context.Context, cfg *config.Config, client *llm.Client, prompt string, n int) (string, error) {
+	samplers := []*llm.Client{client}
+	if models := cfg.Strings("best_of_models"); len(models) > 0 {
+		samplers = nil
+		for _, m := range models {
+			c, err := modelClient(cfg, m)
+			if err != nil {
+				return "", fmt.Errorf("best_of_models: %v", err)
+			}
+			samplers = append(samplers, c)
+		}
+	}
+	judge := client
+	if model := cfg.String("best_of_judge"); model != "" {
+		var err error
+		if judge, err = modelClient(cfg, model); err != nil {
+			return "", fmt.Errorf("best_of_judge: %v", err)
+		}
+	}
+
+	fmt.Fprintf(notices, "Sampling %d answers...\n", n)
+	candidates, err := sampleAnswers(ctx, samplers, prompt, n)
+	if err != nil {
+		return "", err
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	slog.Debug("judging answers", "model", judge.ModelName(), "candidates", len(candidates))
+	return judge.Query(ctx, llm.JudgePrompt(prompt, candidates))
+}
+
+// sampleAnswers sends prompt n times at once, spread across samplers, and
+// returns the distinct answers. Failed samples are skipped unless they all
+// fail.
+func sampleAnswers(ctx context.Context, samplers []*llm.Client, prompt string, n int) ([]string, error) {
+	answers := make([]string, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		sampler := *samplers[i%len(samplers)]
+		sampler.Temperature = sampleTemperature
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			answers[i], errs[i] = sampler.Query(ctx, prompt)
+		}()
+	}
+	wg.Wait()
+
+	var candidates []string
+	var firstErr error
+	for i, answer := range answers {
+		if errs[i] != nil {
+			slog.Debug("sample failed", "error", errs[i])
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			continue
+		}
+		if answer = strings.TrimSpace(answer); !slices.Contains(candidates, answer) {
+			candidates = append(candidates, answer)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, firstErr
+	}
+	return candidates, nil
+}
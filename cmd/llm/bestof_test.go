@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestBestOf(t *testing.T) {
+	// Samples alternate between two answers; the judge merges them
+	var samples atomic.Int32
+	var mu sync.Mutex
+	var temperatures []float64
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req llm.OllamaRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		answer := "merged"
+		if !strings.Contains(req.Prompt, "<candidate 1>") {
+			answer = []string{"ls -la", "ls -l"}[samples.Add(1)%2]
+			mu.Lock()
+			temperatures = append(temperatures, req.Options.Temperature)
+			mu.Unlock()
+		}
+		json.NewEncoder(w).Encode(map[string]string{"response": answer})
+	}))
+	t.Cleanup(provider.Close)
+	client := &llm.Client{Provider: llm.Ollama, Model: "llama3", Endpoint: provider.URL}
+	cfg, _ := config.Parse("")
+
+	got, err := bestOf(context.Background(), cfg, client, "list files", 3)
+	if err != nil || got != "merged" {
+		t.Errorf("bestOf() = %q, %v, want the judge's answer", got, err)
+	}
+	if samples.Load() != 3 {
+		t.Errorf("took %d samples, want 3", samples.Load())
+	}
+	for _, temp := range temperatures {
+		if temp != sampleTemperature {
+			t.Errorf("sampled at temperature %v", temp)
+		}
+	}
+}
+
+func TestSampleAnswersAgree(t *testing.T) {
+	provider := mockProvider(t, http.StatusOK, `{"response":"ls -la"}`)
+	client := &llm.Client{Provider: llm.Ollama, Model: "llama3", Endpoint: provider.URL}
+
+	got, err := sampleAnswers(context.Background(), []*llm.Client{client}, "list files", 3)
+	if err != nil || len(got) != 1 || got[0] != "ls -la" {
+		t.Errorf("sampleAnswers() = %q, %v, want one distinct answer", got, err)
+	}
+	if client.Temperature != 0 {
+		t.Error("sampling changed the client's temperature")
+	}
+
+	broken := mockProvider(t, http.StatusInternalServerError, "oops")
+	client.Endpoint = broken.URL
+	if _, err := sampleAnswers(context.Background(), []*llm.Client{client}, "list files", 2); err == nil {
+		t.Error("expected an error when every sample fails")
+	}
+}
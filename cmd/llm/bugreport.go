@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+const bugReportUsage = "usage: <command> 2>&1 | llm bugreport [--cmd COMMAND] [what went wrong]"
+
+// Limits on the tool versions llm bugreport lists
+const (
+	maxReportTools = 6
+	maxVersionLen  = 120
+)
+
+// runBugReport drafts the body of a bug report from a failing command's
+// output and the user's description, adding the environment it happened
+// in: the OS, shell and versions of the tools involved
+func runBugReport(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	opts := &options{mode: llm.BugReportMode}
+	var command string
+	flagSet := flag.NewFlagSet("llm bugreport", flag.ContinueOnError)
+	flagSet.StringVar(&command, "cmd", "", "The failing `command`, if its output is piped in")
+	flagSet.BoolVar(&opts.context, "context", cfg.Bool("context"), "Include project context in the prompt")
+	flagSet.BoolVar(&opts.yes, "y", false, "Don't ask before sending large output")
+	flagSet.BoolVar(&opts.noRedact, "no-redact", false, "Send the output without redacting secrets")
+	opts.log.register(flagSet)
+	flagSet.Usage = func() {
+		fmt.Fprintln(os.Stderr, bugReportUsage)
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	opts.query = expandAliases(strings.Join(flagSet.Args(), " "), cfg)
+	description := opts.query
+	if opts.query == "" {
+		opts.query = "Write a bug report about this failure."
+	}
+
+	sys := llm.DetectSystem()
+	output, err := readStdin()
+	if err != nil {
+		return err
+	}
+	failing := command
+	if output != "" {
+		sys.Attachments = []llm.Attachment{{Title: "Output of the failing command", Content: output}}
+	} else if command == "" {
+		if sys.Previous = previousCommand(); sys.Previous == nil {
+			return errors.New("pipe in the failing output, e.g. make 2>&1 | llm bugreport --cmd make, or set up llm shell-init")
+		}
+		failing = sys.Previous.Command
+	}
+	if command != "" {
+		sys.Notes = append(sys.Notes, "Failing command: `"+command+"`")
+	}
+	if opts.context {
+		if wd, err := os.Getwd(); err == nil {
+			project := llm.DetectProject(wd)
+			sys.Project = &project
+		}
+	}
+
+	environment := environmentSection(sys, toolVersions(reportTools(sys.Shell, failing, description)))
+
+	if err := opts.log.setup(); err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+	response, err := ask(context.Background(), cfg, client, opts, sys)
+	if err != nil {
+		return err
+	}
+	fmt.Print(strings.TrimSpace(stripFence(response)) + "\n\n" + environment)
+	return nil
+}
+
+// reportTools returns the programs whose versions a bug report lists: the
+// shell, those the failing command runs and those the description names,
+// that are on the PATH
+func reportTools(shell, command, description string) []string {
+	candidates := append([]string{shell}, llm.Programs(command)...)
+	candidates = append(candidates, mentionedCommands(description, maxReportTools)...)
+	var tools []string
+	for _, program := range candidates {
+		if program == "" || slices.Contains(tools, program) {
+			continue
+		}
+		if _, err := exec.LookPath(program); err != nil {
+			continue
+		}
+		tools = append(tools, program)
+		if len(tools) == maxReportTools {
+			break
+		}
+	}
+	return tools
+}
+
+// toolVersions maps each program to its version, leaving out those that
+// don't report one
+func toolVersions(programs []string) [][2]string {
+	var versions [][2]string
+	for _, program := range programs {
+		if version := toolVersion(program); version != "" {
+			if len(version) > maxVersionLen {
+				version = version[:maxVersionLen] + "..."
+			}
+			versions = append(versions, [2]string{program, version})
+		}
+	}
+	return versions
+}
+
+// environmentSection is the markdown listing the OS, architecture and tool
+// versions, which is written locally rather than by the model so that it's
+// exact
+func environmentSection(sys llm.System, versions [][2]string) string {
+	var b strings.Builder
+	b.WriteString("## Environment\n\n")
+	osLine := sys.OS + "/" + runtime.GOARCH
+	if sys.Distro != "" {
+		osLine += ", " + sys.Distro
+	}
+	if sys.WSL != "" {
+		osLine += " (" + sys.WSL + ")"
+	}
+	fmt.Fprintf(&b, "- OS: %s\n", osLine)
+	if sys.Shell != "" {
+		fmt.Fprintf(&b, "- Shell: %s\n", sys.Shell)
+	}
+	for _, v := range versions {
+		fmt.Fprintf(&b, "- %s: `%s`\n", v[0], v[1])
+	}
+	return b.String()
+}
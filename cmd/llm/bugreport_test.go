@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestEnvironmentSection(t *testing.T) {
+	sys := llm.System{OS: "linux", Shell: "zsh", Distro: "Ubuntu 24.04 LTS", WSL: "WSL2"}
+	got := environmentSection(sys, [][2]string{{"zsh", "zsh 5.9 (x86_64-ubuntu-linux-gnu)"}})
+	for _, want := range []string{"## Environment\n\n", ", Ubuntu 24.04 LTS (WSL2)\n", "- Shell: zsh\n", "- zsh: `zsh 5.9 (x86_64-ubuntu-linux-gnu)`\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in %q", want, got)
+		}
+	}
+	if got := environmentSection(llm.System{OS: "darwin"}, nil); strings.Contains(got, "Shell") {
+		t.Errorf("unknown shell listed: %q", got)
+	}
+}
+
+func TestReportTools(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not on PATH")
+	}
+	tools := reportTools("no-such-shell", "CGO_ENABLED=0 go build ./... && no-such-tool", "go fails to build")
+	if len(tools) != 1 || tools[0] != "go" {
+		t.Errorf("got %q, want [go]", tools)
+	}
+	if version := toolVersion("go"); !strings.HasPrefix(version, "go version ") {
+		t.Errorf("go version = %q", version)
+	}
+}
+
+func TestBugReportOutput(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"choices":[{"message":{"content":"## Summary\n\nThe build fails."}}]}`)
+	}))
+	defer srv.Close()
+
+	stdout, stderr, status := runLLM(t, srv.URL, "bugreport", "--cmd", "no-such-tool --all")
+	if status != exitOK || !strings.HasPrefix(stdout, "## Summary\n\nThe build fails.\n\n## Environment\n\n- OS: ") {
+		t.Errorf("got %d, %q, %q", status, stdout, stderr)
+	}
+
+	if _, stderr, status := runLLM(t, srv.URL, "bugreport"); status == exitOK || !strings.Contains(stderr, "pipe in the failing output") {
+		t.Errorf("nothing to report: %d, %q", status, stderr)
+	}
+}
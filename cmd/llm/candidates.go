@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+	"github.com/jamesob/llm-cli/pkg/render"
+)
+
+// askCandidates asks client for n answers to prompt and lets the user pick
+// one on the terminal
+func askCandidates(ctx context.Context, client *llm.Client, prompt string, n int) (string, error) {
+	sampler := *client
+	sampler.Temperature = sampleTemperature
+	fmt.Fprintf(notices, "Asking for %d candidates...\n", n)
+	candidates, err := sampler.QueryN(ctx, prompt, n)
+	if err != nil {
+		return "", err
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+
+	tty, err := openTTY()
+	if err != nil {
+		return "", errors.New("picking a candidate needs a terminal")
+	}
+	defer tty.Close()
+	return pickCandidate(tty, os.Stderr, candidates)
+}
+
+// pickCandidate lists candidates on w and reads the number of the chosen
+// one from r. An empty answer picks the first.
+func pickCandidate(r io.Reader, w io.Writer, candidates []string) (string, error) {
+	for i, c := range candidates {
+		label := fmt.Sprintf("%d) ", i+1)
+		indented := strings.ReplaceAll(c, "\n", "\n"+strings.Repeat(" ", len(label)))
+		fmt.Fprintf(w, "%s%s%s%s\n", render.Bold, label, render.Reset, indented)
+	}
+
+	in := bufio.NewReader(r)
+	for {
+		fmt.Fprintf(w, "Pick one [1-%d]: ", len(candidates))
+		line, err := in.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if answer == "" && err == nil {
+			return candidates[0], nil
+		}
+		if i, convErr := strconv.Atoi(answer); convErr == nil && i >= 1 && i <= len(candidates) {
+			return candidates[i-1], nil
+		}
+		if err != nil {
+			return "", errAborted
+		}
+		fmt.Fprintf(w, "%q isn't one of the candidates\n", answer)
+	}
+}
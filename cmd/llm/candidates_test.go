@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPickCandidate(t *testing.T) {
+	candidates := []string{"ls -la", "ls -al", "find . -maxdepth 1"}
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		err   error
+	}{
+		{"number", "2\n", "ls -al", nil},
+		{"default", "\n", "ls -la", nil},
+		{"retry after bad input", "9\nx\n3\n", "find . -maxdepth 1", nil},
+		{"last line without newline", "3", "find . -maxdepth 1", nil},
+		{"no answer", "", "", errAborted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pickCandidate(strings.NewReader(tt.input), io.Discard, candidates)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
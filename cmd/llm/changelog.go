@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+const changelogUsage = "usage: llm changelog [--since REF] [--until REF] [--version NAME] [--write [-y]] [--file FILE] [hint]"
+
+// changelogHeader starts a CHANGELOG.md that llm changelog creates
+const changelogHeader = `# Changelog
+
+All notable changes to this project are documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).
+`
+
+// unreleased is the version of the changes not yet released
+const unreleased = "Unreleased"
+
+// runChangelog writes a Keep a Changelog section for the commits between
+// two refs and, once the user agrees, adds it to CHANGELOG.md
+func runChangelog(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	opts := &options{mode: llm.ChangelogMode}
+	var since, until, version, path string
+	var write bool
+	flagSet := flag.NewFlagSet("llm changelog", flag.ContinueOnError)
+	flagSet.StringVar(&since, "since", "", "Describe the commits after this ref (default: the latest tag)")
+	flagSet.StringVar(&until, "until", "HEAD", "Describe the commits up to this ref")
+	flagSet.StringVar(&version, "version", unreleased, "Version to head the section with")
+	flagSet.BoolVar(&write, "write", false, "Add the section to the changelog, after showing it")
+	flagSet.StringVar(&path, "file", "CHANGELOG.md", "The changelog --write adds to")
+	flagSet.BoolVar(&opts.yes, "y", false, "Don't ask before writing the changelog")
+	flagSet.BoolVar(&opts.noRedact, "no-redact", false, "Send the commits without redacting secrets")
+	opts.log.register(flagSet)
+	flagSet.Usage = func() {
+		fmt.Fprintln(os.Stderr, changelogUsage)
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	opts.query = expandAliases(strings.Join(flagSet.Args(), " "), cfg)
+	if opts.query == "" {
+		opts.query = "Write the changelog entries for these commits."
+	}
+
+	if since == "" {
+		if since, err = gitOutput("describe", "--tags", "--abbrev=0", until); err != nil {
+			return errors.New("can't find a tag to start from; pass --since")
+		}
+	}
+	commits, err := gitOutput("log", "--no-merges", "--reverse", "--format=%h %s%n%n%b", since+".."+until)
+	if err != nil {
+		return err
+	}
+	if commits == "" {
+		return fmt.Errorf("no commits between %s and %s", since, until)
+	}
+
+	if err := opts.log.setup(); err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+	sys := llm.DetectSystem()
+	if wd, err := os.Getwd(); err == nil {
+		project := llm.DetectProject(wd)
+		sys.Project = &project
+	}
+	sys.Attachments = []llm.Attachment{{Title: fmt.Sprintf("Commits from %s to %s", since, until), Content: commits}}
+
+	response, err := ask(context.Background(), cfg, client, opts, sys)
+	if err != nil {
+		return err
+	}
+	section := changelogSection(version, time.Now(), stripFence(response))
+	fmt.Print(section)
+	if !write {
+		return nil
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	updated, err := insertChangelog(string(existing), section)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if !opts.yes {
+		ok, err := confirm("Add this section to " + path + "?")
+		if err != nil {
+			return fmt.Errorf("%v; pass -y to write without confirming", err)
+		}
+		if !ok {
+			return errAborted
+		}
+	}
+	if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(notices, "Added the %s section to %s\n", version, path)
+	return nil
+}
+
+// changelogSection heads the entries with the version and, once it's
+// released, the date
+func changelogSection(version string, date time.Time, entries string) string {
+	heading := "## [" + version + "]"
+	if version != unreleased {
+		heading += " - " + date.Format("2006-01-02")
+	}
+	return heading + "\n\n" + strings.TrimSpace(entries) + "\n"
+}
+
+// insertChangelog adds section to a Keep a Changelog file above the latest
+// release, below any Unreleased section, creating the file if it's empty
+func insertChangelog(changelog, section string) (string, error) {
+	if strings.TrimSpace(changelog) == "" {
+		return changelogHeader + "\n" + section, nil
+	}
+	newUnreleased := strings.HasPrefix(section, "## ["+unreleased+"]")
+
+	lines := strings.SplitAfter(changelog, "\n")
+	offset := len(changelog)
+	pos := 0
+	for _, line := range lines {
+		if strings.HasPrefix(line, "## ") {
+			if !strings.HasPrefix(line, "## ["+unreleased+"]") {
+				offset = pos
+				break
+			}
+			if newUnreleased {
+				return "", errors.New("already has an Unreleased section; pass --version to name the release")
+			}
+		}
+		pos += len(line)
+	}
+
+	before, after := changelog[:offset], changelog[offset:]
+	if !strings.HasSuffix(before, "\n") {
+		before += "\n"
+	}
+	if !strings.HasSuffix(before, "\n\n") {
+		before += "\n"
+	}
+	if after != "" {
+		section += "\n"
+	}
+	return before + section + after, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChangelogSection(t *testing.T) {
+	date := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	entries := "\n### Added\n\n- The changelog command\n\n"
+	if got, want := changelogSection("1.3.0", date, entries), "## [1.3.0] - 2026-05-01\n\n### Added\n\n- The changelog command\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := changelogSection(unreleased, date, entries); !strings.HasPrefix(got, "## [Unreleased]\n\n") {
+		t.Errorf("unreleased: got %q", got)
+	}
+}
+
+func TestInsertChangelog(t *testing.T) {
+	const release = "## [1.3.0] - 2026-05-01\n\n### Fixed\n\n- A crash\n"
+	const next = "## [Unreleased]\n\n### Added\n\n- More\n"
+	const old = "## [1.2.0] - 2026-01-10\n\n### Added\n\n- Things\n"
+	const header = "# Changelog\n\nNotable changes.\n"
+
+	tests := []struct {
+		name      string
+		changelog string
+		section   string
+		want      string
+		wantErr   bool
+	}{
+		{"new file", "", release, changelogHeader + "\n" + release, false},
+		{"above the latest release", header + "\n" + old, release, header + "\n" + release + "\n" + old, false},
+		{"below unreleased", header + "\n" + next + "\n" + old, release, header + "\n" + next + "\n" + release + "\n" + old, false},
+		{"no releases yet", header, release, header + "\n" + release, false},
+		{"no trailing newline", strings.TrimSuffix(header, "\n"), release, header + "\n" + release, false},
+		{"second unreleased", header + "\n" + next, next, "", true},
+	}
+	for _, tt := range tests {
+		got, err := insertChangelog(tt.changelog, tt.section)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: got %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
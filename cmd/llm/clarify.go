@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+	"github.com/jamesob/llm-cli/pkg/render"
+)
+
+// maxQuestions is how many clarifying questions one query may get
+const maxQuestions = 3
+
+// clarify asks the user the model's question when the response is one
+// rather than a command, then asks the model again with the answer, until
+// it answers with a command. The answers are added to sys's notes.
+func clarify(ctx context.Context, cfg *config.Config, client *llm.Client, opts *options, sys *llm.System, response string) (string, error) {
+	for i := 0; ; i++ {
+		question, ok := llm.ParseQuestion(response)
+		if !ok {
+			return response, nil
+		}
+		if i == maxQuestions {
+			return "", fmt.Errorf("the model is still asking: %s", question)
+		}
+		tty, err := openTTY()
+		if err != nil {
+			return "", fmt.Errorf("the request is ambiguous; the model asks: %s", question)
+		}
+		answer, err := askUser(tty, os.Stderr, question)
+		tty.Close()
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			sys.Notes = append(sys.Notes, fmt.Sprintf("You asked the user %q and they didn't know; make your best guess", question))
+		} else {
+			sys.Notes = append(sys.Notes, fmt.Sprintf("You asked the user %q and they answered %q", question, answer))
+		}
+		if response, _, err = requery(ctx, cfg, client, opts, *sys); err != nil {
+			return "", err
+		}
+	}
+}
+
+// askUser writes question to w and reads the answer from r
+func askUser(r io.Reader, w io.Writer, question string) (string, error) {
+	fmt.Fprintf(w, "%s%s%s ", render.Bold, question, render.Reset)
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errAborted
+	}
+	return strings.TrimSpace(line), nil
+}
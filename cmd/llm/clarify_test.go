@@ -0,0 +1,20 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAskUser(t *testing.T) {
+	var out strings.Builder
+	answer, err := askUser(strings.NewReader("the web one\n"), &out, "Which container?")
+	if err != nil || answer != "the web one" || !strings.Contains(out.String(), "Which container?") {
+		t.Errorf("got %q, %v, wrote %q", answer, err, out.String())
+	}
+	if answer, err := askUser(strings.NewReader("\n"), &out, "Which?"); err != nil || answer != "" {
+		t.Errorf("empty answer = %q, %v", answer, err)
+	}
+	if _, err := askUser(strings.NewReader(""), &out, "Which?"); err != errAborted {
+		t.Errorf("EOF gave %v, want errAborted", err)
+	}
+}
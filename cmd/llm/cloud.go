@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jamesob/llm-cli/internal/cloud"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// cloudAccounts read the account each cloud mode's CLI is set up to use
+var cloudAccounts = map[llm.Mode]func(home string, getenv func(string) string) (cloud.Account, error){
+	llm.AWSMode:    cloud.AWS,
+	llm.GCloudMode: cloud.GCloud,
+	llm.AzureMode:  cloud.Azure,
+}
+
+// prepareCloud tells the model which version of the mode's CLI is
+// installed and, if account is set, the profile, project or subscription
+// and region it uses. The account is read from the CLI's config files, so
+// nothing talks to the cloud.
+func prepareCloud(sys *llm.System, mode llm.Mode, account bool) error {
+	tool := mode.String()
+	if version := toolVersion(tool); version != "" {
+		sys.Notes = append(sys.Notes, fmt.Sprintf("%s is %s", tool, version))
+	}
+	if !account {
+		return nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	a, err := cloudAccounts[mode](home, os.Getenv)
+	if err != nil {
+		return fmt.Errorf("can't find the %s account: %v", tool, err)
+	}
+	sys.Notes = append(sys.Notes, a.Notes(tool)...)
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestPrepareCloud(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	config := filepath.Join(t.TempDir(), "config")
+	os.WriteFile(config, []byte("[profile prod]\nregion = eu-west-2\n"), 0644)
+	t.Setenv("AWS_CONFIG_FILE", config)
+	t.Setenv("AWS_PROFILE", "prod")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+
+	var sys llm.System
+	if err := prepareCloud(&sys, llm.AWSMode, false); err != nil || len(sys.Notes) != 0 {
+		t.Errorf("the account should be opt-in: %q, %v", sys.Notes, err)
+	}
+	if err := prepareCloud(&sys, llm.AWSMode, true); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"AWS profile: prod", "AWS region: eu-west-2"}; !reflect.DeepEqual(sys.Notes, want) {
+		t.Errorf("notes = %q", sys.Notes)
+	}
+
+	t.Setenv("AZURE_CONFIG_DIR", t.TempDir())
+	if err := prepareCloud(&sys, llm.AzureMode, true); err == nil {
+		t.Error("no error when az isn't logged in")
+	}
+}
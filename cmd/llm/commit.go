@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// runCommit writes a commit message for the staged changes and, once the
+// user agrees, commits them with it
+func runCommit(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	opts := &options{mode: llm.CommitMode}
+	var edit bool
+	flagSet := flag.NewFlagSet("llm commit", flag.ContinueOnError)
+	flagSet.BoolVar(&edit, "e", false, "Edit the message before committing")
+	flagSet.BoolVar(&opts.yes, "y", false, "Commit without asking")
+	flagSet.BoolVar(&opts.noRedact, "no-redact", false, "Send the diff without redacting secrets")
+	opts.log.register(flagSet)
+	flagSet.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: llm commit [-e] [-y] [hint about the change]\n")
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	opts.query = expandAliases(strings.Join(flagSet.Args(), " "), cfg)
+	if opts.query == "" {
+		opts.query = "Write a commit message for these changes."
+	}
+
+	diff, err := stagedDiff()
+	if err != nil {
+		return err
+	}
+
+	if err := opts.log.setup(); err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	sys := llm.DetectSystem()
+	if wd, err := os.Getwd(); err == nil {
+		project := llm.DetectProject(wd)
+		sys.Project = &project
+	}
+	sys.Attachments = []llm.Attachment{{Title: "Staged changes", Content: diff}}
+
+	message, err := ask(context.Background(), cfg, client, opts, sys)
+	if err != nil {
+		return err
+	}
+	message = strings.TrimSpace(message)
+	fmt.Println(message)
+
+	switch {
+	case edit:
+		return gitCommit(message, "-e")
+	case opts.yes:
+		return gitCommit(message)
+	case !isTerminal(os.Stdout):
+		// Piped into something else, e.g. git commit -F -
+		return nil
+	}
+	ok, err := confirm("Commit with this message?")
+	if err != nil || !ok {
+		return err
+	}
+	return gitCommit(message)
+}
+
+// stagedDiff returns the changes staged for commit
+func stagedDiff() (string, error) {
+	diff, err := gitOutput("diff", "--cached", "--no-color", "--no-ext-diff")
+	if err != nil {
+		return "", err
+	}
+	if diff == "" {
+		return "", errors.New("nothing is staged; use git add first")
+	}
+	return diff, nil
+}
+
+// gitCommit runs git commit with message, attached to the terminal so that
+// hooks and the editor work
+func gitCommit(message string, args ...string) error {
+	cmd := exec.Command("git", append([]string{"commit", "-m", message}, args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git commit failed: %v", err)
+	}
+	return nil
+}
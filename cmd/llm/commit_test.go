@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestStagedDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	if _, err := stagedDiff(); err == nil {
+		t.Error("expected an error outside a repository")
+	}
+
+	exec.Command("git", "init", "-q").Run()
+	os.WriteFile("hello.txt", []byte("hello\n"), 0644)
+	if _, err := stagedDiff(); err == nil || !strings.Contains(err.Error(), "nothing is staged") {
+		t.Errorf("unstaged changes: %v", err)
+	}
+
+	exec.Command("git", "add", "hello.txt").Run()
+	diff, err := stagedDiff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "+hello") {
+		t.Errorf("diff = %q", diff)
+	}
+}
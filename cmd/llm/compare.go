@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+	"github.com/jamesob/llm-cli/pkg/render"
+)
+
+const compareUsage = `usage: llm compare -m MODEL -m MODEL [--mode MODE] "<query>"`
+
+// comparison is one model's answer in llm compare
+type comparison struct {
+	model   string
+	answer  string
+	err     error
+	elapsed time.Duration
+	tokens  int
+}
+
+// runCompare sends the same query to several models at once and shows
+// their answers side by side
+func runCompare(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	var models []string
+	var modeName string
+	opts := &options{}
+	flagSet := flag.NewFlagSet("llm compare", flag.ContinueOnError)
+	flagSet.Var((*stringList)(&models), "m", "Model to ask, e.g. gpt-4o-mini, claude-sonnet or llama3 (repeatable)")
+	flagSet.Var((*stringList)(&models), "model", "Model to ask (long)")
+	flagSet.StringVar(&modeName, "mode", "command", "Mode to answer in: "+queryModeNames())
+	flagSet.BoolVar(&opts.context, "context", cfg.Bool("context"), "Include project context in the prompt")
+	flagSet.BoolVar(&opts.noRedact, "no-redact", false, "Send secrets in the prompt without redacting them")
+	opts.log.register(flagSet)
+	flagSet.Usage = func() {
+		fmt.Fprintln(os.Stderr, compareUsage)
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	opts.query = expandAliases(strings.Join(flagSet.Args(), " "), cfg)
+	if len(models) == 0 || opts.query == "" {
+		return usageError(compareUsage)
+	}
+	if opts.mode, err = queryMode(modeName); err != nil {
+		return err
+	}
+
+	if err := opts.log.setup(); err != nil {
+		return err
+	}
+	var clients []*llm.Client
+	for _, m := range models {
+		client, err := modelClient(cfg, m)
+		if err != nil {
+			return err
+		}
+		clients = append(clients, client)
+	}
+
+	sys := llm.DetectSystem()
+	if opts.context {
+		if wd, err := os.Getwd(); err == nil {
+			project := llm.DetectProject(wd)
+			sys.Project = &project
+		}
+	}
+	prompt := llm.BuildPrompt(opts.mode, sys, opts.query)
+	if !opts.noRedact {
+		prompt, _ = llm.Redact(prompt)
+	}
+	if err := moderate(context.Background(), cfg, prompt); err != nil {
+		return err
+	}
+
+	results := compareModels(context.Background(), clients, prompt)
+	fmt.Fprintf(notices, "Prompt: ~%d tokens\n\n", llm.EstimateTokens(prompt))
+	fmt.Println(formatComparison(results, outputWidth()))
+	return nil
+}
+
+// compareModels sends prompt to every client at once, returning the
+// answers in the same order
+func compareModels(ctx context.Context, clients []*llm.Client, prompt string) []comparison {
+	results := make([]comparison, len(clients))
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			answer, err := client.Query(ctx, prompt)
+			results[i] = comparison{
+				model:   client.ModelName(),
+				answer:  answer,
+				err:     err,
+				elapsed: time.Since(start),
+				tokens:  llm.EstimateTokens(answer),
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// formatComparison lays out results side by side with each model's latency
+// and answer length
+func formatComparison(results []comparison, width int) string {
+	var cols []render.Column
+	for _, r := range results {
+		text := fmt.Sprintf("%.1fs, ~%d tokens\n\n%s", r.elapsed.Seconds(), r.tokens, r.answer)
+		if r.err != nil {
+			text = fmt.Sprintf("%.1fs\n\nError: %v", r.elapsed.Seconds(), r.err)
+		}
+		cols = append(cols, render.Column{Title: r.model, Text: text})
+	}
+	return render.Columns(cols, width)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestCompareModels(t *testing.T) {
+	fast := mockProvider(t, http.StatusOK, `{"response":"du -sh *"}`)
+	broken := mockProvider(t, http.StatusInternalServerError, `oops`)
+	clients := []*llm.Client{
+		{Provider: llm.Ollama, Model: "llama3", Endpoint: fast.URL},
+		{Provider: llm.Ollama, Model: "mistral", Endpoint: broken.URL},
+	}
+
+	results := compareModels(context.Background(), clients, "disk usage")
+	if len(results) != 2 || results[0].model != "llama3" || results[1].model != "mistral" {
+		t.Fatalf("results out of order: %+v", results)
+	}
+	if results[0].answer != "du -sh *" || results[0].err != nil || results[0].tokens == 0 {
+		t.Errorf("unexpected first result %+v", results[0])
+	}
+	if results[1].err == nil {
+		t.Errorf("expected an error from the broken provider, got %+v", results[1])
+	}
+}
+
+func TestFormatComparison(t *testing.T) {
+	got := formatComparison([]comparison{
+		{model: "llama3", answer: "du -sh *", elapsed: 1200 * time.Millisecond, tokens: 3},
+		{model: "mistral", err: errors.New("timeout"), elapsed: 30 * time.Second},
+	}, 80)
+	for _, want := range []string{"llama3", "1.2s, ~3 tokens", "du -sh *", "mistral", "Error: timeout"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// compilers check code in each language by ID without running it. The file
+// name is added to the end of the arguments, and they run in the file's
+// directory.
+var compilers = map[string][]string{
+	"bash":       {"bash", "-n"},
+	"c":          {"cc", "-fsyntax-only"},
+	"cpp":        {"c++", "-fsyntax-only"},
+	"fish":       {"fish", "--no-execute"},
+	"go":         {"go", "vet"},
+	"javascript": {"node", "--check"},
+	"lua":        {"luac", "-p"},
+	"php":        {"php", "-l"},
+	"python":     {"python3", "-m", "py_compile"},
+	"ruby":       {"ruby", "-c"},
+	"rust":       {"rustc", "--edition", "2021", "--crate-type", "lib", "--emit", "metadata", "--out-dir", "."},
+	"swift":      {"swiftc", "-parse"},
+	"typescript": {"tsc", "--noEmit"},
+	"zsh":        {"zsh", "-n"},
+}
+
+// compileTimeout bounds how long checking code may take, which for Go
+// includes building the standard library the first time
+const compileTimeout = time.Minute
+
+// verifyCode compiles or lints the code in the language asked for, or of
+// -o's extension, and asks the model for one correction if that fails
+func verifyCode(ctx context.Context, cfg *config.Config, client *llm.Client, opts *options, sys llm.System, code string) (string, error) {
+	lang := llm.CodeLanguage(opts.codeLang)
+	if opts.codeLang == "" {
+		var ok bool
+		if lang, ok = llm.ExtensionLanguage(filepath.Ext(opts.output)); !ok {
+			slog.Warn("--verify needs --lang or an -o file extension to know how to check the code")
+			return code, nil
+		}
+	}
+	args, ok := compilers[lang.ID]
+	if !ok {
+		slog.Warn("Don't know how to check " + lang.Name + " code; not verifying it")
+		return code, nil
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		slog.Warn(args[0] + " not found; not verifying the code")
+		return code, nil
+	}
+
+	compileErr := compileCode(ctx, lang, code)
+	if compileErr == nil {
+		return code, nil
+	}
+	if errors.Is(compileErr, errInterrupted) {
+		return "", compileErr
+	}
+	slog.Info("The code doesn't compile; asking for a correction", "error", compileErr)
+	sys.Notes = append(sys.Notes, fmt.Sprintf("Your previous code failed to compile with:\n%v", compileErr))
+	response, _, err := requery(ctx, cfg, client, opts, sys)
+	if err != nil {
+		return "", err
+	}
+	corrected := cleanCode(response)
+	if err := compileCode(ctx, lang, corrected); errors.Is(err, errInterrupted) {
+		return "", err
+	} else if err != nil {
+		slog.Warn("The corrected code doesn't compile either", "error", err)
+	}
+	return corrected, nil
+}
+
+// compileCode checks code with lang's compiler or linter in a temporary
+// directory, returning its messages if it fails, or errInterrupted if
+// Ctrl-C stops it
+func compileCode(ctx context.Context, lang llm.Language, code string) error {
+	args := compilers[lang.ID]
+	dir, err := os.MkdirTemp("", "llm-verify-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	name := "main" + lang.Extension
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(code+"\n"), 0600); err != nil {
+		return err
+	}
+
+	ctx, stop := interruptible(ctx)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, compileTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], append(args[1:], name)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if context.Cause(ctx) == errInterrupted {
+			return errInterrupted
+		}
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return errors.New(msg)
+		}
+		return err
+	}
+	return nil
+}
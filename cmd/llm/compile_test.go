@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestCompileCode(t *testing.T) {
+	tests := []struct {
+		lang string
+		code string
+		err  string
+	}{
+		{"go", "package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println(1) }", ""},
+		{"go", "package main\n\nfunc main() { undefined() }", "undefined"},
+		{"python", "def f(x):\n    return x * 2", ""},
+		{"python", "def f(x)\n    return x", "SyntaxError"},
+		{"bash", "for f in *; do echo \"$f\"; done", ""},
+		{"bash", "if true; then echo", "syntax error"},
+	}
+	for _, tt := range tests {
+		lang := llm.CodeLanguage(tt.lang)
+		if _, err := exec.LookPath(compilers[lang.ID][0]); err != nil {
+			continue
+		}
+		err := compileCode(context.Background(), lang, tt.code)
+		if tt.err == "" && err != nil {
+			t.Errorf("%s %q: %v", tt.lang, tt.code, err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s %q: error = %v, want %q", tt.lang, tt.code, err, tt.err)
+		}
+	}
+}
+
+func TestCompileCodeInterrupted(t *testing.T) {
+	lang := llm.CodeLanguage("bash")
+	if _, err := exec.LookPath(compilers[lang.ID][0]); err != nil {
+		t.Skip("bash not installed")
+	}
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errInterrupted)
+	if err := compileCode(ctx, lang, "echo hi"); err != errInterrupted {
+		t.Errorf("got %v, want errInterrupted", err)
+	}
+}
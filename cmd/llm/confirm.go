@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// defaultConfirmBytes is how much attached context can be sent without
+// asking first, unless confirm_bytes is set
+const defaultConfirmBytes = 32 * 1024
+
+// defaultSensitivePatterns match path components of attachments that
+// commonly hold secrets. sensitive_paths in the config adds to these.
+var defaultSensitivePatterns = []string{
+	".ssh", ".gnupg", ".aws", ".kube", ".docker",
+	".env", ".env.*", ".netrc", ".npmrc", ".pypirc", ".git-credentials",
+	"*.pem", "*.key", "*.p12", "*.pfx", "id_rsa*", "id_ecdsa*", "id_ed25519*",
+	"credentials*", "secrets*",
+}
+
+// errAborted is returned when the user declines to continue
+var errAborted = errors.New("aborted")
+
+// isSensitivePath reports whether any component of path matches a pattern
+func isSensitivePath(path string, patterns []string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	for _, part := range strings.Split(filepath.ToSlash(abs), "/") {
+		for _, pattern := range patterns {
+			if ok, _ := filepath.Match(pattern, part); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// confirmAttachments asks before sending attachments that are large or look
+// sensitive, returning errAborted if the user declines
+func confirmAttachments(attachments []llm.Attachment, cfg *config.Config, provider llm.Provider) error {
+	limit := defaultConfirmBytes
+	if cfg.Has("confirm_bytes") {
+		limit = cfg.Int("confirm_bytes")
+	}
+	patterns := slices.Concat(defaultSensitivePatterns, cfg.Strings("sensitive_paths"))
+
+	total := 0
+	var sensitive []string
+	for _, a := range attachments {
+		total += len(a.Content)
+		if a.Name != "" && isSensitivePath(a.Name, patterns) {
+			sensitive = append(sensitive, a.Name)
+		}
+	}
+	if (limit <= 0 || total <= limit) && len(sensitive) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "About to send %s of attached context to %v:\n", humanBytes(total), provider)
+	for _, a := range attachments {
+		name := a.Name
+		if name == "" {
+			name = "piped input"
+			if a.Title != "" {
+				name = strings.ToLower(a.Title)
+			}
+		}
+		note := ""
+		if a.Name != "" && isSensitivePath(a.Name, patterns) {
+			note = "  (may contain secrets)"
+		}
+		fmt.Fprintf(os.Stderr, "  %-40s %8s%s\n", name, humanBytes(len(a.Content)), note)
+	}
+
+	ok, err := confirm("Continue?")
+	if err != nil {
+		return fmt.Errorf("%v; pass --yes to send without confirming", err)
+	}
+	if !ok {
+		return errAborted
+	}
+	return nil
+}
+
+// confirm asks a yes/no question on the terminal, defaulting to no. It reads
+// from the terminal rather than stdin so that it works with piped input.
+func confirm(question string) (bool, error) {
+	tty, err := openTTY()
+	if err != nil {
+		return false, errors.New("confirmation needed but there is no terminal")
+	}
+	defer tty.Close()
+
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil {
+		return false, errors.New("confirmation needed but there is no terminal")
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// openTTY opens the controlling terminal for reading
+func openTTY() (*os.File, error) {
+	if runtime.GOOS == "windows" {
+		return os.Open("CONIN$")
+	}
+	return os.Open("/dev/tty")
+}
+
+// humanBytes formats n bytes for messages
+func humanBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
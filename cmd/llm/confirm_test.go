@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestIsSensitivePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/home/me/.ssh/config", true},
+		{".env", true},
+		{"config/.env.production", true},
+		{"certs/server.pem", true},
+		{"/home/me/.aws/credentials", true},
+		{"main.go", false},
+		{"docs/environment.md", false},
+	}
+	for _, tt := range tests {
+		if got := isSensitivePath(tt.path, defaultSensitivePatterns); got != tt.want {
+			t.Errorf("isSensitivePath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestConfirmAttachmentsNotNeeded(t *testing.T) {
+	small := []llm.Attachment{{Name: "main.go", Content: "package main"}, {Content: "log line"}}
+	if err := confirmAttachments(small, nil, llm.Claude); err != nil {
+		t.Errorf("small attachments shouldn't need confirmation: %v", err)
+	}
+
+	// A non-positive limit disables the size check
+	cfg, _ := config.Parse("confirm_bytes = 0")
+	big := []llm.Attachment{{Content: string(make([]byte, defaultConfirmBytes+1))}}
+	if err := confirmAttachments(big, cfg, llm.Claude); err != nil {
+		t.Errorf("size check should be disabled: %v", err)
+	}
+}
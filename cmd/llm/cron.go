@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/cron"
+)
+
+// cronRuns is how many upcoming runs are shown for --cron
+const cronRuns = 5
+
+// checkCron parses the crontab line in response, returning it cleaned up
+// along with a description of when it next fires, worked out locally so the
+// user can check the model got it right
+func checkCron(response string, now time.Time) (string, string, error) {
+	line := strings.TrimSpace(strings.Trim(strings.TrimSpace(response), "`"))
+	fields := strings.Fields(line)
+	n := 5
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "@") {
+		n = 1
+	}
+	if len(fields) < n {
+		return "", "", fmt.Errorf("the answer isn't a cron expression: %q", line)
+	}
+
+	schedule, err := cron.Parse(strings.Join(fields[:n], " "))
+	if err != nil {
+		return "", "", fmt.Errorf("the answer isn't a valid cron expression (%v): %q", err, line)
+	}
+
+	var b strings.Builder
+	b.WriteString("Next runs:")
+	t := now
+	for range cronRuns {
+		if t, err = schedule.Next(t); err != nil {
+			return "", "", fmt.Errorf("%q: %v", line, err)
+		}
+		b.WriteString("\n  " + t.Format("Mon Jan _2 2006 15:04"))
+	}
+	return line, b.String(), nil
+}
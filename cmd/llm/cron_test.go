@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckCron(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	line, runs, err := checkCron("`30 6 * * 1-5 /usr/local/bin/backup`\n", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "30 6 * * 1-5 /usr/local/bin/backup" {
+		t.Errorf("line = %q", line)
+	}
+	if !strings.HasPrefix(runs, "Next runs:\n  Mon Oct 19 2026 06:30\n  Tue Oct 20 2026 06:30") {
+		t.Errorf("runs = %q", runs)
+	}
+
+	if line, _, err := checkCron("@daily", now); err != nil || line != "@daily" {
+		t.Errorf("@daily = %q, %v", line, err)
+	}
+
+	for _, bad := range []string{"every day", "0 0 31 2 *", "61 * * * *"} {
+		if _, _, err := checkCron(bad, now); err == nil {
+			t.Errorf("checkCron(%q) should fail", bad)
+		}
+	}
+}
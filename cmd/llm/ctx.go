@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// applyContextVars parses the --ctx KEY=VALUE pairs and fills them into the
+// query, where {{.KEY}} stands for VALUE. A query is only a template when
+// --ctx is given, so queries about templates are sent as they are. With -t
+// the values go to the template instead. A favorite's query has already
+// been expanded, and isn't again, since {{sh}} output may hold braces too.
+func applyContextVars(opts *options) error {
+	if len(opts.ctx) == 0 {
+		return nil
+	}
+	vars, err := parseVars(opts.ctx)
+	if err != nil {
+		return err
+	}
+	opts.ctxVars = vars
+	if opts.template != "" || opts.favorite {
+		return nil
+	}
+	var missing missingVarError
+	opts.query, err = expandTemplate("query", opts.query, vars, templateFuncs(vars))
+	if errors.As(err, &missing) {
+		return usageError(fmt.Sprintf("the query uses %s: add --ctx %s=VALUE", string(missing), string(missing)))
+	}
+	return err
+}
+
+// addContextVars tells the model the --ctx values, e.g. "cluster: staging",
+// whether or not the query mentions them
+func addContextVars(sys *llm.System, vars map[string]string) {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		sys.Notes = append(sys.Notes, k+": "+vars[k])
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestApplyContextVars(t *testing.T) {
+	tests := []struct {
+		args  []string
+		query string
+		notes []string
+		err   string
+	}{
+		{[]string{"--ctx", "cluster=staging", "--ctx", "ns=api", "pods in {{.ns}}"}, "pods in api", []string{"cluster: staging", "ns: api"}, ""},
+		{[]string{"--ctx", "cluster=prod", "list pods"}, "list pods", []string{"cluster: prod"}, ""},
+		{[]string{"what does {{.Name}} do in a Go template"}, "what does {{.Name}} do in a Go template", nil, ""},
+		{[]string{"--ctx", "a=1", "{{.b}}"}, "", nil, "add --ctx b=VALUE"},
+		{[]string{"--ctx", "cluster", "q"}, "", nil, "isn't NAME=VALUE"},
+	}
+	for _, tt := range tests {
+		opts, err := parseArgs(tt.args, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = applyContextVars(opts)
+		if tt.err != "" {
+			if exitCode(err) != exitUsage || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: error = %v, want %q", tt.args, err, tt.err)
+			}
+			continue
+		}
+		var sys llm.System
+		addContextVars(&sys, opts.ctxVars)
+		if err != nil || opts.query != tt.query || !slices.Equal(sys.Notes, tt.notes) {
+			t.Errorf("%q: query = %q, notes = %q, %v; want %q, %q", tt.args, opts.query, sys.Notes, err, tt.query, tt.notes)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/internal/paths"
+)
+
+// daemonTargetHeader carries the URL a request forwarded through the
+// daemon is meant for
+const daemonTargetHeader = "X-Llm-Target"
+
+// daemonErrorHeader marks a response as the daemon's report that it
+// couldn't reach the provider, rather than the provider's answer. It holds
+// the operation that failed, such as "dial".
+const daemonErrorHeader = "X-Llm-Relay-Error"
+
+// daemonIdleTimeout is how long the daemon keeps an unused provider
+// connection open
+const daemonIdleTimeout = 10 * time.Minute
+
+// daemonSocket returns the path of the daemon's Unix socket
+func daemonSocket() (string, error) {
+	dir, err := paths.RuntimeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "daemon.sock"), nil
+}
+
+// runDaemon runs, stops or reports on the daemon that keeps provider
+// connections and the config loaded between invocations. Each run still
+// builds its own client.
+func runDaemon(args []string) error {
+	flagSet := flag.NewFlagSet("llm daemon", flag.ContinueOnError)
+	var logs logFlags
+	logs.register(flagSet)
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	socket, err := daemonSocket()
+	if err != nil {
+		return err
+	}
+
+	switch flagSet.Arg(0) {
+	case "":
+		if err := logs.setup(); err != nil {
+			return err
+		}
+		return serveDaemon(socket)
+	case "status":
+		var status struct {
+			PID      int    `json:"pid"`
+			Uptime   string `json:"uptime"`
+			Requests int64  `json:"requests"`
+		}
+		if err := callDaemon(socket, "GET", "/status", &status); err != nil {
+			return err
+		}
+		fmt.Printf("Running as pid %d for %s, %d requests forwarded\n", status.PID, status.Uptime, status.Requests)
+		return nil
+	case "stop":
+		if err := callDaemon(socket, "POST", "/stop", nil); err != nil {
+			return err
+		}
+		fmt.Println("Stopped the daemon")
+		return nil
+	}
+	return usageError("usage: llm daemon [status|stop]")
+}
+
+// serveDaemon listens on socket until interrupted or stopped
+func serveDaemon(socket string) error {
+	if err := os.MkdirAll(filepath.Dir(socket), 0700); err != nil {
+		return err
+	}
+	if conn, err := net.Dial("unix", socket); err == nil {
+		conn.Close()
+		return errors.New("the daemon is already running")
+	}
+	// Left behind by a daemon that didn't exit cleanly
+	os.Remove(socket)
+
+	ln, err := listenPrivate(socket)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	srv := &http.Server{Handler: newDaemonHandler(stop)}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", socket)
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// newDaemonHandler forwards requests to the URL in daemonTargetHeader over
+// connections that are kept open, and answers /config, /status and /stop
+func newDaemonHandler(stop func()) http.Handler {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.IdleConnTimeout = daemonIdleTimeout
+	client := &http.Client{Transport: transport}
+	start := time.Now()
+	var forwarded atomic.Int64
+	var configs configCache
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"pid":      os.Getpid(),
+			"uptime":   time.Since(start).Round(time.Second).String(),
+			"requests": forwarded.Load(),
+		})
+	})
+	mux.HandleFunc("GET /config", func(w http.ResponseWriter, r *http.Request) {
+		if path, err := config.DefaultPath(); err != nil || r.URL.Query().Get("path") != path {
+			// The run reads another file, e.g. with XDG_CONFIG_HOME set
+			http.Error(w, "not the daemon's config file", http.StatusNotFound)
+			return
+		}
+		cfg, err := configs.load()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var b bytes.Buffer
+		if err := cfg.Encode(&b); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write(b.Bytes())
+	})
+	mux.HandleFunc("POST /stop", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		stop()
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		target, err := url.Parse(r.Header.Get(daemonTargetHeader))
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			http.Error(w, "missing or invalid "+daemonTargetHeader, http.StatusBadRequest)
+			return
+		}
+
+		req, err := http.NewRequestWithContext(r.Context(), r.Method, target.String(), r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Header = r.Header.Clone()
+		req.Header.Del(daemonTargetHeader)
+		req.ContentLength = r.ContentLength
+
+		forwarded.Add(1)
+		slog.Debug("forwarding request", "method", req.Method, "url", target)
+		resp, err := client.Do(req)
+		if err != nil {
+			op, msg := "request", err.Error()
+			var opErr *net.OpError
+			if errors.As(err, &opErr) {
+				op, msg = opErr.Op, opErr.Err.Error()
+			}
+			w.Header().Set(daemonErrorHeader, op)
+			http.Error(w, msg, http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		for name, values := range resp.Header {
+			w.Header()[name] = values
+		}
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	})
+	return mux
+}
+
+// configCache keeps the config loaded, reloading it when the config or
+// policy file changes
+type configCache struct {
+	mu    sync.Mutex
+	stamp string
+	cfg   *config.Config
+}
+
+// load returns the loaded config, reloading it if its files have changed
+func (c *configCache) load() (*config.Config, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stamp := config.Stamp()
+	if c.cfg == nil || stamp != c.stamp {
+		cfg, err := config.Load()
+		if err != nil {
+			return nil, err
+		}
+		c.cfg, c.stamp = cfg, stamp
+	}
+	return c.cfg, nil
+}
+
+// loadConfig gets the config from llm daemon when it's running, unless
+// LLM_NO_DAEMON is set, and otherwise reads it
+func loadConfig() (*config.Config, error) {
+	if socket, err := daemonSocket(); err == nil && os.Getenv("LLM_NO_DAEMON") == "" {
+		if cfg, err := daemonConfig(socket); err == nil {
+			return cfg, nil
+		}
+	}
+	return config.Load()
+}
+
+// daemonConfig gets the config the daemon on socket keeps loaded, if it
+// reads the same file this run would. The policy is applied again, so that
+// it holds whatever answers on the socket.
+func daemonConfig(socket string) (*config.Config, error) {
+	if _, err := os.Stat(socket); err != nil {
+		return nil, err
+	}
+	path, err := config.DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := daemonHTTPClient(socket).Get("http://daemon/config?path=" + url.QueryEscape(path))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("daemon returned status %d", resp.StatusCode)
+	}
+	cfg, err := config.Decode(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := config.LoadFile(paths.PolicyFile())
+	if err != nil {
+		return nil, err
+	}
+	cfg.Enforce(policy)
+	return cfg, nil
+}
+
+// daemonHTTPClient returns a client whose requests go to the daemon
+func daemonHTTPClient(socket string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}}
+}
+
+// callDaemon sends a control request to the daemon, decoding the answer
+// into result if it isn't nil
+func callDaemon(socket, method, path string, result any) error {
+	req, err := http.NewRequest(method, "http://daemon"+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := daemonHTTPClient(socket).Do(req)
+	if err != nil {
+		return errors.New("the daemon isn't running")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("daemon returned status %d", resp.StatusCode)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// daemonTransport sends requests through the daemon, or with base directly
+// if the daemon can't be reached
+type daemonTransport struct {
+	socket string
+	daemon http.RoundTripper
+	base   http.RoundTripper
+}
+
+// newDaemonTransport returns a daemonTransport for the daemon listening on
+// socket
+func newDaemonTransport(socket string, base http.RoundTripper) *daemonTransport {
+	return &daemonTransport{socket: socket, daemon: daemonHTTPClient(socket).Transport, base: base}
+}
+
+func (t *daemonTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.URL = &url.URL{Scheme: "http", Host: "daemon", Path: "/forward"}
+	out.Host = ""
+	out.Header.Set(daemonTargetHeader, req.URL.String())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		out.Body = body
+	}
+
+	resp, err := t.daemon.RoundTrip(out)
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		slog.Debug("daemon unreachable, connecting directly", "socket", t.socket, "error", err)
+		return t.base.RoundTrip(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if op := resp.Header.Get(daemonErrorHeader); op != "" {
+		// Rebuild the error the request would have failed with directly, so
+		// that it's reported and retried as a network failure
+		msg, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, &net.OpError{Op: op, Net: "tcp", Err: errors.New(strings.TrimSpace(string(msg)))}
+	}
+	return resp, nil
+}
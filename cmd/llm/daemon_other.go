@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "net"
+
+// listenPrivate listens on a Unix socket. There's no umask here; the socket
+// is kept private by the per-user directory it's in.
+func listenPrivate(socket string) (net.Listener, error) {
+	return net.Listen("unix", socket)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// startDaemon serves the daemon on a socket in a temporary directory
+func startDaemon(t *testing.T) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "daemon.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	srv := &http.Server{Handler: newDaemonHandler(func() {})}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	return socket
+}
+
+func TestDaemonForwards(t *testing.T) {
+	provider := mockProvider(t, http.StatusOK, `{"response":"uptime"}`)
+	socket := startDaemon(t)
+
+	transport := newDaemonTransport(socket, roundTripFunc(func(*http.Request) (*http.Response, error) {
+		t.Fatal("request bypassed the daemon")
+		return nil, nil
+	}))
+	c := &llm.Client{Provider: llm.Ollama, Model: "llama3", Endpoint: provider.URL, HTTPClient: &http.Client{Transport: transport}}
+	for range 2 {
+		if got, err := c.Query(context.Background(), "how long up"); err != nil || got != "uptime" {
+			t.Fatalf("got %q, %v", got, err)
+		}
+	}
+
+	var status struct{ Requests int64 }
+	if err := callDaemon(socket, "GET", "/status", &status); err != nil || status.Requests != 2 {
+		t.Errorf("status = %+v, %v, want 2 requests", status, err)
+	}
+}
+
+func TestDaemonConfig(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("config directory comes from the user profile")
+	}
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	path := filepath.Join(dir, "llm", "config.toml")
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	socket := startDaemon(t)
+
+	for _, model := range []string{"gpt-4o", "gpt-4o-mini"} {
+		if err := os.WriteFile(path, []byte("model = \""+model+"\"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := daemonConfig(socket)
+		if err != nil || cfg.String("model") != model || cfg.Path() != path {
+			t.Errorf("got %v, %v, want model %s from %s", cfg, err, model, path)
+		}
+	}
+
+	// A run that reads another config file reads it itself
+	resp, err := daemonHTTPClient(socket).Get("http://daemon/config?path=/elsewhere/config.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("another file's config gave status %d", resp.StatusCode)
+	}
+}
+
+func TestDaemonRelaysNetworkErrors(t *testing.T) {
+	// Nothing listens on the provider's address
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := "http://" + ln.Addr().String()
+	ln.Close()
+	socket := startDaemon(t)
+
+	c := &llm.Client{Provider: llm.Ollama, Model: "llama3", Endpoint: endpoint,
+		HTTPClient: &http.Client{Transport: newDaemonTransport(socket, http.DefaultTransport)}}
+	_, err = c.Query(context.Background(), "how long up")
+	var netErr *llm.NetworkError
+	if !errors.As(err, &netErr) {
+		t.Fatalf("got %v, want a network error", err)
+	}
+	if exitCode(err) != exitNetwork {
+		t.Errorf("exit status = %d, want %d", exitCode(err), exitNetwork)
+	}
+}
+
+func TestDaemonUnreachable(t *testing.T) {
+	provider := mockProvider(t, http.StatusOK, `{"response":"uptime"}`)
+	socket := filepath.Join(t.TempDir(), "missing.sock")
+
+	transport := newDaemonTransport(socket, http.DefaultTransport)
+	c := &llm.Client{Provider: llm.Ollama, Model: "llama3", Endpoint: provider.URL, HTTPClient: &http.Client{Transport: transport}}
+	if got, err := c.Query(context.Background(), "how long up"); err != nil || got != "uptime" {
+		t.Fatalf("got %q, %v, want a direct answer", got, err)
+	}
+	if err := callDaemon(socket, "GET", "/status", nil); err == nil {
+		t.Error("expected an error with no daemon running")
+	}
+}
+
+func TestListenPrivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix permissions")
+	}
+	socket := filepath.Join(t.TempDir(), "daemon.sock")
+	ln, err := listenPrivate(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		t.Errorf("socket permissions = %v, want only the owner", perm)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// Limits on how much of the project --docker looks at
+const (
+	maxScanFiles    = 500
+	maxScanFileSize = 256 << 10
+	maxManifestSize = 4 << 10
+)
+
+// entrypointPatterns are files that usually start an application
+var entrypointPatterns = []string{
+	"main.go", "cmd/*/main.go",
+	"app.py", "main.py", "manage.py", "wsgi.py", "asgi.py",
+	"server.js", "index.js", "app.js", "src/index.ts", "src/server.ts",
+	"src/main.rs", "config.ru", "Procfile",
+}
+
+// manifestFiles are sent whole (up to maxManifestSize), since versions and
+// dependencies decide the base image and build steps
+var manifestFiles = []string{
+	"go.mod", "package.json", "pyproject.toml", "requirements.txt", "Pipfile",
+	"Cargo.toml", "Gemfile", "pom.xml", "build.gradle", "composer.json",
+	"Dockerfile", "compose.yaml", "docker-compose.yml", ".dockerignore",
+}
+
+// skipDirs aren't searched for ports
+var skipDirs = []string{".git", "node_modules", "vendor", "target", "dist", "build", ".venv", "venv", "__pycache__"}
+
+// sourceExts are the files searched for ports
+var sourceExts = []string{".go", ".py", ".js", ".ts", ".rb", ".rs", ".java", ".kt", ".php", ".env", ".yaml", ".yml", ".toml", ".json"}
+
+// portRe finds port numbers near words that suggest a server listens on them
+var portRe = regexp.MustCompile(`(?i)(listen|port|addr|expose|bind)\w*["'\s:=(,|]{0,8}(?:[\w.]*:)?(\d{2,5})\b`)
+
+// prepareDocker describes the project in dir for --docker: what kind it is,
+// likely entrypoints, ports mentioned in the source, and its manifests
+func prepareDocker(sys *llm.System, dir string) {
+	project := llm.DetectProject(dir)
+	sys.Project = &project
+
+	var entrypoints []string
+	for _, pattern := range entrypointPatterns {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		for _, m := range matches {
+			rel, _ := filepath.Rel(dir, m)
+			entrypoints = append(entrypoints, filepath.ToSlash(rel))
+		}
+	}
+	if len(entrypoints) > 0 {
+		sys.Notes = append(sys.Notes, "Likely entrypoints: "+strings.Join(entrypoints, ", "))
+	}
+	if ports := findPorts(dir); len(ports) > 0 {
+		sys.Notes = append(sys.Notes, "Ports mentioned in the source: "+strings.Join(ports, ", "))
+	}
+
+	for _, name := range manifestFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || len(data) == 0 {
+			continue
+		}
+		content := string(data)
+		if len(content) > maxManifestSize {
+			content = content[:maxManifestSize] + "\n..."
+		}
+		sys.Attachments = append(sys.Attachments, llm.Attachment{Name: name, Content: content})
+	}
+}
+
+// findPorts returns the likely server ports mentioned in source files under
+// dir, in the order found
+func findPorts(dir string) []string {
+	var ports []string
+	scanned := 0
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir && slices.Contains(skipDirs, d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if scanned >= maxScanFiles {
+			return filepath.SkipAll
+		}
+		if !slices.Contains(sourceExts, filepath.Ext(path)) && d.Name() != "Dockerfile" {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxScanFileSize {
+			return nil
+		}
+		scanned++
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		for _, m := range portRe.FindAllStringSubmatch(string(data), -1) {
+			n, _ := strconv.Atoi(m[2])
+			if (n == 80 || n == 443 || n >= 1024 && n <= 65535) && !slices.Contains(ports, m[2]) {
+				ports = append(ports, m[2])
+			}
+		}
+		return nil
+	})
+	return ports
+}
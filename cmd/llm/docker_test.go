@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestFindPorts(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":              `http.ListenAndServe(":8080", nil)`,
+		"app.py":               "app.run(host='0.0.0.0', port=5000)",
+		"config.yaml":          "port: 8080\nretries: 3",
+		"node_modules/x/a.js":  "server.listen(3000)",
+		"src/server.ts":        "const PORT = process.env.PORT || 4000;",
+		"README.md":            "listens on port 9999",
+		"internal/db/db.go":    `addr := "localhost:5432"`,
+		"internal/db/retry.go": "for port := 0; port < 10; port++ {}",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	got := findPorts(dir)
+	want := []string{"5000", "8080", "5432", "4000"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findPorts() = %q, want %q", got, want)
+	}
+}
+
+func TestPrepareDocker(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "cmd", "server"), 0755)
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.22\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "cmd", "server", "main.go"), []byte(`http.ListenAndServe(":8080", nil)`), 0644)
+
+	var sys llm.System
+	prepareDocker(&sys, dir)
+
+	notes := strings.Join(sys.Notes, "\n")
+	for _, want := range []string{"Likely entrypoints: cmd/server/main.go", "Ports mentioned in the source: 8080"} {
+		if !strings.Contains(notes, want) {
+			t.Errorf("notes %q don't contain %q", notes, want)
+		}
+	}
+	if len(sys.Attachments) != 1 || sys.Attachments[0].Name != "go.mod" {
+		t.Errorf("attachments = %+v, want go.mod", sys.Attachments)
+	}
+	if sys.Project == nil || len(sys.Project.Types) == 0 {
+		t.Errorf("project = %+v, want it detected", sys.Project)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/internal/patch"
+	"github.com/jamesob/llm-cli/pkg/llm"
+	"github.com/jamesob/llm-cli/pkg/render"
+)
+
+const editUsage = `usage: llm edit [-y] [--no-backup] <file> "<change to make>"`
+
+// runEdit asks for a change to one file, shows it as a diff and writes it
+// once the user agrees, keeping the original as FILE.bak
+func runEdit(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	opts := &options{mode: llm.PatchMode}
+	var noBackup bool
+	flagSet := flag.NewFlagSet("llm edit", flag.ContinueOnError)
+	flagSet.BoolVar(&opts.yes, "y", false, "Write the change without asking")
+	flagSet.BoolVar(&noBackup, "no-backup", false, "Don't keep the original as FILE.bak")
+	flagSet.BoolVar(&opts.noRedact, "no-redact", false, "Send the file without redacting secrets")
+	opts.log.register(flagSet)
+	flagSet.Usage = func() {
+		fmt.Fprintln(os.Stderr, editUsage)
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if flagSet.NArg() < 2 {
+		return usageError(editUsage)
+	}
+	path := flagSet.Arg(0)
+	opts.files = []string{path}
+	opts.query = expandAliases(strings.Join(flagSet.Args()[1:], " "), cfg)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s isn't a regular file", path)
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := opts.log.setup(); err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+	sys := llm.DetectSystem()
+	sys.Attachments = []llm.Attachment{{Name: path, Content: string(original)}}
+
+	response, err := ask(context.Background(), cfg, client, opts, sys)
+	if err != nil {
+		return err
+	}
+	edited, err := editedContent(path, string(original), response)
+	if err != nil {
+		return err
+	}
+
+	diff := patch.Diff(path, string(original), edited)
+	if diff == "" {
+		fmt.Fprintf(notices, "No changes to %s\n", path)
+		return nil
+	}
+	if isTerminal(os.Stdout) {
+		diff = render.Diff(diff)
+	}
+	fmt.Print(diff)
+
+	if !opts.yes {
+		ok, err := confirm("Write the changes to " + path + "?")
+		if err != nil {
+			return fmt.Errorf("%v; pass -y to write without confirming", err)
+		}
+		if !ok {
+			return errAborted
+		}
+	}
+	if !noBackup {
+		if err := os.WriteFile(path+".bak", original, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to back up %s: %v", path, err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(edited), info.Mode().Perm()); err != nil {
+		return err
+	}
+	if noBackup {
+		fmt.Fprintf(notices, "Edited %s\n", path)
+	} else {
+		fmt.Fprintf(notices, "Edited %s; the original is in %s.bak\n", path, path)
+	}
+	return nil
+}
+
+// editedContent returns the file as the model's answer leaves it. The
+// answer is asked to be a diff, but may instead be the whole new file.
+func editedContent(path, original, response string) (string, error) {
+	response = stripFence(response)
+	if strings.HasPrefix(response, "--- ") || strings.HasPrefix(response, "diff ") {
+		changes, err := checkPatch(response, []string{path})
+		if err != nil {
+			return "", fmt.Errorf("the patch doesn't apply: %v", err)
+		}
+		if len(changes) != 1 || changes[0].created || changes[0].deleted {
+			return "", fmt.Errorf("the patch must only change %s", path)
+		}
+		return changes[0].content, nil
+	}
+
+	edited := cleanCode(response)
+	if strings.HasSuffix(original, "\n") {
+		edited += "\n"
+	}
+	return edited, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEditedContent(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	t.Cleanup(func() { os.Chdir(wd) })
+	original := "hello\nworld\n"
+	os.WriteFile("greet.txt", []byte(original), 0644)
+
+	tests := []struct {
+		name     string
+		response string
+		want     string
+		err      string
+	}{
+		{
+			name:     "diff",
+			response: "--- a/greet.txt\n+++ b/greet.txt\n@@ -1,2 +1,2 @@\n-hello\n+goodbye\n world\n",
+			want:     "goodbye\nworld\n",
+		},
+		{
+			name:     "fenced diff",
+			response: "```diff\n--- a/greet.txt\n+++ b/greet.txt\n@@ -1,2 +1,2 @@\n hello\n-world\n+there\n```",
+			want:     "hello\nthere\n",
+		},
+		{
+			name:     "whole file",
+			response: "```\ngoodbye\nworld\n```",
+			want:     "goodbye\nworld\n",
+		},
+		{
+			name:     "diff that doesn't apply",
+			response: "--- a/greet.txt\n+++ b/greet.txt\n@@ -1 +1 @@\n-howdy\n+bye\n",
+			err:      "doesn't apply",
+		},
+		{
+			name:     "diff of another file",
+			response: "--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+x\n",
+			err:      "must only change greet.txt",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := editedContent("greet.txt", original, tt.response)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("editedContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// Exit statuses, so that scripts can tell failures apart
+const (
+	exitOK          = 0
+	exitError       = 1 // anything not listed below
+	exitUsage       = 2 // bad flags or arguments
+	exitAuth        = 3 // no API key, or the provider rejected it
+	exitRateLimited = 4
+	exitNetwork     = 5 // the provider couldn't be reached
+	exitProvider    = 6 // the provider returned some other error
+	exitInterrupted = 130
+)
+
+// usageError is returned for a malformed command line. Its text is the
+// problem or the usage line.
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// exitCode returns the exit status for a failure with err
+func exitCode(err error) int {
+	var usage usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errInterrupted):
+		return exitInterrupted
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, errNoKey):
+		return exitAuth
+	}
+	switch llm.ClassifyError(err) {
+	case llm.ErrorAuth:
+		return exitAuth
+	case llm.ErrorRateLimit:
+		return exitRateLimited
+	case llm.ErrorNetwork:
+		return exitNetwork
+	case llm.ErrorProvider:
+		return exitProvider
+	}
+	return exitError
+}
+
+// errorReport is an error as --json reports it
+type errorReport struct {
+	// Type is "auth", "rate_limit", "network" or "provider" for failed
+	// requests, or "usage", "interrupted" or "error"
+	Type      string `json:"type"`
+	Provider  string `json:"provider,omitempty"`
+	Status    int    `json:"status,omitempty"`
+	Retryable bool   `json:"retryable"`
+	Message   string `json:"message"`
+
+	// RequestID is the provider's reference for the request, for support
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// newErrorReport describes err for --json
+func newErrorReport(err error) errorReport {
+	report := errorReport{
+		Type:      string(llm.ClassifyError(err)),
+		Retryable: llm.Retryable(err),
+		Message:   err.Error(),
+	}
+	var usage usageError
+	switch {
+	case errors.Is(err, errInterrupted):
+		report.Type = "interrupted"
+	case errors.As(err, &usage):
+		report.Type = "usage"
+	case errors.Is(err, errNoKey):
+		report.Type = string(llm.ErrorAuth)
+	case report.Type == "":
+		report.Type = "error"
+	}
+
+	var status *llm.StatusError
+	var netErr *llm.NetworkError
+	if errors.As(err, &status) {
+		report.Provider, report.Status, report.RequestID = status.Provider.String(), status.StatusCode, status.RequestID
+	} else if errors.As(err, &netErr) {
+		report.Provider = netErr.Provider.String()
+	}
+	return report
+}
+
+// reportError writes err to w, as a line of JSON if asJSON is set
+func reportError(w io.Writer, err error, asJSON bool) {
+	if asJSON {
+		json.NewEncoder(w).Encode(newErrorReport(err))
+		return
+	}
+	fmt.Fprintf(w, "Error: %v\n", err)
+}
+
+// fatal reports err on stderr and exits with its status
+func fatal(err error, asJSON bool) {
+	reportError(os.Stderr, err, asJSON)
+	exit(exitCode(err))
+}
+
+// exit exports telemetry, which deferred calls would miss, and exits
+func exit(code int) {
+	flushTelemetry()
+	os.Exit(code)
+}
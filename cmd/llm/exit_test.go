@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, exitOK},
+		{"other", errors.New("failed to read image"), exitError},
+		{"usage", usageError(batchUsage), exitUsage},
+		{"no provider", llm.ErrNoProvider, exitAuth},
+		{"no key", fmt.Errorf("%w for gpt-4o", errNoKey), exitAuth},
+		{"bad key", &llm.StatusError{StatusCode: 401}, exitAuth},
+		{"rate limited", &llm.StatusError{StatusCode: 429}, exitRateLimited},
+		{"network", &llm.NetworkError{Err: errors.New("connection refused")}, exitNetwork},
+		{"server error", &llm.StatusError{StatusCode: 500}, exitProvider},
+		{"api error", &llm.APIError{Type: "overloaded_error", Message: "overloaded"}, exitProvider},
+		{"interrupted", errInterrupted, exitInterrupted},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("%s: exitCode(%v) = %d, want %d", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestReportError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want errorReport
+	}{
+		{
+			&llm.StatusError{Provider: llm.Claude, StatusCode: 429, Body: "slow down", RequestID: "req_01"},
+			errorReport{Type: "rate_limit", Provider: "claude", Status: 429, Retryable: true,
+				Message: "API request failed with status 429: slow down (request ID req_01)", RequestID: "req_01"},
+		},
+		{
+			&llm.StatusError{Provider: llm.OpenAI, StatusCode: 401, Body: "bad key"},
+			errorReport{Type: "auth", Provider: "openai", Status: 401, Message: "API request failed with status 401: bad key"},
+		},
+		{
+			&llm.NetworkError{Provider: llm.Ollama, Err: errors.New("connection refused")},
+			errorReport{Type: "network", Provider: "ollama", Retryable: true, Message: "failed to make request: connection refused"},
+		},
+		{fmt.Errorf("%w for gpt-4o", errNoKey), errorReport{Type: "auth", Message: "no API key for gpt-4o"}},
+		{usageError(batchUsage), errorReport{Type: "usage", Message: batchUsage}},
+		{errInterrupted, errorReport{Type: "interrupted", Message: "interrupted"}},
+		{errors.New("failed to read image"), errorReport{Type: "error", Message: "failed to read image"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		reportError(&buf, tt.err, true)
+		var got errorReport
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("%q isn't JSON: %v", buf.String(), err)
+		}
+		if got != tt.want {
+			t.Errorf("report for %v = %+v, want %+v", tt.err, got, tt.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	reportError(&buf, errors.New("boom"), false)
+	if buf.String() != "Error: boom\n" {
+		t.Errorf("plain report = %q", buf.String())
+	}
+}
+
+func TestBrokenConfigReport(t *testing.T) {
+	// The extra quote leaves the config file malformed
+	_, stderr, status := runLLM(t, `http://localhost"`, "--json", "-m", "gpt-4o", "list", "files")
+	var got errorReport
+	if err := json.Unmarshal([]byte(stderr), &got); err != nil || got.Type != "error" || !strings.Contains(got.Message, "config.toml") {
+		t.Errorf("stderr = %q, want a JSON error about the config file", stderr)
+	}
+	if status != exitError {
+		t.Errorf("status = %d, want %d", status, exitError)
+	}
+
+	if _, stderr, _ := runLLM(t, `http://localhost"`, "list", "files"); !strings.HasPrefix(stderr, "Error: ") {
+		t.Errorf("stderr = %q, want a plain error", stderr)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+	"github.com/jamesob/llm-cli/pkg/render"
+)
+
+// runExplainCmd explains a shell command flag by flag
+func runExplainCmd(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	opts := &options{mode: llm.ExplainCommandMode, yes: true}
+	flagSet := flag.NewFlagSet("llm explain-cmd", flag.ContinueOnError)
+	flagSet.BoolVar(&opts.noPager, "no-pager", false, "Never pipe output through a pager")
+	flagSet.BoolVar(&opts.noRedact, "no-redact", false, "Send the command without redacting secrets")
+	opts.log.register(flagSet)
+	flagSet.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: llm explain-cmd '<command>'\n")
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	command := strings.Join(flagSet.Args(), " ")
+	if command == "" {
+		return usageError("usage: llm explain-cmd '<command>'")
+	}
+	return explainCommand(cfg, opts, command)
+}
+
+// suggestionModes are the modes whose answers are commands to run
+var suggestionModes = []llm.Mode{
+	llm.CommandMode, llm.K8sMode, llm.AWSMode, llm.GCloudMode, llm.AzureMode,
+	llm.SedMode, llm.AwkMode, llm.PortMode,
+}
+
+// runExplainLast explains the last command suggested, from the history
+func runExplainLast(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	opts := &options{mode: llm.ExplainCommandMode, yes: true}
+	flagSet := flag.NewFlagSet("llm explain-last", flag.ContinueOnError)
+	flagSet.BoolVar(&opts.noPager, "no-pager", false, "Never pipe output through a pager")
+	flagSet.BoolVar(&opts.noRedact, "no-redact", false, "Send the command without redacting secrets")
+	opts.log.register(flagSet)
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if flagSet.NArg() > 0 {
+		return usageError("usage: llm explain-last, or llm why")
+	}
+
+	command, err := lastSuggestion(cfg)
+	if err != nil {
+		return err
+	}
+	return explainCommand(cfg, opts, command)
+}
+
+// lastSuggestion returns the most recent answer in the history from one of
+// suggestionModes
+func lastSuggestion(cfg *config.Config) (string, error) {
+	store, err := openHistory(cfg, false)
+	if err != nil {
+		return "", err
+	}
+	entries, err := store.Entries()
+	if err != nil {
+		return "", err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		for _, m := range suggestionModes {
+			if e.Mode == m.String() && e.Response != "" {
+				return e.Response, nil
+			}
+		}
+	}
+	return "", errors.New("no suggested commands in the history")
+}
+
+// explainCommand asks for a breakdown of command and shows it
+func explainCommand(cfg *config.Config, opts *options, command string) error {
+	opts.query = command
+
+	if err := opts.log.setup(); err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	response, err := ask(context.Background(), cfg, client, opts, llm.DetectSystem())
+	if err != nil {
+		return err
+	}
+	breakdown, err := llm.ParseBreakdown(response)
+	if err != nil {
+		return err
+	}
+	printOutput(formatBreakdown(command, breakdown, outputWidth()), opts.noPager)
+	return nil
+}
+
+// formatBreakdown shows the command, its summary, and a line per part
+func formatBreakdown(command string, b *llm.CommandBreakdown, width int) string {
+	defs := make([]render.Definition, len(b.Parts))
+	for i, p := range b.Parts {
+		defs[i] = render.Definition{Term: p.Text, Description: p.Explanation}
+	}
+	return fmt.Sprintf("%s%s%s\n%s\n\n%s", render.Bold, command, render.Reset,
+		b.Summary, render.DefinitionList(defs, width))
+}
+
+// outputWidth returns the terminal's width, or 80 if it can't be told
+func outputWidth() int {
+	if _, cols := terminalSize(); cols > 0 {
+		return cols
+	}
+	return 80
+}
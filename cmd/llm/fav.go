@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/jamesob/llm-cli/internal/paths"
+)
+
+const favUsage = `usage: llm fav add [--var NAME=VALUE ...] <name> [options] "<prompt>"
+       llm fav run <name> [NAME=VALUE ...]
+       llm fav list
+       llm fav remove <name>`
+
+// favorite is a saved llm command line. Its arguments are templates, in
+// which {{.NAME}} is a variable and {{sh "command"}} is a command's output.
+type favorite struct {
+	Args []string          `json:"args"`
+	Vars map[string]string `json:"vars,omitempty"` // defaults
+}
+
+// varNameRe matches variable names, which are also environment variables
+// for the commands a favorite runs
+var varNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// favoritesPath returns where favorites are saved
+func favoritesPath() (string, error) {
+	dir, err := paths.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "favorites.json"), nil
+}
+
+// loadFavorites reads the saved favorites by name
+func loadFavorites() (map[string]favorite, error) {
+	favs := map[string]favorite{}
+	path, err := favoritesPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return favs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &favs); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return favs, nil
+}
+
+// saveFavorites writes favs, replacing the file atomically
+func saveFavorites(favs map[string]favorite) error {
+	path, err := favoritesPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(favs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// runFav saves, lists and removes favorites. "llm fav run" is expanded into
+// the command line it stands for before subcommands are dispatched.
+func runFav(args []string) error {
+	if len(args) == 0 {
+		return usageError(favUsage)
+	}
+	switch args[0] {
+	case "add":
+		return addFavorite(args[1:])
+	case "list":
+		if len(args) > 1 {
+			return usageError(favUsage)
+		}
+		return listFavorites()
+	case "remove":
+		if len(args) != 2 {
+			return usageError(favUsage)
+		}
+		favs, err := loadFavorites()
+		if err != nil {
+			return err
+		}
+		if _, ok := favs[args[1]]; !ok {
+			return fmt.Errorf("no favorite named %q", args[1])
+		}
+		delete(favs, args[1])
+		if err := saveFavorites(favs); err != nil {
+			return err
+		}
+		fmt.Fprintf(notices, "Removed %s\n", args[1])
+		return nil
+	}
+	return usageError(favUsage)
+}
+
+// addFavorite saves the command line after the name, which must start with
+// llm's options or a query, as the named favorite
+func addFavorite(args []string) error {
+	var vars []string
+	flagSet := flag.NewFlagSet("llm fav add", flag.ContinueOnError)
+	flagSet.Var((*stringList)(&vars), "var", "Default `NAME=VALUE` of a variable (repeatable)")
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if flagSet.NArg() < 2 {
+		return usageError(favUsage)
+	}
+	name := flagSet.Arg(0)
+	if strings.ContainsAny(name, " \t\n/") {
+		return usageError(fmt.Sprintf("invalid favorite name %q", name))
+	}
+	fav := favorite{Args: flagSet.Args()[1:]}
+	if len(vars) > 0 {
+		var err error
+		if fav.Vars, err = parseVars(vars); err != nil {
+			return err
+		}
+	}
+	// Catch mistakes in the templates now rather than when it's run
+	for _, arg := range fav.Args {
+		if _, err := template.New(name).Funcs(templateFuncs(nil)).Parse(arg); err != nil {
+			return usageError(err.Error())
+		}
+	}
+
+	favs, err := loadFavorites()
+	if err != nil {
+		return err
+	}
+	_, replaced := favs[name]
+	favs[name] = fav
+	if err := saveFavorites(favs); err != nil {
+		return err
+	}
+	if replaced {
+		fmt.Fprintf(notices, "Replaced %s\n", name)
+	} else {
+		fmt.Fprintf(notices, "Saved %s; run it with: llm fav run %s\n", name, name)
+	}
+	return nil
+}
+
+// listFavorites prints each favorite's name and command line
+func listFavorites() error {
+	favs, err := loadFavorites()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(favs))
+	for name := range favs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fav := favs[name]
+		line := quoteArgs(fav.Args)
+		var defaults []string
+		for v, value := range fav.Vars {
+			defaults = append(defaults, v+"="+value)
+		}
+		if len(defaults) > 0 {
+			slices.Sort(defaults)
+			line += "  (" + strings.Join(defaults, ", ") + ")"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", name, line)
+	}
+	return w.Flush()
+}
+
+// quoteArgs joins args, quoting the ones with spaces or quotes
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"") {
+			arg = strconv.Quote(arg)
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+// parseVars parses NAME=VALUE pairs
+func parseVars(pairs []string) (map[string]string, error) {
+	vars := map[string]string{}
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || !varNameRe.MatchString(name) {
+			return nil, usageError(fmt.Sprintf("%q isn't NAME=VALUE", pair))
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
+
+// missingVarRe finds the variable a template needed but wasn't given
+var missingVarRe = regexp.MustCompile(`map has no entry for key "([^"]+)"`)
+
+// favoriteArgs returns the command line the favorite named by args[0]
+// stands for, with its variables set from the NAME=VALUE pairs after it
+func favoriteArgs(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return nil, usageError(favUsage)
+	}
+	name := args[0]
+	given, err := parseVars(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	favs, err := loadFavorites()
+	if err != nil {
+		return nil, err
+	}
+	fav, ok := favs[name]
+	if !ok {
+		return nil, fmt.Errorf("no favorite named %q; see llm fav list", name)
+	}
+
+	vars := map[string]string{}
+	for k, v := range fav.Vars {
+		vars[k] = v
+	}
+	for k, v := range given {
+		vars[k] = v
+	}
+	expanded := make([]string, len(fav.Args))
+	for i, arg := range fav.Args {
+		var missing missingVarError
+		expanded[i], err = expandTemplate(name, arg, vars, templateFuncs(vars))
+		if errors.As(err, &missing) {
+			v := string(missing)
+			return nil, usageError(fmt.Sprintf("%s needs a value for %s: llm fav run %s %s=VALUE", name, v, name, v))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return expanded, nil
+}
+
+// missingVarError is returned by expandTemplate for a variable that has no
+// value
+type missingVarError string
+
+func (e missingVarError) Error() string {
+	return "no value for " + string(e)
+}
+
+// expandTemplate fills vars into text, in which {{.NAME}} is a variable and
+// funcs, if any, can be called, as in {{sh "command"}}
+func expandTemplate(name, text string, vars map[string]string, funcs template.FuncMap) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", usageError(err.Error())
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		if m := missingVarRe.FindStringSubmatch(err.Error()); m != nil {
+			return "", missingVarError(m[1])
+		}
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// templateFuncs are the functions favorites and --ctx queries can use, but
+// -t templates can't, since they may come from someone else's pack. sh
+// runs a command with the shell, with the variables in its environment, and
+// returns its output.
+func templateFuncs(vars map[string]string) template.FuncMap {
+	return template.FuncMap{
+		"sh": func(command string) (string, error) {
+			cmd := shellCommand(context.Background(), command)
+			cmd.Env = os.Environ()
+			for k, v := range vars {
+				cmd.Env = append(cmd.Env, k+"="+v)
+			}
+			cmd.Stderr = os.Stderr
+			out, err := cmd.Output()
+			if err != nil {
+				return "", fmt.Errorf("%q failed: %v", command, err)
+			}
+			return strings.TrimSpace(string(out)), nil
+		},
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFavorites(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	if err := runFav([]string{"add", "--var", "cluster=staging", "pods", "--k8s", "list pods in {{.cluster}}"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := runFav([]string{"add", "ask", "{{.q}}"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args []string
+		want []string
+		err  string
+	}{
+		{[]string{"pods"}, []string{"--k8s", "list pods in staging"}, ""},
+		{[]string{"pods", "cluster=prod"}, []string{"--k8s", "list pods in prod"}, ""},
+		{[]string{"ask", "q=why is the sky blue"}, []string{"why is the sky blue"}, ""},
+		{[]string{"ask"}, nil, "needs a value for q"},
+		{[]string{"ask", "q"}, nil, "isn't NAME=VALUE"},
+		{[]string{"standup"}, nil, "no favorite named"},
+	}
+	for _, tt := range tests {
+		got, err := favoriteArgs(tt.args)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("favoriteArgs(%q) error = %v, want %q", tt.args, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("favoriteArgs(%q) = %q, %v, want %q", tt.args, got, err, tt.want)
+		}
+	}
+
+	if err := runFav([]string{"add", "bad", "{{.x"}); exitCode(err) != exitUsage {
+		t.Errorf("saving a broken template gave %v, want a usage error", err)
+	}
+	if err := runFav([]string{"remove", "ask"}); err != nil {
+		t.Fatal(err)
+	}
+	favs, err := loadFavorites()
+	if err != nil || len(favs) != 1 {
+		t.Errorf("favorites after removing one = %v, %v", favs, err)
+	}
+}
+
+func TestFavoriteShellCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	if err := runFav([]string{"add", "--var", "since=yesterday", "standup", `Summarize: {{sh "echo commits since $since"}}`}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := favoriteArgs([]string{"standup", "since=monday"})
+	if err != nil || len(got) != 1 || got[0] != "Summarize: commits since monday" {
+		t.Errorf("got %q, %v", got, err)
+	}
+
+	if err := runFav([]string{"add", "broken", `{{sh "exit 3"}}`}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := favoriteArgs([]string{"broken"}); err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("failing command gave %v", err)
+	}
+}
+
+func TestFavoriteExpandedOnce(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		io.WriteString(w, `{"choices":[{"message":{"content":"ls"}}]}`)
+	}))
+	defer srv.Close()
+
+	// The command's output is itself a template, which mustn't be run
+	home := t.TempDir()
+	marker := filepath.Join(home, "ran")
+	if _, stderr, status := runLLMIn(t, home, srv.URL, "fav", "add", "--var", `out={{sh "touch `+marker+`"}}`,
+		"summary", "--ctx", "k=v", `summarize {{sh "echo \"$out\""}}`); status != exitOK {
+		t.Fatalf("fav add: %s", stderr)
+	}
+	if _, stderr, status := runLLMIn(t, home, srv.URL, "fav", "run", "summary"); status != exitOK {
+		t.Fatalf("fav run: %s", stderr)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("the command's output was run as a template")
+	}
+	if !strings.Contains(body, `summarize {{sh \"touch `) {
+		t.Errorf("request = %s, want the command's output as it is", body)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/internal/history"
+)
+
+// runGood marks the last answer in the history as good
+func runGood(args []string) error {
+	if len(args) > 0 {
+		return usageError("usage: llm good")
+	}
+	return tagLast("good", "")
+}
+
+// runBad marks the last answer in the history as bad, with an optional
+// reason
+func runBad(args []string) error {
+	return tagLast("bad", strings.Join(args, " "))
+}
+
+// tagLast records feedback on the last history entry
+func tagLast(feedback, reason string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if cfg.Has("history") && !cfg.Bool("history") {
+		return errors.New("history is turned off, so there's no answer to mark")
+	}
+	store, err := openHistory(cfg, false)
+	if err != nil {
+		return err
+	}
+	e, err := store.UpdateLast(func(e *history.Entry) {
+		e.Feedback, e.Reason = feedback, reason
+	})
+	if err != nil {
+		return err
+	}
+	answer, _, _ := strings.Cut(e.Response, "\n")
+	fmt.Fprintf(notices, "Marked the answer to %q (%s) as %s\n", e.Query, answer, feedback)
+	return nil
+}
+
+// modelUsage counts a model's answers and the feedback on them
+type modelUsage struct {
+	model     string
+	answers   int
+	good, bad int
+}
+
+// runUsage reports how often each model's answers were marked good or bad
+func runUsage(args []string) error {
+	if len(args) > 0 {
+		return usageError("usage: llm usage")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	store, err := openHistory(cfg, false)
+	if err != nil {
+		return err
+	}
+	entries, err := store.Entries()
+	if err != nil {
+		return err
+	}
+	printUsageReport(os.Stdout, summarizeUsage(entries))
+	return nil
+}
+
+// summarizeUsage tallies entries by model, in order of first use
+func summarizeUsage(entries []history.Entry) []modelUsage {
+	var usage []modelUsage
+	index := map[string]int{}
+	for _, e := range entries {
+		model := e.Model
+		if model == "" {
+			model = "(unknown)"
+		}
+		i, ok := index[model]
+		if !ok {
+			i = len(usage)
+			index[model] = i
+			usage = append(usage, modelUsage{model: model})
+		}
+		usage[i].answers++
+		switch e.Feedback {
+		case "good":
+			usage[i].good++
+		case "bad":
+			usage[i].bad++
+		}
+	}
+	return usage
+}
+
+// printUsageReport writes usage as a table. The success rate counts only
+// answers that were marked.
+func printUsageReport(w io.Writer, usage []modelUsage) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODEL\tANSWERS\tGOOD\tBAD\tSUCCESS")
+	for _, u := range usage {
+		success := "-"
+		if rated := u.good + u.bad; rated > 0 {
+			success = fmt.Sprintf("%.0f%%", 100*float64(u.good)/float64(rated))
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\n", u.model, u.answers, u.good, u.bad, success)
+	}
+	tw.Flush()
+}
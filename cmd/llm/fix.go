@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+	"github.com/jamesob/llm-cli/pkg/render"
+)
+
+// runFix diagnoses a failed command from its piped output, or from what the
+// shell integration recorded, and suggests a corrected command. The
+// diagnosis goes to stderr and the command to stdout, so the command can be
+// copied or piped on its own.
+func runFix(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	opts := &options{mode: llm.FixMode}
+	flagSet := flag.NewFlagSet("llm fix", flag.ContinueOnError)
+	flagSet.BoolVar(&opts.context, "context", cfg.Bool("context"), "Include project context in the prompt")
+	flagSet.BoolVar(&opts.yes, "y", false, "Don't ask before sending large output")
+	flagSet.BoolVar(&opts.noRedact, "no-redact", false, "Send the output without redacting secrets")
+	opts.log.register(flagSet)
+	flagSet.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: <command> 2>&1 | llm fix [what you were trying to do]\n")
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	opts.query = expandAliases(strings.Join(flagSet.Args(), " "), cfg)
+	if opts.query == "" {
+		opts.query = "Why did this fail, and how do I fix it?"
+	}
+
+	sys := llm.DetectSystem()
+	output, err := readStdin()
+	if err != nil {
+		return err
+	}
+	if output != "" {
+		sys.Attachments = []llm.Attachment{{Title: "Output of the failing command", Content: output}}
+	} else if sys.Previous = previousCommand(); sys.Previous == nil {
+		// The shell integration only describes the command before a
+		// pipeline, so it's used only when nothing is piped in
+		return errors.New("pipe in the failing output, e.g. make 2>&1 | llm fix, or set up llm shell-init")
+	}
+	if opts.context {
+		if wd, err := os.Getwd(); err == nil {
+			project := llm.DetectProject(wd)
+			sys.Project = &project
+		}
+	}
+
+	if err := opts.log.setup(); err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	response, err := ask(context.Background(), cfg, client, opts, sys)
+	if err != nil {
+		return err
+	}
+	fix, err := llm.ParseFix(response)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, render.Markdown(fix.Cause))
+	if fix.Steps != "" {
+		fmt.Fprintln(os.Stderr, "\n"+render.Markdown(fix.Steps))
+	}
+	if fix.Command != "" {
+		if isTerminal(os.Stdout) {
+			fmt.Fprintln(os.Stderr)
+		}
+		warnDangers(fix.Command)
+		fmt.Println(fix.Command)
+	}
+	return nil
+}
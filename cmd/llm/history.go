@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/internal/history"
+	"github.com/jamesob/llm-cli/internal/keyring"
+	"github.com/jamesob/llm-cli/internal/paths"
+)
+
+// historyKeyName is the keychain entry holding the history encryption key
+const historyKeyName = "history-key"
+
+// historyPath returns where the history file lives
+func historyPath() (string, error) {
+	dir, err := paths.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.jsonl"), nil
+}
+
+// openHistory opens the history store. With encrypt_history set, a key is
+// created in the keychain the first time it's needed, and the next entry
+// saved seals those written in the clear before. Otherwise an existing
+// key is still used for reading, so entries written while encryption was on
+// stay readable.
+func openHistory(cfg *config.Config, writing bool) (*history.Store, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, err
+	}
+
+	var key []byte
+	if cfg.Bool("encrypt_history") {
+		if key, err = historyKey(true); err != nil {
+			return nil, fmt.Errorf("encrypt_history: %v", err)
+		}
+	} else if !writing {
+		key, _ = historyKey(false)
+	}
+	return history.Open(path, key)
+}
+
+// historyKey returns the history key from the keychain, generating and
+// storing one if create is set
+func historyKey(create bool) ([]byte, error) {
+	encoded, err := keyring.Get(historyKeyName)
+	if errors.Is(err, keyring.ErrNotFound) && create {
+		key, err := history.NewKey()
+		if err != nil {
+			return nil, err
+		}
+		if err := keyring.Set(historyKeyName, base64.StdEncoding.EncodeToString(key)); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(encoded)
+}
+
+// saveHistory records a query and its response unless history is turned off
+func saveHistory(cfg *config.Config, entry history.Entry) error {
+	if cfg.Has("history") && !cfg.Bool("history") {
+		return nil
+	}
+	store, err := openHistory(cfg, true)
+	if err != nil {
+		return err
+	}
+	return store.Append(entry)
+}
+
+const historyUsage = "usage: llm history [list [--unique]], or llm history purge [--older-than <age>]"
+
+// runHistory lists or purges past queries
+func runHistory(args []string) error {
+	if len(args) == 0 || args[0] == "list" {
+		flagSet := flag.NewFlagSet("llm history list", flag.ContinueOnError)
+		unique := flagSet.Bool("unique", false, "Show each query once, with how often it was asked")
+		if len(args) > 0 {
+			if err := flagSet.Parse(args[1:]); err != nil {
+				return usageError(err.Error())
+			}
+		}
+		if flagSet.NArg() > 0 {
+			return usageError(historyUsage)
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		return listHistory(cfg, *unique)
+	}
+	if args[0] != "purge" {
+		return usageError(historyUsage)
+	}
+
+	flagSet := flag.NewFlagSet("llm history purge", flag.ContinueOnError)
+	olderThan := flagSet.String("older-than", "", "Only remove entries older than this, e.g. 30d or 12h")
+	if err := flagSet.Parse(args[1:]); err != nil {
+		return usageError(err.Error())
+	}
+	if flagSet.NArg() > 0 {
+		return usageError(historyUsage)
+	}
+
+	var cutoff time.Time
+	if *olderThan != "" {
+		age, err := parseAge(*olderThan)
+		if err != nil {
+			return err
+		}
+		cutoff = time.Now().Add(-age)
+	}
+
+	// Purging doesn't need the key, since entry times aren't encrypted
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	store, err := history.Open(path, nil)
+	if err != nil {
+		return err
+	}
+	n, err := store.Purge(cutoff)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(notices, "Removed %d history entries\n", n)
+	return nil
+}
+
+// listHistory prints past queries, oldest first, marking the steps of --pipe
+// chains. With unique, repeated queries are listed once, when they were last
+// asked.
+func listHistory(cfg *config.Config, unique bool) error {
+	store, err := openHistory(cfg, false)
+	if err != nil {
+		return err
+	}
+	entries, err := store.Entries()
+	if err != nil {
+		return err
+	}
+	if !unique {
+		for _, e := range entries {
+			query := e.Query
+			if e.Session != "" {
+				// A step of a --pipe chain
+				query = "↳ " + query
+			}
+			fmt.Printf("%s  %-7s  %s\n", e.Time.Local().Format("2006-01-02 15:04"), e.Mode, query)
+		}
+		return nil
+	}
+	for _, q := range uniqueQueries(entries) {
+		line := fmt.Sprintf("%s  %-7s  %s", q.last.Local().Format("2006-01-02 15:04"), q.mode, q.query)
+		if q.count > 1 {
+			line += fmt.Sprintf("  (%d times)", q.count)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// askedQuery is a query asked one or more times in the same mode
+type askedQuery struct {
+	mode, query string
+	count       int
+	last        time.Time
+}
+
+// uniqueQueries collapses repeats of the same query in the same mode,
+// ordered by when each was last asked. Queries differing only in case or
+// spacing count as the same.
+func uniqueQueries(entries []history.Entry) []askedQuery {
+	key := func(e history.Entry) string {
+		return e.Mode + "\x00" + strings.ToLower(strings.Join(strings.Fields(e.Query), " "))
+	}
+	counts, last := map[string]int{}, map[string]int{}
+	for i, e := range entries {
+		counts[key(e)]++
+		last[key(e)] = i
+	}
+	var queries []askedQuery
+	for i, e := range entries {
+		if k := key(e); e.Query != "" && last[k] == i {
+			queries = append(queries, askedQuery{mode: e.Mode, query: e.Query, count: counts[k], last: e.Time})
+		}
+	}
+	return queries
+}
+
+// parseAge parses a duration that may also be given in days or weeks, e.g.
+// "30d" or "2w"
+func parseAge(s string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			if days, err := strconv.Atoi(n); err == nil && days >= 0 {
+				return time.Duration(days) * unit, nil
+			}
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 30d, 2w or 12h)", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/internal/history"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"30d", 30 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"12h", 12 * time.Hour},
+		{"90m", 90 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got, err := parseAge(tt.in); err != nil || got != tt.want {
+			t.Errorf("parseAge(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+	for _, bad := range []string{"", "d", "-1d", "soon", "-5h"} {
+		if _, err := parseAge(bad); err == nil {
+			t.Errorf("parseAge(%q) should fail", bad)
+		}
+	}
+}
+
+func TestHistoryUsageErrors(t *testing.T) {
+	for _, args := range [][]string{{"list", "--nope"}, {"purge", "--nope"}, {"purge", "now"}, {"clear"}} {
+		if err := runHistory(args); exitCode(err) != exitUsage {
+			t.Errorf("runHistory(%q) = %v, want a usage error", args, err)
+		}
+	}
+}
+
+func TestLastSuggestion(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	cfg, _ := config.Parse("")
+
+	if _, err := lastSuggestion(cfg); err == nil {
+		t.Error("expected an error with an empty history")
+	}
+
+	for _, e := range []history.Entry{
+		{Mode: "command", Query: "disk usage", Response: "du -sh *"},
+		{Mode: "k8s", Query: "failing pods", Response: "kubectl get pods -A"},
+		{Mode: "explain", Query: "what is du", Response: "du estimates file space usage."},
+	} {
+		if err := saveHistory(cfg, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, err := lastSuggestion(cfg); err != nil || got != "kubectl get pods -A" {
+		t.Errorf("lastSuggestion() = %q, %v", got, err)
+	}
+}
+
+func TestLastQuery(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	cfg, _ := config.Parse("")
+
+	if _, err := lastQuery(cfg); err == nil {
+		t.Error("expected an error with an empty history")
+	}
+	saveHistory(cfg, history.Entry{Mode: "code", Query: "fizzbuzz in go"})
+	saveHistory(cfg, history.Entry{Mode: "commit", Query: "", Response: "Fix typo"})
+	saveHistory(cfg, history.Entry{Mode: "agent", Query: "clean up", Response: "transcript"})
+	if got, err := lastQuery(cfg); err != nil || got.Query != "fizzbuzz in go" {
+		t.Errorf("lastQuery() = %+v, %v", got, err)
+	}
+}
+
+func TestUsageReport(t *testing.T) {
+	usage := summarizeUsage([]history.Entry{
+		{Model: "llama3", Feedback: "bad"},
+		{Model: "gpt-4o-mini", Feedback: "good"},
+		{Model: "llama3", Feedback: "good"},
+		{Model: "llama3"},
+		{Model: "gpt-4o-mini"},
+		{},
+	})
+
+	var b strings.Builder
+	printUsageReport(&b, usage)
+	want := "MODEL        ANSWERS  GOOD  BAD  SUCCESS\n" +
+		"llama3       3        1     1    50%\n" +
+		"gpt-4o-mini  2        1     0    100%\n" +
+		"(unknown)    1        0     0    -\n"
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestTagLast(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	cfg, _ := config.Parse("")
+	saveHistory(cfg, history.Entry{Mode: "command", Query: "disk usage", Response: "du -sh *"})
+
+	if err := tagLast("bad", "wrong directory"); err != nil {
+		t.Fatal(err)
+	}
+	last, err := lastQuery(cfg)
+	if err != nil || last.Feedback != "bad" || last.Reason != "wrong directory" {
+		t.Errorf("last entry = %+v, %v", last, err)
+	}
+}
+
+func TestUniqueQueries(t *testing.T) {
+	at := func(h int) time.Time { return time.Date(2025, 1, 2, h, 0, 0, 0, time.UTC) }
+	entries := []history.Entry{
+		{Time: at(1), Mode: "command", Query: "list files by size"},
+		{Time: at(2), Mode: "explain", Query: "tar"},
+		{Time: at(3), Mode: "command", Query: "List  files by size"},
+		{Time: at(4), Mode: "command", Query: "tar"},
+		{Time: at(5), Mode: "agent"},
+	}
+	got := uniqueQueries(entries)
+	want := []askedQuery{
+		{mode: "explain", query: "tar", count: 1, last: at(2)},
+		{mode: "command", query: "List  files by size", count: 2, last: at(3)},
+		{mode: "command", query: "tar", count: 1, last: at(4)},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
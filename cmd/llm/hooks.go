@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//go:embed hooks/commit-msg hooks/pre-push
+var hookScripts embed.FS
+
+// hookMarker starts the second line of the hooks llm installs, so that llm
+// only ever replaces or removes its own
+const hookMarker = "# Installed by llm install-hook"
+
+const installHookUsage = "usage: llm install-hook [--force] [--uninstall] <commit-msg|pre-push>"
+
+// runInstallHook installs one of the git hooks embedded in llm into the
+// current repository, or removes it
+func runInstallHook(args []string) error {
+	var force, uninstall bool
+	flagSet := flag.NewFlagSet("llm install-hook", flag.ContinueOnError)
+	flagSet.BoolVar(&force, "force", false, "Replace a hook llm didn't install, keeping it as HOOK.bak")
+	flagSet.BoolVar(&uninstall, "uninstall", false, "Remove the hook, restoring the one it replaced")
+	flagSet.Usage = func() {
+		fmt.Fprintln(os.Stderr, installHookUsage)
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if flagSet.NArg() != 1 {
+		return usageError(installHookUsage)
+	}
+	name := flagSet.Arg(0)
+	script, err := hookScripts.ReadFile("hooks/" + name)
+	if err != nil {
+		return usageError(fmt.Sprintf("unknown hook %q (expected %s)", name, strings.Join(hookNames(), " or ")))
+	}
+
+	// Honors core.hooksPath
+	dir, err := gitOutput("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, name)
+	if uninstall {
+		return uninstallHook(path)
+	}
+	return installHook(path, script, force)
+}
+
+// hookNames lists the hooks llm can install
+func hookNames() []string {
+	entries, _ := fs.ReadDir(hookScripts, "hooks")
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// isLLMHook reports whether a hook's contents are one llm installed
+func isLLMHook(data []byte) bool {
+	_, rest, _ := bytes.Cut(data, []byte("\n"))
+	return bytes.HasPrefix(rest, []byte(hookMarker))
+}
+
+// installHook writes script to path, updating a hook llm installed before
+// and, with force, moving any other hook there to path.bak
+func installHook(path string, script []byte, force bool) error {
+	existing, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	case isLLMHook(existing):
+		if bytes.Equal(existing, script) {
+			fmt.Fprintf(notices, "%s is already installed\n", path)
+			return nil
+		}
+	case !force:
+		return fmt.Errorf("%s already exists; pass --force to replace it (it will be kept as %s.bak)", path, filepath.Base(path))
+	default:
+		if err := os.Rename(path, path+".bak"); err != nil {
+			return fmt.Errorf("failed to back up %s: %v", path, err)
+		}
+		fmt.Fprintf(notices, "Moved the existing hook to %s.bak\n", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, script, 0755); err != nil {
+		return err
+	}
+	// WriteFile leaves the mode of a file that already existed alone
+	if err := os.Chmod(path, 0755); err != nil {
+		return err
+	}
+	fmt.Fprintf(notices, "Installed %s\n", path)
+	return nil
+}
+
+// uninstallHook removes the hook llm installed at path, and puts back the
+// one it replaced, if any
+func uninstallHook(path string) error {
+	existing, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s isn't installed", path)
+	}
+	if err != nil {
+		return err
+	}
+	if !isLLMHook(existing) {
+		return fmt.Errorf("%s wasn't installed by llm, so it was left alone", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".bak", path); err == nil {
+		fmt.Fprintf(notices, "Removed %s and restored the hook it replaced\n", path)
+		return nil
+	}
+	fmt.Fprintf(notices, "Removed %s\n", path)
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestHookScripts(t *testing.T) {
+	for _, name := range hookNames() {
+		script, err := hookScripts.ReadFile("hooks/" + name)
+		if err != nil || !isLLMHook(script) {
+			t.Errorf("%s: missing the marker line (%v)", name, err)
+		}
+		if _, err := exec.LookPath("sh"); err == nil {
+			if out, err := exec.Command("sh", "-n", filepath.Join("hooks", name)).CombinedOutput(); err != nil {
+				t.Errorf("%s: %v: %s", name, err, out)
+			}
+		}
+	}
+}
+
+func TestInstallHook(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hooks", "commit-msg")
+	script, _ := hookScripts.ReadFile("hooks/commit-msg")
+	read := func() string {
+		data, _ := os.ReadFile(path)
+		return string(data)
+	}
+
+	if err := uninstallHook(path); err == nil {
+		t.Error("uninstalling a missing hook succeeded")
+	}
+	if err := installHook(path, script, false); err != nil || read() != string(script) {
+		t.Fatalf("install: %v", err)
+	}
+	if err := installHook(path, script, false); err != nil {
+		t.Errorf("reinstalling: %v", err)
+	}
+	if err := uninstallHook(path); err != nil || read() != "" {
+		t.Fatalf("uninstall: %v", err)
+	}
+
+	// Someone else's hook is only replaced with --force, and comes back
+	// when llm's is removed
+	own := "#!/bin/sh\nexec ./lint-message \"$1\"\n"
+	os.WriteFile(path, []byte(own), 0755)
+	if err := installHook(path, script, false); err == nil || read() != own {
+		t.Errorf("replaced another hook without --force: %v", err)
+	}
+	if err := uninstallHook(path); err == nil || read() != own {
+		t.Errorf("removed another hook: %v", err)
+	}
+	if err := installHook(path, script, true); err != nil || read() != string(script) {
+		t.Fatalf("install --force: %v", err)
+	}
+	if backup, _ := os.ReadFile(path + ".bak"); string(backup) != own {
+		t.Errorf("backup = %q", backup)
+	}
+	if err := uninstallHook(path); err != nil || read() != own {
+		t.Errorf("uninstall didn't restore the backup: %v, %q", err, read())
+	}
+}
+
+func TestCommitMsgHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are run by git's sh")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	fakeLLM := filepath.Join(dir, "fake-llm")
+	os.WriteFile(fakeLLM, []byte("#!/bin/sh\necho 'Add the README'\n"), 0755)
+	repo := filepath.Join(dir, "repo")
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), "LLM="+fakeLLM, "GIT_CONFIG_GLOBAL=/dev/null",
+			"GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@example.com",
+			"GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@example.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	os.MkdirAll(repo, 0755)
+	git("init", "-q")
+	script, _ := hookScripts.ReadFile("hooks/commit-msg")
+	if err := installHook(filepath.Join(repo, ".git", "hooks", "commit-msg"), script, false); err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(filepath.Join(repo, "README"), []byte("hi\n"), 0644)
+	git("add", "README")
+	git("commit", "-q", "-m", "")
+	if got := git("log", "-1", "--format=%s"); got != "Add the README" {
+		t.Errorf("empty message became %q", got)
+	}
+
+	os.WriteFile(filepath.Join(repo, "README"), []byte("hello\n"), 0644)
+	git("commit", "-q", "-a", "-m", "Say hello")
+	if got := git("log", "-1", "--format=%s"); got != "Say hello" {
+		t.Errorf("given message became %q", got)
+	}
+}
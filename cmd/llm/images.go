@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// imageQuery is asked about images attached without a query
+const imageQuery = "What does this show? If it's an error, how do I fix it?"
+
+// attachImages reads the images given with --image, and a screenshot with
+// --screenshot, into sys, checking first that the model can read them
+func attachImages(sys *llm.System, client *llm.Client, opts *options) error {
+	if len(opts.images) == 0 && !opts.screenshot {
+		return nil
+	}
+	if !client.SupportsImages() {
+		return fmt.Errorf("%s can't read images; pick a vision model with -m", client.ModelName())
+	}
+	for _, path := range opts.images {
+		img, err := llm.ReadImage(path)
+		if err != nil {
+			return err
+		}
+		sys.Images = append(sys.Images, img)
+	}
+	if opts.screenshot {
+		path, err := takeScreenshot()
+		if err != nil {
+			return err
+		}
+		img, err := llm.ReadImage(path)
+		os.RemoveAll(filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		sys.Images = append(sys.Images, img)
+	}
+	if opts.query == "" {
+		opts.query = imageQuery
+	}
+	return nil
+}
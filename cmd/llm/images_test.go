@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestAttachImages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "error.png")
+	if err := os.WriteFile(path, []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var sys llm.System
+	opts := &options{images: []string{path}}
+	if err := attachImages(&sys, &llm.Client{Provider: llm.Claude}, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(sys.Images) != 1 || sys.Images[0].MediaType != "image/png" {
+		t.Errorf("images = %+v", sys.Images)
+	}
+	if opts.query != imageQuery {
+		t.Errorf("query = %q, want the default", opts.query)
+	}
+
+	sys = llm.System{}
+	err := attachImages(&sys, &llm.Client{Provider: llm.Ollama, Model: "llama3"}, &options{images: []string{path}})
+	if err == nil || len(sys.Images) != 0 {
+		t.Errorf("expected an error for a model without vision, got %v", err)
+	}
+}
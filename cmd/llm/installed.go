@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// packageManagers are the commands install hints suggest, in order of
+// preference where there are several
+var packageManagers = []struct{ program, install string }{
+	{"brew", "brew install"},
+	{"apt-get", "sudo apt install"},
+	{"dnf", "sudo dnf install"},
+	{"pacman", "sudo pacman -S"},
+	{"zypper", "sudo zypper install"},
+	{"apk", "sudo apk add"},
+	{"winget", "winget install"},
+	{"scoop", "scoop install"},
+	{"choco", "choco install"},
+}
+
+// checkPrograms asks the model once more when the suggested command runs
+// programs that aren't installed, telling it which. If the new answer still
+// needs them, or the query asked for them by name, it's kept and llm says
+// how they might be installed.
+func checkPrograms(ctx context.Context, cfg *config.Config, client *llm.Client, opts *options, sys llm.System, response string) (string, error) {
+	if sys.Shell == "pwsh" || sys.Shell == "powershell" || sys.Shell == "cmd" {
+		// Cmdlets and cmd's builtins aren't on the PATH
+		return response, nil
+	}
+	suggestions := llm.ParseSuggestions(response)
+	if len(suggestions) == 0 {
+		return response, nil
+	}
+	missing := missingPrograms(suggestions[0].Command)
+	if len(missing) == 0 {
+		return response, nil
+	}
+
+	if !slices.ContainsFunc(missing, func(p string) bool { return mentions(opts.query, p) }) {
+		slog.Info("The command needs programs that aren't installed; asking for another", "programs", strings.Join(missing, ", "))
+		sys.Notes = append(sys.Notes, fmt.Sprintf(
+			"Your suggestion `%s` runs %s, which isn't installed here. Suggest commands that use installed programs instead, unless the request can't be done without it.",
+			suggestions[0].Command, strings.Join(missing, ", ")))
+		var err error
+		if response, _, err = requery(ctx, cfg, client, opts, sys); err != nil {
+			return "", err
+		}
+		if suggestions = llm.ParseSuggestions(response); len(suggestions) == 0 {
+			return response, nil
+		}
+		missing = missingPrograms(suggestions[0].Command)
+	}
+	if len(missing) > 0 {
+		if hint := installHint(missing); hint != "" {
+			slog.Warn("Not installed: "+strings.Join(missing, ", "), "install", hint)
+		} else {
+			slog.Warn("Not installed: " + strings.Join(missing, ", "))
+		}
+	}
+	return response, nil
+}
+
+// missingPrograms returns the programs command runs that aren't on the PATH
+func missingPrograms(command string) []string {
+	var missing []string
+	for _, program := range llm.Programs(command) {
+		if _, err := exec.LookPath(program); err != nil {
+			missing = append(missing, program)
+		}
+	}
+	return missing
+}
+
+// mentions reports whether query names program
+func mentions(query, program string) bool {
+	for _, word := range strings.Fields(query) {
+		if strings.EqualFold(strings.Trim(word, `"'`+"`,.:;?!()"), program) {
+			return true
+		}
+	}
+	return false
+}
+
+// installHint suggests installing programs with the first package manager
+// found, or returns "" if there's none. Package names sometimes differ from
+// program names, so it's only a hint.
+func installHint(programs []string) string {
+	for _, pm := range packageManagers {
+		if _, err := exec.LookPath(pm.program); err == nil {
+			return pm.install + " " + strings.Join(programs, " ")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMissingPrograms(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses executable files without extensions")
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"du", "sort", "apt-get"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir)
+
+	if got := missingPrograms("du -sh * | sort -h"); len(got) != 0 {
+		t.Errorf("installed programs reported missing: %q", got)
+	}
+	got := missingPrograms("fd --size +100m | sort | xsv table")
+	if !slices.Equal(got, []string{"fd", "xsv"}) {
+		t.Errorf("missing = %q", got)
+	}
+	if hint := installHint(got); hint != "sudo apt install fd xsv" {
+		t.Errorf("hint = %q", hint)
+	}
+	if !mentions("find files with fd, not find", "fd") || mentions("find big files", "fd") {
+		t.Error("mentions is wrong")
+	}
+}
+
+func TestMissingProgramRetrySavedOnce(t *testing.T) {
+	answers := []string{"llm-no-such-program --all", "ls"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"choices":[{"message":{"content":"`+answers[0]+`"}}]}`)
+		answers = answers[1:]
+	}))
+	defer srv.Close()
+
+	home := t.TempDir()
+	if stdout, stderr, status := runLLMIn(t, home, srv.URL, "list", "everything"); status != exitOK || stdout != "ls\n" {
+		t.Fatalf("got %d, %q; stderr:\n%s", status, stdout, stderr)
+	}
+	if stdout, _, _ := runLLMIn(t, home, srv.URL, "history"); strings.Count(stdout, "list everything") != 1 {
+		t.Errorf("history = %q, want one entry", stdout)
+	}
+	if _, stderr, _ := runLLMIn(t, home, srv.URL, "bad"); !strings.Contains(stderr, "(ls)") {
+		t.Errorf("llm bad said %q, want it to mark the second answer", stderr)
+	}
+}
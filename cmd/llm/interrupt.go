@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// errInterrupted is returned when Ctrl-C cancels a request
+var errInterrupted = errors.New("interrupted")
+
+// interruptible returns a context that Ctrl-C or SIGTERM cancels, so that a
+// request in flight is abandoned cleanly instead of llm dying mid-write. A
+// second Ctrl-C kills llm as usual, as does any Ctrl-C once stop is called,
+// so prompts waiting for input aren't affected.
+func interruptible(parent context.Context) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancelCause(parent)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			signal.Stop(signals)
+			cancel(errInterrupted)
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel(nil)
+	}
+}
+
+// interrupted returns errInterrupted if ctx was canceled by Ctrl-C, and err
+// otherwise. Errors from requests don't wrap the context's, so this tells
+// them apart.
+func interrupted(ctx context.Context, err error) error {
+	if err != nil && context.Cause(ctx) == errInterrupted {
+		return errInterrupted
+	}
+	return err
+}
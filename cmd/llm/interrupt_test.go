@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestInterruptible(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows processes can't signal themselves")
+	}
+	// The request hangs until Ctrl-C abandons it
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	client := &llm.Client{Provider: llm.Ollama, Model: "llama3", Endpoint: server.URL}
+
+	ctx, stop := interruptible(context.Background())
+	defer stop()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		if p, err := os.FindProcess(os.Getpid()); err == nil {
+			p.Signal(syscall.SIGTERM)
+		}
+	}()
+	_, err := client.Query(ctx, "list files")
+	if err = interrupted(ctx, err); !errors.Is(err, errInterrupted) {
+		t.Fatalf("err = %v, want errInterrupted", err)
+	}
+	if got := exitCode(err); got != exitInterrupted {
+		t.Errorf("exitCode = %d, want %d", got, exitInterrupted)
+	}
+}
+
+func TestInterruptedOtherErrors(t *testing.T) {
+	ctx, stop := interruptible(context.Background())
+	stop()
+	failure := errors.New("API request failed")
+	if err := interrupted(ctx, failure); err != failure {
+		t.Errorf("interrupted() = %v, want the original error", err)
+	}
+	if got := exitCode(failure); got != 1 {
+		t.Errorf("exitCode = %d, want 1", got)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// Sample sizes for JSON sent in --jq mode
+const (
+	jqSampleItems  = 3
+	jqSampleString = 200
+)
+
+// jqTimeout bounds how long a filter may run on the sample, since filters
+// such as repeat(.) never finish
+const jqTimeout = 5 * time.Second
+
+// sampleJQInput replaces piped JSON in sys with a shortened sample, which
+// is all the model needs to write a filter
+func sampleJQInput(sys *llm.System) (string, error) {
+	for i, a := range sys.Attachments {
+		if a.Name != "" {
+			continue
+		}
+		sample, cut, err := llm.SampleJSON(a.Content, jqSampleItems, jqSampleString)
+		if err != nil {
+			return "", fmt.Errorf("piped %v", err)
+		}
+		a.Content = sample
+		a.Title = "Input JSON"
+		if cut {
+			a.Title = fmt.Sprintf("Sample of the input JSON (arrays cut to %d items, long strings shortened)", jqSampleItems)
+		}
+		sys.Attachments[i] = a
+		return sample, nil
+	}
+	return "", errors.New("pipe in the JSON to write a filter for, e.g. curl -s URL | llm --jq ...")
+}
+
+// verifyJQ runs filter on sample with jq. If it fails, the model is asked
+// once to correct it, and the corrected filter is returned.
+func verifyJQ(ctx context.Context, cfg *config.Config, client *llm.Client, opts *options, sys llm.System, sample, filter string) (string, error) {
+	if _, err := exec.LookPath("jq"); err != nil {
+		slog.Warn("jq not found; not verifying the filter")
+		return filter, nil
+	}
+	jqErr := runJQ(ctx, filter, sample)
+	if jqErr == nil {
+		return filter, nil
+	}
+
+	slog.Info("The filter failed; asking for a correction", "error", jqErr)
+	sys.Notes = append(sys.Notes, fmt.Sprintf("The filter `%s` failed on this input with: %v", filter, jqErr))
+	response, _, err := requery(ctx, cfg, client, opts, sys)
+	if err != nil {
+		return "", err
+	}
+	corrected := cleanFilter(response)
+	if err := runJQ(ctx, corrected, sample); err != nil {
+		slog.Warn("The corrected filter also fails", "error", err)
+	}
+	return corrected, nil
+}
+
+// runJQ runs jq with filter on input, returning jq's error message if it
+// fails. jq gets an empty environment, so that a filter can't put $ENV,
+// and the API keys in it, into the error sent back to the model.
+func runJQ(ctx context.Context, filter, input string) error {
+	ctx, cancel := context.WithTimeout(ctx, jqTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "jq", filter)
+	cmd.Env = []string{}
+	cmd.Stdin = strings.NewReader(input)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("timed out after %v", jqTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return errors.New(msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// cleanFilter removes the fences, quoting or jq command that models
+// sometimes put around a filter
+func cleanFilter(response string) string {
+	filter := strings.TrimSpace(strings.Trim(stripFence(response), "`"))
+	filter = strings.TrimSpace(strings.TrimPrefix(filter, "jq "))
+	if len(filter) >= 2 && filter[0] == '\'' && filter[len(filter)-1] == '\'' {
+		filter = filter[1 : len(filter)-1]
+	}
+	return filter
+}
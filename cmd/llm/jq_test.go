@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestCleanFilter(t *testing.T) {
+	for in, want := range map[string]string{
+		".[].name":                 ".[].name",
+		"`.[].name`":               ".[].name",
+		"jq '.[] | .name'":         ".[] | .name",
+		"```jq\n.[] | .name\n```":  ".[] | .name",
+		"```\n.a\n```\n":           ".a",
+		"  map(select(.x > 1))\n ": "map(select(.x > 1))",
+	} {
+		if got := cleanFilter(in); got != want {
+			t.Errorf("cleanFilter(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSampleJQInput(t *testing.T) {
+	sys := llm.System{Attachments: []llm.Attachment{
+		{Name: "notes.txt", Content: "not json"},
+		{Content: `[1, 2, 3, 4, 5]`},
+	}}
+	sample, err := sampleJQInput(&sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sample != "[\n  1,\n  2,\n  3\n]" || sys.Attachments[1].Content != sample {
+		t.Errorf("sample = %q", sample)
+	}
+
+	if _, err := sampleJQInput(&llm.System{}); err == nil {
+		t.Error("expected an error without piped input")
+	}
+	if _, err := sampleJQInput(&llm.System{Attachments: []llm.Attachment{{Content: "oops"}}}); err == nil {
+		t.Error("expected an error for input that isn't JSON")
+	}
+}
+
+func TestRunJQ(t *testing.T) {
+	if _, err := exec.LookPath("jq"); err != nil {
+		t.Skip("jq not installed")
+	}
+	ctx := context.Background()
+	if err := runJQ(ctx, ".[].name", `[{"name": "a"}]`); err != nil {
+		t.Error(err)
+	}
+	if err := runJQ(ctx, ".[] | .name)", `[]`); err == nil {
+		t.Error("expected a syntax error")
+	}
+	t.Setenv("LLM_TEST_SECRET", "sk-hidden")
+	if err := runJQ(ctx, `error($ENV|tostring)`, `{}`); err == nil || strings.Contains(err.Error(), "sk-hidden") {
+		t.Errorf("jq saw the environment: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if err := runJQ(ctx, "repeat(.)", `1`); err == nil {
+		t.Error("expected a filter that never ends to be stopped")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// kubectlTimeout bounds each kubectl call, so an unreachable cluster
+// doesn't stall a query
+const kubectlTimeout = 5 * time.Second
+
+// prepareK8s adds the kubectl context and namespace to sys, and the
+// cluster's resource types if apiResources is set. Only the latter talks to
+// the cluster.
+func prepareK8s(sys *llm.System, apiResources bool) error {
+	if _, err := exec.LookPath("kubectl"); err != nil {
+		return errors.New("kubectl not found; --k8s needs it to find the current cluster")
+	}
+
+	kubeContext, err := kubectl("config", "current-context")
+	if err != nil {
+		return err
+	}
+	namespace, _ := kubectl("config", "view", "--minify", "-o", "jsonpath={..namespace}")
+	if namespace == "" {
+		namespace = "default"
+	}
+	sys.Notes = append(sys.Notes, "kubectl context: "+kubeContext, "Namespace: "+namespace)
+	if _, err := exec.LookPath("helm"); err == nil {
+		sys.Notes = append(sys.Notes, "Helm is installed")
+	}
+
+	if apiResources {
+		resources, err := kubectl("api-resources", "--request-timeout=5s")
+		if err != nil {
+			slog.Warn("Not including the cluster's resource types", "error", err)
+		} else {
+			sys.Attachments = append(sys.Attachments, llm.Attachment{
+				Title:   "Resource types on the cluster (kubectl api-resources)",
+				Content: resources,
+			})
+		}
+	}
+	return nil
+}
+
+// kubectl runs kubectl and returns its trimmed output
+func kubectl(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), kubectlTimeout)
+	defer cancel()
+
+	var stderr strings.Builder
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("kubectl %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("kubectl %s: %v", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// fakeKubectl puts a kubectl on PATH that answers like a cluster with no
+// namespace set in its context
+func fakeKubectl(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell script")
+	}
+	dir := t.TempDir()
+	script := `#!/bin/sh
+case "$1 $2" in
+"config current-context") echo staging ;;
+"config view") ;;
+api-resources*) echo "NAME SHORTNAMES APIVERSION NAMESPACED KIND"; echo "pods po v1 true Pod" ;;
+*) echo "unexpected: $*" >&2; exit 1 ;;
+esac
+`
+	os.WriteFile(filepath.Join(dir, "kubectl"), []byte(script), 0755)
+	t.Setenv("PATH", dir)
+}
+
+func TestPrepareK8s(t *testing.T) {
+	fakeKubectl(t)
+
+	var sys llm.System
+	if err := prepareK8s(&sys, false); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"kubectl context: staging", "Namespace: default"}; !reflect.DeepEqual(sys.Notes, want) {
+		t.Errorf("notes = %q", sys.Notes)
+	}
+	if len(sys.Attachments) != 0 {
+		t.Error("api-resources should be opt-in")
+	}
+
+	sys = llm.System{}
+	if err := prepareK8s(&sys, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(sys.Attachments) != 1 || sys.Attachments[0].Content == "" {
+		t.Errorf("attachments = %+v", sys.Attachments)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/internal/keyring"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// keyProviders are the providers whose API keys can be stored in the
+// keychain, by the name used with "llm keys"
+var keyProviders = []struct {
+	name     string
+	provider llm.Provider
+	env      string
+}{
+	{"anthropic", llm.Claude, "ANTHROPIC_API_KEY"},
+	{"openai", llm.OpenAI, "OPENAI_API_KEY"},
+}
+
+// errNoKey is returned when a model's provider has no API key
+var errNoKey = errors.New("no API key")
+
+const keysUsage = "usage: llm keys <set|remove> <anthropic|openai>, or llm keys list"
+
+// runKeys manages API keys stored in the OS keychain
+func runKeys(args []string) error {
+	if len(args) == 0 {
+		return usageError(keysUsage)
+	}
+	if args[0] == "list" {
+		if len(args) != 1 {
+			return usageError(keysUsage)
+		}
+		return listKeys()
+	}
+	if len(args) != 2 {
+		return usageError(keysUsage)
+	}
+	name, err := keyProviderName(args[1])
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "set":
+		key, err := readSecret(fmt.Sprintf("API key for %s: ", name))
+		if err != nil {
+			return err
+		}
+		if key == "" {
+			return errors.New("no key given")
+		}
+		if err := keyring.Set(name, key); err != nil {
+			return err
+		}
+		fmt.Fprintf(notices, "Stored the %s key in the keychain\n", name)
+	case "remove":
+		if err := keyring.Delete(name); err != nil {
+			return fmt.Errorf("%s key: %v", name, err)
+		}
+		fmt.Fprintf(notices, "Removed the %s key from the keychain\n", name)
+	default:
+		return usageError(keysUsage)
+	}
+	return nil
+}
+
+// keyProviderName checks that name is a provider with an API key
+func keyProviderName(name string) (string, error) {
+	for _, p := range keyProviders {
+		if p.name == name {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown provider %q (expected anthropic or openai)", name)
+}
+
+// listKeys prints where each provider's key comes from, without the keys
+func listKeys() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range keyProviders {
+		var source string
+		if cfg.Has(p.name + "_key_cmd") {
+			source = p.name + "_key_cmd"
+		} else if _, err := keyring.Get(p.name); err == nil {
+			source = "keychain"
+		} else if !errors.Is(err, keyring.ErrNotFound) {
+			return err
+		}
+
+		var status string
+		switch {
+		case source != "" && os.Getenv(p.env) != "":
+			status = source + " (overridden by $" + p.env + ")"
+		case source != "":
+			status = source
+		case os.Getenv(p.env) != "":
+			status = "$" + p.env
+		default:
+			status = "not set"
+		}
+		fmt.Printf("%-10s %s\n", p.name, status)
+	}
+	return nil
+}
+
+// storedKeyClient returns a client for the first provider with a key
+// command in cfg or a key in the keychain, in the same priority order as the
+// environment variables
+func storedKeyClient(cfg *config.Config) (*llm.Client, error) {
+	for _, p := range keyProviders {
+		key, err := storedKey(cfg, p.name)
+		if err != nil {
+			return nil, err
+		}
+		if key != "" {
+			return llm.NewClient(p.provider, key, ""), nil
+		}
+	}
+	return nil, llm.ErrNoProvider
+}
+
+// storedKey returns the key for the named provider from its key command or
+// the keychain, or "" if there's neither
+func storedKey(cfg *config.Config, name string) (string, error) {
+	if command := cfg.String(name + "_key_cmd"); command != "" {
+		key, err := runKeyCommand(command)
+		if err != nil {
+			return "", fmt.Errorf("%s_key_cmd: %v", name, err)
+		}
+		return key, nil
+	}
+	if key, err := keyring.Get(name); err == nil {
+		return key, nil
+	}
+	return "", nil
+}
+
+// modelClient returns a client for model, as named for llm.ResolveModel,
+// finding the provider's key the same way newClient does
+func modelClient(cfg *config.Config, model string) (*llm.Client, error) {
+	provider, model := llm.ResolveModel(model)
+	client := llm.NewClient(provider, "", model)
+	if err := setupClient(cfg, client); err != nil {
+		return nil, err
+	}
+	for _, p := range keyProviders {
+		if p.provider != provider {
+			continue
+		}
+		client.APIKey = os.Getenv(p.env)
+		if client.APIKey == "" {
+			key, err := storedKey(cfg, p.name)
+			if err != nil {
+				return nil, err
+			}
+			client.APIKey = key
+		}
+		if client.APIKey == "" && os.Getenv("LLM_REPLAY_DIR") == "" {
+			return nil, fmt.Errorf("%w for %s; set %s or run: llm keys set %s", errNoKey, model, p.env, p.name)
+		}
+	}
+	return client, nil
+}
+
+// runKeyCommand runs command with the shell and returns the first line of
+// its output, which is where pass and similar tools put the secret
+func runKeyCommand(command string) (string, error) {
+	cmd := shellCommand(context.Background(), command)
+	// Let password managers prompt for a passphrase or fingerprint
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	key, _, _ := strings.Cut(string(out), "\n")
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", errors.New("command printed no key")
+	}
+	return key, nil
+}
+
+// readSecret reads a line from the terminal without echoing it, or from
+// stdin if it isn't a terminal, e.g. "pass show api | llm keys set openai"
+func readSecret(prompt string) (string, error) {
+	if !isTerminal(os.Stdin) {
+		data, err := io.ReadAll(io.LimitReader(os.Stdin, maxStdin))
+		if err != nil {
+			return "", fmt.Errorf("failed to read key: %v", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	if echo(false) == nil {
+		defer func() {
+			echo(true)
+			fmt.Fprintln(os.Stderr)
+		}()
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read key: %v", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// echo turns terminal echo on or off
+func echo(on bool) error {
+	arg := "-echo"
+	if on {
+		arg = "echo"
+	}
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
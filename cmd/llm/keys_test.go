@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestRunKeysUsage(t *testing.T) {
+	for _, args := range [][]string{
+		nil,
+		{"set"},
+		{"list", "openai"},
+		{"set", "ollama"},
+		{"rotate", "openai"},
+	} {
+		if err := runKeys(args); err == nil {
+			t.Errorf("runKeys(%q) should fail", args)
+		}
+	}
+}
+
+func TestKeyCommand(t *testing.T) {
+	for _, name := range []string{"ANTHROPIC_API_KEY", "OPENAI_API_KEY", "OLLAMA_MODEL"} {
+		t.Setenv(name, "")
+	}
+
+	cfg, err := config.Parse(`openai_key_cmd = "printf 'sk-from-cmd\nurl: example.com\n'"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Provider != llm.OpenAI || client.APIKey != "sk-from-cmd" {
+		t.Errorf("got provider %v, key %q", client.Provider, client.APIKey)
+	}
+
+	// The environment still wins
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant-env")
+	if client, err = newClient(cfg); err != nil || client.Provider != llm.Claude {
+		t.Errorf("got %v, %v", client, err)
+	}
+	t.Setenv("ANTHROPIC_API_KEY", "")
+
+	for _, command := range []string{"exit 1", "true"} {
+		cfg, _ := config.Parse(`anthropic_key_cmd = "` + command + `"`)
+		if _, err := newClient(cfg); err == nil {
+			t.Errorf("key command %q should fail", command)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+const lintCommitUsage = `usage: llm lint-commit [--json] [--no-rewrite] ["<message>" | -f FILE]`
+
+// defaultCommitTypes are the Conventional Commits types a subject may
+// have, unless commit_types is set
+var defaultCommitTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// maxSubjectLength is the longest subject line llm lint-commit passes,
+// as llm commit is asked to write
+const maxSubjectLength = 72
+
+// subjectRe matches a Conventional Commits subject line:
+// type(scope)!: description
+var subjectRe = regexp.MustCompile(`^([A-Za-z]+)(\([^()\s]+\))?(!)?:(.*)$`)
+
+// scissorsRe matches the line git commit -v puts above the diff
+var scissorsRe = regexp.MustCompile(`^# -+ >8 -+$`)
+
+// commitLint is what llm lint-commit reports, and prints with --json
+type commitLint struct {
+	Pass     bool     `json:"pass"`
+	Problems []string `json:"problems"`
+	Rewrite  string   `json:"rewrite,omitempty"`
+}
+
+// runLintCommit checks a commit message against Conventional Commits and,
+// if it fails, suggests a rewrite. The problems go to stderr and the
+// rewrite to stdout, and it exits with status 1 if the message fails.
+func runLintCommit(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	opts := &options{mode: llm.CommitLintMode}
+	var path string
+	var asJSON, noRewrite bool
+	flagSet := flag.NewFlagSet("llm lint-commit", flag.ContinueOnError)
+	flagSet.StringVar(&path, "f", "", "Read the message from `file`, e.g. the one a commit-msg hook is given (default: .git/COMMIT_EDITMSG)")
+	flagSet.BoolVar(&asJSON, "json", false, "Print the result as JSON")
+	flagSet.BoolVar(&noRewrite, "no-rewrite", false, "Only check the message, without asking for a rewrite")
+	flagSet.BoolVar(&opts.noRedact, "no-redact", false, "Send the message without redacting secrets")
+	opts.log.register(flagSet)
+	flagSet.Usage = func() {
+		fmt.Fprintln(os.Stderr, lintCommitUsage)
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if path != "" && flagSet.NArg() > 0 {
+		return usageError("give the message or -f, not both")
+	}
+
+	message := strings.Join(flagSet.Args(), " ")
+	if message == "" {
+		if path == "" {
+			if path, err = gitOutput("rev-parse", "--git-path", "COMMIT_EDITMSG"); err != nil {
+				return err
+			}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		message = stripCommitComments(string(data))
+	}
+
+	types := defaultCommitTypes
+	if cfg.Has("commit_types") {
+		types = cfg.Strings("commit_types")
+	}
+	lint := commitLint{Problems: lintCommit(message, types)}
+	lint.Pass = len(lint.Problems) == 0
+
+	if !lint.Pass && !noRewrite && strings.TrimSpace(message) != "" {
+		if err := opts.log.setup(); err != nil {
+			return err
+		}
+		client, err := newClient(cfg)
+		if err != nil {
+			return err
+		}
+		sys := llm.DetectSystem()
+		sys.Attachments = []llm.Attachment{{Title: "Commit message", Content: message}}
+		if diff, err := stagedDiff(); err == nil {
+			sys.Attachments = append(sys.Attachments, llm.Attachment{Title: "Staged changes", Content: diff})
+		}
+		sys.Notes = append(sys.Notes, "Allowed types: "+strings.Join(types, ", "))
+		for _, problem := range lint.Problems {
+			sys.Notes = append(sys.Notes, "Problem: "+problem)
+		}
+		opts.query = "Rewrite this commit message in the Conventional Commits style."
+		// Commit messages are short, and hooks have no one to ask
+		opts.yes = true
+
+		rewrite, err := ask(context.Background(), cfg, client, opts, sys)
+		if err != nil {
+			return err
+		}
+		lint.Rewrite = strings.TrimSpace(stripFence(rewrite))
+		if problems := lintCommit(lint.Rewrite, types); len(problems) > 0 {
+			slog.Warn("The suggested rewrite has problems too", "problems", strings.Join(problems, "; "))
+		}
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if lint.Problems == nil {
+			lint.Problems = []string{}
+		}
+		if err := enc.Encode(lint); err != nil {
+			return err
+		}
+	} else if lint.Pass {
+		fmt.Fprintln(notices, "The message follows Conventional Commits")
+	} else {
+		fmt.Fprintln(os.Stderr, "The message doesn't follow Conventional Commits:")
+		for _, problem := range lint.Problems {
+			fmt.Fprintf(os.Stderr, "  - %s\n", problem)
+		}
+		if lint.Rewrite != "" {
+			fmt.Fprintln(os.Stderr, "\nSuggested rewrite:")
+			fmt.Println(lint.Rewrite)
+		}
+	}
+	if !lint.Pass {
+		exit(exitError)
+	}
+	return nil
+}
+
+// lintCommit returns the ways message doesn't follow Conventional Commits,
+// with the subject's type one of types
+func lintCommit(message string, types []string) []string {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return []string{"the message is empty"}
+	}
+	lines := strings.Split(message, "\n")
+	subject := strings.TrimRight(lines[0], " \t\r")
+
+	var problems []string
+	if m := subjectRe.FindStringSubmatch(subject); m == nil {
+		problems = append(problems, `the subject line isn't of the form "type(scope): description"`)
+	} else {
+		if !slices.Contains(types, m[1]) {
+			problems = append(problems, fmt.Sprintf("%q isn't one of the types %s", m[1], strings.Join(types, ", ")))
+		}
+		switch description := m[4]; {
+		case strings.TrimSpace(description) == "":
+			problems = append(problems, "the description after the colon is empty")
+		case !strings.HasPrefix(description, " "):
+			problems = append(problems, "there's no space after the colon")
+		case strings.HasPrefix(description, "  "):
+			problems = append(problems, "there's more than one space after the colon")
+		case strings.HasSuffix(description, "."):
+			problems = append(problems, "the subject line ends with a period")
+		}
+	}
+	if n := len([]rune(subject)); n > maxSubjectLength {
+		problems = append(problems, fmt.Sprintf("the subject line is %d characters long; keep it to %d", n, maxSubjectLength))
+	}
+	if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		problems = append(problems, "the subject line isn't followed by a blank line")
+	}
+	return problems
+}
+
+// stripCommitComments removes what git would from a message file before
+// committing it: comment lines, and the diff git commit -v adds below the
+// scissors line
+func stripCommitComments(message string) string {
+	var kept []string
+	for _, line := range strings.Split(message, "\n") {
+		if scissorsRe.MatchString(line) {
+			break
+		}
+		if !strings.HasPrefix(line, "#") {
+			kept = append(kept, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLintCommit(t *testing.T) {
+	tests := []struct {
+		message  string
+		problems []string // substrings, one per problem
+	}{
+		{"feat(cli): add lint-commit", nil},
+		{"fix!: drop the old config path\n\nBREAKING CHANGE: ~/.llmrc is no longer read", nil},
+		{"revert: feat(cli): add lint-commit", nil},
+		{"", []string{"empty"}},
+		{"Add lint-commit", []string{"isn't of the form"}},
+		{"feature: add lint-commit", []string{`"feature" isn't one of the types`}},
+		{"feat: ", []string{"description after the colon is empty"}},
+		{"feat:add it", []string{"no space after the colon"}},
+		{"feat:  add it", []string{"more than one space"}},
+		{"docs: fix typo.", []string{"ends with a period"}},
+		{"feat: " + strings.Repeat("x", 70), []string{"76 characters long"}},
+		{"feat: add it\nand more", []string{"followed by a blank line"}},
+		{"Feat: Add it.\nmore", []string{`"Feat" isn't one`, "period", "blank line"}},
+	}
+	for _, tt := range tests {
+		problems := lintCommit(tt.message, defaultCommitTypes)
+		if len(problems) != len(tt.problems) {
+			t.Errorf("%q: got problems %q, want %q", tt.message, problems, tt.problems)
+			continue
+		}
+		for i, want := range tt.problems {
+			if !strings.Contains(problems[i], want) {
+				t.Errorf("%q: problem %q doesn't mention %q", tt.message, problems[i], want)
+			}
+		}
+	}
+
+	if problems := lintCommit("wip: try things", []string{"wip"}); problems != nil {
+		t.Errorf("custom types: %q", problems)
+	}
+}
+
+func TestStripCommitComments(t *testing.T) {
+	message := "feat: add it\n\nWhy it's needed.\n# Please enter the commit message for your changes.\n#\n" +
+		"# ------------------------ >8 ------------------------\n# Do not modify or remove the line above.\ndiff --git a/x b/x\n"
+	if got, want := stripCommitComments(message), "feat: add it\n\nWhy it's needed."; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLintCommitOutput(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"choices":[{"message":{"content":"feat(cli): add lint-commit"}}]}`)
+	}))
+	defer srv.Close()
+
+	stdout, stderr, status := runLLM(t, srv.URL, "lint-commit", "feat: add lint-commit")
+	if status != exitOK || stdout != "" || !strings.Contains(stderr, "follows Conventional Commits") {
+		t.Errorf("passing message: %d, %q, %q", status, stdout, stderr)
+	}
+
+	stdout, stderr, status = runLLM(t, srv.URL, "lint-commit", "Added lint-commit.")
+	if status != exitError || stdout != "feat(cli): add lint-commit\n" || !strings.Contains(stderr, "isn't of the form") {
+		t.Errorf("failing message: %d, %q, %q", status, stdout, stderr)
+	}
+
+	stdout, _, status = runLLM(t, srv.URL, "lint-commit", "--json", "--no-rewrite", "Added lint-commit.")
+	var lint commitLint
+	if err := json.Unmarshal([]byte(stdout), &lint); err != nil || status != exitError || lint.Pass || len(lint.Problems) != 1 || lint.Rewrite != "" {
+		t.Errorf("--json --no-rewrite: %d, %q, %v", status, stdout, err)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// notices is where progress, warnings and other messages that aren't
+// errors are written: stderr, or nowhere with --quiet
+var notices io.Writer = os.Stderr
+
+// logFlags are the logging flags every command that talks to a provider
+// takes
+type logFlags struct {
+	debug bool
+	quiet bool
+	level string
+	file  string
+}
+
+// register adds --debug, -q, --log-level and --log-file to flagSet
+func (f *logFlags) register(flagSet *flag.FlagSet) {
+	flagSet.BoolVar(&f.debug, "debug", false, "Log requests and responses (same as --log-level debug)")
+	flagSet.BoolVar(&f.quiet, "quiet", false, "Write nothing but the answer and errors")
+	flagSet.BoolVar(&f.quiet, "q", false, "Write nothing but the answer and errors (short)")
+	flagSet.StringVar(&f.level, "log-level", "info", "Log `level`: debug, info, warn or error")
+	flagSet.StringVar(&f.file, "log-file", os.Getenv("LLM_LOG_FILE"), "Write log messages to `file` instead of stderr")
+}
+
+// setup configures the default logger from the flags
+func (f *logFlags) setup() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cmp.Or(f.level, "info"))); err != nil {
+		return usageError(fmt.Sprintf("invalid --log-level %q: use debug, info, warn or error", f.level))
+	}
+	if f.quiet {
+		level = max(level, slog.LevelError)
+		notices = io.Discard
+	}
+	if f.debug {
+		level = slog.LevelDebug
+	}
+	return setupLogging(level, f.file)
+}
+
+// setupLogging sends log messages at level and above to stderr, or to path
+// in logfmt with timestamps if it's set
+func setupLogging(level slog.Level, path string) error {
+	if path == "" {
+		slog.SetDefault(slog.New(newCLIHandler(os.Stderr, level)))
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(f, &slog.HandlerOptions{Level: level})))
+	return nil
+}
+
+// cliHandler writes log messages for a person at a terminal: the message as
+// is, prefixed with "Warning: " or "Error: " as needed, then any attributes
+// as key=value
+type cliHandler struct {
+	w     io.Writer
+	level slog.Leveler
+	mu    *sync.Mutex
+
+	attrs  string // from WithAttrs, already formatted
+	prefix string // from WithGroup, as "group."
+}
+
+func newCLIHandler(w io.Writer, level slog.Leveler) *cliHandler {
+	return &cliHandler{w: w, level: level, mu: new(sync.Mutex)}
+}
+
+func (h *cliHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *cliHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	switch {
+	case r.Level >= slog.LevelError:
+		b.WriteString("Error: ")
+	case r.Level >= slog.LevelWarn:
+		b.WriteString("Warning: ")
+	case r.Level < slog.LevelInfo:
+		b.WriteString("debug: ")
+	}
+	b.WriteString(r.Message)
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(&b, h.prefix, a)
+		return true
+	})
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *cliHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	for _, a := range attrs {
+		appendAttr(&b, h.prefix, a)
+	}
+	h2 := *h
+	h2.attrs += b.String()
+	return &h2
+}
+
+func (h *cliHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.prefix += name + "."
+	return &h2
+}
+
+// appendAttr writes a as " key=value", quoting values that need it, and
+// flattens groups into dotted keys
+func appendAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, g := range a.Value.Group() {
+			appendAttr(b, prefix, g)
+		}
+		return
+	}
+	value := a.Value.String()
+	if value == "" || strings.ContainsAny(value, " \"=\t\n") {
+		value = strconv.Quote(value)
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, a.Key, value)
+}
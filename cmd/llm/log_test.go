@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestCLIHandler(t *testing.T) {
+	var b strings.Builder
+	logger := slog.New(newCLIHandler(&b, slog.LevelInfo))
+	logger.Debug("hidden")
+	logger.Info("Waiting for the rate limit to reset", "host", "api.anthropic.com", "wait", "2s")
+	logger.Warn("Failed to save history", "error", errors.New("disk full"))
+	logger.With("provider", "claude").WithGroup("usage").Error("Request failed", "status", 500, slog.Group("tokens", "in", 3))
+	logger.Info("Empty", "value", "")
+
+	want := `Waiting for the rate limit to reset host=api.anthropic.com wait=2s
+Warning: Failed to save history error="disk full"
+Error: Request failed provider=claude usage.status=500 usage.tokens.in=3
+Empty value=""
+`
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestLogFlags(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	if err := (&logFlags{level: "loud"}).setup(); exitCode(err) != exitUsage {
+		t.Errorf("invalid level gave %v, want a usage error", err)
+	}
+	if err := (&logFlags{level: "warn"}).setup(); err != nil {
+		t.Fatal(err)
+	}
+	if slog.Default().Enabled(context.Background(), slog.LevelInfo) || !slog.Default().Enabled(context.Background(), slog.LevelWarn) {
+		t.Error("--log-level warn doesn't log just warnings and errors")
+	}
+	if err := (&logFlags{level: "error", debug: true}).setup(); err != nil {
+		t.Fatal(err)
+	}
+	if !slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("--debug doesn't log debug messages")
+	}
+}
+
+func TestQuiet(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	defer func(w io.Writer) { notices = w }(notices)
+
+	if err := (&logFlags{level: "info", quiet: true}).setup(); err != nil {
+		t.Fatal(err)
+	}
+	if slog.Default().Enabled(context.Background(), slog.LevelWarn) || !slog.Default().Enabled(context.Background(), slog.LevelError) {
+		t.Error("--quiet doesn't log just errors")
+	}
+	if notices != io.Discard {
+		t.Error("--quiet doesn't discard notices")
+	}
+}
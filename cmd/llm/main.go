@@ -0,0 +1,1237 @@
+// Command llm suggests shell commands, writes code snippets and explains
+// concepts using Claude, OpenAI or a local Ollama model.
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/internal/history"
+	"github.com/jamesob/llm-cli/internal/paths"
+	"github.com/jamesob/llm-cli/pkg/llm"
+	"github.com/jamesob/llm-cli/pkg/render"
+)
+
+const version = "1.0.0"
+
+// maxListing caps how many directory entries --ls sends
+const maxListing = 200
+
+// maxStdin caps how much piped input is read
+const maxStdin = 1 << 20
+
+// options holds everything parsed from the command line
+type options struct {
+	mode       llm.Mode
+	noPager    bool
+	log        logFlags
+	context    bool
+	listDir    bool
+	recent     int
+	noRedact   bool
+	offline    bool
+	notify     bool
+	stats      bool
+	yes        bool
+	jsonErrors bool
+	files      []string
+	images     []string
+	screenshot bool
+	repo       bool
+	dialect    string
+	schema     string
+	dsn        string
+	verify     bool
+	apiRes     bool
+	account    bool
+	output     string
+	force      bool
+	language   string
+	outputLang string
+	codeLang   string
+	apply      bool
+	tldr       string
+	portTo     string
+	portFrom   string
+	man        bool
+	tools      bool
+	bestOf     int
+	n          int
+	stop       []string
+	ctx        []string
+	ctxVars    map[string]string
+	favorite   bool
+	template   string
+	pipe       bool
+	exec       bool
+	dir        string
+	session    string
+	args       []string
+	seed       int
+	model      string
+	retry      bool
+	query      string
+}
+
+// stringList is a flag that can be repeated
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// parseArgs parses command-line arguments (excluding the program name).
+// Settings from cfg provide the defaults for flags.
+func parseArgs(args []string, cfg *config.Config) (*options, error) {
+	var codeMode bool
+	var explainMode bool
+	var regexMode bool
+	var jqMode bool
+	var sqlMode bool
+	var cronMode bool
+	var k8sMode bool
+	var awsMode bool
+	var gcloudMode bool
+	var azMode bool
+	var tfMode bool
+	var systemdMode bool
+	var dockerMode bool
+	var sedMode bool
+	var awkMode bool
+	var proofreadMode bool
+	var patchMode bool
+	opts := &options{}
+
+	// Custom flag set to handle both short and long flags
+	flagSet := flag.NewFlagSet("llm", flag.ContinueOnError)
+	flagSet.BoolVar(&codeMode, "code", false, "Code generation mode")
+	flagSet.BoolVar(&codeMode, "c", false, "Code generation mode (short)")
+	flagSet.StringVar(&opts.codeLang, "lang", "", "Language to write code in, e.g. python or go (implies --code)")
+	flagSet.BoolVar(&explainMode, "explain", false, "Explanation mode")
+	flagSet.BoolVar(&explainMode, "x", false, "Explanation mode (short)")
+	flagSet.BoolVar(&regexMode, "regex", false, "Regular expression mode")
+	flagSet.StringVar(&opts.dialect, "dialect", "", "Regex dialect (pcre, re2/go, ere/grep, bre/sed) or SQL dialect (postgres, mysql, sqlite)")
+	flagSet.BoolVar(&jqMode, "jq", false, "jq filter mode")
+	flagSet.BoolVar(&sqlMode, "sql", false, "SQL query mode")
+	flagSet.BoolVar(&cronMode, "cron", false, "Cron expression mode")
+	flagSet.BoolVar(&k8sMode, "k8s", false, "Kubernetes mode")
+	flagSet.BoolVar(&awsMode, "aws", false, "AWS CLI mode")
+	flagSet.BoolVar(&gcloudMode, "gcloud", false, "Google Cloud CLI mode")
+	flagSet.BoolVar(&azMode, "az", false, "Azure CLI mode")
+	flagSet.BoolVar(&tfMode, "tf", false, "Terraform mode")
+	flagSet.BoolVar(&systemdMode, "systemd", false, "systemd unit file mode")
+	flagSet.BoolVar(&sedMode, "sed", false, "sed one-liner mode")
+	flagSet.BoolVar(&awkMode, "awk", false, "awk one-liner mode")
+	flagSet.StringVar(&opts.language, "translate", "", "Translate text into this language")
+	flagSet.StringVar(&opts.outputLang, "output-lang", cfg.String("output_lang"), "Language to explain in (default: your locale's)")
+	flagSet.BoolVar(&proofreadMode, "proofread", false, "Correct grammar and spelling in text")
+	flagSet.BoolVar(&patchMode, "patch", false, "Unified diff mode for attached files")
+	flagSet.BoolVar(&opts.apply, "apply", false, "Apply the patch from --patch, or install the units from --systemd")
+	flagSet.StringVar(&opts.tldr, "tldr", "", "Show a tldr-style cheat sheet for a command")
+	flagSet.StringVar(&opts.portTo, "port-to", "", "Translate a command or script into this shell")
+	flagSet.StringVar(&opts.portFrom, "from", "", "Shell to translate from with --port-to (default: yours)")
+	flagSet.BoolVar(&dockerMode, "docker", false, "Dockerfile or compose file mode")
+	flagSet.StringVar(&opts.output, "output", "", "Write the answer to a file")
+	flagSet.StringVar(&opts.output, "o", "", "Write the answer to a file (short)")
+	flagSet.StringVar(&opts.dir, "dir", "", "Write the files of a multi-file code answer under this directory")
+	flagSet.BoolVar(&opts.force, "force", false, "Let -o or --dir overwrite existing files")
+	flagSet.BoolVar(&opts.apiRes, "api-resources", cfg.Bool("k8s_api_resources"), "Include the cluster's resource types with --k8s")
+	flagSet.BoolVar(&opts.account, "account", cfg.Bool("cloud_account"), "Include the profile or project and region the cloud CLI uses with --aws, --gcloud or --az")
+	flagSet.StringVar(&opts.schema, "schema", "", "File with the database schema for --sql")
+	flagSet.StringVar(&opts.dsn, "dsn", "", "Database to read the schema from for --sql")
+	flagSet.BoolVar(&opts.verify, "verify", false, "Check the answer by running it, and ask for one correction if it fails")
+	flagSet.BoolVar(&opts.noPager, "no-pager", false, "Never pipe output through a pager")
+	opts.log.register(flagSet)
+	flagSet.BoolVar(&opts.jsonErrors, "json", false, "Report errors as JSON on stderr")
+	flagSet.BoolVar(&opts.context, "context", cfg.Bool("context"), "Include project context in the prompt")
+	flagSet.BoolVar(&opts.man, "man", !cfg.Has("man_pages") || cfg.Bool("man_pages"), "Include excerpts from local man pages of commands the query names")
+	flagSet.BoolVar(&opts.tools, "tools", cfg.Bool("tools"), "Let the model read files and run read-only commands before answering")
+	flagSet.StringVar(&opts.model, "model", "", "Ask this model instead of the usual one, e.g. gpt-4o or llama3")
+	flagSet.StringVar(&opts.model, "m", "", "Ask this model instead (short)")
+	flagSet.BoolVar(&opts.retry, "retry", false, "Ask the previous query again and show both answers")
+	flagSet.Var((*stringList)(&opts.stop), "stop", "End the answer at this string (repeatable)")
+	flagSet.Var((*stringList)(&opts.ctx), "ctx", "Tell the model KEY=VALUE, which {{.KEY}} in the query also stands for (repeatable)")
+	flagSet.BoolVar(&opts.exec, "exec", false, "Run the suggested command, after asking which unless -y is given")
+	flagSet.BoolVar(&opts.pipe, "pipe", false, "Build on the answer piped in, or the last one, as part of the same session")
+	flagSet.StringVar(&opts.template, "template", "", "Fill in this prompt template and ask it")
+	flagSet.StringVar(&opts.template, "t", "", "Prompt template (short)")
+	flagSet.Var((*stringList)(&opts.args), "arg", "A template's argument as NAME=VALUE (repeatable)")
+	flagSet.IntVar(&opts.seed, "seed", 0, "Sampling seed, for repeatable answers from OpenAI and Ollama")
+	flagSet.IntVar(&opts.n, "n", 1, "Ask for this many candidate answers and pick one")
+	flagSet.IntVar(&opts.bestOf, "best-of", cfg.Int("best_of"), "Sample this many answers and have the model pick or merge the best")
+	flagSet.BoolVar(&opts.repo, "repo", false, "Answer a question about the current git repository, sending its files list and matching excerpts")
+	flagSet.BoolVar(&opts.listDir, "ls", false, "Include a listing of the current directory in the prompt")
+	flagSet.IntVar(&opts.recent, "recent", 0, "Include your last N shell commands, from Atuin, zsh-histdb or the history file")
+	flagSet.BoolVar(&opts.noRedact, "no-redact", false, "Send secrets in the prompt without redacting them")
+	flagSet.BoolVar(&opts.offline, "offline", cfg.Bool("offline"), "Send nothing off this machine, except to offline_hosts")
+	flagSet.BoolVar(&opts.notify, "notify", cfg.Bool("notify"), "Notify when an answer that took a while arrives")
+	flagSet.BoolVar(&opts.stats, "stats", cfg.Bool("stats"), "Show the model, time taken and tokens used after the answer")
+	flagSet.Var((*stringList)(&opts.files), "file", "Attach a file (repeatable)")
+	flagSet.Var((*stringList)(&opts.files), "f", "Attach a file (short)")
+	flagSet.Var((*stringList)(&opts.images), "image", "Attach an image, e.g. a screenshot of an error (repeatable)")
+	flagSet.BoolVar(&opts.screenshot, "screenshot", false, "Select part of the screen and attach it as an image")
+	flagSet.BoolVar(&opts.yes, "yes", false, "Don't ask before sending large or sensitive context")
+	flagSet.BoolVar(&opts.yes, "y", false, "Don't ask before sending (short)")
+
+	// main prints the help, on stdout for -h and on stderr after a mistake
+	flagSet.Usage = func() {}
+
+	if err := flagSet.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if codeMode {
+		opts.mode = llm.CodeMode
+	} else if explainMode {
+		opts.mode = llm.ExplainMode
+	} else if regexMode {
+		opts.mode = llm.RegexMode
+	} else if jqMode {
+		opts.mode = llm.JQMode
+	} else if sqlMode {
+		opts.mode = llm.SQLMode
+	} else if cronMode {
+		opts.mode = llm.CronMode
+	} else if k8sMode {
+		opts.mode = llm.K8sMode
+	} else if awsMode {
+		opts.mode = llm.AWSMode
+	} else if gcloudMode {
+		opts.mode = llm.GCloudMode
+	} else if azMode {
+		opts.mode = llm.AzureMode
+	} else if tfMode {
+		opts.mode = llm.TFMode
+	} else if systemdMode {
+		opts.mode = llm.SystemdMode
+	} else if dockerMode {
+		opts.mode = llm.DockerMode
+	} else if sedMode {
+		opts.mode = llm.SedMode
+	} else if awkMode {
+		opts.mode = llm.AwkMode
+	} else if opts.language != "" {
+		opts.mode = llm.TranslateMode
+	} else if proofreadMode {
+		opts.mode = llm.ProofreadMode
+	} else if patchMode {
+		opts.mode = llm.PatchMode
+	} else if opts.tldr != "" {
+		opts.mode = llm.TLDRMode
+	} else if opts.portTo != "" {
+		opts.mode = llm.PortMode
+	} else if opts.codeLang != "" || opts.dir != "" {
+		opts.mode = llm.CodeMode
+	} else if opts.repo {
+		opts.mode = llm.RepoMode
+	}
+	opts.query = expandAliases(strings.Join(flagSet.Args(), " "), cfg)
+	if opts.mode == llm.TLDRMode {
+		// "--tldr git rebase" is about "git rebase"
+		opts.query = strings.TrimSpace(opts.tldr + " " + opts.query)
+	}
+	if opts.mode == llm.CodeMode && opts.codeLang != "" && opts.output != "" && filepath.Ext(opts.output) == "" {
+		// "-o fetch --lang python" writes fetch.py
+		opts.output += llm.CodeLanguage(opts.codeLang).Extension
+	}
+
+	return opts, nil
+}
+
+// readStdin returns piped input, or "" if stdin is a terminal
+func readStdin() (string, error) {
+	if isTerminal(os.Stdin) {
+		return "", nil
+	}
+	data, err := io.ReadAll(io.LimitReader(os.Stdin, maxStdin))
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin: %v", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// newClient returns a client for the provider configured in the
+// environment, falling back to key commands in cfg and keys stored in the
+// keychain
+func newClient(cfg *config.Config) (*llm.Client, error) {
+	client, err := llm.FromEnv()
+	if errors.Is(err, llm.ErrNoProvider) {
+		client, err = storedKeyClient(cfg)
+	}
+	if errors.Is(err, llm.ErrNoProvider) && os.Getenv("LLM_REPLAY_DIR") != "" {
+		// Fixtures can be replayed without any credentials
+		client, err = llm.NewClient(llm.Claude, "replay", ""), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := setupClient(cfg, client); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// printSetupHelp explains how to configure a provider
+func printSetupHelp() {
+	fmt.Fprintf(os.Stderr, "Set one of the following environment variables:\n")
+	fmt.Fprintf(os.Stderr, "  export ANTHROPIC_API_KEY=your_claude_api_key\n")
+	fmt.Fprintf(os.Stderr, "  export OPENAI_API_KEY=your_openai_api_key\n")
+	fmt.Fprintf(os.Stderr, "or store a key in the keychain with: llm keys set anthropic\n")
+}
+
+// subcommands are dispatched on the first argument. A query that starts
+// with one of these words can be passed after "--".
+var subcommands = map[string]func(args []string) error{
+	"agent":           runAgent,
+	"bad":             runBad,
+	"batch":           runBatch,
+	"bench":           runBench,
+	"bugreport":       runBugReport,
+	"changelog":       runChangelog,
+	"commit":          runCommit,
+	"compare":         runCompare,
+	"daemon":          runDaemon,
+	"edit":            runEdit,
+	"explain-cmd":     runExplainCmd,
+	"explain-last":    runExplainLast,
+	"fav":             runFav,
+	"fix":             runFix,
+	"good":            runGood,
+	"history":         runHistory,
+	"index":           runIndex,
+	"install-hook":    runInstallHook,
+	"keys":            runKeys,
+	"lint-commit":     runLintCommit,
+	"pr":              runPR,
+	"recall":          runRecall,
+	"review":          runReview,
+	"serve":           runServe,
+	"shell-init":      runShellInit,
+	"suggest-aliases": runSuggestAliases,
+	"templates":       runTemplates,
+	"tmux-capture":    runTmuxCapture,
+	"usage":           runUsage,
+	"watch":           runWatch,
+}
+
+func main() {
+	enableANSI()
+	if len(os.Args) < 2 {
+		printUsage(os.Stderr)
+		os.Exit(exitUsage)
+	}
+
+	// Handle help and version flags
+	if os.Args[1] == "--help" || os.Args[1] == "-h" {
+		printUsage(os.Stdout)
+		return
+	}
+	if os.Args[1] == "--version" || os.Args[1] == "-v" {
+		fmt.Printf("llm version %s\n", version)
+		return
+	}
+
+	// Until a command's flags say otherwise, log warnings and notes to stderr
+	setupLogging(slog.LevelInfo, "")
+
+	// Move files left where older versions kept them
+	moves, err := paths.Migrate()
+	for _, m := range moves {
+		slog.Info("Moved a file to where llm now keeps it", "from", m.From, "to", m.To)
+	}
+	if err != nil {
+		slog.Error("Failed to move old files", "error", err)
+	}
+
+	setupTelemetry()
+	defer flushTelemetry()
+
+	// "llm fav run NAME" stands for the command line saved as NAME
+	favorite := len(os.Args) > 2 && os.Args[1] == "fav" && os.Args[2] == "run"
+	if favorite {
+		args, err := favoriteArgs(os.Args[3:])
+		if err != nil {
+			reportError(os.Stderr, err, false)
+			exit(exitCode(err))
+		}
+		os.Args = append(os.Args[:1], args...)
+	}
+
+	run, ok := subcommands[os.Args[1]]
+	if len(os.Args) == 2 && os.Args[1] == "why" {
+		// "llm why" alone explains the last suggestion, while "llm why did
+		// that fail" is an ordinary query
+		run, ok = runExplainLast, true
+	}
+	if len(os.Args) == 2 && os.Args[1] == "man" {
+		// Likewise "llm man" alone prints the man page
+		run, ok = runMan, true
+	}
+	if ok {
+		if err := run(os.Args[2:]); err != nil {
+			// llm review --json reports errors as JSON too
+			asJSON := slices.Contains(os.Args[2:], "--json") || slices.Contains(os.Args[2:], "-json")
+			reportError(os.Stderr, err, asJSON)
+			if errors.Is(err, llm.ErrNoProvider) && !asJSON {
+				printSetupHelp()
+			}
+			exit(exitCode(err))
+		}
+		return
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		// Without the config for their defaults, the flags are only parsed
+		// to see whether --json was given
+		opts, parseErr := parseArgs(os.Args[1:], nil)
+		fatal(err, parseErr == nil && opts.jsonErrors)
+	}
+
+	// Parse flags and get remaining arguments
+	opts, err := parseArgs(os.Args[1:], cfg)
+	if errors.Is(err, flag.ErrHelp) {
+		printUsage(os.Stdout)
+		return
+	}
+	if err != nil {
+		printUsage(os.Stderr)
+		os.Exit(exitUsage)
+	}
+	opts.favorite = favorite
+	if opts.offline {
+		cfg.Set("offline", true)
+	}
+	if opts.stats {
+		meter = &usageMeter{}
+	}
+
+	if err := opts.log.setup(); err != nil {
+		fatal(err, opts.jsonErrors)
+	}
+	if err := applyContextVars(opts); err != nil {
+		fatal(err, opts.jsonErrors)
+	}
+	if err := applyTemplate(opts); err != nil {
+		fatal(err, opts.jsonErrors)
+	}
+	if opts.exec && opts.mode != llm.CommandMode && opts.mode != llm.K8sMode {
+		fatal(usageError("--exec runs commands, so only works in command mode and with --k8s"), opts.jsonErrors)
+	}
+
+	// Determine which API to use
+	var client *llm.Client
+	if opts.model != "" {
+		client, err = modelClient(cfg, opts.model)
+	} else {
+		client, err = newClient(cfg)
+	}
+	if err != nil {
+		reportError(os.Stderr, err, opts.jsonErrors)
+		if !opts.jsonErrors {
+			printSetupHelp()
+		}
+		exit(exitCode(err))
+	}
+	client.Stop, client.Seed = opts.stop, opts.seed
+	if opts.seed != 0 && client.Provider == llm.Claude {
+		slog.Warn("Claude doesn't support --seed; answers may still vary")
+	}
+
+	// Check before asking, rather than fail once the answer is in
+	if opts.output != "" {
+		if err := checkOutput(opts.output, opts.force); err != nil {
+			fatal(err, opts.jsonErrors)
+		}
+	}
+
+	if opts.retry {
+		if err := retryLast(context.Background(), cfg, client, opts); err != nil {
+			fatal(err, opts.jsonErrors)
+		}
+		printStats(client)
+		return
+	}
+
+	// Get system context
+	sys := llm.DetectSystem()
+	switch opts.mode {
+	case llm.RegexMode:
+		dialect, err := llm.RegexDialect(cmp.Or(opts.dialect, cfg.String("regex_dialect"), "pcre"))
+		if err != nil {
+			fatal(usageError(err.Error()), opts.jsonErrors)
+		}
+		sys.Notes = append(sys.Notes, "Regex dialect: "+dialect)
+	case llm.SQLMode:
+		if err := prepareSQL(cfg, opts, &sys); err != nil {
+			fatal(err, opts.jsonErrors)
+		}
+	case llm.K8sMode:
+		if err := prepareK8s(&sys, opts.apiRes); err != nil {
+			fatal(err, opts.jsonErrors)
+		}
+	case llm.AWSMode, llm.GCloudMode, llm.AzureMode:
+		if err := prepareCloud(&sys, opts.mode, opts.account); err != nil {
+			fatal(err, opts.jsonErrors)
+		}
+	case llm.SedMode, llm.AwkMode:
+		prepareOneLiner(&sys, opts.mode)
+	case llm.CodeMode:
+		if opts.codeLang != "" {
+			sys.Notes = append(sys.Notes, "Language: "+llm.CodeLanguage(opts.codeLang).Name)
+		}
+	}
+	addOutputLanguage(&sys, opts)
+	addContextVars(&sys, opts.ctxVars)
+	sys.Previous = previousCommand()
+	if wd, err := os.Getwd(); err == nil {
+		if opts.mode == llm.DockerMode {
+			prepareDocker(&sys, wd)
+			if opts.query == "" {
+				opts.query = "Write a Dockerfile for this project."
+			}
+		} else if opts.context {
+			project := llm.DetectProject(wd)
+			sys.Project = &project
+		}
+		if opts.listDir {
+			if sys.Listing, err = llm.ListDir(wd, maxListing); err != nil {
+				fatal(err, opts.jsonErrors)
+			}
+		}
+	}
+	if input, err := readStdin(); err != nil {
+		fatal(err, opts.jsonErrors)
+	} else if opts.pipe {
+		if err := preparePipe(cfg, opts, &sys, input); err != nil {
+			fatal(err, opts.jsonErrors)
+		}
+	} else if input != "" {
+		sys.Attachments = append(sys.Attachments, llm.Attachment{Content: input})
+	}
+	for _, path := range opts.files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fatal(fmt.Errorf("failed to read attachment: %v", err), opts.jsonErrors)
+		}
+		sys.Attachments = append(sys.Attachments, llm.Attachment{Name: path, Content: string(data)})
+	}
+	if opts.recent > 0 {
+		att, err := recentCommands(sys.Shell, opts.recent)
+		if err != nil {
+			fatal(err, opts.jsonErrors)
+		}
+		sys.Attachments = append(sys.Attachments, att)
+	}
+	if opts.repo {
+		atts, err := loadRepoContext(opts.query, cmp.Or(cfg.Int("repo_tokens"), defaultRepoTokens))
+		if err != nil {
+			fatal(err, opts.jsonErrors)
+		}
+		sys.Attachments = append(sys.Attachments, atts...)
+	}
+	if err := attachImages(&sys, client, opts); err != nil {
+		fatal(err, opts.jsonErrors)
+	}
+
+	var sample string
+	switch opts.mode {
+	case llm.CommandMode:
+		if opts.man {
+			addManPages(&sys, opts.query)
+		}
+	case llm.JQMode:
+		if sample, err = sampleJQInput(&sys); err != nil {
+			fatal(err, opts.jsonErrors)
+		}
+	case llm.SedMode, llm.AwkMode:
+		sample = sampleOneLinerInput(&sys)
+	case llm.TFMode:
+		if err := prepareTF(&sys); err != nil {
+			fatal(err, opts.jsonErrors)
+		}
+	case llm.TranslateMode, llm.ProofreadMode:
+		if err := prepareText(&sys, opts); err != nil {
+			fatal(err, opts.jsonErrors)
+		}
+	case llm.PortMode:
+		if err := preparePort(&sys, opts); err != nil {
+			fatal(err, opts.jsonErrors)
+		}
+	case llm.PatchMode:
+		if len(opts.files) == 0 {
+			fatal(usageError("attach the files to change with -f"), opts.jsonErrors)
+		}
+	}
+
+	ctx := context.Background()
+	var changes []patchedFile
+	var files []llm.File
+	start := time.Now()
+	response, entry, err := askUnsaved(ctx, cfg, client, opts, sys)
+	notifyAfter := defaultNotifyAfter
+	if cfg.Has("notify_after") {
+		notifyAfter = time.Duration(cfg.Int("notify_after")) * time.Second
+	}
+	notifyIfSlow(opts, notifyAfter, time.Since(start), err != nil)
+	if err == nil {
+		switch opts.mode {
+		case llm.CommandMode:
+			if response, err = clarify(ctx, cfg, client, opts, &sys, response); err == nil {
+				if response, err = verifySyntax(ctx, cfg, client, opts, sys, response); err == nil {
+					response, err = checkPrograms(ctx, cfg, client, opts, sys, response)
+				}
+			}
+		case llm.K8sMode, llm.AWSMode, llm.GCloudMode, llm.AzureMode:
+			response, err = verifySyntax(ctx, cfg, client, opts, sys, response)
+		case llm.JQMode:
+			response = cleanFilter(response)
+			if opts.verify {
+				response, err = verifyJQ(ctx, cfg, client, opts, sys, sample, response)
+			}
+		case llm.CronMode:
+			var runs string
+			if response, runs, err = checkCron(response, time.Now()); err == nil {
+				fmt.Fprintln(notices, runs)
+			}
+		case llm.CodeMode:
+			response = cleanCode(response)
+			if files = llm.ParseFiles(response); files != nil && opts.verify {
+				slog.Warn("--verify only checks answers of a single file")
+			} else if opts.verify {
+				response, err = verifyCode(ctx, cfg, client, opts, sys, response)
+			}
+		case llm.DockerMode, llm.PortMode:
+			response = stripFence(response)
+		case llm.SystemdMode:
+			response, files, err = verifyUnits(ctx, cfg, client, opts, sys, response)
+		case llm.PatchMode:
+			response = stripFence(response)
+			if changes, err = checkPatch(response, opts.files); err != nil {
+				printResponse(opts, response)
+				err = fmt.Errorf("the patch doesn't apply: %v", err)
+			}
+		case llm.SedMode, llm.AwkMode:
+			response = cleanOneLiner(response)
+			if sample != "" {
+				response, err = verifyOneLiner(ctx, cfg, client, opts, sys, sample, response)
+			}
+		}
+		// One entry for the query, with the answer that was checked rather
+		// than any asked for on the way
+		if err == nil {
+			entry.Response = response
+		}
+		saveExchange(cfg, entry)
+	}
+	if err != nil {
+		fatal(err, opts.jsonErrors)
+	}
+
+	switch opts.mode {
+	case llm.CommandMode, llm.K8sMode, llm.AWSMode, llm.GCloudMode, llm.AzureMode:
+		warnDangers(response)
+	}
+	suggestions := []llm.Suggestion{{Command: response, Preferred: true}}
+	if opts.mode == llm.CommandMode {
+		if suggestions = llm.ParseSuggestions(response); len(suggestions) > 0 {
+			response = suggestions[0].Command
+		}
+	}
+	switch {
+	case len(suggestions) > 1 && isTerminal(os.Stdout):
+		printSuggestions(os.Stdout, suggestions)
+	case files != nil && isTerminal(os.Stdout):
+		printFiles(os.Stdout, files)
+	default:
+		// Only the preferred command, so that it can be piped to a shell
+		printResponse(opts, response)
+	}
+	printStats(client)
+	if opts.mode == llm.PatchMode {
+		if !opts.apply {
+			fmt.Fprintln(notices, "The patch applies cleanly")
+		} else if err := applyPatch(changes, opts.yes); err != nil {
+			fatal(err, opts.jsonErrors)
+		}
+	}
+	if opts.mode == llm.SystemdMode {
+		if err := installUnits(files, opts.apply, opts.yes, opts.force); err != nil {
+			fatal(err, opts.jsonErrors)
+		}
+	}
+	if opts.output != "" {
+		if err := writeAnswer(opts.output, response, opts.yes, opts.force); err != nil {
+			fatal(err, opts.jsonErrors)
+		}
+	}
+	if opts.dir != "" {
+		if files == nil {
+			slog.Warn("The answer is a single file, so --dir wrote nothing; use -o to save it")
+		} else if err := writeFiles(opts.dir, files, opts.yes, opts.force); err != nil {
+			fatal(err, opts.jsonErrors)
+		}
+	}
+	if opts.exec && len(suggestions) > 0 {
+		code, err := execSuggestion(suggestions, sys.Shell, opts.yes)
+		if err != nil {
+			fatal(err, opts.jsonErrors)
+		}
+		exit(code)
+	}
+}
+
+// warnDangers warns on stderr about suggested commands that look
+// destructive
+func warnDangers(commands string) {
+	for _, line := range strings.Split(commands, "\n") {
+		for _, reason := range llm.Dangers(line) {
+			fmt.Fprintf(notices, "Warning: `%s` %s\n", strings.TrimSpace(line), reason)
+		}
+	}
+}
+
+// explainingModes answer in prose, so can answer in the user's language.
+// Other modes answer with commands, code or text in a language of its own.
+var explainingModes = []llm.Mode{llm.ExplainMode, llm.TLDRMode, llm.RecallMode, llm.RepoMode}
+
+// addOutputLanguage asks for explanations in --output-lang or the user's
+// locale's language, when that isn't English
+func addOutputLanguage(sys *llm.System, opts *options) {
+	if !slices.Contains(explainingModes, opts.mode) {
+		return
+	}
+	if lang := llm.OutputLanguage(opts.outputLang, os.Getenv); lang != "" {
+		sys.Notes = append(sys.Notes, "Answer in: "+lang)
+	}
+}
+
+// printResponse writes the answer to stdout, rendering markdown and paging
+// it as appropriate. Piped answers are written as the model gave them, so
+// that $(llm ...) captures the command itself.
+func printResponse(opts *options, response string) {
+	output := response
+	switch {
+	case !isTerminal(os.Stdout):
+	case opts.mode.Markdown():
+		output = render.Markdown(response)
+	case render.IsDiff(response):
+		// --patch answers, and code answers that are diffs
+		output = render.Diff(response)
+	case opts.mode == llm.CodeMode && opts.codeLang != "":
+		output = render.Code(response, llm.CodeLanguage(opts.codeLang).ID)
+	}
+	printOutput(output, opts.noPager)
+}
+
+// printOutput writes output to stdout, through a pager if it's taller than
+// the terminal
+func printOutput(output string, noPager bool) {
+	if !noPager && shouldPage(output) {
+		if err := runPager(output); err == nil {
+			return
+		}
+	}
+	fmt.Println(output)
+}
+
+// contextWindow returns the size of the model's context window in tokens,
+// unless context_window overrides it
+func contextWindow(cfg *config.Config, client *llm.Client) int {
+	if cfg.Has("context_window") {
+		return cfg.Int("context_window")
+	}
+	return llm.ContextWindow(client.ModelName())
+}
+
+// ask sends query in opts.mode to client along with the context in sys. The
+// context is trimmed to fit the model, confirmed with the user if it's large
+// or sensitive, and redacted first. The exchange is saved to the history.
+func ask(ctx context.Context, cfg *config.Config, client *llm.Client, opts *options, sys llm.System) (string, error) {
+	response, entry, err := askUnsaved(ctx, cfg, client, opts, sys)
+	if err != nil {
+		return "", err
+	}
+	saveExchange(cfg, entry)
+	return response, nil
+}
+
+// askUnsaved is ask without saving the exchange, which it returns for the
+// caller to save once it has the final answer
+func askUnsaved(ctx context.Context, cfg *config.Config, client *llm.Client, opts *options, sys llm.System) (string, history.Entry, error) {
+	useTools := opts.tools
+	if useTools && len(sys.Images) > 0 {
+		slog.Warn("Tools aren't used with images; answering without them")
+		useTools = false
+	}
+	if useTools && !client.SupportsTools() {
+		slog.Warn("Tools aren't supported by the provider; answering without them", "provider", client.Provider)
+		useTools = false
+	}
+	if useTools {
+		sys.Notes = append(sys.Notes, toolsNote)
+	}
+
+	// Leave room for the answer in the model's context window
+	window := contextWindow(cfg, client)
+	limit := window - llm.MaxOutputTokens
+	if llm.FitPrompt(opts.mode, &sys, opts.query, limit) {
+		slog.Warn("Attachments don't fit in the model's context window; sending the start and end of each",
+			"model", client.ModelName(), "window", window)
+	}
+
+	if !opts.yes {
+		if err := confirmAttachments(sys.Attachments, cfg, client.Provider); err != nil {
+			return "", history.Entry{}, err
+		}
+	}
+
+	prompt := llm.BuildPrompt(opts.mode, sys, opts.query)
+	if tokens := llm.EstimateTokens(prompt); tokens > limit {
+		slog.Warn("The prompt may not fit in the model's context window",
+			"tokens", tokens, "model", client.ModelName(), "window", window)
+	}
+	if !opts.noRedact {
+		var n int
+		if prompt, n = llm.Redact(prompt); n > 0 {
+			slog.Info("Redacted possible secrets from the prompt; use --no-redact to send them", "count", n)
+		}
+	}
+	if err := moderate(ctx, cfg, prompt); err != nil {
+		return "", history.Entry{}, err
+	}
+
+	slog.Debug("querying provider", "provider", client.Provider, "model", client.ModelName(),
+		"mode", opts.mode)
+	start := time.Now()
+
+	ctx, stop := interruptible(ctx)
+	defer stop()
+	var response string
+	var err error
+	switch {
+	case len(sys.Images) > 0:
+		if opts.bestOf > 1 || opts.n > 1 {
+			slog.Warn("--best-of and -n aren't used with images")
+		}
+		response, err = client.QueryImages(ctx, prompt, sys.Images)
+	case useTools:
+		if opts.bestOf > 1 || opts.n > 1 {
+			slog.Warn("--best-of and -n aren't used with --tools")
+		}
+		response, err = client.QueryWithTools(ctx, prompt, toolbox(cfg, opts), maxToolRounds)
+	case opts.bestOf > 1:
+		if opts.n > 1 {
+			slog.Warn("-n isn't used with --best-of")
+		}
+		response, err = bestOf(ctx, cfg, client, prompt, opts.bestOf)
+	case opts.n > 1:
+		response, err = askCandidates(ctx, client, prompt, opts.n)
+	default:
+		response, err = client.Query(ctx, prompt)
+	}
+
+	slog.Debug("query finished", "elapsed", time.Since(start), "error", err)
+
+	if err != nil {
+		return "", history.Entry{}, interrupted(ctx, err)
+	}
+
+	query := opts.query
+	if !opts.noRedact {
+		query, _ = llm.Redact(query)
+	}
+	return response, history.Entry{
+		Time:     start,
+		Mode:     opts.mode.String(),
+		Provider: client.Provider.String(),
+		Model:    client.ModelName(),
+		Query:    query,
+		Response: response,
+		Session:  opts.session,
+	}, nil
+}
+
+// saveExchange saves entry to the history, warning if it can't
+func saveExchange(cfg *config.Config, entry history.Entry) {
+	if err := saveHistory(cfg, entry); err != nil {
+		slog.Warn("Failed to save history", "error", err)
+	}
+}
+
+// requery asks again, returning the answer and roughly how many tokens were
+// sent and received. Unlike ask, it doesn't confirm the attachments, which
+// the user has already agreed to send or which are the output of commands
+// they agreed to run, asks for a single answer, and doesn't save it to the
+// history.
+func requery(ctx context.Context, cfg *config.Config, client *llm.Client, opts *options, sys llm.System) (string, int, error) {
+	llm.FitPrompt(opts.mode, &sys, opts.query, contextWindow(cfg, client)-llm.MaxOutputTokens)
+	prompt := llm.BuildPrompt(opts.mode, sys, opts.query)
+	if !opts.noRedact {
+		prompt, _ = llm.Redact(prompt)
+	}
+	if err := moderate(ctx, cfg, prompt); err != nil {
+		return "", 0, err
+	}
+
+	slog.Debug("querying provider", "provider", client.Provider, "model", client.ModelName(), "mode", opts.mode)
+	start := time.Now()
+	ctx, stop := interruptible(ctx)
+	defer stop()
+	response, err := client.Query(ctx, prompt)
+	slog.Debug("query finished", "elapsed", time.Since(start), "error", err)
+	return response, llm.EstimateTokens(prompt) + llm.EstimateTokens(response), interrupted(ctx, err)
+}
+
+// printUsage writes the help text to w, which is stderr unless the help
+// was asked for, so that $(llm ...) never captures it
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, usageText())
+}
+
+// usageText is the help text, which llm man also turns into the man page
+func usageText() string {
+	return fmt.Sprintf(`llm - Multi-API Command Suggester v%s
+
+USAGE:
+    llm [options] <description of what you want to do>
+    <command> | llm [options] <question about its output>
+    llm commit [-e] [-y] [hint]   Write a message for the staged changes
+                                  and commit them (-e to edit it first)
+    llm pr [--base <branch>] [--create]
+                                  Describe the current branch's changes as
+                                  a pull request (and open it with gh)
+    llm changelog [--since REF] [--version NAME] [--write [-y]]
+                                  Write a Keep a Changelog section for the
+                                  commits since a tag (the latest by
+                                  default), and add it to CHANGELOG.md
+    llm review [--json] [git diff arguments]
+                                  Review uncommitted changes or a piped diff
+    llm lint-commit [--json] [--no-rewrite] ["<message>" | -f FILE]
+                                  Check a commit message (by default
+                                  .git/COMMIT_EDITMSG) against Conventional
+                                  Commits, suggesting a rewrite if it fails
+    llm install-hook [--force] [--uninstall] <commit-msg|pre-push>
+                                  Install a git hook that writes empty
+                                  commit messages, or reviews commits
+                                  before they're pushed
+    llm edit [-y] [--no-backup] <file> "<change>"
+                                  Change a file in place, after showing the
+                                  change as a diff (the original is kept as
+                                  FILE.bak)
+    llm explain-cmd '<command>'   Explain a command flag by flag
+    llm why, llm explain-last     Explain the last suggested command
+    <command> 2>&1 | llm fix      Explain a failure and suggest a fixed command
+    <command> 2>&1 | llm bugreport [--cmd COMMAND] [what went wrong]
+                                  Draft a bug report about a failure, with
+                                  the OS, shell and tool versions
+    llm tmux-capture [--pane TARGET] [--lines N] [question]
+                                  Ask about the tmux pane's scrollback,
+                                  "what went wrong above?" by default
+    llm watch -f FILE [--mode fix|explain] [--trigger REGEXP]
+                                  Follow a log and diagnose each new block
+                                  of errors in it, among its lines
+    llm agent [--max-steps N] [--max-tokens N] "<goal>"
+                                  Work towards a goal one confirmed command
+                                  at a time, showing the model each result
+    llm shell-init <bash|zsh|fish>
+    llm suggest-aliases [--apply] [-y] [--min N] [hint]
+                                  Suggest aliases for the long commands in
+                                  your shell history that you type most,
+                                  and with --apply add them to your rc file
+    llm batch [--input FILE] [--mode MODE] [--concurrency N] [--out FILE]
+                                  Answer each line of a file as a query, and
+                                  write the answers as JSON lines
+    llm compare -m MODEL -m MODEL "<query>"
+                                  Ask several models at once and show their
+                                  answers side by side
+    llm bench [-m MODEL ...] [--runs N]
+                                  Time a fixed set of queries against each
+                                  model and report latency and failures
+    llm index <dir> ...           Embed the notes (markdown and text files)
+                                  under each directory for llm recall
+    llm recall [--top N] "<question>"
+                                  Answer a question from the closest
+                                  passages of your indexed notes
+    llm serve [--listen ADDR]     Serve an OpenAI-compatible API backed by the
+                                  configured provider
+    llm daemon [status|stop]      Keep provider connections and the config
+                                  loaded so answers start sooner
+    llm keys <set|remove> <anthropic|openai>
+    llm keys list
+    llm history [list] [--unique] List past queries (--unique: each once,
+                                  with how often it was asked)
+    llm fav add [--var NAME=VALUE] <name> [options] "<prompt>"
+                                  Save a command line to run again, with
+                                  {{.NAME}} variables and {{sh "command"}}
+                                  output in it
+    llm fav run <name> [NAME=VALUE ...]
+                                  Run a saved command line
+    llm fav list, llm fav remove <name>
+    llm templates install <git URL>
+                                  Install or update a pack of -t templates,
+                                  e.g. github.com/user/llm-prompts
+    llm templates list            List installed templates
+    llm good, llm bad [reason]    Mark the last answer as good or bad
+    llm usage                     Show how often each model's answers were
+                                  marked good or bad
+    llm history purge [--older-than <age, e.g. 30d>]
+    llm man                       Print this help as a man page, e.g.
+                                  llm man > llm.1
+
+    Use "llm -- <query>" for a query that starts with a subcommand name.
+
+EXAMPLES:
+    llm search for foo in directory
+    llm list files by size
+    llm find files modified today
+    llm compress this directory
+    llm show disk usage
+	llm --code write a python function to diff a file
+	llm --explain explain the cp command
+
+SETUP:
+    Set one of the following environment variables:
+    export ANTHROPIC_API_KEY=your_claude_api_key
+    export OPENAI_API_KEY=your_openai_api_key
+    export OLLAMA_MODEL=your_ollama_model_name
+
+    The script will automatically detect which API key or Ollama model is available and use the corresponding service.
+    Priority order: Claude > OpenAI > Ollama
+
+    Or keep the key in the OS keychain instead of your shell's rc file:
+    llm keys set anthropic
+    Environment variables take precedence over stored keys.
+
+    Or have a password manager supply it by setting anthropic_key_cmd or
+    openai_key_cmd in the config file, e.g.
+    anthropic_key_cmd = "pass show anthropic/api"
+
+OPTIONS:
+    -h, --help     Show this help message
+    -v, --version  Show version information
+    -c, --code     Code generation mode
+    --lang LANG    Write code in LANG, e.g. python, go or rust, and highlight
+                   it; implies --code. -o adds LANG's extension to a file
+                   name without one
+    -x, --explain  Explanation mode
+    --regex        Write a regular expression, with an explanation and
+                   examples that match and don't
+    --cron         Write a cron schedule, and list when it will next run
+    --k8s          Write kubectl or helm commands for the current kubectl
+                   context and namespace
+    --api-resources
+                   With --k8s, also send the cluster's resource types
+                   (kubectl api-resources). Set k8s_api_resources = true
+                   in the config file to always do this
+    --aws, --gcloud, --az
+                   Write aws, gcloud or az commands, warning about those
+                   that delete resources
+    --account      With --aws, --gcloud or --az, also send the profile,
+                   project or subscription and the region the CLI is set
+                   up to use, from its config files. Set cloud_account =
+                   true in the config file to always do this
+    --tf           Answer questions about Terraform plans piped in, such
+                   as why a resource is being replaced, or write HCL to
+                   fit the .tf files attached with -f. Saved plans
+                   attached with -f are read with terraform show
+    --systemd      Write systemd unit files, checked with systemd-analyze
+                   verify if it's installed; add --apply to install them
+                   in ~/.config/systemd/user
+    --sed, --awk   Write a sed or awk one-liner. Pipe in sample input and
+                   the command is run on its first 20 lines, in an empty
+                   temporary directory, and the output shown; if it fails
+                   the model is asked for one correction
+    --translate LANG
+                   Translate the text given as the query, or piped in,
+                   into LANG
+    --output-lang LANG
+                   Explain in LANG, a name like German or a code like de,
+                   with -x, --repo, --tldr and llm recall. By default this
+                   follows your locale (LC_ALL, LC_MESSAGES or LANG); set
+                   output_lang in the config file to change it
+    --proofread    Correct grammar, spelling and phrasing in the text given
+                   as the query, or piped in, e.g.
+                   git log -1 --format=%%B | llm --proofread
+    --tldr CMD     Show a short, examples-first cheat sheet for CMD in the
+                   tldr-pages layout, e.g. llm --tldr tar
+    --port-to SHELL
+                   Translate a command, or a script piped in, from your
+                   shell to SHELL (bash, zsh, fish, sh or powershell)
+    --from SHELL   With --port-to, translate from SHELL instead of yours
+    --patch        Answer with a unified diff against the files attached with
+                   -f, checked to apply cleanly
+    --apply        With --patch, apply the diff after asking; with
+                   --systemd, install the units after asking
+    --docker       Write a Dockerfile, or a compose.yaml if asked for one,
+                   from the project's manifests, entrypoints and ports
+    -o, --output FILE
+                   Also write the raw answer to FILE, after showing it and
+                   asking (-y writes without asking). Missing directories
+                   are created
+    --dir DIR      When the code is several files, such as a scaffolded
+                   project, write them under DIR after listing them and
+                   asking (-y writes without asking); implies --code
+    --force        Let -o or --dir overwrite existing files
+    --sql          Write a SQL query
+    --schema FILE  Include the table definitions in FILE with --sql
+    --dsn DSN      Read the table definitions from a live database with
+                   --sql (postgres://..., mysql://..., sqlite:file.db),
+                   using pg_dump, mysqldump or sqlite3
+    --dialect      Regex dialect for --regex: pcre (default), re2/go,
+                   ere/grep or bre/sed, or SQL dialect for --sql: postgres
+                   (default), mysql or sqlite. Set regex_dialect or
+                   sql_dialect in the config file to change the defaults
+    --jq           Write a jq filter for the JSON piped in. Only a sample
+                   of the JSON is sent
+    --verify       With --jq, run the filter on the sample and ask for one
+                   correction if jq rejects it. With -c, check the code with
+                   its compiler or linter (go vet, python3 -m py_compile,
+                   node --check, rustc, cc, ...) and ask for one correction
+                   if it fails; needs --lang or an -o file extension
+    --no-pager     Don't page output that is taller than the terminal
+    --json         Report errors on stderr as a line of JSON, e.g.
+                   {"type":"rate_limit","provider":"claude","status":429,
+                   "retryable":true,"message":"..."}, for scripts to act on.
+                   llm review --json does the same
+    --log-level LEVEL
+                   Log messages at LEVEL and above: debug, info (the
+                   default), warn or error. Warnings and notes such as
+                   rate-limit waits go through the log, so --log-level
+                   error hides them
+    --log-file FILE
+                   Write log messages to FILE, with timestamps, instead of
+                   stderr ($LLM_LOG_FILE by default)
+    --debug        Same as --log-level debug: log requests, responses and
+                   timings
+    -q, --quiet    Write nothing but the answer on stdout and errors on
+                   stderr: no warnings, progress or --stats, for use in
+                   $(llm ...)
+    --context      Tell the model the current directory name, git branch and
+                   status, and project type (go.mod, package.json, ...).
+                   Set "context = true" in the config file to always do this,
+                   and --context=false to skip it once.
+    --tools        Let the model read files, list directories and run
+                   read-only commands (df, du, ps, git status, ...) before
+                   answering, e.g. to check disk usage before suggesting
+                   what to delete. Each is shown and confirmed first (-y
+                   allows them all), and there are at most 5 rounds.
+                   Set "tools = true" in the config file to always do this
+    --man          In command mode, send excerpts from the local man pages
+                   of programs the query names, so suggested flags match
+                   the installed versions. On by default; set
+                   "man_pages = false" in the config file to turn it off,
+                   and --man=false to skip it once
+    -m, --model MODEL
+                   Ask MODEL instead of the usual one, e.g. gpt-4o,
+                   claude-sonnet or llama3
+    --retry        Ask the previous query again, with -m to another model,
+                   and show the old and new answers
+    --best-of N    Sample N answers and have the model check them and pick
+                   or merge the best one. Set best_of_models to sample
+                   several models in turn, and best_of_judge to pick with
+                   another model
+    --stop STRING  End the answer where the model writes STRING (repeatable)
+    --seed N       Sample with seed N so that the same query gets the same
+                   answer, where the provider supports it (OpenAI, Ollama)
+    --ctx KEY=VALUE
+                   Tell the model KEY is VALUE, e.g. --ctx cluster=staging;
+                   {{.KEY}} in the query stands for VALUE (repeatable)
+    -t, --template FILE
+                   Ask the prompt in FILE, or in templates/FILE.tmpl in the
+                   config directory, which may set mode, model and lang
+    --arg NAME=VALUE
+                   Fill in {{.NAME}} in the -t template (repeatable)
+    --exec         Run the suggested command, asking first which to run when
+                   there are alternatives (Enter runs the preferred one);
+                   -y runs the preferred one without asking. llm exits with
+                   the command's status. Command mode and --k8s only
+    --pipe         Build on the answer piped in from another llm, or on the
+                   last answer if nothing is piped in, e.g.
+                   llm list big dirs | llm --pipe make it a cleanup script
+                   The steps are saved to the history as one session
+    -n N           Ask for N candidate answers and pick one on the terminal
+    -f, --file     Attach a file to the prompt (repeatable)
+    --image PATH   Attach a PNG, JPEG, GIF or WebP image, such as a
+                   screenshot of an error (repeatable; needs a vision model)
+    --screenshot   Select part of the screen with the system's screenshot
+                   tool and attach it as an image
+    -y, --yes      Don't ask before sending more than confirm_bytes (32KB) of
+                   attachments or files that may hold secrets (~/.ssh, .env)
+    --no-redact    Don't replace things that look like API keys, private
+                   keys and passwords with placeholders before sending
+    --offline      Fail rather than send anything off this machine, except
+                   to hosts listed in offline_hosts (for a local model)
+    --notify       When an answer took over notify_after seconds (10), say
+                   it's arrived with a desktop notification (notify-send or
+                   osascript), or else the terminal bell
+    --stats        Show the model, how long it took and the tokens used on
+                   stderr after the answer (or set stats = true)
+    --repo         Answer a question about the current git repository,
+                   sending its file list, README and manifests, and the
+                   excerpts that best match the question
+    --ls           Include a listing of the current directory (names, sizes
+                   and types) so commands can use the actual file names
+    --recent N     Include your last N shell commands, with their
+                   directories and exit statuses if Atuin or zsh-histdb
+                   recorded them, for questions like "what was I doing?"
+
+SHELL INTEGRATION:
+    Add eval "$(llm shell-init bash)" (or zsh) to your shell's rc file, or
+    "llm shell-init fish | source" to config.fish, and llm will see the
+    previous command and its exit status, e.g. "llm why did that fail".
+    Set LLM_CAPTURE_STDERR=1 beforehand to include its error output too.
+
+CONFIG:
+    Settings are read from $XDG_CONFIG_HOME/llm/config.toml (by default
+    ~/.config/llm/config.toml; %%APPDATA%%\llm\config.toml on Windows).
+
+    Queries and responses are saved to $XDG_DATA_HOME/llm/history.jsonl
+    (by default ~/.local/share/llm/history.jsonl). Set
+    "history = false" to stop saving them, or "encrypt_history = true" to
+    encrypt them with a key kept in the OS keychain.
+
+    Set moderation to "block" to check every prompt before it's sent and
+    refuse flagged ones, or to "warn" to only warn. The check is OpenAI's
+    moderation API, or the local classifier that moderation_cmd names.
+
+    To use a gateway instead of OpenAI's API, set openai_endpoint to its
+    URL. Headers it needs, such as X-Org-Id, go in an [openai_headers]
+    section. If it requires mutual TLS, set openai_client_cert and
+    openai_client_key to PEM files, and openai_ca_cert if its certificate
+    isn't signed by a public CA. Set openai_idempotency_keys to true if it
+    deduplicates requests by their Idempotency-Key header. Use the
+    anthropic_ or ollama_ prefix for those providers.
+
+    Admins can put settings in /etc/llm/policy.toml
+    (%%ProgramData%%\llm\policy.toml on Windows), where they override the
+    user's config: allowed_providers, allowed_endpoints, force_redaction,
+    max_tokens and history, for example.
+
+EXIT STATUS:
+    0    Success
+    1    Any other error
+    2    Bad flags or arguments
+    3    No API key, or the provider rejected it
+    4    Rate limited by the provider
+    5    The provider couldn't be reached
+    6    The provider returned another error
+    130  Interrupted with Ctrl-C
+`, version)
+}
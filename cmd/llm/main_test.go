@@ -0,0 +1,152 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		mode  llm.Mode
+		query string
+	}{
+		{"command", []string{"list", "files"}, llm.CommandMode, "list files"},
+		{"code short", []string{"-c", "python", "hello"}, llm.CodeMode, "python hello"},
+		{"code long", []string{"--code", "x"}, llm.CodeMode, "x"},
+		{"explain short", []string{"-x", "grep"}, llm.ExplainMode, "grep"},
+		{"explain long", []string{"--explain", "grep", "-r"}, llm.ExplainMode, "grep -r"},
+		{"code wins", []string{"-c", "-x", "q"}, llm.CodeMode, "q"},
+		{"regex", []string{"--regex", "--dialect", "go", "iso", "dates"}, llm.RegexMode, "iso dates"},
+		{"cron", []string{"--cron", "every", "weekday"}, llm.CronMode, "every weekday"},
+		{"tldr", []string{"--tldr", "tar"}, llm.TLDRMode, "tar"},
+		{"tldr subcommand", []string{"--tldr", "git", "rebase"}, llm.TLDRMode, "git rebase"},
+		{"translate", []string{"--translate", "de", "good", "morning"}, llm.TranslateMode, "good morning"},
+		{"repo", []string{"--repo", "where", "are", "flags", "parsed"}, llm.RepoMode, "where are flags parsed"},
+		{"lang implies code", []string{"--lang", "go", "fizzbuzz"}, llm.CodeMode, "fizzbuzz"},
+		{"flags stop at query", []string{"find", "-c"}, llm.CommandMode, "find -c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseArgs(tt.args, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if opts.mode != tt.mode {
+				t.Errorf("mode = %v, want %v", opts.mode, tt.mode)
+			}
+			if opts.query != tt.query {
+				t.Errorf("query = %q, want %q", opts.query, tt.query)
+			}
+		})
+	}
+}
+
+func TestParseArgsFlags(t *testing.T) {
+	opts, err := parseArgs([]string{"--no-pager", "--debug", "--retry", "-m", "llama3", "q"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.noPager || !opts.log.debug || !opts.retry || opts.model != "llama3" {
+		t.Errorf("got %+v", opts)
+	}
+}
+
+func TestParseArgsOutputExtension(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--lang", "python", "-o", "fetch", "q"}, "fetch.py"},
+		{[]string{"--lang", "rust", "-o", "src/main.txt", "q"}, "src/main.txt"},
+		{[]string{"--lang", "haskell", "-o", "fetch", "q"}, "fetch"},
+		{[]string{"-o", "fetch", "q"}, "fetch"},
+	}
+
+	for _, tt := range tests {
+		opts, err := parseArgs(tt.args, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opts.output != tt.want {
+			t.Errorf("parseArgs(%q): output = %q, want %q", tt.args, opts.output, tt.want)
+		}
+	}
+}
+
+func TestParseArgsConfigDefaults(t *testing.T) {
+	cfg, err := config.Parse("context = true")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts, err := parseArgs([]string{"q"}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.context {
+		t.Error("config should enable context")
+	}
+
+	opts, err = parseArgs([]string{"--context=false", "q"}, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.context {
+		t.Error("flag should override config")
+	}
+}
+
+func TestAddOutputLanguage(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "de_DE.UTF-8")
+	tests := []struct {
+		opts options
+		want []string
+	}{
+		{options{mode: llm.ExplainMode}, []string{"Answer in: German"}},
+		{options{mode: llm.ExplainMode, outputLang: "fr"}, []string{"Answer in: French"}},
+		{options{mode: llm.RepoMode, outputLang: "Esperanto"}, []string{"Answer in: Esperanto"}},
+		{options{mode: llm.CommandMode}, nil},
+		{options{mode: llm.CodeMode, outputLang: "fr"}, nil},
+	}
+	for _, tt := range tests {
+		var sys llm.System
+		addOutputLanguage(&sys, &tt.opts)
+		if !slices.Equal(sys.Notes, tt.want) {
+			t.Errorf("%v with %q: notes = %q, want %q", tt.opts.mode, tt.opts.outputLang, sys.Notes, tt.want)
+		}
+	}
+}
+
+func TestParseArgsUnknownFlag(t *testing.T) {
+	if _, err := parseArgs([]string{"--bogus", "q"}, nil); err == nil {
+		t.Error("expected an error for an unknown flag")
+	}
+}
+
+func TestNewClientReplayWithoutKeys(t *testing.T) {
+	for _, name := range []string{"ANTHROPIC_API_KEY", "OPENAI_API_KEY", "OLLAMA_MODEL"} {
+		t.Setenv(name, "")
+	}
+
+	t.Setenv("LLM_REPLAY_DIR", "")
+	if _, err := newClient(nil); err == nil {
+		t.Error("expected an error with nothing configured")
+	}
+
+	t.Setenv("LLM_REPLAY_DIR", t.TempDir())
+	client, err := newClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Provider != llm.Claude {
+		t.Errorf("provider = %v", client.Provider)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// Limits on man pages sent in command mode
+const (
+	maxManPages   = 2
+	maxManChars   = 4000
+	manTimeout    = 3 * time.Second
+	manPageWidth  = "80"
+	minManPageLen = 100
+)
+
+// commandWordRe matches words in a query that could name a program
+var commandWordRe = regexp.MustCompile(`^[a-z0-9][a-z0-9._+-]*$`)
+
+// commonWords are ordinary words in queries that happen to name programs
+// too, e.g. "file" and "time", so they aren't taken to mean them
+var commonWords = []string{
+	"a", "as", "at", "in", "do", "is", "it", "of", "on", "or", "to", "up", "w",
+	"all", "and", "for", "the", "last", "file", "files", "time", "test",
+	"which", "write", "yes", "true", "false", "link", "users", "who", "what",
+	"more", "less", "look", "join", "size", "wait",
+}
+
+// addManPages attaches excerpts from the local man pages of programs the
+// query names, so that suggested flags match the installed versions
+func addManPages(sys *llm.System, query string) {
+	if _, err := exec.LookPath("man"); err != nil {
+		return
+	}
+	for _, command := range mentionedCommands(query, maxManPages) {
+		page := manPage(command)
+		if len(page) < minManPageLen {
+			continue
+		}
+		sys.Attachments = append(sys.Attachments, llm.Attachment{
+			Title:   "Excerpt from the local man page for " + command,
+			Content: llm.ManExcerpt(page, command, query, maxManChars),
+		})
+	}
+}
+
+// mentionedCommands returns up to max words in query that are programs on
+// the PATH
+func mentionedCommands(query string, max int) []string {
+	var commands []string
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		word = strings.Trim(word, `"'`+"`,.:;?!()")
+		if !commandWordRe.MatchString(word) || slices.Contains(commonWords, word) || slices.Contains(commands, word) {
+			continue
+		}
+		if _, err := exec.LookPath(word); err != nil {
+			continue
+		}
+		commands = append(commands, word)
+		if len(commands) == max {
+			break
+		}
+	}
+	return commands
+}
+
+// manPage returns the formatted man page for command, or "" if there isn't
+// one. Formatting is removed by ManExcerpt, as col -b would.
+func manPage(command string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), manTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "man", command)
+	cmd.Env = append(os.Environ(), "MANPAGER=cat", "PAGER=cat", "MANWIDTH="+manPageWidth)
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// fakeMan puts man and a few other programs on PATH. man prints a short
+// page for tar and fails for anything else.
+func fakeMan(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell script")
+	}
+	dir := t.TempDir()
+	script := `#!/bin/sh
+[ "$1" = tar ] || exit 16
+printf 'NAME\n       tar - an archiving utility\n\nSYNOPSIS\n       tar [OPTION...] [FILE]...\n\nOPTIONS\n       -z, --gzip\n              Filter the archive through gzip.\n\n       -j, --bzip2\n              Filter the archive through bzip2.\n'
+`
+	os.WriteFile(filepath.Join(dir, "man"), []byte(script), 0755)
+	for _, name := range []string{"tar", "grep", "file", "time"} {
+		os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestMentionedCommands(t *testing.T) {
+	fakeMan(t)
+	got := mentionedCommands("use tar, then grep the file list; time it with tar", maxManPages)
+	if want := []string{"tar", "grep"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("mentionedCommands() = %q, want %q", got, want)
+	}
+}
+
+func TestAddManPages(t *testing.T) {
+	fakeMan(t)
+	var sys llm.System
+	addManPages(&sys, "compress a folder with tar and gzip")
+
+	if len(sys.Attachments) != 1 {
+		t.Fatalf("attachments = %+v, want one for tar", sys.Attachments)
+	}
+	a := sys.Attachments[0]
+	if a.Title != "Excerpt from the local man page for tar" || !strings.Contains(a.Content, "--gzip") || strings.Contains(a.Content, "--bzip2") {
+		t.Errorf("attachment = %+v", a)
+	}
+}
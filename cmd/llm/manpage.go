@@ -0,0 +1,297 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envVars are the environment variables llm reads, for the man page
+var envVars = []struct{ name, desc string }{
+	{"ANTHROPIC_API_KEY", "API key for Claude, which is used if it's set"},
+	{"OPENAI_API_KEY", "API key for OpenAI, used if ANTHROPIC_API_KEY isn't set"},
+	{"OLLAMA_MODEL", "Local Ollama model to use if neither key is set"},
+	{"LLM_LOG_FILE", "Default for --log-file"},
+	{"LLM_NO_DAEMON", "Connect to providers directly even if llm daemon is running"},
+	{"LLM_RECORD_DIR", "Save every provider response as a JSON fixture in this directory"},
+	{"LLM_REPLAY_DIR", "Answer requests from the fixtures in this directory instead of the network"},
+	{"TMUX", "Set by tmux; llm tmux-capture without --pane needs it"},
+	{"TMUX_PANE", "The pane llm tmux-capture captures without --pane"},
+	{"HISTFILE", "The bash or zsh history file llm suggest-aliases and --recent read"},
+	{"ZDOTDIR", "Where zsh keeps .zshrc and .zsh_history, if not in ~"},
+	{"ATUIN_DB_PATH", "Atuin's database, which --recent reads (default ~/.local/share/atuin/history.db)"},
+	{"HISTDB_FILE", "zsh-histdb's database, which --recent reads if exported (default ~/.histdb/zsh-history.db)"},
+	{"AWS_PROFILE", "The AWS profile --aws --account describes (default default)"},
+	{"AWS_DEFAULT_PROFILE", "See AWS_PROFILE"},
+	{"AWS_REGION", "The AWS region --aws --account gives, instead of the profile's"},
+	{"AWS_DEFAULT_REGION", "See AWS_REGION"},
+	{"AWS_CONFIG_FILE", "The aws config file (default ~/.aws/config)"},
+	{"CLOUDSDK_CONFIG", "gcloud's config directory (default ~/.config/gcloud, or %APPDATA%\\gcloud on Windows)"},
+	{"APPDATA", "See CLOUDSDK_CONFIG"},
+	{"CLOUDSDK_ACTIVE_CONFIG_NAME", "The gcloud configuration --gcloud --account describes, instead of the active one"},
+	{"CLOUDSDK_CORE_PROJECT", "The project --gcloud --account gives, instead of the configuration's (also CLOUDSDK_CORE_ACCOUNT, CLOUDSDK_COMPUTE_REGION and CLOUDSDK_COMPUTE_ZONE)"},
+	{"CLOUDSDK_CORE_ACCOUNT", "See CLOUDSDK_CORE_PROJECT"},
+	{"CLOUDSDK_COMPUTE_REGION", "See CLOUDSDK_CORE_PROJECT"},
+	{"CLOUDSDK_COMPUTE_ZONE", "See CLOUDSDK_CORE_PROJECT"},
+	{"AZURE_CONFIG_DIR", "az's config directory (default ~/.azure)"},
+	{"AZURE_DEFAULTS_LOCATION", "The location --az --account gives, instead of the config's (also AZURE_DEFAULTS_GROUP)"},
+	{"AZURE_DEFAULTS_GROUP", "See AZURE_DEFAULTS_LOCATION"},
+	{"LLM", "The llm that hooks from llm install-hook run, if not the one on PATH"},
+	{"LLM_CAPTURE_STDERR", "Set to 1 before llm shell-init's code runs to send the previous command's error output too"},
+	{"LLM_LAST_COMMAND", "The previous command, set by llm shell-init"},
+	{"LLM_LAST_STATUS", "The previous command's exit status, set by llm shell-init"},
+	{"LLM_LAST_STDERR", "File holding the previous command's error output, set by llm shell-init"},
+	{"LC_ALL", "Locale, whose language explanations are written in (also LC_MESSAGES and LANG)"},
+	{"LC_MESSAGES", "See LC_ALL"},
+	{"LANG", "See LC_ALL"},
+	{"SHELL", "Shell that commands are suggested for"},
+	{"PAGER", "Pager for output taller than the terminal (default less)"},
+	{"XDG_CONFIG_HOME", "Where the config file is kept (default ~/.config)"},
+	{"ProgramData", "Where the policy file is kept on Windows (default C:\\ProgramData)"},
+	{"XDG_DATA_HOME", "Where the history and notes index are kept (default ~/.local/share)"},
+	{"XDG_CACHE_HOME", "Where caches and rate-limit state are kept (default ~/.cache)"},
+	{"OTEL_EXPORTER_OTLP_ENDPOINT", "OpenTelemetry collector to send request spans and metrics to, over OTLP/HTTP with JSON"},
+	{"OTEL_EXPORTER_OTLP_PROTOCOL", "Must be http/json if set"},
+	{"OTEL_EXPORTER_OTLP_HEADERS", "Headers for the collector, as key=value pairs separated by commas"},
+	{"OTEL_EXPORTER_OTLP_TIMEOUT", "How long an export may take, in milliseconds (default 10000)"},
+	{"OTEL_RESOURCE_ATTRIBUTES", "Resource attributes for exported telemetry, as key=value pairs separated by commas"},
+	{"OTEL_SERVICE_NAME", "Service name for exported telemetry (default llm-cli)"},
+	{"OTEL_SDK_DISABLED", "Set to true to export no telemetry"},
+	{"SOURCE_DATE_EPOCH", "Date to put in the page llm man prints, for reproducible builds"},
+}
+
+// configKeys are the settings llm reads from the config file, for the man
+// page
+var configKeys = []struct{ key, desc string }{
+	{"anthropic_key_cmd", "Command whose first line of output is the Claude API key"},
+	{"openai_key_cmd", "Command whose first line of output is the OpenAI API key"},
+	{"context", "Always send project context, as with --context"},
+	{"tools", "Always let the model look around, as with --tools"},
+	{"tool_commands", "More programs --tools may run"},
+	{"man_pages", "Set to false to not send man page excerpts (--man)"},
+	{"k8s_api_resources", "Always send the cluster's resource types with --k8s"},
+	{"cloud_account", "Always send the cloud CLI's profile or project and region with --aws, --gcloud or --az"},
+	{"regex_dialect", "Default --dialect for --regex"},
+	{"sql_dialect", "Default --dialect for --sql"},
+	{"output_lang", "Default --output-lang"},
+	{"best_of", "Default --best-of"},
+	{"best_of_models", "Models to sample in turn with --best-of"},
+	{"best_of_judge", "Model that picks the best answer with --best-of"},
+	{"confirm_bytes", "Ask before sending more than this many bytes of attachments; 0 never asks (default 32768)"},
+	{"sensitive_paths", "More file patterns that may hold secrets, to ask before sending"},
+	{"context_window", "Context window of the model, in tokens, for models llm doesn't know"},
+	{"repo_tokens", "How many tokens of the repository --repo sends (default about 6000)"},
+	{"moderation", "Check prompts before they're sent: \"warn\" sends flagged prompts with a warning, \"block\" refuses them (default off)"},
+	{"moderation_cmd", "Local classifier for moderation, given the prompt on stdin, which exits 1 and prints categories if it's flagged; OpenAI's moderation API is used otherwise"},
+	{"history", "Set to false to not save queries and answers"},
+	{"encrypt_history", "Encrypt the history with a key kept in the OS keychain"},
+	{"agent_max_steps", "Most commands llm agent runs (default 10)"},
+	{"agent_max_tokens", "Most tokens llm agent sends and receives (default 100000)"},
+	{"batch_concurrency", "Default --concurrency for llm batch"},
+	{"batch_rpm", "Default --rpm for llm batch"},
+	{"bench_models", "Models llm bench times if none are given"},
+	{"embedding_model", "Model that embeds notes for llm index and llm recall"},
+	{"serve_listen", "Default --listen for llm serve"},
+	{"serve_token", "Bearer token llm serve requires of every request; without it, llm serve only listens on and answers localhost"},
+	{"offline", "Always run as with --offline"},
+	{"tmux_lines", "Lines of scrollback llm tmux-capture sends (default 200)"},
+	{"commit_types", "Types llm lint-commit allows in a subject line (default feat, fix, docs, style, refactor, perf, test, build, ci, chore and revert)"},
+	{"watch_trigger", "Regular expression for the lines that start a block of errors in llm watch"},
+	{"stats", "Always show the footer --stats shows"},
+	{"notify", "Always run as with --notify"},
+	{"notify_after", "Seconds an answer takes before --notify says it has arrived (default 10)"},
+	{"offline_hosts", "Hosts, or URLs, that --offline still allows requests to"},
+	{"allowed_providers", "Providers llm may use, of claude, openai and ollama; usually set in the policy file"},
+	{"allowed_endpoints", "URLs, or host names, that llm may send requests to; usually set in the policy file"},
+	{"force_redaction", "Redact secrets from every request even with --no-redact; usually set in the policy file"},
+	{"max_tokens", "Most tokens an answer may have, if fewer than the usual 1000; usually set in the policy file"},
+	{"anthropic_endpoint", "URL Claude requests are sent to instead of Anthropic's API, such as a gateway's"},
+	{"openai_endpoint", "URL OpenAI requests are sent to instead of OpenAI's API"},
+	{"ollama_endpoint", "URL Ollama requests are sent to instead of the local server"},
+	{"anthropic_headers", "Section of headers added to requests to Claude, as in [anthropic_headers] then X-Org-Id = \"acme\""},
+	{"openai_headers", "Section of headers added to requests to OpenAI"},
+	{"ollama_headers", "Section of headers added to requests to Ollama"},
+	{"anthropic_client_cert", "Client certificate for requests to Claude, for gateways that require mutual TLS"},
+	{"anthropic_client_key", "Private key for anthropic_client_cert"},
+	{"anthropic_ca_cert", "CA certificates that Claude's endpoint is verified against instead of the system's"},
+	{"openai_client_cert", "Client certificate for requests to OpenAI"},
+	{"openai_client_key", "Private key for openai_client_cert"},
+	{"openai_ca_cert", "CA certificates that OpenAI's endpoint is verified against"},
+	{"ollama_client_cert", "Client certificate for requests to Ollama"},
+	{"ollama_client_key", "Private key for ollama_client_cert"},
+	{"ollama_ca_cert", "CA certificates that Ollama's endpoint is verified against"},
+	{"anthropic_idempotency_keys", "Send Claude requests with an Idempotency-Key header their retries reuse, for gateways that deduplicate by it"},
+	{"openai_idempotency_keys", "Send OpenAI requests with an Idempotency-Key header"},
+	{"ollama_idempotency_keys", "Send Ollama requests with an Idempotency-Key header"},
+	{"aliases", "Section of snippets that @NAME in a query stands for, as in [aliases] then prod = \"on our Ubuntu production servers\""},
+}
+
+// manSections are the sections of the help text that go in the man page,
+// and their names there. Lists are of terms and descriptions; the rest are
+// prose.
+var manSections = []struct {
+	help, man string
+	list      bool
+}{
+	{"USAGE", "COMMANDS", true},
+	{"OPTIONS", "OPTIONS", true},
+	{"SETUP", "SETUP", false},
+	{"SHELL INTEGRATION", "SHELL INTEGRATION", false},
+	{"CONFIG", "FILES", false},
+	{"EXIT STATUS", "EXIT STATUS", true},
+	{"EXAMPLES", "EXAMPLES", false},
+}
+
+// runMan prints the man page
+func runMan(args []string) error {
+	if len(args) > 0 {
+		return usageError("usage: llm man > llm.1")
+	}
+	date := time.Now()
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		date = time.Unix(epoch, 0)
+	}
+	fmt.Print(manualPage(usageText(), date))
+	return nil
+}
+
+// manualPage turns the help text into a roff man page
+func manualPage(help string, date time.Time) string {
+	sections := helpSections(help)
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH LLM 1 %q %q \"User Commands\"\n", date.UTC().Format("2006-01-02"), "llm "+version)
+	b.WriteString(".SH NAME\nllm \\- suggest shell commands, write code and explain things with Claude, OpenAI or Ollama\n")
+	b.WriteString(".SH SYNOPSIS\n")
+	b.WriteString(".B llm\n[\\fIoptions\\fR] \\fIdescription of what you want to do\\fR\n.br\n")
+	b.WriteString("\\fIcommand\\fR | \\fBllm\\fR [\\fIoptions\\fR] \\fIquestion about its output\\fR\n.br\n")
+	b.WriteString(".B llm\n\\fIsubcommand\\fR [\\fIarguments\\fR]\n")
+	b.WriteString(".SH DESCRIPTION\n")
+	b.WriteString("llm turns a description in plain words into a shell command, and with options writes code, regular expressions, SQL and more, or explains commands and errors. ")
+	b.WriteString("It asks Claude, OpenAI or a local Ollama model, whichever is configured.\n")
+
+	for _, s := range manSections {
+		lines, ok := sections[s.help]
+		if !ok {
+			continue
+		}
+		b.WriteString(".SH " + s.man + "\n")
+		switch {
+		case s.help == "EXAMPLES":
+			b.WriteString(".nf\n")
+			for _, line := range lines {
+				if line = strings.TrimSpace(line); line != "" {
+					b.WriteString(roffLine(line) + "\n")
+				}
+			}
+			b.WriteString(".fi\n")
+		case s.list:
+			writeList(&b, lines)
+		default:
+			writeProse(&b, lines)
+		}
+	}
+
+	b.WriteString(".SH ENVIRONMENT\n")
+	for _, v := range envVars {
+		fmt.Fprintf(&b, ".TP\n.B %s\n%s\n", v.name, roffLine(v.desc))
+	}
+	b.WriteString(".SH CONFIGURATION KEYS\n")
+	b.WriteString("These may be set in the config file, as in \\fBkey = value\\fR.\n")
+	for _, k := range configKeys {
+		fmt.Fprintf(&b, ".TP\n.B %s\n%s\n", roffLine(k.key), roffLine(k.desc))
+	}
+	return b.String()
+}
+
+// helpSectionRe matches a section heading in the help text
+var helpSectionRe = regexp.MustCompile(`^([A-Z][A-Z ]+):$`)
+
+// helpSections splits the help text into its sections' lines
+func helpSections(help string) map[string][]string {
+	sections := map[string][]string{}
+	section := ""
+	for _, line := range strings.Split(help, "\n") {
+		if m := helpSectionRe.FindStringSubmatch(line); m != nil {
+			section = m[1]
+			continue
+		}
+		if section != "" {
+			sections[section] = append(sections[section], strings.TrimRight(line, " "))
+		}
+	}
+	return sections
+}
+
+// termRe matches the start of an entry in a list: a command, a flag or an
+// exit status, then its description if it's on the same line
+var termRe = regexp.MustCompile(`^ {4}((?:llm|<|-|[0-9]).*?)(?: {2,}(.*))?$`)
+
+// writeList writes lines of terms, each indented 4 spaces and followed by
+// their descriptions, as tagged paragraphs. Other lines are prose.
+func writeList(b *strings.Builder, lines []string) {
+	var prose []string
+	for _, line := range lines {
+		if m := termRe.FindStringSubmatch(line); m != nil {
+			writeProse(b, prose)
+			prose = nil
+			fmt.Fprintf(b, ".TP\n\\fB%s\\fR\n", roffLine(m[1]))
+			if m[2] != "" {
+				b.WriteString(roffLine(m[2]) + "\n")
+			}
+			continue
+		}
+		if strings.TrimSpace(line) != "" && len(prose) == 0 && strings.HasPrefix(line, "     ") {
+			// The rest of the description
+			b.WriteString(roffLine(strings.TrimSpace(line)) + "\n")
+			continue
+		}
+		prose = append(prose, line)
+	}
+	writeProse(b, prose)
+}
+
+// writeProse writes lines as paragraphs, keeping the lines that show what
+// to type (commands and settings) as they are
+func writeProse(b *strings.Builder, lines []string) {
+	started, verbatim := false, false
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if verbatim {
+				b.WriteString(".fi\n.RE\n")
+			}
+			started, verbatim = false, false
+			continue
+		}
+		literal := strings.HasPrefix(line, "export ") || strings.HasPrefix(line, "llm ") || strings.Contains(line, ` = "`)
+		if !started {
+			b.WriteString(".PP\n")
+			started = true
+		}
+		if literal != verbatim {
+			if literal {
+				b.WriteString(".RS\n.nf\n")
+			} else {
+				b.WriteString(".fi\n.RE\n")
+			}
+			verbatim = literal
+		}
+		b.WriteString(roffLine(line) + "\n")
+	}
+	if verbatim {
+		b.WriteString(".fi\n.RE\n")
+	}
+}
+
+// roffLine escapes text for roff
+func roffLine(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\e`)
+	s = strings.ReplaceAll(s, "-", `\-`)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManualPage(t *testing.T) {
+	page := manualPage(usageText(), time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC))
+	for _, want := range []string{
+		`.TH LLM 1 "2025-01-02" "llm ` + version + `" "User Commands"`,
+		".SH COMMANDS\n",
+		".TP\n\\fB\\-c, \\-\\-code\\fR\nCode generation mode\n",
+		".TP\n\\fB130\\fR\nInterrupted with Ctrl\\-C\n",
+		".RS\n.nf\nexport ANTHROPIC_API_KEY=your_claude_api_key\n",
+		"%APPDATA%\\ellm\\econfig.toml",
+		".TP\n.B OTEL_EXPORTER_OTLP_ENDPOINT\n",
+		".TP\n.B best_of_judge\n",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+	if strings.Count(page, ".nf\n") != strings.Count(page, ".fi\n") {
+		t.Error("unbalanced .nf and .fi")
+	}
+}
+
+// TestManualPageCoverage checks that the man page documents every option,
+// subcommand, environment variable and config key the code uses, so that it
+// can't fall behind
+func TestManualPageCoverage(t *testing.T) {
+	page := manualPage(usageText(), time.Now())
+	documented := func(pattern string) bool {
+		return regexp.MustCompile(pattern).MatchString(page)
+	}
+
+	// Incidental variables that aren't settings
+	undocumented := []string{"LESS", "LINES", "PSModulePath", "WAYLAND_DISPLAY", "XDG_RUNTIME_DIR"}
+	var files []string
+	for _, pattern := range []string{"*.go", "../../pkg/llm/*.go", "../../internal/*/*.go"} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, matches...)
+	}
+	fset := token.NewFileSet()
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			var fun string
+			if ok {
+				if x, ok := sel.X.(*ast.Ident); ok {
+					fun = x.Name + "." + sel.Sel.Name
+				}
+			} else if id, ok := call.Fun.(*ast.Ident); ok {
+				fun = id.Name
+			}
+			arg := func(i int) (string, bool) {
+				if len(call.Args) <= i {
+					return "", false
+				}
+				lit, ok := call.Args[i].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					return "", false
+				}
+				s, err := strconv.Unquote(lit.Value)
+				return s, err == nil
+			}
+			where := fset.Position(call.Pos())
+
+			switch {
+			case fun == "os.Getenv" || fun == "getenv":
+				if name, ok := arg(0); ok && !slices.Contains(undocumented, name) && !documented(`\.B `+name+"\n") {
+					t.Errorf("%s: $%s isn't in the man page", where, name)
+				}
+			case strings.HasPrefix(fun, "cfg."):
+				if key, ok := arg(0); ok && !documented(`\.B `+roffLine(key)+"\n") {
+					t.Errorf("%s: config key %s isn't in the man page", where, key)
+				}
+			case strings.HasPrefix(fun, "flagSet.") && strings.HasSuffix(fun, "Var") &&
+				(filepath.Base(path) == "main.go" || filepath.Base(path) == "log.go"):
+				// llm's own options, rather than a subcommand's
+				name, ok := arg(1)
+				if !ok {
+					return true
+				}
+				dashes := `\\-\\-`
+				if len(name) == 1 {
+					dashes = `(?:^|[^-])\\-`
+				}
+				if !documented(dashes + regexp.QuoteMeta(roffLine(name)) + `(?:[^a-z\\]|\\f|$)`) {
+					t.Errorf("%s: option %s isn't in the man page", where, name)
+				}
+			}
+			return true
+		})
+	}
+
+	for name := range subcommands {
+		if !strings.Contains(page, "llm "+roffLine(name)) {
+			t.Errorf("subcommand %s isn't in the man page", name)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// errFlagged is returned when moderation blocks a prompt
+var errFlagged = errors.New("the prompt was flagged by moderation and wasn't sent")
+
+// moderate checks an outgoing prompt when the config's moderation setting
+// is "warn" or "block". The check is moderation_cmd if it's set, and
+// otherwise OpenAI's moderation API. A flagged prompt is refused under
+// "block" and sent with a warning under "warn"; so is a prompt that couldn't
+// be checked.
+func moderate(ctx context.Context, cfg *config.Config, prompt string) error {
+	policy := cfg.String("moderation")
+	switch policy {
+	case "", "off":
+		return nil
+	case "warn", "block":
+	default:
+		return fmt.Errorf(`moderation must be "off", "warn" or "block", not %q`, policy)
+	}
+
+	var m *llm.Moderation
+	var err error
+	if command := cfg.String("moderation_cmd"); command != "" {
+		m, err = classify(ctx, command, prompt)
+	} else {
+		m, err = moderateWithOpenAI(ctx, cfg, prompt)
+	}
+	if err != nil {
+		if policy == "block" {
+			return fmt.Errorf("moderation check failed, so the prompt wasn't sent: %v", err)
+		}
+		slog.Warn("Moderation check failed; sending the prompt anyway", "error", err)
+		return nil
+	}
+	if !m.Flagged {
+		slog.Debug("moderation passed")
+		return nil
+	}
+
+	categories := strings.Join(m.Categories, ", ")
+	if policy == "block" {
+		if categories != "" {
+			return fmt.Errorf("%w (%s)", errFlagged, categories)
+		}
+		return errFlagged
+	}
+	slog.Warn("The prompt was flagged by moderation; sending it anyway", "categories", categories)
+	return nil
+}
+
+// moderateWithOpenAI checks prompt with OpenAI's moderation API, using the
+// OpenAI key whichever provider answers
+func moderateWithOpenAI(ctx context.Context, cfg *config.Config, prompt string) (*llm.Moderation, error) {
+	key := os.Getenv("OPENAI_API_KEY")
+	if key == "" {
+		var err error
+		if key, err = storedKey(cfg, "openai"); err != nil {
+			return nil, err
+		}
+	}
+	if key == "" && os.Getenv("LLM_REPLAY_DIR") == "" {
+		return nil, errors.New("moderation needs an OpenAI key, or a moderation_cmd")
+	}
+	client := llm.NewClient(llm.OpenAI, key, llm.ModerationModel)
+	if err := setupClient(cfg, client); err != nil {
+		return nil, err
+	}
+	return client.Moderate(ctx, prompt)
+}
+
+// classify runs a local classifier with prompt on its stdin. It exits 0 if
+// the prompt is fine and 1 if it's flagged, printing the categories one per
+// line; any other failure is an error.
+func classify(ctx context.Context, command, prompt string) (*llm.Moderation, error) {
+	cmd := shellCommand(ctx, command)
+	cmd.Stdin = strings.NewReader(prompt)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return &llm.Moderation{}, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		m := &llm.Moderation{Flagged: true}
+		for _, line := range strings.Split(string(out), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				m.Categories = append(m.Categories, line)
+			}
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("moderation_cmd: %v", err)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/internal/config"
+)
+
+func TestModerate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{name: "off", config: `moderation = "off"`},
+		{name: "unset", config: `moderation_cmd = "exit 1"`},
+		{name: "passes", config: "moderation = \"block\"\nmoderation_cmd = \"exit 0\""},
+		{name: "blocked", config: "moderation = \"block\"\nmoderation_cmd = \"echo violence; exit 1\"", err: "flagged by moderation and wasn't sent (violence)"},
+		{name: "warned", config: "moderation = \"warn\"\nmoderation_cmd = \"echo violence; exit 1\""},
+		{name: "check fails when blocking", config: "moderation = \"block\"\nmoderation_cmd = \"exit 3\"", err: "moderation check failed"},
+		{name: "check fails when warning", config: "moderation = \"warn\"\nmoderation_cmd = \"exit 3\""},
+		{name: "bad policy", config: `moderation = "yes"`, err: `not "yes"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := config.Parse(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = moderate(context.Background(), cfg, "prompt")
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	m, err := classify(context.Background(), `grep -q secret && printf 'self-harm\n\nviolence\n' && exit 1; exit 0`, "a secret plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Flagged || strings.Join(m.Categories, ",") != "self-harm,violence" {
+		t.Errorf("got %+v", m)
+	}
+	if m, err := classify(context.Background(), "cat > /dev/null", "hello"); err != nil || m.Flagged {
+		t.Errorf("got %+v, %v", m, err)
+	}
+	if _, err := classify(context.Background(), "exit 2", "hello"); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// defaultNotifyAfter is how long an answer takes before --notify says it
+// has arrived, unless notify_after is set
+const defaultNotifyAfter = 10 * time.Second
+
+// notifyCommand returns the command that shows a desktop notification, or
+// nil if there's no way to show one here
+func notifyCommand(goos string, lookPath func(string) (string, error), title, message string) []string {
+	switch goos {
+	case "darwin":
+		// Passed as arguments so they needn't be escaped for AppleScript
+		return []string{"osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, message}
+	case "windows":
+		return nil
+	}
+	if _, err := lookPath("notify-send"); err == nil {
+		return []string{"notify-send", "--app-name=llm", title, message}
+	}
+	return nil
+}
+
+// notifyIfSlow lets the user know a request that took longer than
+// notify_after has finished, with a desktop notification or, failing that,
+// the terminal bell
+func notifyIfSlow(opts *options, notifyAfter, elapsed time.Duration, failed bool) {
+	if !opts.notify || elapsed < notifyAfter {
+		return
+	}
+	title := "llm answered"
+	if failed {
+		title = "llm failed"
+	}
+	message := opts.query
+	if runes := []rune(message); len(runes) > 80 {
+		message = string(runes[:77]) + "..."
+	}
+
+	if args := notifyCommand(runtime.GOOS, exec.LookPath, title, message); args != nil {
+		err := exec.Command(args[0], args[1:]...).Run()
+		if err == nil {
+			return
+		}
+		slog.Debug("desktop notification failed", "command", args[0], "error", err)
+	}
+	if isTerminal(os.Stderr) {
+		fmt.Fprint(notices, "\a")
+	}
+}
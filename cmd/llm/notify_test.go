@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestNotifyCommand(t *testing.T) {
+	found := func(string) (string, error) { return "/usr/bin/notify-send", nil }
+	missing := func(string) (string, error) { return "", errors.New("not found") }
+
+	tests := []struct {
+		goos     string
+		lookPath func(string) (string, error)
+		want     []string
+	}{
+		{"linux", found, []string{"notify-send", "--app-name=llm", "llm answered", `say "hi"`}},
+		{"linux", missing, nil},
+		{"windows", found, nil},
+		{"darwin", missing, []string{"osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			"llm answered", `say "hi"`}},
+	}
+	for _, tt := range tests {
+		if got := notifyCommand(tt.goos, tt.lookPath, "llm answered", `say "hi"`); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.goos, got, tt.want)
+		}
+	}
+}
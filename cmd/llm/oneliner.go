@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// Limits for checking --sed and --awk one-liners
+const (
+	oneLinerSampleLines = 20
+	oneLinerTimeout     = 5 * time.Second
+	oneLinerShownLines  = 10
+)
+
+// oneLinerTools are the programs each mode's answer may run
+var oneLinerTools = map[llm.Mode][]string{
+	llm.SedMode: {"sed", "gsed"},
+	llm.AwkMode: {"awk", "gawk", "mawk", "nawk"},
+}
+
+// errNoSandbox is returned by runOneLiner when the sed or awk installed has
+// no --sandbox, so a command can't be run safely
+var errNoSandbox = errors.New("it has no --sandbox, so the command wasn't run on the sample")
+
+// sedInPlaceRe matches sed's options for editing files in place, which
+// --sandbox still allows, including abbreviations of --in-place such as
+// --in
+var sedInPlaceRe = regexp.MustCompile(`^(-[A-Za-z]*i|--i)`)
+
+// prepareOneLiner tells the model which sed or awk is installed, since
+// GNU, BSD and busybox versions accept different syntax
+func prepareOneLiner(sys *llm.System, mode llm.Mode) {
+	tool := oneLinerTools[mode][0]
+	if _, err := exec.LookPath(tool); err != nil {
+		return
+	}
+	version := toolVersion(tool)
+	if version == "" {
+		version = "BSD " + tool
+	}
+	sys.Notes = append(sys.Notes, fmt.Sprintf("%s is %s", tool, version))
+}
+
+// versionArgs are the arguments that print the version of programs that
+// don't take --version
+var versionArgs = map[string][][]string{
+	"go":    {{"version"}},
+	"java":  {{"-version"}},
+	"javac": {{"-version"}},
+	"ssh":   {{"-V"}},
+}
+
+// toolVersion returns the first line of `tool --version`, or of `tool -W
+// version` for mawk, or "" if neither works. Programs in versionArgs are
+// asked their way instead, and may answer on stderr, as java does.
+func toolVersion(tool string) string {
+	argLists, ok := versionArgs[tool]
+	if !ok {
+		argLists = [][]string{{"--version"}, {"-W", "version"}}
+	}
+	for _, args := range argLists {
+		ctx, cancel := context.WithTimeout(context.Background(), oneLinerTimeout)
+		cmd := exec.CommandContext(ctx, tool, args...)
+		output := cmd.Output
+		if ok {
+			output = cmd.CombinedOutput
+		}
+		out, err := output()
+		cancel()
+		if line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n"); err == nil && line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// sampleOneLinerInput cuts piped input in sys down to its first lines,
+// returning the sample, or "" if nothing was piped in
+func sampleOneLinerInput(sys *llm.System) string {
+	for i, a := range sys.Attachments {
+		if a.Name != "" {
+			continue
+		}
+		lines := strings.SplitAfter(a.Content, "\n")
+		a.Title = "Sample input"
+		if len(lines) > oneLinerSampleLines {
+			a.Content = strings.Join(lines[:oneLinerSampleLines], "")
+			a.Title = fmt.Sprintf("Sample input (first %d lines)", oneLinerSampleLines)
+		}
+		sys.Attachments[i] = a
+		return a.Content
+	}
+	return ""
+}
+
+// verifyOneLiner runs command on sample and shows what it printed. If it
+// fails, the model is asked once to correct it, and the corrected command
+// is returned.
+func verifyOneLiner(ctx context.Context, cfg *config.Config, client *llm.Client, opts *options, sys llm.System, sample, command string) (string, error) {
+	output, runErr := runOneLiner(opts.mode, command, sample)
+	if runErr != nil {
+		if errors.Is(runErr, errNoSandbox) {
+			slog.Info("Not checking the command", "error", runErr)
+			return command, nil
+		}
+		slog.Info("The command failed on the sample; asking for a correction", "error", runErr)
+		sys.Notes = append(sys.Notes, fmt.Sprintf("The command `%s` failed on the sample input with: %v", command, runErr))
+		response, _, err := requery(ctx, cfg, client, opts, sys)
+		if err != nil {
+			return "", err
+		}
+		command = cleanOneLiner(response)
+		if output, runErr = runOneLiner(opts.mode, command, sample); runErr != nil {
+			slog.Warn("The corrected command also fails", "error", runErr)
+			return command, nil
+		}
+	}
+
+	fmt.Fprintln(notices, "Output on the sample:")
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	for i, line := range lines {
+		if i == oneLinerShownLines {
+			fmt.Fprintf(notices, "  ... and %d more lines\n", len(lines)-i)
+			break
+		}
+		fmt.Fprintln(notices, "  "+line)
+	}
+	return command, nil
+}
+
+// runOneLiner runs command with input on stdin and returns its output. It
+// must be a single sed or awk command (whichever mode asks for), which is
+// run with --sandbox, without a shell, in an empty temporary directory,
+// with a timeout. The sandbox keeps sed's e, r and w commands and awk's
+// system(), getline and redirections from reaching the machine; without
+// one, errNoSandbox is returned and nothing is run.
+func runOneLiner(mode llm.Mode, command, input string) (string, error) {
+	args, err := splitCommand(command)
+	if err != nil {
+		return "", err
+	}
+	if len(args) == 0 || !slices.Contains(oneLinerTools[mode], filepath.Base(args[0])) {
+		return "", fmt.Errorf("not a %s command", oneLinerTools[mode][0])
+	}
+	// The installed tool is run, never a path the model gave
+	path, err := exec.LookPath(filepath.Base(args[0]))
+	if err != nil {
+		return "", fmt.Errorf("%s not found", filepath.Base(args[0]))
+	}
+	args[0] = path
+	if mode == llm.SedMode && slices.ContainsFunc(args[1:], sedInPlaceRe.MatchString) {
+		return "", errors.New("edits files in place instead of reading stdin")
+	}
+	if !hasSandbox(args[0]) {
+		return "", fmt.Errorf("%s: %w", args[0], errNoSandbox)
+	}
+	args = slices.Insert(args, 1, "--sandbox")
+
+	dir, err := os.MkdirTemp("", "llm-oneliner-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), oneLinerTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr strings.Builder
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("timed out after %v", oneLinerTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// hasSandbox reports whether tool is GNU sed or gawk, which take --sandbox
+func hasSandbox(tool string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), oneLinerTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, tool, "--sandbox", "--version").Output()
+	return err == nil && strings.Contains(string(out), "GNU")
+}
+
+// splitCommand splits a command line into words the way sh would, handling
+// quotes and backslashes. Pipes, redirections, substitutions and other
+// shell syntax are refused rather than interpreted.
+func splitCommand(command string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+			continue
+		case c == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			word.WriteString(command[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			i++
+			for ; i < len(command) && command[i] != '"'; i++ {
+				switch command[i] {
+				case '\\':
+					if i+1 < len(command) && strings.IndexByte("\"\\$`", command[i+1]) >= 0 {
+						i++
+					}
+				case '$', '`':
+					return nil, errors.New("uses shell substitution")
+				}
+				word.WriteByte(command[i])
+			}
+			if i == len(command) {
+				return nil, errors.New("unterminated double quote")
+			}
+		case c == '\\':
+			if i+1 < len(command) {
+				i++
+				word.WriteByte(command[i])
+			}
+		case strings.IndexByte("|&;<>()$`\n", c) >= 0:
+			return nil, fmt.Errorf("uses shell syntax (%q), so it isn't a single command", c)
+		default:
+			word.WriteByte(c)
+		}
+		inWord = true
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// cleanOneLiner removes the fences or prompt that models sometimes put
+// around a command
+func cleanOneLiner(response string) string {
+	command := strings.TrimSpace(strings.Trim(stripFence(response), "`"))
+	return strings.TrimSpace(strings.TrimPrefix(command, "$ "))
+}
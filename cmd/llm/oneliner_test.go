@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+		err     bool
+	}{
+		{`sed 's/foo/bar/g'`, []string{"sed", "s/foo/bar/g"}, false},
+		{`awk -F: '{print $1}'`, []string{"awk", "-F:", "{print $1}"}, false},
+		{`sed -E "s/a\"b/\\1/"`, []string{"sed", "-E", `s/a"b/\1/`}, false},
+		{`awk  'NR>1'  `, []string{"awk", "NR>1"}, false},
+		{`sed s/a\ b/c/`, []string{"sed", "s/a b/c/"}, false},
+		{`sed ''`, []string{"sed", ""}, false},
+		{`sed 's/a/b/' | sort`, nil, true},
+		{`awk '{print}' > out`, nil, true},
+		{`sed "s/$HOME/~/"`, nil, true},
+		{`sed $(cat script)`, nil, true},
+		{`sed 's/a/b/`, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := splitCommand(tt.command)
+		if (err != nil) != tt.err {
+			t.Errorf("splitCommand(%q) error = %v, want error %v", tt.command, err, tt.err)
+			continue
+		}
+		if !tt.err && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommand(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestRunOneLiner(t *testing.T) {
+	if _, err := exec.LookPath("sed"); err != nil {
+		t.Skip("sed not installed")
+	}
+	if _, err := runOneLiner(llm.SedMode, `awk '{print}'`, "foo\n"); err == nil || !strings.Contains(err.Error(), "not a sed command") {
+		t.Errorf("expected awk to be refused in sed mode, got %v", err)
+	}
+	if _, err := runOneLiner(llm.SedMode, `sed -Ei 's/o/0/' /etc/hosts`, "foo\n"); err == nil || !strings.Contains(err.Error(), "in place") {
+		t.Errorf("expected sed -i to be refused, got %v", err)
+	}
+	file := filepath.Join(t.TempDir(), "keep")
+	if err := os.WriteFile(file, []byte("hello\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, opt := range []string{"--in", "--in-p", "--in-place=.bak", "-ni"} {
+		if _, err := runOneLiner(llm.SedMode, `sed `+opt+` 's/hello/pwned/' `+file, "foo\n"); err == nil || !strings.Contains(err.Error(), "in place") {
+			t.Errorf("expected sed %s to be refused, got %v", opt, err)
+		}
+	}
+	if b, _ := os.ReadFile(file); string(b) != "hello\n" {
+		t.Errorf("file was edited: %q", b)
+	}
+	if runtime.GOOS != "windows" {
+		// A sed the model names by path isn't the one that's run
+		dir := t.TempDir()
+		marker := filepath.Join(dir, "ran")
+		if err := os.WriteFile(filepath.Join(dir, "sed"), []byte("#!/bin/sh\ntouch "+marker+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		runOneLiner(llm.SedMode, filepath.Join(dir, "sed")+` 's/o/0/'`, "foo\n")
+		if _, err := os.Stat(marker); err == nil {
+			t.Error("ran the sed the model gave the path of")
+		}
+	}
+	if !hasSandbox("sed") {
+		if _, err := runOneLiner(llm.SedMode, `sed 's/o/0/g'`, "foo\n"); !errors.Is(err, errNoSandbox) {
+			t.Errorf("ran without a sandbox: %v", err)
+		}
+		t.Skip("sed has no --sandbox")
+	}
+
+	out, err := runOneLiner(llm.SedMode, `sed 's/o/0/g'`, "foo\nbar\n")
+	if err != nil || out != "f00\nbar\n" {
+		t.Errorf("runOneLiner() = %q, %v", out, err)
+	}
+	if _, err := runOneLiner(llm.SedMode, `sed 's/o/0/q'`, "foo\n"); err == nil {
+		t.Error("expected sed to reject the command")
+	}
+	if _, err := runOneLiner(llm.SedMode, `sed '1e touch pwned'`, "foo\n"); err == nil || !strings.Contains(err.Error(), "sandbox") {
+		t.Errorf("expected the sandbox to refuse e, got %v", err)
+	}
+}
+
+func TestSampleOneLinerInput(t *testing.T) {
+	sys := llm.System{Attachments: []llm.Attachment{{Content: strings.Repeat("line\n", 30)}}}
+	sample := sampleOneLinerInput(&sys)
+	if sample != strings.Repeat("line\n", oneLinerSampleLines) || sys.Attachments[0].Content != sample {
+		t.Errorf("sample = %q", sample)
+	}
+	if sample := sampleOneLinerInput(&llm.System{}); sample != "" {
+		t.Errorf("sample without input = %q", sample)
+	}
+}
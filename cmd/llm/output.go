@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// preamble matches an introductory line such as "Here's the script:"
+var preamble = regexp.MustCompile(`(?i)^(sure[,!.]?\s+)?here('s| is| are)\b[^\n]*\n`)
+
+// cleanCode strips a leading "Here is..." line and an enclosing code fence
+// from a code-mode answer, so that it can be run or piped as is
+func cleanCode(response string) string {
+	return stripFence(preamble.ReplaceAllString(strings.TrimSpace(response), ""))
+}
+
+// stripFence removes a code fence enclosing the whole of text, which models
+// add despite being asked not to. Text with more than one fenced block is
+// left alone.
+func stripFence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") || !strings.HasSuffix(text, "```") || len(text) < 6 {
+		return text
+	}
+	if strings.Count("\n"+text, "\n```") != 2 {
+		return text
+	}
+	// Drop the opening line, including any language tag
+	_, body, ok := strings.Cut(text, "\n")
+	if !ok {
+		return text
+	}
+	return strings.TrimSpace(strings.TrimSuffix(body, "```"))
+}
+
+// checkOutput makes sure path can be written, refusing to replace an
+// existing file unless force is set
+func checkOutput(path string, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists; pass --force to overwrite it", path)
+	}
+	return nil
+}
+
+// writeAnswer writes content to path, creating its directory, once the
+// user has seen it and agreed, unless yes is set. An existing file is only
+// replaced with force.
+func writeAnswer(path, content string, yes, force bool) error {
+	if err := checkOutput(path, force); err != nil {
+		return err
+	}
+	if !yes {
+		question := "Write this to " + path + "?"
+		if _, err := os.Stat(path); err == nil {
+			question = "Overwrite " + path + " with this?"
+		}
+		ok, err := confirm(question)
+		if err != nil {
+			return fmt.Errorf("%v; pass --yes to write without confirming", err)
+		}
+		if !ok {
+			return errAborted
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write output: %v", err)
+	}
+	fmt.Fprintf(notices, "Wrote %s\n", path)
+	return nil
+}
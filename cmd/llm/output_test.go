@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"plain", "print(1)", "print(1)"},
+		{"fence", "```python\nprint(1)\n```", "print(1)"},
+		{"fence without language", "```\nprint(1)\n```\n", "print(1)"},
+		{"preamble", "Here's a script that prints 1:\n```python\nprint(1)\n```", "print(1)"},
+		{"preamble without fence", "Here is the code:\nprint(1)", "print(1)"},
+		{"sure", "Sure! Here is the code.\n\n```\nprint(1)\n```", "print(1)"},
+		{"two blocks", "```\na\n```\nthen\n```\nb\n```", "```\na\n```\nthen\n```\nb\n```"},
+		{"code that starts with here", "heredoc = 1", "heredoc = 1"},
+		{"inner backticks", "```bash\necho `date`\n```", "echo `date`"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanCode(tt.response); got != tt.want {
+				t.Errorf("cleanCode(%q) = %q, want %q", tt.response, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteAnswer(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "new", "dir", "main.go")
+
+	if err := writeAnswer(path, "package main", true, false); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "package main\n" {
+		t.Errorf("wrote %q, %v", data, err)
+	}
+
+	// Existing files are only replaced with force
+	if err := writeAnswer(path, "package other", true, false); err == nil {
+		t.Error("expected an error overwriting without force")
+	}
+	if err := checkOutput(path, false); err == nil {
+		t.Error("checkOutput should refuse an existing file")
+	}
+	if err := writeAnswer(path, "package other", true, true); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "package other\n" {
+		t.Errorf("force didn't overwrite: %q", data)
+	}
+}
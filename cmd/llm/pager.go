@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalSize returns the rows and columns of the controlling terminal, or
+// zeros if they can't be determined
+func terminalSize() (int, int) {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return 0, 0
+	}
+	defer tty.Close()
+
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, 0
+	}
+
+	var rows, cols int
+	if _, err := fmt.Sscan(string(out), &rows, &cols); err != nil {
+		return 0, 0
+	}
+	return rows, cols
+}
+
+var ansiRe = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// shouldPage reports whether output would scroll off an interactive terminal
+func shouldPage(output string) bool {
+	if !isTerminal(os.Stdout) {
+		return false
+	}
+
+	rows, cols := terminalSize()
+	if lines, err := strconv.Atoi(os.Getenv("LINES")); err == nil && lines > 0 {
+		rows = lines
+	}
+	if rows == 0 {
+		return false
+	}
+
+	// Count wrapped lines, ignoring the width of color escape codes
+	height := 0
+	for _, line := range strings.Split(output, "\n") {
+		width := utf8.RuneCountInString(ansiRe.ReplaceAllString(line, ""))
+		if cols > 0 && width > cols {
+			height += (width + cols - 1) / cols
+		} else {
+			height++
+		}
+	}
+
+	// Leave a row for the shell prompt
+	return height >= rows
+}
+
+// runPager pipes output through $PAGER, falling back to `less -R`. An error
+// is returned only if the pager couldn't be started, in which case nothing
+// has been written.
+func runPager(output string) error {
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less", "-R"}
+	}
+
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = strings.NewReader(output + "\n")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// Like git, make less keep colors and exit on short output unless the
+	// user has configured it themselves
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	cmd.Wait()
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/patch"
+)
+
+// patchedFile is a file as it will be once a patch is applied
+type patchedFile struct {
+	path    string
+	content string
+	created bool
+	deleted bool
+}
+
+// checkPatch applies the diff the model wrote to the attached files in
+// memory. It may only change those files, or create new ones inside the
+// working directory.
+func checkPatch(diff string, attached []string) ([]patchedFile, error) {
+	files, err := patch.Parse(diff)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []patchedFile
+	for _, f := range files {
+		path := filepath.Clean(filepath.FromSlash(f.Name()))
+		change := patchedFile{
+			path:    path,
+			created: f.OldName == patch.DevNull,
+			deleted: f.NewName == patch.DevNull,
+		}
+
+		var original []byte
+		if change.created {
+			if !filepath.IsLocal(path) {
+				return nil, fmt.Errorf("%s: new files must be inside the current directory", f.Name())
+			}
+			if _, err := os.Stat(path); err == nil {
+				return nil, fmt.Errorf("%s: already exists", f.Name())
+			}
+		} else {
+			if !slices.ContainsFunc(attached, func(a string) bool { return filepath.Clean(a) == path }) {
+				return nil, fmt.Errorf("%s: not one of the attached files", f.Name())
+			}
+			if original, err = os.ReadFile(path); err != nil {
+				return nil, err
+			}
+		}
+
+		if change.content, err = f.Apply(string(original)); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// applyPatch writes the patched files, after asking unless yes is set
+func applyPatch(changes []patchedFile, yes bool) error {
+	if !yes {
+		names := make([]string, len(changes))
+		for i, c := range changes {
+			names[i] = c.path
+		}
+		ok, err := confirm("Apply the changes to " + strings.Join(names, ", ") + "?")
+		if err != nil {
+			return fmt.Errorf("%v; pass --yes to apply without confirming", err)
+		}
+		if !ok {
+			return errAborted
+		}
+	}
+
+	for _, c := range changes {
+		var err error
+		switch {
+		case c.deleted:
+			err = os.Remove(c.path)
+		case c.created:
+			if err = os.MkdirAll(filepath.Dir(c.path), 0755); err == nil {
+				err = os.WriteFile(c.path, []byte(c.content), 0644)
+			}
+		default:
+			var info os.FileInfo
+			if info, err = os.Stat(c.path); err == nil {
+				err = os.WriteFile(c.path, []byte(c.content), info.Mode().Perm())
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to apply the patch: %v", err)
+		}
+		fmt.Fprintf(notices, "Patched %s\n", c.path)
+	}
+	return nil
+}
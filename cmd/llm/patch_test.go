@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCheckPatch(t *testing.T) {
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	t.Cleanup(func() { os.Chdir(wd) })
+	os.WriteFile("greet.txt", []byte("hello\nworld\n"), 0644)
+	os.WriteFile("other.txt", []byte("hello\n"), 0644)
+
+	changes, err := checkPatch("--- a/greet.txt\n+++ b/greet.txt\n@@ -1,2 +1,2 @@\n-hello\n+goodbye\n world\n--- /dev/null\n+++ b/docs/new.txt\n@@ -0,0 +1 @@\n+new\n", []string{"./greet.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].content != "goodbye\nworld\n" || !changes[1].created {
+		t.Fatalf("changes = %+v", changes)
+	}
+	if err := applyPatch(changes, true); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile("docs/new.txt"); string(data) != "new\n" {
+		t.Errorf("docs/new.txt = %q", data)
+	}
+
+	for diff, want := range map[string]string{
+		"--- a/other.txt\n+++ b/other.txt\n@@ -1 +1 @@\n-hello\n+bye\n": "not one of the attached files",
+		"--- a/greet.txt\n+++ b/greet.txt\n@@ -1 +1 @@\n-hello\n+bye\n": "doesn't match",
+		"--- /dev/null\n+++ b/../escape.txt\n@@ -0,0 +1 @@\n+x\n":       "inside the current directory",
+		"--- /dev/null\n+++ b/other.txt\n@@ -0,0 +1 @@\n+x\n":           "already exists",
+	} {
+		if _, err := checkPatch(diff, []string{"greet.txt"}); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("checkPatch(%q) error = %v, want %q", diff, err, want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"cmp"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/internal/history"
+	"github.com/jamesob/llm-cli/pkg/llm"
+	"github.com/jamesob/llm-cli/pkg/render"
+)
+
+// preparePipe attaches the answer --pipe builds on: the piped input, or the
+// last answer in the history if nothing is piped in. When that answer is in
+// the history, the model is told what it answered and the new entry joins
+// its session.
+func preparePipe(cfg *config.Config, opts *options, sys *llm.System, input string) error {
+	input = strings.TrimSpace(ansiRe.ReplaceAllString(input, ""))
+	var entries []history.Entry
+	if store, err := openHistory(cfg, false); err == nil {
+		entries, _ = store.Entries()
+	}
+	prev, ok := answeredEntry(entries, input)
+	switch {
+	case input == "" && !ok:
+		return usageError("--pipe needs an answer piped in, or one in the history")
+	case input == "":
+		input = strings.TrimSpace(prev.Response)
+	}
+	if ok {
+		sys.Notes = append(sys.Notes, "Previous request: "+prev.Query)
+		opts.session = sessionID(prev)
+	}
+	sys.Attachments = append(sys.Attachments, llm.Attachment{
+		Title:   "Previous answer, which the request is about",
+		Content: input,
+	})
+	return nil
+}
+
+// answeredEntry returns the latest entry whose answer is text, as printed,
+// or the latest answer of all if text is empty
+func answeredEntry(entries []history.Entry, text string) (history.Entry, bool) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.Response == "" {
+			continue
+		}
+		if text == "" || strings.TrimSpace(e.Response) == text ||
+			strings.TrimSpace(ansiRe.ReplaceAllString(render.Markdown(e.Response), "")) == text {
+			return e, true
+		}
+	}
+	return history.Entry{}, false
+}
+
+// sessionID returns the session e belongs to. The first entry of a chain
+// has none recorded, so a session is named after when it started.
+func sessionID(e history.Entry) string {
+	return cmp.Or(e.Session, e.Time.UTC().Format(time.RFC3339Nano))
+}
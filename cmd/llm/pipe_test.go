@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/history"
+)
+
+func TestAnsweredEntry(t *testing.T) {
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	entries := []history.Entry{
+		{Time: start, Query: "list big dirs", Response: "du -sh */ | sort -h"},
+		{Time: start.Add(time.Minute), Query: "explain it", Response: "### Usage\nSorts by size", Session: "s"},
+		{Time: start.Add(2 * time.Minute), Query: "marked bad"},
+	}
+	tests := []struct {
+		text  string
+		query string
+		ok    bool
+	}{
+		{"du -sh */ | sort -h", "list big dirs", true},
+		{"Usage\nSorts by size", "explain it", true},
+		{"", "explain it", true},
+		{"something else", "", false},
+	}
+	for _, tt := range tests {
+		e, ok := answeredEntry(entries, tt.text)
+		if ok != tt.ok || e.Query != tt.query {
+			t.Errorf("answeredEntry(%q) = %q, %v, want %q, %v", tt.text, e.Query, ok, tt.query, tt.ok)
+		}
+	}
+
+	if got := sessionID(entries[0]); got != "2025-03-01T09:00:00Z" {
+		t.Errorf("first entry's session = %q", got)
+	}
+	if got := sessionID(entries[1]); got != "s" {
+		t.Errorf("later entry's session = %q", got)
+	}
+}
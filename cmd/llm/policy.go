@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// The settings below are usually set in the system-wide policy file, where
+// the user's config can't override them (see config.Load)
+
+// checkPolicy refuses a client for a provider allowed_providers doesn't
+// list, or whose API is somewhere requests aren't allowed, and caps its
+// answers at max_tokens
+func checkPolicy(cfg *config.Config, client *llm.Client) error {
+	if allowed := cfg.Strings("allowed_providers"); len(allowed) > 0 && !slices.Contains(allowed, client.Provider.String()) {
+		return fmt.Errorf("%s isn't allowed here; allowed_providers is %s", client.Provider, strings.Join(allowed, ", "))
+	}
+	if u, err := url.Parse(client.URL()); err == nil {
+		if err := newRequestPolicy(cfg).check(u); err != nil {
+			return err
+		}
+	}
+	if n := cfg.Int("max_tokens"); n > 0 {
+		client.MaxTokens = n
+	}
+	return nil
+}
+
+// requestPolicy restricts where requests go and what they may contain
+type requestPolicy struct {
+	// endpoints are allowed_endpoints
+	endpoints []string
+
+	// offline allows only this machine and offlineHosts
+	offline      bool
+	offlineHosts []string
+
+	// redact is force_redaction
+	redact bool
+}
+
+func newRequestPolicy(cfg *config.Config) requestPolicy {
+	return requestPolicy{
+		endpoints:    cfg.Strings("allowed_endpoints"),
+		offline:      cfg.Bool("offline"),
+		offlineHosts: cfg.Strings("offline_hosts"),
+		redact:       cfg.Bool("force_redaction"),
+	}
+}
+
+// check returns an error if requests may not be sent to u
+func (p requestPolicy) check(u *url.URL) error {
+	if p.offline && !isLocalHost(u.Hostname()) && !allowedEndpoint(u, p.offlineHosts) {
+		return fmt.Errorf("offline mode allows no requests to %s; use a local model, or add the host to offline_hosts", u.Host)
+	}
+	if len(p.endpoints) > 0 && !allowedEndpoint(u, p.endpoints) {
+		return fmt.Errorf("%s isn't one of the allowed_endpoints", u.Redacted())
+	}
+	return nil
+}
+
+// policyTransport enforces a requestPolicy on each request. Secrets are
+// redacted from request bodies under force_redaction, whatever --no-redact
+// says.
+type policyTransport struct {
+	policy requestPolicy
+	base   http.RoundTripper
+}
+
+// withPolicy wraps base in a policyTransport if the config restricts
+// requests
+func withPolicy(cfg *config.Config, base http.RoundTripper) http.RoundTripper {
+	p := newRequestPolicy(cfg)
+	if len(p.endpoints) == 0 && !p.offline && !p.redact {
+		return base
+	}
+	return &policyTransport{policy: p, base: base}
+}
+
+func (t *policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.policy.check(req.URL); err != nil {
+		return nil, err
+	}
+	if !t.policy.redact || req.Body == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	body = redactBody(body)
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	req.ContentLength = int64(len(body))
+	return t.base.RoundTrip(req)
+}
+
+// allowedEndpoint reports whether u is under one of endpoints, which are
+// URLs, or host names allowing any URL on that host
+func allowedEndpoint(u *url.URL, endpoints []string) bool {
+	for _, e := range endpoints {
+		if !strings.Contains(e, "://") {
+			if strings.EqualFold(u.Host, e) || strings.EqualFold(u.Hostname(), e) {
+				return true
+			}
+			continue
+		}
+		e = strings.TrimSuffix(e, "/")
+		if s := u.String(); s == e || strings.HasPrefix(s, e+"/") || strings.HasPrefix(s, e+"?") {
+			return true
+		}
+	}
+	return false
+}
+
+// isLocalHost reports whether host is this machine
+func isLocalHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// redactBody redacts secrets from each string in a JSON request body, or
+// from the whole body if it isn't JSON
+func redactBody(body []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		redacted, _ := llm.Redact(string(body))
+		return []byte(redacted)
+	}
+	redacted, err := json.Marshal(redactJSON(v))
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// redactJSON redacts the strings in a decoded JSON value
+func redactJSON(v any) any {
+	switch v := v.(type) {
+	case string:
+		redacted, _ := llm.Redact(v)
+		return redacted
+	case []any:
+		for i := range v {
+			v[i] = redactJSON(v[i])
+		}
+	case map[string]any:
+		for k := range v {
+			v[k] = redactJSON(v[k])
+		}
+	}
+	return v
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// preparePort tells the model which shells --port-to translates between.
+// The source is the user's own shell unless --from names another.
+func preparePort(sys *llm.System, opts *options) error {
+	target, err := llm.ShellName(opts.portTo)
+	if err != nil {
+		return err
+	}
+	source := sys.Shell
+	if opts.portFrom != "" {
+		if source, err = llm.ShellName(opts.portFrom); err != nil {
+			return err
+		}
+	} else if name, err := llm.ShellName(sys.Shell); err == nil {
+		source = name
+	}
+	sys.Notes = append(sys.Notes, "Source shell: "+source, "Target shell: "+target)
+
+	for i, a := range sys.Attachments {
+		if a.Name == "" {
+			sys.Attachments[i].Title = "Code to translate"
+		}
+	}
+	if opts.query == "" && len(sys.Attachments) == 0 {
+		return errors.New("give the command as the query or pipe in a script")
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestPreparePort(t *testing.T) {
+	tests := []struct {
+		shell, from, to string
+		notes           []string
+	}{
+		{"bash", "", "fish", []string{"Source shell: bash", "Target shell: fish"}},
+		{"zsh", "pwsh", "sh", []string{"Source shell: PowerShell", "Target shell: POSIX sh"}},
+		{"tcsh", "", "bash", []string{"Source shell: tcsh", "Target shell: bash"}},
+	}
+	for _, tt := range tests {
+		sys := llm.System{Shell: tt.shell}
+		opts := &options{portTo: tt.to, portFrom: tt.from, query: "for f in *; do echo $f; done"}
+		if err := preparePort(&sys, opts); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(sys.Notes, tt.notes) {
+			t.Errorf("notes = %q, want %q", sys.Notes, tt.notes)
+		}
+	}
+
+	if err := preparePort(&llm.System{Shell: "bash"}, &options{portTo: "tcsh", query: "ls"}); err == nil {
+		t.Error("expected an error for an unknown target shell")
+	}
+	if err := preparePort(&llm.System{Shell: "bash"}, &options{portTo: "fish"}); err == nil {
+		t.Error("expected an error with nothing to translate")
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// runPR writes a pull request title and description for the current branch
+// and can open the pull request with gh
+func runPR(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	opts := &options{mode: llm.PRMode}
+	var base string
+	var create bool
+	flagSet := flag.NewFlagSet("llm pr", flag.ContinueOnError)
+	flagSet.StringVar(&base, "base", "", "Branch the pull request merges into (default: the remote's default branch)")
+	flagSet.BoolVar(&create, "create", false, "Open the pull request with gh")
+	flagSet.BoolVar(&opts.yes, "y", false, "Don't ask before opening the pull request")
+	flagSet.BoolVar(&opts.noRedact, "no-redact", false, "Send the diff without redacting secrets")
+	opts.log.register(flagSet)
+	flagSet.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: llm pr [--base <branch>] [--create [-y]] [hint about the change]\n")
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if create && cfg.Bool("offline") {
+		return usageError("--create opens the pull request on GitHub, which offline mode doesn't allow")
+	}
+	opts.query = expandAliases(strings.Join(flagSet.Args(), " "), cfg)
+	if opts.query == "" {
+		opts.query = "Write a pull request title and description for these changes."
+	}
+
+	if base == "" {
+		if base, err = defaultBase(); err != nil {
+			return err
+		}
+	}
+	commits, err := gitOutput("log", "--no-merges", "--reverse", "--format=%h %s%n%n%b", base+"..HEAD")
+	if err != nil {
+		return err
+	}
+	if commits == "" {
+		return fmt.Errorf("no commits on this branch that aren't on %s", base)
+	}
+	diff, err := gitOutput("diff", "--no-color", "--no-ext-diff", base+"...HEAD")
+	if err != nil {
+		return err
+	}
+
+	if err := opts.log.setup(); err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	sys := llm.DetectSystem()
+	if wd, err := os.Getwd(); err == nil {
+		project := llm.DetectProject(wd)
+		sys.Project = &project
+	}
+	sys.Attachments = []llm.Attachment{
+		{Title: "Commits since " + base, Content: commits},
+		{Title: "Diff against " + base, Content: diff},
+	}
+
+	response, err := ask(context.Background(), cfg, client, opts, sys)
+	if err != nil {
+		return err
+	}
+	title, body := splitTitle(response)
+	fmt.Printf("%s\n\n%s\n", title, body)
+
+	if !create {
+		return nil
+	}
+	if !opts.yes {
+		ok, err := confirm("Open a pull request with this description?")
+		if err != nil || !ok {
+			return err
+		}
+	}
+	return createPR(base, title, body)
+}
+
+// defaultBase returns the branch pull requests usually merge into: the
+// remote's default branch, or else the current branch's upstream
+func defaultBase() (string, error) {
+	if ref, err := gitOutput("symbolic-ref", "--short", "-q", "refs/remotes/origin/HEAD"); err == nil && ref != "" {
+		return ref, nil
+	}
+	if ref, err := gitOutput("rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}"); err == nil && ref != "" {
+		return ref, nil
+	}
+	return "", errors.New("can't tell which branch to compare against; pass --base")
+}
+
+// splitTitle separates the first line of a response from the rest
+func splitTitle(response string) (title, body string) {
+	title, body, _ = strings.Cut(strings.TrimSpace(response), "\n")
+	title = strings.TrimSpace(strings.TrimLeft(title, "# "))
+	title = strings.TrimPrefix(title, "Title: ")
+	return title, strings.TrimSpace(body)
+}
+
+// createPR opens a pull request with the GitHub CLI
+func createPR(base, title, body string) error {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return errors.New("gh not found; install the GitHub CLI to open pull requests")
+	}
+	// gh wants the branch name on the remote, not the remote-tracking ref
+	remotes, _ := gitOutput("remote")
+	for _, remote := range strings.Fields(remotes) {
+		if branch, ok := strings.CutPrefix(base, remote+"/"); ok {
+			base = branch
+			break
+		}
+	}
+	cmd := exec.Command("gh", "pr", "create", "--base", base, "--title", title, "--body-file", "-")
+	cmd.Stdin = strings.NewReader(body)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("gh pr create failed: %v", err)
+	}
+	return nil
+}
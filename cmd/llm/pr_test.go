@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestSplitTitle(t *testing.T) {
+	tests := []struct {
+		response, title, body string
+	}{
+		{"Add pager\n\nPages long output.", "Add pager", "Pages long output."},
+		{"# Add pager\n\n## Summary\n- x", "Add pager", "## Summary\n- x"},
+		{"Title: Add pager\nbody", "Add pager", "body"},
+		{"\n  Just a title  \n", "Just a title", ""},
+	}
+	for _, tt := range tests {
+		title, body := splitTitle(tt.response)
+		if title != tt.title || body != tt.body {
+			t.Errorf("splitTitle(%q) = %q, %q", tt.response, title, body)
+		}
+	}
+}
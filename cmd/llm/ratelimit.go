@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/paths"
+)
+
+const (
+	// lowRequests is how few requests may be left before the limit resets
+	// before llm warns
+	lowRequests = 10
+
+	// maxRateWait is the longest llm waits for a rate limit to reset;
+	// requests are sent anyway after longer ones
+	maxRateWait = time.Minute
+)
+
+// rateLimit is what a provider last said about its limits for the API key
+type rateLimit struct {
+	RequestsLimit     int       `json:"requests_limit"`
+	RequestsRemaining int       `json:"requests_remaining"`
+	RequestsReset     time.Time `json:"requests_reset"`
+	TokensRemaining   int       `json:"tokens_remaining"`
+	TokensReset       time.Time `json:"tokens_reset"`
+
+	// RetryAfter is when a rate-limited provider said to try again
+	RetryAfter time.Time `json:"retry_after"`
+}
+
+// parseRateLimit reads Anthropic's and OpenAI's rate-limit headers, and
+// Retry-After, reporting whether there were any
+func parseRateLimit(h http.Header, now time.Time) (rateLimit, bool) {
+	var limit rateLimit
+	found := false
+	number := func(name string, n *int) {
+		if v, err := strconv.Atoi(h.Get(name)); err == nil {
+			*n, found = v, true
+		}
+	}
+	// Anthropic gives times, as in "2025-01-02T15:04:05Z"
+	timestamp := func(name string, t *time.Time) {
+		if v, err := time.Parse(time.RFC3339, h.Get(name)); err == nil {
+			*t, found = v, true
+		}
+	}
+	// OpenAI gives durations, as in "6m0s" or "20ms"
+	duration := func(name string, t *time.Time) {
+		if d, err := time.ParseDuration(h.Get(name)); err == nil {
+			*t, found = now.Add(d), true
+		}
+	}
+
+	number("anthropic-ratelimit-requests-limit", &limit.RequestsLimit)
+	number("anthropic-ratelimit-requests-remaining", &limit.RequestsRemaining)
+	timestamp("anthropic-ratelimit-requests-reset", &limit.RequestsReset)
+	number("anthropic-ratelimit-tokens-remaining", &limit.TokensRemaining)
+	timestamp("anthropic-ratelimit-tokens-reset", &limit.TokensReset)
+
+	number("x-ratelimit-limit-requests", &limit.RequestsLimit)
+	number("x-ratelimit-remaining-requests", &limit.RequestsRemaining)
+	duration("x-ratelimit-reset-requests", &limit.RequestsReset)
+	number("x-ratelimit-remaining-tokens", &limit.TokensRemaining)
+	duration("x-ratelimit-reset-tokens", &limit.TokensReset)
+
+	if v := h.Get("retry-after"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			limit.RetryAfter, found = now.Add(time.Duration(secs)*time.Second), true
+		} else if t, err := http.ParseTime(v); err == nil {
+			limit.RetryAfter, found = t, true
+		}
+	}
+	return limit, found
+}
+
+// wait returns how long until the next request can succeed, or 0 if it
+// can be sent now
+func (l rateLimit) wait(now time.Time) time.Duration {
+	until := l.RetryAfter
+	if l.RequestsRemaining == 0 && l.RequestsReset.After(until) {
+		until = l.RequestsReset
+	}
+	if l.TokensRemaining == 0 && l.TokensReset.After(until) {
+		until = l.TokensReset
+	}
+	return max(until.Sub(now), 0)
+}
+
+// rateLimitTransport remembers providers' rate limits, in a state file so
+// they carry over between runs, and waits for a limit to reset rather than
+// send a request that's bound to be refused. It warns when few requests are
+// left.
+type rateLimitTransport struct {
+	base http.RoundTripper
+	path string // "" keeps the limits in memory only
+
+	mu     sync.Mutex
+	limits map[string]rateLimit // by host
+	warned map[string]bool
+}
+
+func newRateLimitTransport(base http.RoundTripper) *rateLimitTransport {
+	t := &rateLimitTransport{base: base}
+	if dir, err := paths.CacheDir(); err == nil {
+		t.path = filepath.Join(dir, "ratelimits.json")
+	}
+	return t
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	t.mu.Lock()
+	t.load()
+	limit := t.limits[host]
+	t.mu.Unlock()
+
+	if d := limit.wait(time.Now()); d > maxRateWait {
+		slog.Warn("Rate limited for longer than llm waits; trying anyway", "host", host, "wait", d.Round(time.Second))
+	} else if d > 0 {
+		slog.Info("Waiting for the rate limit to reset", "host", host, "wait", d.Round(100*time.Millisecond))
+		select {
+		case <-time.After(d):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	limit, ok := parseRateLimit(resp.Header, now)
+	if !ok {
+		return resp, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.limits[host] = limit
+	t.save()
+	if left := limit.RequestsRemaining; left > 0 && left <= lowRequests && limit.RequestsReset.After(now) && !t.warned[host] {
+		t.warned[host] = true
+		slog.Warn("Few requests left before the rate limit resets", "host", host, "remaining", left, "reset", limit.RequestsReset.Sub(now).Round(time.Second))
+	}
+	return resp, nil
+}
+
+// load reads the state file the first time it's called. t.mu must be held.
+func (t *rateLimitTransport) load() {
+	if t.limits != nil {
+		return
+	}
+	t.limits, t.warned = map[string]rateLimit{}, map[string]bool{}
+	if t.path == "" {
+		return
+	}
+	if data, err := os.ReadFile(t.path); err == nil {
+		if err := json.Unmarshal(data, &t.limits); err != nil {
+			slog.Debug("ignoring rate limit state", "path", t.path, "error", err)
+			t.limits = map[string]rateLimit{}
+		}
+	}
+}
+
+// save writes the state file, replacing it atomically. t.mu must be held.
+// Failures only lose the state, so they're just logged.
+func (t *rateLimitTransport) save() {
+	if t.path == "" {
+		return
+	}
+	data, err := json.Marshal(t.limits)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(t.path), 0700)
+	}
+	tmp := t.path + ".tmp"
+	if err == nil {
+		err = os.WriteFile(tmp, data, 0600)
+	}
+	if err == nil {
+		err = os.Rename(tmp, t.path)
+	}
+	if err != nil {
+		slog.Debug("failed to save rate limit state", "path", t.path, "error", err)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	now := time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    rateLimit
+		ok      bool
+	}{
+		{"anthropic", map[string]string{
+			"anthropic-ratelimit-requests-limit":     "50",
+			"anthropic-ratelimit-requests-remaining": "8",
+			"anthropic-ratelimit-requests-reset":     "2025-01-02T15:00:30Z",
+			"anthropic-ratelimit-tokens-remaining":   "12000",
+			"anthropic-ratelimit-tokens-reset":       "2025-01-02T15:00:05Z",
+		}, rateLimit{
+			RequestsLimit: 50, RequestsRemaining: 8, RequestsReset: now.Add(30 * time.Second),
+			TokensRemaining: 12000, TokensReset: now.Add(5 * time.Second),
+		}, true},
+		{"openai", map[string]string{
+			"x-ratelimit-limit-requests":     "500",
+			"x-ratelimit-remaining-requests": "0",
+			"x-ratelimit-reset-requests":     "120ms",
+			"x-ratelimit-remaining-tokens":   "149984",
+			"x-ratelimit-reset-tokens":       "6m0s",
+		}, rateLimit{
+			RequestsLimit: 500, RequestsReset: now.Add(120 * time.Millisecond),
+			TokensRemaining: 149984, TokensReset: now.Add(6 * time.Minute),
+		}, true},
+		{"retry after", map[string]string{"retry-after": "20"}, rateLimit{RetryAfter: now.Add(20 * time.Second)}, true},
+		{"none", map[string]string{"content-type": "application/json"}, rateLimit{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for name, value := range tt.headers {
+				h.Set(name, value)
+			}
+			got, ok := parseRateLimit(h, now)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("parseRateLimit = %+v, %v, want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name  string
+		limit rateLimit
+		want  time.Duration
+	}{
+		{"requests left", rateLimit{RequestsRemaining: 3, RequestsReset: now.Add(time.Minute), TokensRemaining: 100}, 0},
+		{"out of requests", rateLimit{RequestsReset: now.Add(time.Second), TokensRemaining: 100}, time.Second},
+		{"out of tokens", rateLimit{RequestsRemaining: 3, TokensReset: now.Add(2 * time.Second)}, 2 * time.Second},
+		{"retry after", rateLimit{RequestsRemaining: 3, TokensRemaining: 100, RetryAfter: now.Add(5 * time.Second)}, 5 * time.Second},
+		{"reset passed", rateLimit{RequestsReset: now.Add(-time.Second)}, 0},
+		{"unknown", rateLimit{}, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.limit.wait(now); got != tt.want {
+			t.Errorf("%s: wait = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	// The provider has no requests left for the next 200ms
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-ratelimit-remaining-requests", "0")
+		w.Header().Set("x-ratelimit-reset-requests", "200ms")
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "ratelimits.json")
+	client := &http.Client{Transport: &rateLimitTransport{base: http.DefaultTransport, path: path}}
+	if _, err := client.Get(server.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	// A later run reads the limit back and waits for it to reset
+	client = &http.Client{Transport: &rateLimitTransport{base: http.DefaultTransport, path: path}}
+	start := time.Now()
+	if _, err := client.Get(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("second request sent after %v, want it to wait for the reset", elapsed)
+	}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/internal/index"
+	"github.com/jamesob/llm-cli/internal/paths"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+const (
+	indexUsage  = "usage: llm index <dir> ..."
+	recallUsage = "usage: llm recall [--top N] \"<question>\""
+
+	// chunkBytes is roughly how much text goes in each indexed chunk
+	chunkBytes = 1500
+
+	// embedBatch is how many chunks are embedded per request
+	embedBatch = 32
+)
+
+// indexExtensions are the kinds of file llm index reads
+var indexExtensions = []string{".md", ".markdown", ".txt", ".rst", ".org", ".adoc"}
+
+// indexPath returns the path of the notes index
+func indexPath() (string, error) {
+	dir, err := paths.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "index.json"), nil
+}
+
+// embeddingClient returns a client for embedding_model, or for the usual
+// provider's embedding model
+func embeddingClient(cfg *config.Config) (*llm.Client, error) {
+	if name := cfg.String("embedding_model"); name != "" {
+		return modelClient(cfg, name)
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client.Model = llm.DefaultEmbeddingModel(client.Provider)
+	if client.Model == "" {
+		return nil, fmt.Errorf(`%v has no embeddings API; set embedding_model, e.g. to "text-embedding-3-small" or "nomic-embed-text"`, client.Provider)
+	}
+	return client, nil
+}
+
+// runIndex embeds the text and markdown files under each directory given
+// into the notes index, skipping files unchanged since they were indexed
+func runIndex(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	var logs logFlags
+	flagSet := flag.NewFlagSet("llm index", flag.ContinueOnError)
+	logs.register(flagSet)
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if flagSet.NArg() == 0 {
+		return usageError(indexUsage)
+	}
+	if err := logs.setup(); err != nil {
+		return err
+	}
+
+	embedder, err := embeddingClient(cfg)
+	if err != nil {
+		return err
+	}
+	path, err := indexPath()
+	if err != nil {
+		return err
+	}
+	ix, err := index.Load(path)
+	if err != nil {
+		return err
+	}
+	if ix.Model != "" && ix.Model != embedder.Model {
+		return fmt.Errorf("the index was built with %s; to use %s, delete %s and index again", ix.Model, embedder.Model, path)
+	}
+	ix.Model = embedder.Model
+
+	ctx, stop := interruptible(context.Background())
+	defer stop()
+	for _, dir := range flagSet.Args() {
+		if dir, err = filepath.Abs(dir); err != nil {
+			return err
+		}
+		changed, removed, err := indexDir(ctx, embedder, ix, dir)
+		if err = interrupted(ctx, err); errors.Is(err, errInterrupted) {
+			// Keep the files indexed before Ctrl-C
+			if err := ix.Save(path); err != nil {
+				return err
+			}
+			fmt.Fprintf(notices, "%s: indexed %d changed file(s) before stopping\n", dir, changed)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(notices, "%s: indexed %d changed file(s), dropped %d removed\n", dir, changed, removed)
+		// Save after each directory so an error later doesn't lose the work
+		if err := ix.Save(path); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(notices, "%d chunks from %d files in %s\n", len(ix.Chunks), len(ix.Files), path)
+	return nil
+}
+
+// indexDir updates ix with the files under dir, returning how many were
+// (re)indexed and how many had been removed
+func indexDir(ctx context.Context, embedder *llm.Client, ix *index.Index, dir string) (changed, removed int, err error) {
+	files, err := noteFiles(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+	for path := range ix.Files {
+		if strings.HasPrefix(path, dir+string(filepath.Separator)) && !slices.Contains(files, path) {
+			ix.Remove(path)
+			removed++
+		}
+	}
+
+	for i, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return changed, removed, err
+		}
+		if mod, ok := ix.Files[path]; ok && mod.Equal(info.ModTime()) {
+			continue
+		}
+		fmt.Fprintf(notices, "\r[%d/%d] %s\033[K", i+1, len(files), filepath.Base(path))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return changed, removed, err
+		}
+		chunks, err := embedFile(ctx, embedder, path, string(data))
+		if err != nil {
+			fmt.Fprintln(notices)
+			return changed, removed, fmt.Errorf("%s: %v", path, err)
+		}
+		ix.Remove(path)
+		ix.Chunks = append(ix.Chunks, chunks...)
+		ix.Files[path] = info.ModTime()
+		changed++
+	}
+	if changed > 0 {
+		fmt.Fprintln(notices)
+	}
+	return changed, removed, nil
+}
+
+// noteFiles returns the files under dir with one of indexExtensions,
+// skipping hidden files and directories
+func noteFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && slices.Contains(indexExtensions, strings.ToLower(filepath.Ext(path))) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// embedFile splits a file's text into chunks and embeds them
+func embedFile(ctx context.Context, embedder *llm.Client, path, text string) ([]index.Chunk, error) {
+	pieces := index.Split(text, chunkBytes)
+	var chunks []index.Chunk
+	for len(pieces) > 0 {
+		batch := pieces[:min(embedBatch, len(pieces))]
+		pieces = pieces[len(batch):]
+		texts := make([]string, len(batch))
+		for i, p := range batch {
+			texts[i] = p.Text
+		}
+		vectors, err := embedder.Embed(ctx, texts)
+		if err != nil {
+			return nil, err
+		}
+		for i, p := range batch {
+			chunks = append(chunks, index.Chunk{Path: path, Line: p.Line, Text: p.Text, Vector: vectors[i]})
+		}
+	}
+	return chunks, nil
+}
+
+// runRecall answers a question with the closest chunks of the notes index
+// as context
+func runRecall(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	opts := &options{mode: llm.RecallMode}
+	var top int
+	flagSet := flag.NewFlagSet("llm recall", flag.ContinueOnError)
+	flagSet.IntVar(&top, "top", 5, "Number of chunks to answer from")
+	flagSet.StringVar(&opts.model, "m", "", "Answer with this model instead of the usual one")
+	flagSet.StringVar(&opts.outputLang, "output-lang", cfg.String("output_lang"), "Language to answer in (default: your locale's)")
+	flagSet.BoolVar(&opts.yes, "y", false, "Don't ask before sending large or sensitive notes")
+	flagSet.BoolVar(&opts.noPager, "no-pager", false, "Never pipe output through a pager")
+	opts.log.register(flagSet)
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	opts.query = expandAliases(strings.Join(flagSet.Args(), " "), cfg)
+	if opts.query == "" || top < 1 {
+		return usageError(recallUsage)
+	}
+	if err := opts.log.setup(); err != nil {
+		return err
+	}
+
+	path, err := indexPath()
+	if err != nil {
+		return err
+	}
+	ix, err := index.Load(path)
+	if err != nil {
+		return err
+	}
+	if len(ix.Chunks) == 0 {
+		return errors.New("nothing is indexed yet; run: llm index <dir>")
+	}
+	embedder, err := embeddingClient(cfg)
+	if err != nil {
+		return err
+	}
+	if embedder.Model != ix.Model {
+		return fmt.Errorf("the index was built with %s, not %s; set embedding_model to match", ix.Model, embedder.Model)
+	}
+	var client *llm.Client
+	if opts.model != "" {
+		client, err = modelClient(cfg, opts.model)
+	} else {
+		client, err = newClient(cfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	vectors, err := embedder.Embed(ctx, []string{opts.query})
+	if err != nil {
+		return err
+	}
+	results := ix.Search(vectors[0], top)
+	sys := llm.System{}
+	addOutputLanguage(&sys, opts)
+	for _, r := range results {
+		sys.Attachments = append(sys.Attachments, llm.Attachment{
+			Name:    r.Path,
+			Content: r.Text,
+			Title:   fmt.Sprintf("From %s, line %d", r.Path, r.Line),
+		})
+	}
+
+	response, err := ask(ctx, cfg, client, opts, sys)
+	if err != nil {
+		return err
+	}
+	printResponse(opts, response)
+	fmt.Fprintln(notices, "\nSources:")
+	for _, r := range results {
+		fmt.Fprintf(notices, "  %s:%d (%.2f)\n", r.Path, r.Line, r.Score)
+	}
+	return nil
+}
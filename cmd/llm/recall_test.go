@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/index"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestIndexDir(t *testing.T) {
+	var embedded []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req llm.OllamaEmbedRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		embedded = append(embedded, req.Input...)
+		var resp llm.OllamaEmbedResponse
+		for range req.Input {
+			resp.Embeddings = append(resp.Embeddings, []float32{1, 0})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+	embedder := &llm.Client{Provider: llm.Ollama, Model: "nomic-embed-text", Endpoint: srv.URL}
+
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.md")
+	os.WriteFile(notes, []byte("# Backups\n\nRestic runs nightly."), 0644)
+	os.WriteFile(filepath.Join(dir, "image.png"), []byte("not text"), 0644)
+	os.Mkdir(filepath.Join(dir, ".git"), 0755)
+	os.WriteFile(filepath.Join(dir, ".git", "README.md"), []byte("hidden"), 0644)
+
+	ix := &index.Index{Files: map[string]time.Time{}}
+	changed, removed, err := indexDir(context.Background(), embedder, ix, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 1 || removed != 0 || len(ix.Chunks) != 1 || ix.Chunks[0].Path != notes {
+		t.Fatalf("changed %d, removed %d, chunks %+v", changed, removed, ix.Chunks)
+	}
+	if !slices.Equal(embedded, []string{"# Backups\n\nRestic runs nightly."}) {
+		t.Errorf("embedded %q", embedded)
+	}
+
+	// Unchanged files aren't embedded again, and deleted ones are dropped
+	embedded = nil
+	if changed, _, _ = indexDir(context.Background(), embedder, ix, dir); changed != 0 || embedded != nil {
+		t.Errorf("re-indexed unchanged files: %q", embedded)
+	}
+	os.Remove(notes)
+	if _, removed, _ = indexDir(context.Background(), embedder, ix, dir); removed != 1 || len(ix.Chunks) != 0 {
+		t.Errorf("removed %d, chunks %+v", removed, ix.Chunks)
+	}
+}
+
+func TestEmbeddingClient(t *testing.T) {
+	for _, name := range []string{"ANTHROPIC_API_KEY", "OPENAI_API_KEY", "OLLAMA_MODEL", "LLM_REPLAY_DIR"} {
+		t.Setenv(name, "")
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant")
+	if _, err := embeddingClient(nil); err == nil || !strings.Contains(err.Error(), "embedding_model") {
+		t.Errorf("expected Claude to need embedding_model, got %v", err)
+	}
+
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("OLLAMA_MODEL", "llama3")
+	client, err := embeddingClient(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Provider != llm.Ollama || client.Model != "nomic-embed-text" {
+		t.Errorf("got %v %s", client.Provider, client.Model)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/shellhistory"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// recentCommands returns the user's last n shell commands as an
+// attachment, for --recent
+func recentCommands(shell string, n int) (llm.Attachment, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return llm.Attachment{}, err
+	}
+	entries, source, err := shellhistory.Recent(shell, home, os.Getenv, n)
+	if err != nil {
+		return llm.Attachment{}, err
+	}
+	return llm.Attachment{
+		Title:   fmt.Sprintf("My last %d shell commands, oldest first (from %s)", len(entries), source),
+		Content: formatRecent(entries),
+	}, nil
+}
+
+// formatRecent writes one entry per line, with the time, directory and a
+// failing exit status when they're known
+func formatRecent(entries []shellhistory.Entry) string {
+	var b strings.Builder
+	for _, e := range entries {
+		if !e.Time.IsZero() {
+			b.WriteString(e.Time.Format("2006-01-02 15:04") + "  ")
+		}
+		if e.Dir != "" {
+			b.WriteString(e.Dir + "  ")
+		}
+		b.WriteString("$ " + e.Command)
+		if e.Exit > 0 {
+			fmt.Fprintf(&b, "  [exit %d]", e.Exit)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
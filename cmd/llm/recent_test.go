@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/shellhistory"
+)
+
+func TestFormatRecent(t *testing.T) {
+	at := time.Date(2026, 3, 4, 9, 30, 0, 0, time.Local)
+	entries := []shellhistory.Entry{
+		{Command: "ls", Exit: -1},
+		{Time: at, Dir: "/src/app", Command: "make test", Exit: 2},
+		{Time: at, Dir: "/src/app", Command: "git status", Exit: 0},
+	}
+	want := "$ ls\n" +
+		"2026-03-04 09:30  /src/app  $ make test  [exit 2]\n" +
+		"2026-03-04 09:30  /src/app  $ git status\n"
+	if got := formatRecent(entries); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/jamesob/llm-cli/internal/index"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+const (
+	// defaultRepoTokens is how much of the prompt --repo fills by default,
+	// which stays under confirm_bytes
+	defaultRepoTokens = 6000
+
+	// maxRepoFile is the largest file --repo searches
+	maxRepoFile = 256 << 10
+
+	// repoChunkBytes is the size of the excerpts --repo picks from
+	repoChunkBytes = 1200
+
+	// maxKeyFileBytes caps how much of the README and manifests is sent
+	maxKeyFileBytes = 3000
+)
+
+// repoKeyFiles are sent whole, or nearly, when they're at the root
+var repoKeyFiles = []string{
+	"README.md", "README", "README.rst", "README.txt",
+	"go.mod", "Cargo.toml", "package.json", "pyproject.toml", "setup.py",
+	"Gemfile", "pom.xml", "build.gradle", "Makefile", "CMakeLists.txt",
+}
+
+// stopWords are left out when matching a question against files
+var stopWords = []string{
+	"about", "and", "are", "can", "code", "does", "done", "for", "from",
+	"handle", "handled", "handles", "how", "the", "this", "what", "when",
+	"where", "which", "who", "why", "with",
+}
+
+// loadRepoContext describes the git repository around the working
+// directory for a question about it
+func loadRepoContext(question string, budget int) ([]llm.Attachment, error) {
+	root, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("--repo needs a git repository: %v", err)
+	}
+	out, err := gitOutput("ls-files", "--full-name", ":/")
+	if err != nil {
+		return nil, err
+	}
+	return repoContext(root, strings.Split(out, "\n"), question, budget), nil
+}
+
+// repoContext returns the file tree of the repository at root, its README
+// and manifests, and the excerpts of other files that best match the
+// question's words, until about budget tokens are used. files are paths
+// relative to root.
+func repoContext(root string, files []string, question string, budget int) []llm.Attachment {
+	var atts []llm.Attachment
+	used := 0
+	add := func(a llm.Attachment) {
+		if tokens := llm.EstimateTokens(a.Content); used+tokens <= budget {
+			atts = append(atts, a)
+			used += tokens
+		}
+	}
+
+	// The tree gets up to a quarter of the budget, at about four bytes a
+	// token
+	tree := strings.Join(files, "\n")
+	if len(tree) > budget {
+		tree = tree[:max(strings.LastIndex(tree[:budget], "\n"), 0)] + "\n..."
+	}
+	add(llm.Attachment{Title: "Files in the repository", Content: tree})
+
+	for _, name := range repoKeyFiles {
+		if !slices.Contains(files, name) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			continue
+		}
+		if len(data) > maxKeyFileBytes {
+			data = append(data[:maxKeyFileBytes], "\n..."...)
+		}
+		add(llm.Attachment{Name: name, Content: string(data)})
+	}
+
+	terms := questionTerms(question)
+	if len(terms) == 0 {
+		return atts
+	}
+	type excerpt struct {
+		path  string
+		piece index.Piece
+		text  string // lowercased
+		score float64
+	}
+	var excerpts []excerpt
+	for _, path := range files {
+		if slices.Contains(repoKeyFiles, path) {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(root, path))
+		if err != nil || !info.Mode().IsRegular() || info.Size() > maxRepoFile {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, path))
+		if err != nil || bytes.IndexByte(data, 0) >= 0 {
+			// Binary files are no use to the model
+			continue
+		}
+		for _, piece := range index.Split(string(data), repoChunkBytes) {
+			excerpts = append(excerpts, excerpt{path: path, piece: piece, text: strings.ToLower(path + "\n" + piece.Text)})
+		}
+	}
+
+	// Words found in few excerpts say more about them than common ones
+	weights := make([]float64, len(terms))
+	for i, t := range terms {
+		found := 0
+		for _, e := range excerpts {
+			if strings.Contains(e.text, t) {
+				found++
+			}
+		}
+		weights[i] = math.Log(float64(len(excerpts)+1) / float64(found+1))
+	}
+	for i := range excerpts {
+		e := &excerpts[i]
+		for j, t := range terms {
+			if n := strings.Count(e.text, t); n > 0 {
+				e.score += weights[j] * (1 + math.Log(float64(n)))
+			}
+		}
+	}
+	excerpts = slices.DeleteFunc(excerpts, func(e excerpt) bool { return e.score <= 0 })
+	slices.SortStableFunc(excerpts, func(a, b excerpt) int { return cmp.Compare(b.score, a.score) })
+	for _, e := range excerpts {
+		add(llm.Attachment{
+			Name:    e.path,
+			Content: e.piece.Text,
+			Title:   fmt.Sprintf("From %s, line %d", e.path, e.piece.Line),
+		})
+	}
+	return atts
+}
+
+// questionTerms returns the distinctive words of a question, lowercased and
+// with common endings removed, so that "parsing" matches "parseArgs"
+func questionTerms(question string) []string {
+	var terms []string
+	words := strings.FieldsFunc(strings.ToLower(question), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for _, w := range words {
+		if slices.Contains(stopWords, w) {
+			continue
+		}
+		for _, suffix := range []string{"ing", "ed", "es", "s"} {
+			if stem, ok := strings.CutSuffix(w, suffix); ok && len(stem) >= 4 {
+				w = stem
+				break
+			}
+		}
+		if len(w) >= 3 && !slices.Contains(terms, w) {
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestQuestionTerms(t *testing.T) {
+	got := questionTerms("Where is flag parsing handled? And the config files")
+	want := []string{"flag", "pars", "config", "file"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRepoContext(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"README.md":         "# tool\n\nDoes things.",
+		"go.mod":            "module example.com/tool",
+		"cmd/tool/flags.go": "package main\n\nfunc parseArgs() {}\n",
+		"cmd/tool/main.go":  "package main\n\nfunc main() {}\n",
+		"docs/logo.png":     "\x89PNG\x00\x00flag",
+	}
+	var names []string
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	atts := repoContext(root, names, "where is flag parsing handled", 2000)
+	var titles []string
+	for _, a := range atts {
+		titles = append(titles, a.Title+a.Name)
+	}
+	want := []string{"Files in the repository", "README.md", "go.mod", "From cmd/tool/flags.go, line 1cmd/tool/flags.go"}
+	if !slices.Equal(titles, want) {
+		t.Errorf("got attachments %q, want %q", titles, want)
+	}
+	if !strings.Contains(atts[0].Content, "cmd/tool/main.go") {
+		t.Errorf("tree missing files:\n%s", atts[0].Content)
+	}
+
+	// A small budget keeps only what fits
+	atts = repoContext(root, names, "where is flag parsing handled", 5)
+	if len(atts) != 1 || !strings.HasSuffix(atts[0].Content, "...") {
+		t.Errorf("got %+v", atts)
+	}
+}
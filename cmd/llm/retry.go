@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/internal/history"
+	"github.com/jamesob/llm-cli/pkg/llm"
+	"github.com/jamesob/llm-cli/pkg/render"
+)
+
+// retryLast asks client the last query in one of queryModes again and, on
+// a terminal, shows the previous answer above the new one. Piped input and
+// attachments aren't in the history, so only the query and the system
+// context are sent, along with the previous answer if it was marked bad.
+func retryLast(ctx context.Context, cfg *config.Config, client *llm.Client, opts *options) error {
+	last, err := lastQuery(cfg)
+	if err != nil {
+		return err
+	}
+	mode, err := llm.ParseMode(last.Mode)
+	if err != nil {
+		return err
+	}
+
+	sys := llm.DetectSystem()
+	if last.Feedback == "bad" {
+		note := "Previously rejected answer: " + last.Response
+		if last.Reason != "" {
+			note += "\nReason: " + last.Reason
+		}
+		sys.Notes = append(sys.Notes, note)
+	}
+	prompt := llm.BuildPrompt(mode, sys, last.Query)
+	if !opts.noRedact {
+		prompt, _ = llm.Redact(prompt)
+	}
+	if err := moderate(ctx, cfg, prompt); err != nil {
+		return err
+	}
+	fmt.Fprintf(notices, "Asking %s again: %s\n", client.ModelName(), last.Query)
+	start := time.Now()
+	ctx, stop := interruptible(ctx)
+	response, err := client.Query(ctx, prompt)
+	stop()
+	if err != nil {
+		return interrupted(ctx, err)
+	}
+
+	retried := *opts
+	retried.mode = mode
+	if isTerminal(os.Stdout) {
+		fmt.Printf("%sPrevious answer (%s):%s\n", render.Bold, last.Model, render.Reset)
+		printResponse(&retried, last.Response)
+		fmt.Printf("\n%sNew answer (%s):%s\n", render.Bold, client.ModelName(), render.Reset)
+	}
+	printResponse(&retried, response)
+
+	if err := saveHistory(cfg, history.Entry{
+		Time:     start,
+		Mode:     last.Mode,
+		Provider: client.Provider.String(),
+		Model:    client.ModelName(),
+		Query:    last.Query,
+		Response: response,
+	}); err != nil {
+		slog.Warn("Failed to save history", "error", err)
+	}
+	return nil
+}
+
+// lastQuery returns the most recent history entry in one of queryModes
+func lastQuery(cfg *config.Config) (history.Entry, error) {
+	store, err := openHistory(cfg, false)
+	if err != nil {
+		return history.Entry{}, err
+	}
+	entries, err := store.Entries()
+	if err != nil {
+		return history.Entry{}, err
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		for _, m := range queryModes {
+			if entries[i].Mode == m.String() && entries[i].Query != "" {
+				return entries[i], nil
+			}
+		}
+	}
+	return history.Entry{}, errors.New("no previous query in the history to retry")
+}
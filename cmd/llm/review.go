@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+	"github.com/jamesob/llm-cli/pkg/render"
+)
+
+// runReview reviews a diff read from stdin, or the output of git diff
+func runReview(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	opts := &options{mode: llm.ReviewMode}
+	var asJSON bool
+	flagSet := flag.NewFlagSet("llm review", flag.ContinueOnError)
+	flagSet.BoolVar(&asJSON, "json", false, "Print the review as JSON")
+	flagSet.BoolVar(&opts.yes, "y", false, "Don't ask before sending a large diff")
+	flagSet.BoolVar(&opts.noRedact, "no-redact", false, "Send the diff without redacting secrets")
+	flagSet.BoolVar(&opts.noPager, "no-pager", false, "Never pipe output through a pager")
+	opts.log.register(flagSet)
+	flagSet.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: llm review [--json] [git diff arguments], or <diff> | llm review [--json]\n")
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	opts.query = "Review this change."
+
+	diff, err := readStdin()
+	if err != nil {
+		return err
+	}
+	if diff == "" {
+		// Uncommitted changes by default, or e.g. "main..." or "HEAD~3"
+		gitArgs := append([]string{"diff", "--no-color", "--no-ext-diff"}, flagSet.Args()...)
+		if flagSet.NArg() == 0 {
+			gitArgs = append(gitArgs, "HEAD")
+		}
+		if diff, err = gitOutput(gitArgs...); err != nil {
+			return err
+		}
+	}
+	if diff == "" {
+		return errors.New("no changes to review")
+	}
+
+	if err := opts.log.setup(); err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	sys := llm.DetectSystem()
+	if wd, err := os.Getwd(); err == nil {
+		project := llm.DetectProject(wd)
+		sys.Project = &project
+	}
+	sys.Attachments = []llm.Attachment{{Title: "Diff", Content: diff}}
+
+	response, err := ask(context.Background(), cfg, client, opts, sys)
+	if err != nil {
+		return err
+	}
+	review, err := llm.ParseReview(response)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(review)
+	}
+	printOutput(render.Markdown(review.Markdown()), opts.noPager)
+	return nil
+}
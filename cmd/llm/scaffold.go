@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+	"github.com/jamesob/llm-cli/pkg/render"
+)
+
+// printFiles writes each file of a multi-file answer under its path,
+// highlighted for its language
+func printFiles(w io.Writer, files []llm.File) {
+	for i, f := range files {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s%s%s\n", render.Bold, f.Path, render.Reset)
+		lang, _ := llm.ExtensionLanguage(filepath.Ext(f.Path))
+		fmt.Fprintln(w, render.Code(f.Content, lang.ID))
+	}
+}
+
+// writeFiles lists the files of a multi-file answer and writes them under
+// dir once the user agrees, unless yes is set. Existing files are only
+// replaced with force, and paths outside dir are refused.
+func writeFiles(dir string, files []llm.File, yes, force bool) error {
+	var existing []string
+	fmt.Fprintf(os.Stderr, "Files to write under %s:\n", dir)
+	for _, f := range files {
+		if !filepath.IsLocal(filepath.FromSlash(f.Path)) {
+			return fmt.Errorf("refusing to write %s, which is outside %s", f.Path, dir)
+		}
+		status := "new"
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(f.Path))); err == nil {
+			status = "overwrite"
+			existing = append(existing, f.Path)
+		}
+		fmt.Fprintf(os.Stderr, "  %-9s  %s (%d lines)\n", status, f.Path, strings.Count(f.Content, "\n")+1)
+	}
+	if len(existing) > 0 && !force {
+		return fmt.Errorf("%s already exists; pass --force to overwrite it", strings.Join(existing, ", "))
+	}
+	if !yes {
+		ok, err := confirm(fmt.Sprintf("Write %d files under %s?", len(files), dir))
+		if err != nil {
+			return fmt.Errorf("%v; pass --yes to write without confirming", err)
+		}
+		if !ok {
+			return errAborted
+		}
+	}
+
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(f.Content+"\n"), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %v", f.Path, err)
+		}
+	}
+	fmt.Fprintf(notices, "Wrote %d files under %s\n", len(files), dir)
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestWriteFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "svc")
+	files := []llm.File{
+		{Path: "go.mod", Content: "module example.com/svc"},
+		{Path: "cmd/svc/main.go", Content: "package main"},
+	}
+	if err := writeFiles(dir, files, true, false); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "cmd", "svc", "main.go"))
+	if err != nil || string(data) != "package main\n" {
+		t.Errorf("main.go = %q, %v", data, err)
+	}
+
+	if err := writeFiles(dir, files, true, false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("overwriting without --force gave %v", err)
+	}
+	if err := writeFiles(dir, files, true, true); err != nil {
+		t.Errorf("overwriting with --force: %v", err)
+	}
+	for _, path := range []string{"../escape.txt", "/etc/passwd"} {
+		if err := writeFiles(dir, []llm.File{{Path: path}}, true, true); err == nil {
+			t.Errorf("wrote %s", path)
+		}
+	}
+}
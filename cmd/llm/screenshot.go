@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// screenshotCommand returns the command that lets the user select a region
+// of the screen and saves it to path as a PNG, using the first tool found
+func screenshotCommand(goos string, lookPath func(string) (string, error), getenv func(string) string, path string) ([]string, error) {
+	if goos == "darwin" {
+		return []string{"screencapture", "-i", "-x", path}, nil
+	}
+	if goos == "windows" {
+		return nil, errors.New("--screenshot isn't supported on Windows; save a screenshot and pass it with --image")
+	}
+
+	var candidates [][]string
+	if getenv("WAYLAND_DISPLAY") != "" {
+		candidates = append(candidates, []string{"sh", "-c", `grim -g "$(slurp)" "$1"`, "sh", path})
+	}
+	candidates = append(candidates,
+		[]string{"spectacle", "-r", "-b", "-n", "-o", path},
+		[]string{"gnome-screenshot", "-a", "-f", path},
+		[]string{"maim", "-s", path},
+		[]string{"import", path},
+	)
+	for _, c := range candidates {
+		tool := c[0]
+		if tool == "sh" {
+			// grim needs slurp to pick the region
+			if _, err := lookPath("slurp"); err != nil {
+				continue
+			}
+			tool = "grim"
+		}
+		if _, err := lookPath(tool); err == nil {
+			return c, nil
+		}
+	}
+	return nil, errors.New("no screenshot tool found; install grim and slurp, spectacle, gnome-screenshot or maim")
+}
+
+// takeScreenshot lets the user select a region of the screen and returns
+// the path of a temporary PNG holding it, which the caller removes
+func takeScreenshot() (string, error) {
+	dir, err := os.MkdirTemp("", "llm-screenshot")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "screenshot.png")
+	args, err := screenshotCommand(runtime.GOOS, exec.LookPath, os.Getenv, path)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	fmt.Fprintln(os.Stderr, "Select the part of the screen to ask about")
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+	err = cmd.Run()
+	if info, statErr := os.Stat(path); statErr != nil || info.Size() == 0 {
+		// Cancelling the selection leaves no file, and some tools still
+		// exit successfully
+		os.RemoveAll(dir)
+		if err != nil {
+			return "", fmt.Errorf("%s failed: %v", args[0], err)
+		}
+		return "", errors.New("no screenshot was taken")
+	}
+	return path, nil
+}
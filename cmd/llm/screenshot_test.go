@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestScreenshotCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		goos      string
+		installed []string
+		wayland   bool
+		want      string
+	}{
+		{"macOS", "darwin", nil, false, "screencapture"},
+		{"wayland", "linux", []string{"grim", "slurp", "spectacle"}, true, "sh"},
+		{"wayland without slurp", "linux", []string{"grim", "spectacle"}, true, "spectacle"},
+		{"x11 ignores grim", "linux", []string{"grim", "slurp", "maim"}, false, "maim"},
+		{"gnome", "linux", []string{"gnome-screenshot", "import"}, false, "gnome-screenshot"},
+		{"nothing installed", "linux", nil, false, ""},
+		{"windows", "windows", nil, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookPath := func(name string) (string, error) {
+				if slices.Contains(tt.installed, name) {
+					return "/usr/bin/" + name, nil
+				}
+				return "", errors.New("not found")
+			}
+			getenv := func(name string) string {
+				if name == "WAYLAND_DISPLAY" && tt.wayland {
+					return "wayland-0"
+				}
+				return ""
+			}
+			args, err := screenshotCommand(tt.goos, lookPath, getenv, "/tmp/shot.png")
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("expected an error, got %q", args)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if args[0] != tt.want || !slices.Contains(args, "/tmp/shot.png") {
+				t.Errorf("got %q, want %s saving to the path", args, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// defaultListen is where llm serve listens unless --listen or serve_listen
+// says otherwise
+const defaultListen = "127.0.0.1:8089"
+
+// maxChatRequest caps the size of a chat completion request body
+const maxChatRequest = 4 << 20
+
+// runServe answers OpenAI-style chat completion requests with the
+// configured provider, so tools that speak OpenAI's API can use it
+func runServe(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	var listen string
+	var logs logFlags
+	flagSet := flag.NewFlagSet("llm serve", flag.ContinueOnError)
+	flagSet.StringVar(&listen, "listen", cmp.Or(cfg.String("serve_listen"), defaultListen), "Address to listen on")
+	logs.register(flagSet)
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if flagSet.NArg() > 0 {
+		return usageError("usage: llm serve [--listen ADDR]")
+	}
+
+	if err := logs.setup(); err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", listen)
+	if err != nil {
+		return err
+	}
+	token := cfg.String("serve_token")
+	if !isLoopback(ln.Addr()) && token == "" {
+		ln.Close()
+		return fmt.Errorf("%s is reachable from other machines: set serve_token so that only clients with it can use your API key", ln.Addr())
+	}
+	fmt.Fprintf(os.Stderr, "Serving %v %s at http://%s/v1\n", client.Provider, client.ModelName(), ln.Addr())
+
+	srv := &http.Server{Handler: guardServe(token, newServeHandler(cfg, client)), ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// isLoopback reports whether addr only accepts connections from this machine
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
+
+// guardServe rejects requests from other machines and those a web page
+// could have made: without a token, requests that don't come from this
+// machine or whose Host isn't this machine, which DNS rebinding needs, and
+// POSTs that aren't JSON, which cross-origin forms send. With a token, every
+// request must carry it as a bearer token.
+func guardServe(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+				writeAPIError(w, http.StatusUnauthorized, "invalid or missing bearer token")
+				return
+			}
+		} else if !isLoopbackHost(r.RemoteAddr) {
+			writeAPIError(w, http.StatusForbidden, "requests from other machines need serve_token")
+			return
+		} else if !isLoopbackHost(r.Host) {
+			writeAPIError(w, http.StatusForbidden, fmt.Sprintf("host %q isn't localhost; set serve_token to serve other hosts", r.Host))
+			return
+		}
+		if r.Method == http.MethodPost {
+			if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+				writeAPIError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoopbackHost reports whether a Host header, or a remote address, names
+// this machine
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// chatRequest is the part of an OpenAI chat completion request llm serve
+// understands. Other fields, including the model, are ignored.
+type chatRequest struct {
+	Messages []struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"messages"`
+	Stream bool `json:"stream"`
+}
+
+// chatContent returns the text of a message's content, which is either a
+// string or a list of parts
+func chatContent(raw json.RawMessage) (string, error) {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", errors.New("content must be a string or a list of parts")
+	}
+	var texts []string
+	for _, p := range parts {
+		if p.Type != "text" {
+			return "", fmt.Errorf("%s content isn't supported", p.Type)
+		}
+		texts = append(texts, p.Text)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// newServeHandler returns the HTTP API served by llm serve. Messages are
+// redacted and moderated as prompts from the command line are.
+func newServeHandler(cfg *config.Config, client *llm.Client) http.Handler {
+	var requests atomic.Int64
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/models", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"object": "list",
+			"data": []map[string]any{{
+				"id":       client.ModelName(),
+				"object":   "model",
+				"owned_by": client.Provider.String(),
+			}},
+		})
+	})
+	mux.HandleFunc("POST /v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxChatRequest)).Decode(&req); err != nil {
+			writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+			return
+		}
+		var messages []llm.Message
+		var contents []string
+		redacted := 0
+		for _, m := range req.Messages {
+			content, err := chatContent(m.Content)
+			if err != nil {
+				writeAPIError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			var n int
+			content, n = llm.Redact(content)
+			redacted += n
+			messages = append(messages, llm.Message{Role: m.Role, Content: content})
+			contents = append(contents, content)
+		}
+		if len(messages) == 0 {
+			writeAPIError(w, http.StatusBadRequest, "messages is required")
+			return
+		}
+		if redacted > 0 {
+			slog.Info("Redacted possible secrets from the messages", "count", redacted)
+		}
+		if err := moderate(r.Context(), cfg, strings.Join(contents, "\n\n")); err != nil {
+			status := http.StatusBadGateway
+			if errors.Is(err, errFlagged) {
+				status = http.StatusBadRequest
+			}
+			writeAPIError(w, status, err.Error())
+			return
+		}
+
+		slog.Debug("chat completion", "messages", len(messages), "stream", req.Stream)
+		answer, err := client.Chat(r.Context(), messages)
+		if err != nil {
+			writeAPIError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+
+		id := fmt.Sprintf("chatcmpl-llm-%d", requests.Add(1))
+		created := time.Now().Unix()
+		if req.Stream {
+			writeChatStream(w, id, created, client.ModelName(), answer)
+			return
+		}
+		promptTokens := 0
+		for _, m := range messages {
+			promptTokens += llm.EstimateTokens(m.Content)
+		}
+		completionTokens := llm.EstimateTokens(answer)
+		writeJSON(w, http.StatusOK, map[string]any{
+			"id":      id,
+			"object":  "chat.completion",
+			"created": created,
+			"model":   client.ModelName(),
+			"choices": []map[string]any{{
+				"index":         0,
+				"message":       map[string]string{"role": "assistant", "content": answer},
+				"finish_reason": "stop",
+			}},
+			"usage": map[string]int{
+				"prompt_tokens":     promptTokens,
+				"completion_tokens": completionTokens,
+				"total_tokens":      promptTokens + completionTokens,
+			},
+		})
+	})
+	return mux
+}
+
+// writeChatStream sends answer as server-sent events, for clients that
+// always stream. The whole answer arrives in one chunk.
+func writeChatStream(w http.ResponseWriter, id string, created int64, model, answer string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	chunk := func(delta map[string]string, finish any) {
+		data, _ := json.Marshal(map[string]any{
+			"id":      id,
+			"object":  "chat.completion.chunk",
+			"created": created,
+			"model":   model,
+			"choices": []map[string]any{{"index": 0, "delta": delta, "finish_reason": finish}},
+		})
+		fmt.Fprintf(w, "data: %s\n\n", data)
+	}
+	chunk(map[string]string{"role": "assistant", "content": answer}, nil)
+	chunk(map[string]string{}, "stop")
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+// writeJSON sends v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeAPIError sends an error shaped like OpenAI's
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	errType := "api_error"
+	if status == http.StatusBadRequest {
+		errType = "invalid_request_error"
+	}
+	writeJSON(w, status, map[string]any{
+		"error": map[string]string{"message": message, "type": errType},
+	})
+}
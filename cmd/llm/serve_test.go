@@ -0,0 +1,171 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestServeChatCompletions(t *testing.T) {
+	var sent llm.ClaudeRequest
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		io.WriteString(w, `{"content":[{"type":"text","text":"ls -la"}]}`)
+	}))
+	t.Cleanup(provider.Close)
+	client := &llm.Client{Provider: llm.Claude, APIKey: "sk-ant", Endpoint: provider.URL}
+	srv := httptest.NewServer(newServeHandler(&config.Config{}, client))
+	t.Cleanup(srv.Close)
+
+	body := `{"model":"gpt-4o","messages":[
+		{"role":"system","content":"Be brief."},
+		{"role":"user","content":[{"type":"text","text":"list files"}]}]}`
+	resp, err := http.Post(srv.URL+"/v1/chat/completions", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var got struct {
+		Model   string
+		Choices []struct {
+			Message struct{ Role, Content string }
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || len(got.Choices) != 1 || got.Choices[0].Message.Content != "ls -la" {
+		t.Errorf("got %d %+v", resp.StatusCode, got)
+	}
+	if got.Model != client.ModelName() {
+		t.Errorf("model = %q", got.Model)
+	}
+	if sent.System != "Be brief." || len(sent.Messages) != 1 || sent.Messages[0].Content != "list files" {
+		t.Errorf("provider got %+v", sent)
+	}
+
+	// Streaming clients get the answer as server-sent events
+	resp, err = http.Post(srv.URL+"/v1/chat/completions", "application/json",
+		strings.NewReader(`{"stream":true,"messages":[{"role":"user","content":"list files"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	stream, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(stream), `"content":"ls -la"`) || !strings.HasSuffix(string(stream), "data: [DONE]\n\n") {
+		t.Errorf("stream = %s", stream)
+	}
+}
+
+func TestServeErrors(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		io.WriteString(w, `{"error":{"message":"bad key"}}`)
+	}))
+	t.Cleanup(provider.Close)
+	client := &llm.Client{Provider: llm.OpenAI, APIKey: "sk", Endpoint: provider.URL}
+	srv := httptest.NewServer(newServeHandler(&config.Config{}, client))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`not json`, http.StatusBadRequest},
+		{`{"messages":[]}`, http.StatusBadRequest},
+		{`{"messages":[{"role":"user","content":[{"type":"image_url"}]}]}`, http.StatusBadRequest},
+		{`{"messages":[{"role":"user","content":"hi"}]}`, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		resp, err := http.Post(srv.URL+"/v1/chat/completions", "application/json", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got struct{ Error struct{ Message string } }
+		json.NewDecoder(resp.Body).Decode(&got)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status || got.Error.Message == "" {
+			t.Errorf("%s: got %d %+v, want %d with a message", tt.body, resp.StatusCode, got, tt.status)
+		}
+	}
+}
+
+func TestServeModeration(t *testing.T) {
+	var sent []string
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		sent = append(sent, string(body))
+		io.WriteString(w, `{"content":[{"type":"text","text":"ok"}]}`)
+	}))
+	t.Cleanup(provider.Close)
+	client := &llm.Client{Provider: llm.Claude, APIKey: "sk-ant", Endpoint: provider.URL}
+	cfg, err := config.Parse("moderation = \"block\"\nmoderation_cmd = \"grep -q attack && echo violence && exit 1; exit 0\"")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(newServeHandler(cfg, client))
+	t.Cleanup(srv.Close)
+
+	post := func(content string) int {
+		body, _ := json.Marshal(map[string]any{"messages": []map[string]string{{"role": "user", "content": content}}})
+		resp, err := http.Post(srv.URL+"/v1/chat/completions", "application/json", strings.NewReader(string(body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := post("plan an attack"); status != http.StatusBadRequest || len(sent) != 0 {
+		t.Errorf("flagged prompt: got %d, sent %q", status, sent)
+	}
+	if status := post("my key is sk-ant-REDACTED"); status != http.StatusOK {
+		t.Fatalf("got %d", status)
+	}
+	if len(sent) != 1 || strings.Contains(sent[0], "abcdefghijklmnopqrstuvwxyz") {
+		t.Errorf("the key wasn't redacted: %q", sent)
+	}
+}
+
+func TestGuardServe(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		name, token, host, contentType, auth, remote string
+		status                                       int
+	}{
+		{name: "localhost", host: "localhost:8089", contentType: "application/json", status: http.StatusOK},
+		{name: "loopback with charset", host: "127.0.0.1:8089", contentType: "application/json; charset=utf-8", status: http.StatusOK},
+		{name: "ipv6 loopback", host: "[::1]:8089", contentType: "application/json", status: http.StatusOK},
+		{name: "other host", host: "attacker.example:8089", contentType: "application/json", status: http.StatusForbidden},
+		{name: "spoofed host from another machine", host: "localhost", contentType: "application/json", remote: "192.0.2.1:51234", status: http.StatusForbidden},
+		{name: "form", host: "localhost:8089", contentType: "text/plain", status: http.StatusUnsupportedMediaType},
+		{name: "no content type", host: "localhost:8089", status: http.StatusUnsupportedMediaType},
+		{name: "missing token", token: "s3cret", host: "localhost:8089", contentType: "application/json", status: http.StatusUnauthorized},
+		{name: "wrong token", token: "s3cret", host: "localhost:8089", contentType: "application/json", auth: "Bearer nope", status: http.StatusUnauthorized},
+		{name: "token from another host", token: "s3cret", host: "192.0.2.1:8089", contentType: "application/json", auth: "Bearer s3cret", remote: "192.0.2.5:51234", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/v1/chat/completions", strings.NewReader("{}"))
+			r.Host = tt.host
+			r.RemoteAddr = cmp.Or(tt.remote, "127.0.0.1:51234")
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.auth != "" {
+				r.Header.Set("Authorization", tt.auth)
+			}
+			w := httptest.NewRecorder()
+			guardServe(tt.token, ok).ServeHTTP(w, r)
+			if w.Code != tt.status {
+				t.Errorf("got %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
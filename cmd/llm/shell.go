@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+)
+
+// shellArgs are the options that make each shell run a command given as an
+// argument
+var shellArgs = map[string][]string{
+	"sh":         {"-c"},
+	"bash":       {"-c"},
+	"dash":       {"-c"},
+	"ksh":        {"-c"},
+	"zsh":        {"-c"},
+	"fish":       {"-c"},
+	"nu":         {"-c"},
+	"pwsh":       {"-NoProfile", "-Command"},
+	"powershell": {"-NoProfile", "-Command"},
+	"cmd":        {"/C"},
+}
+
+// shellCommand returns a command that runs command with sh, or cmd.exe on
+// Windows, and is killed when ctx is done
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// userShellCommand returns a command that runs command with shell, the
+// user's shell, which the model wrote it for and verifySyntax checked it
+// with. Shells that aren't in shellArgs or aren't installed fall back to
+// shellCommand.
+func userShellCommand(ctx context.Context, shell, command string) *exec.Cmd {
+	args, ok := shellArgs[shell]
+	if !ok {
+		return shellCommand(ctx, command)
+	}
+	if _, err := exec.LookPath(shell); err != nil {
+		return shellCommand(ctx, command)
+	}
+	return exec.CommandContext(ctx, shell, append(args, command)...)
+}
@@ -0,0 +1,33 @@
+# llm shell integration for bash. Add to ~/.bashrc:
+#   eval "$(llm shell-init bash)"
+#
+# Exports the previous command and its exit status so llm can include them
+# in prompts. Set LLM_CAPTURE_STDERR=1 before the eval to also capture
+# stderr; commands will then see stderr as a pipe rather than a terminal.
+
+__llm_precmd() {
+    local status=$?
+    local cmd
+    cmd=$(HISTTIMEFORMAT= builtin history 1)
+    cmd="${cmd#"${cmd%%[![:space:]]*}"}"  # leading spaces
+    cmd="${cmd#*[[:digit:]][* ] }"        # history number
+    export LLM_LAST_COMMAND="$cmd"
+    export LLM_LAST_STATUS=$status
+    if [ -n "$__llm_stderr" ]; then
+        cp "$__llm_stderr" "$LLM_LAST_STDERR" 2>/dev/null
+        : > "$__llm_stderr"
+    fi
+    return $status
+}
+
+if [ -n "$LLM_CAPTURE_STDERR" ] && [ -z "$__llm_stderr" ]; then
+    __llm_stderr=$(mktemp "${TMPDIR:-/tmp}/llm-stderr.XXXXXX")
+    export LLM_LAST_STDERR="$__llm_stderr.last"
+    trap 'rm -f "$__llm_stderr" "$LLM_LAST_STDERR"' EXIT
+    exec 2> >(tee -a "$__llm_stderr" >&2)
+fi
+
+case ";$PROMPT_COMMAND;" in
+    *";__llm_precmd;"*) ;;
+    *) PROMPT_COMMAND="__llm_precmd${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
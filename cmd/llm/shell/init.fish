@@ -0,0 +1,11 @@
+# llm shell integration for fish. Add to ~/.config/fish/config.fish:
+#   llm shell-init fish | source
+#
+# Exports the previous command and its exit status so llm can include them
+# in prompts. Capturing stderr isn't supported in fish.
+
+function __llm_postexec --on-event fish_postexec
+    set -l last_status $status
+    set -gx LLM_LAST_COMMAND $argv[1]
+    set -gx LLM_LAST_STATUS $last_status
+end
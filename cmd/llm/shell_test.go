@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"testing"
+)
+
+func TestUserShellCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	if out, err := userShellCommand(context.Background(), "no-such-shell", "echo hi").Output(); err != nil || string(out) != "hi\n" {
+		t.Errorf("unknown shell: %q, %v, want sh to run it", out, err)
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not installed")
+	}
+	// [[ ]] is bash syntax that sh would reject
+	if out, err := userShellCommand(context.Background(), "bash", "[[ -n $BASH_VERSION ]] && echo bash").Output(); err != nil || string(out) != "bash\n" {
+		t.Errorf("bash: %q, %v", out, err)
+	}
+}
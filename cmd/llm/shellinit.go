@@ -0,0 +1,81 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+//go:embed shell/init.bash shell/init.zsh shell/init.fish
+var shellScripts embed.FS
+
+// maxStderrContext caps how much captured stderr is sent to the model
+const maxStderrContext = 4096
+
+// runShellInit prints the integration script for the named shell
+func runShellInit(args []string) error {
+	if len(args) != 1 {
+		return usageError("usage: llm shell-init <bash|zsh|fish>")
+	}
+	data, err := shellScripts.ReadFile("shell/init." + args[0])
+	if err != nil {
+		return fmt.Errorf("unsupported shell %q (supported: bash, zsh, fish)", args[0])
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// previousCommand returns what the shell integration recorded about the last
+// command, or nil if it isn't installed
+func previousCommand() *llm.CommandResult {
+	command := strings.TrimSpace(os.Getenv("LLM_LAST_COMMAND"))
+	if command == "" {
+		return nil
+	}
+
+	// Asking about an earlier llm invocation is rarely what's wanted
+	if filepath.Base(strings.Fields(command)[0]) == filepath.Base(os.Args[0]) {
+		return nil
+	}
+
+	result := &llm.CommandResult{Command: command}
+	result.ExitStatus, _ = strconv.Atoi(os.Getenv("LLM_LAST_STATUS"))
+	if path := os.Getenv("LLM_LAST_STDERR"); path != "" {
+		result.Stderr = ansiRe.ReplaceAllString(readTail(path, maxStderrContext), "")
+	}
+	return result
+}
+
+// readTail returns at most the last max bytes of the file at path, or "" if
+// it can't be read
+func readTail(path string, max int64) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return ""
+	}
+	prefix := ""
+	if info.Size() > max {
+		if _, err := f.Seek(-max, io.SeekEnd); err != nil {
+			return ""
+		}
+		prefix = "..."
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return ""
+	}
+	return prefix + strings.TrimSpace(string(data))
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShellScriptsEmbedded(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		data, err := shellScripts.ReadFile("shell/init." + shell)
+		if err != nil {
+			t.Fatalf("%s: %v", shell, err)
+		}
+		if !strings.Contains(string(data), "LLM_LAST_COMMAND") {
+			t.Errorf("%s script doesn't export LLM_LAST_COMMAND", shell)
+		}
+	}
+	if err := runShellInit([]string{"csh"}); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
+
+func TestPreviousCommand(t *testing.T) {
+	stderr := filepath.Join(t.TempDir(), "stderr")
+	os.WriteFile(stderr, []byte("\x1b[31mmake: *** No rule\x1b[0m\n"), 0644)
+
+	t.Setenv("LLM_LAST_COMMAND", "make build")
+	t.Setenv("LLM_LAST_STATUS", "2")
+	t.Setenv("LLM_LAST_STDERR", stderr)
+
+	got := previousCommand()
+	if got == nil {
+		t.Fatal("expected a previous command")
+	}
+	if got.Command != "make build" || got.ExitStatus != 2 || got.Stderr != "make: *** No rule" {
+		t.Errorf("got %+v", got)
+	}
+
+	t.Setenv("LLM_LAST_COMMAND", "")
+	if got := previousCommand(); got != nil {
+		t.Errorf("expected nil without the hook, got %+v", got)
+	}
+
+	t.Setenv("LLM_LAST_COMMAND", filepath.Base(os.Args[0])+" list files")
+	if got := previousCommand(); got != nil {
+		t.Errorf("earlier llm invocations should be ignored, got %+v", got)
+	}
+}
+
+func TestReadTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f")
+	os.WriteFile(path, []byte("0123456789"), 0644)
+
+	if got := readTail(path, 100); got != "0123456789" {
+		t.Errorf("short file: %q", got)
+	}
+	if got := readTail(path, 4); got != "...6789" {
+		t.Errorf("long file: %q", got)
+	}
+	if got := readTail(path+".missing", 4); got != "" {
+		t.Errorf("missing file: %q", got)
+	}
+}
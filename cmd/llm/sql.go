@@ -0,0 +1,135 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// introspectTimeout bounds how long reading a live database's schema may take
+const introspectTimeout = 30 * time.Second
+
+// prepareSQL adds the schema and dialect for --sql to sys. The schema comes
+// from --schema or is read from the database at --dsn, whose scheme also
+// implies the dialect.
+func prepareSQL(cfg *config.Config, opts *options, sys *llm.System) error {
+	var schema, implied string
+	switch {
+	case opts.schema != "":
+		data, err := os.ReadFile(opts.schema)
+		if err != nil {
+			return fmt.Errorf("failed to read schema: %v", err)
+		}
+		schema = string(data)
+	case opts.dsn != "":
+		var err error
+		if schema, implied, err = introspect(opts.dsn); err != nil {
+			return err
+		}
+	}
+
+	dialect, err := llm.SQLDialect(cmp.Or(opts.dialect, implied, cfg.String("sql_dialect"), "postgres"))
+	if err != nil {
+		return err
+	}
+	sys.Notes = append(sys.Notes, "SQL dialect: "+dialect)
+	if schema = strings.TrimSpace(schema); schema != "" {
+		sys.Attachments = append(sys.Attachments, llm.Attachment{Title: "Database schema", Content: schema})
+	}
+	return nil
+}
+
+// introspect dumps the table definitions of the database at dsn using the
+// database's own command-line client, returning them and the dialect
+func introspect(dsn string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), introspectTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	var dialect string
+	u, err := url.Parse(dsn)
+	switch {
+	case err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql"):
+		dialect = "postgres"
+		cmd = exec.CommandContext(ctx, "pg_dump", "--schema-only", "--no-owner", "--no-privileges", "--dbname="+dsn)
+	case err == nil && u.Scheme == "mysql":
+		dialect = "mysql"
+		args := []string{"--no-data", "--skip-comments", "--host=" + u.Hostname()}
+		if port := u.Port(); port != "" {
+			args = append(args, "--port="+port)
+		}
+		if user := u.User.Username(); user != "" {
+			args = append(args, "--user="+user)
+		}
+		cmd = exec.CommandContext(ctx, "mysqldump", append(args, strings.TrimPrefix(u.Path, "/"))...)
+		if password, ok := u.User.Password(); ok {
+			// Keep the password out of the process list
+			cmd.Env = append(os.Environ(), "MYSQL_PWD="+password)
+		}
+	case err == nil && (u.Scheme == "sqlite" || u.Scheme == "sqlite3"):
+		dialect = "sqlite"
+		cmd = exec.CommandContext(ctx, "sqlite3", "-readonly", cmp.Or(u.Opaque, u.Path), ".schema")
+	case isSQLiteFile(dsn):
+		dialect = "sqlite"
+		cmd = exec.CommandContext(ctx, "sqlite3", "-readonly", dsn, ".schema")
+	default:
+		return "", "", fmt.Errorf("unsupported --dsn %q (expected postgres://, mysql://, sqlite: or a .db file)", redactDSN(dsn))
+	}
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", "", fmt.Errorf("%s not found; install it to read the schema, or pass --schema", cmd.Args[0])
+	}
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", "", fmt.Errorf("failed to read the schema of %s: %s", redactDSN(dsn), msg)
+	}
+	return stripSQLComments(string(out)), dialect, nil
+}
+
+// isSQLiteFile reports whether path looks like an SQLite database
+func isSQLiteFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".db", ".sqlite", ".sqlite3":
+		return true
+	}
+	return false
+}
+
+// redactDSN hides the password in a DSN for messages
+func redactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil {
+		return dsn
+	}
+	return u.Redacted()
+}
+
+// stripSQLComments drops comment lines and blank lines from a schema dump,
+// which are mostly noise from the dump tool
+func stripSQLComments(schema string) string {
+	var lines []string
+	for _, line := range strings.Split(schema, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
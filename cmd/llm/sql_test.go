@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestPrepareSQL(t *testing.T) {
+	schema := filepath.Join(t.TempDir(), "schema.sql")
+	os.WriteFile(schema, []byte("CREATE TABLE users (id int);\n"), 0644)
+	cfg, _ := config.Parse(`sql_dialect = "mysql"`)
+
+	var sys llm.System
+	if err := prepareSQL(cfg, &options{schema: schema}, &sys); err != nil {
+		t.Fatal(err)
+	}
+	if len(sys.Notes) != 1 || sys.Notes[0] != "SQL dialect: MySQL" {
+		t.Errorf("notes = %q", sys.Notes)
+	}
+	if len(sys.Attachments) != 1 || !strings.Contains(sys.Attachments[0].Content, "CREATE TABLE users") {
+		t.Errorf("attachments = %+v", sys.Attachments)
+	}
+
+	sys = llm.System{}
+	if err := prepareSQL(nil, &options{dialect: "sqlite"}, &sys); err != nil || sys.Notes[0] != "SQL dialect: SQLite" {
+		t.Errorf("notes = %q, %v", sys.Notes, err)
+	}
+	if err := prepareSQL(nil, &options{dialect: "oracle"}, &sys); err == nil {
+		t.Error("expected an error for an unknown dialect")
+	}
+}
+
+func TestIntrospectSQLite(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+	db := filepath.Join(t.TempDir(), "app.db")
+	if err := exec.Command("sqlite3", db, "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);").Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dsn := range []string{db, "sqlite:" + db} {
+		schema, dialect, err := introspect(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dialect != "sqlite" || !strings.Contains(schema, "CREATE TABLE users") {
+			t.Errorf("introspect(%q) = %q, %q", dsn, schema, dialect)
+		}
+	}
+}
+
+func TestIntrospectUnsupported(t *testing.T) {
+	_, _, err := introspect("oracle://scott:tiger@db/prod")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if strings.Contains(err.Error(), "tiger") {
+		t.Errorf("error leaks the password: %v", err)
+	}
+}
+
+func TestStripSQLComments(t *testing.T) {
+	got := stripSQLComments("--\n-- PostgreSQL database dump\n--\n\nSET x = 1;\n\nCREATE TABLE t (\n    id int -- key\n);\n")
+	want := "SET x = 1;\nCREATE TABLE t (\n    id int -- key\n);"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
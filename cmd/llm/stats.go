@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+	"github.com/jamesob/llm-cli/pkg/render"
+)
+
+// meter adds up the provider requests made for --stats, and is nil
+// otherwise
+var meter *usageMeter
+
+// usageMeter adds up the requests made to providers: how many, how long
+// from the first starting to the last finishing, and the tokens they used
+type usageMeter struct {
+	mu            sync.Mutex
+	requests      int
+	start, end    time.Time
+	input, output int
+	counted       bool // whether any response reported its tokens
+}
+
+func (m *usageMeter) add(start, end time.Time, body []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.requests == 0 || start.Before(m.start) {
+		m.start = start
+	}
+	if end.After(m.end) {
+		m.end = end
+	}
+	m.requests++
+	if input, output, ok := tokenUsage(body); ok {
+		m.input += input
+		m.output += output
+		m.counted = true
+	}
+}
+
+// footer summarizes the requests for client's answer, as in
+// "claude-sonnet-4-20250514 (claude) · 1.8s · 412 tokens in, 37 out"
+func (m *usageMeter) footer(client *llm.Client) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	parts := []string{fmt.Sprintf("%s (%s)", client.ModelName(), client.Provider)}
+	if m.requests > 0 {
+		parts = append(parts, m.end.Sub(m.start).Round(100*time.Millisecond).String())
+	}
+	if m.requests > 1 {
+		parts = append(parts, fmt.Sprintf("%d requests", m.requests))
+	}
+	if m.counted {
+		parts = append(parts, fmt.Sprintf("%d tokens in, %d out", m.input, m.output))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// printStats writes the --stats footer to stderr, dimmed on a terminal
+func printStats(client *llm.Client) {
+	if meter == nil {
+		return
+	}
+	footer := meter.footer(client)
+	if isTerminal(os.Stderr) {
+		footer = render.Dim + footer + render.Reset
+	}
+	fmt.Fprintln(notices, footer)
+}
+
+// meterTransport adds each successful request to a usageMeter
+type meterTransport struct {
+	meter *usageMeter
+	base  http.RoundTripper
+}
+
+func (t *meterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	t.meter.add(start, time.Now(), body)
+	return resp, nil
+}
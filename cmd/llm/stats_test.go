@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestUsageMeterFooter(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	client := llm.NewClient(llm.OpenAI, "", "gpt-4o")
+	tests := []struct {
+		name   string
+		bodies []string
+		want   string
+	}{
+		{"no requests", nil, "gpt-4o (openai)"},
+		{"one", []string{`{"usage":{"prompt_tokens":412,"completion_tokens":37}}`}, "gpt-4o (openai) · 1.5s · 412 tokens in, 37 out"},
+		{"several", []string{
+			`{"usage":{"prompt_tokens":10,"completion_tokens":5}}`,
+			`{"usage":{"prompt_tokens":20,"completion_tokens":7}}`,
+		}, "gpt-4o (openai) · 3s · 2 requests · 30 tokens in, 12 out"},
+		{"no usage reported", []string{`{"choices":[]}`}, "gpt-4o (openai) · 1.5s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &usageMeter{}
+			for i, body := range tt.bodies {
+				s := start.Add(time.Duration(i) * 1500 * time.Millisecond)
+				m.add(s, s.Add(1500*time.Millisecond), []byte(body))
+			}
+			if got := m.footer(client); got != tt.want {
+				t.Errorf("footer = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMeterTransport(t *testing.T) {
+	srv := mockProvider(t, http.StatusOK, `{"response":"uptime","prompt_eval_count":3,"eval_count":4}`)
+	t.Setenv("LLM_NO_DAEMON", "1")
+	t.Setenv("LLM_RECORD_DIR", "")
+	t.Setenv("LLM_REPLAY_DIR", "")
+	defer func(m *usageMeter) { meter = m }(meter)
+	meter = &usageMeter{}
+
+	c := &llm.Client{Provider: llm.Ollama, Model: "llama3", Endpoint: srv.URL, HTTPClient: testHTTPClient(t, nil, llm.Ollama)}
+	if got, err := c.Query(context.Background(), "how long up"); err != nil || got != "uptime" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	if meter.requests != 1 || meter.input != 3 || meter.output != 4 {
+		t.Errorf("meter = %+v", meter)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain runs llm itself instead of the tests when LLM_TEST_MAIN is set,
+// so that tests can check what a whole run writes where
+func TestMain(m *testing.M) {
+	if os.Getenv("LLM_TEST_MAIN") != "" {
+		main()
+		exit(exitOK)
+	}
+	os.Exit(m.Run())
+}
+
+// runLLM runs llm with args against a provider at endpoint, returning its
+// stdout, stderr and exit status
+func runLLM(t *testing.T, endpoint string, args ...string) (stdout, stderr string, status int) {
+	t.Helper()
+	return runLLMIn(t, t.TempDir(), endpoint, args...)
+}
+
+// runLLMIn is runLLM with home as the home directory, so that runs can
+// share their history
+func runLLMIn(t *testing.T, home, endpoint string, args ...string) (stdout, stderr string, status int) {
+	t.Helper()
+	configDir := filepath.Join(home, "config")
+	if err := os.MkdirAll(filepath.Join(configDir, "llm"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "llm", "config.toml"), []byte("openai_endpoint = \""+endpoint+"\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(),
+		"LLM_TEST_MAIN=1",
+		"HOME="+home,
+		"XDG_CONFIG_HOME="+configDir,
+		"XDG_DATA_HOME="+filepath.Join(home, "data"),
+		"XDG_CACHE_HOME="+filepath.Join(home, "cache"),
+		"APPDATA="+configDir,
+		"LOCALAPPDATA="+filepath.Join(home, "cache"),
+		"ANTHROPIC_API_KEY=",
+		"OPENAI_API_KEY=sk-test",
+		"LLM_NO_DAEMON=1",
+		"LLM_RECORD_DIR=",
+		"LLM_REPLAY_DIR=",
+		"LLM_LOG_FILE=",
+		"OTEL_EXPORTER_OTLP_ENDPOINT=",
+	)
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		status = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return out.String(), errOut.String(), status
+}
+
+func TestStdoutHoldsOnlyTheAnswer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "broken") {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"error":{"message":"bad request"}}`)
+			return
+		}
+		answer := "ls -la"
+		switch {
+		case strings.Contains(string(body), "globs"):
+			answer = "find . -name *.go -o -name *.mod"
+		case strings.Contains(string(body), "pyc"):
+			answer = "rm -f __init__.pyc"
+		}
+		io.WriteString(w, `{"choices":[{"message":{"content":"`+answer+`"}}],"usage":{"prompt_tokens":12,"completion_tokens":3}}`)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		args      []string
+		stdout    string
+		status    int
+		stderrHas string // "" means stderr must be empty
+		anyStderr bool
+		stdoutHas string // checked instead of stdout when set
+	}{
+		{name: "answer", args: []string{"list", "files"}, stdout: "ls -la\n", anyStderr: true},
+		{name: "markdown emphasis", args: []string{"-q", "find", "go", "globs"}, stdout: "find . -name *.go -o -name *.mod\n"},
+		{name: "markdown bold", args: []string{"-q", "remove", "the", "pyc"}, stdout: "rm -f __init__.pyc\n"},
+		{name: "stats", args: []string{"--stats", "list", "files"}, stdout: "ls -la\n", stderrHas: "12 tokens in, 3 out"},
+		{name: "quiet", args: []string{"-q", "--stats", "list", "files"}, stdout: "ls -la\n"},
+		{name: "provider error", args: []string{"broken", "request"}, status: exitProvider, stderrHas: "Error:"},
+		{name: "quiet error", args: []string{"-q", "broken", "request"}, status: exitProvider, stderrHas: "Error:"},
+		{name: "bad flag", args: []string{"--no-such-flag", "x"}, status: exitUsage, stderrHas: "USAGE:"},
+		{name: "no arguments", status: exitUsage, stderrHas: "USAGE:"},
+		{name: "help", args: []string{"--help"}, stdoutHas: "USAGE:"},
+		{name: "help after a flag", args: []string{"-q", "--help"}, stdoutHas: "USAGE:"},
+		{name: "subcommand error", args: []string{"batch", "--mode", "nonsense"}, status: exitError, stderrHas: "unknown mode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, status := runLLM(t, srv.URL, tt.args...)
+			if status != tt.status {
+				t.Errorf("status = %d, want %d; stderr:\n%s", status, tt.status, stderr)
+			}
+			if tt.stdoutHas != "" {
+				if !strings.Contains(stdout, tt.stdoutHas) {
+					t.Errorf("stdout = %q, want it to contain %q", stdout, tt.stdoutHas)
+				}
+			} else if stdout != tt.stdout {
+				t.Errorf("stdout = %q, want %q", stdout, tt.stdout)
+			}
+			switch {
+			case tt.anyStderr:
+			case tt.stderrHas == "" && stderr != "":
+				t.Errorf("stderr = %q, want nothing", stderr)
+			case !strings.Contains(stderr, tt.stderrHas):
+				t.Errorf("stderr = %q, want it to contain %q", stderr, tt.stderrHas)
+			}
+		})
+	}
+}
+
+func TestRetryStdoutHoldsOnlyTheNewAnswer(t *testing.T) {
+	answers := []string{"ls -la", "ls -lah"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"choices":[{"message":{"content":"`+answers[0]+`"}}]}`)
+		answers = answers[1:]
+	}))
+	defer srv.Close()
+
+	home := t.TempDir()
+	if stdout, stderr, status := runLLMIn(t, home, srv.URL, "list", "files"); status != exitOK || stdout != "ls -la\n" {
+		t.Fatalf("got %d, %q; stderr:\n%s", status, stdout, stderr)
+	}
+	stdout, stderr, status := runLLMIn(t, home, srv.URL, "--retry")
+	if status != exitOK || stdout != "ls -lah\n" {
+		t.Errorf("got %d, %q; stderr:\n%s", status, stdout, stderr)
+	}
+}
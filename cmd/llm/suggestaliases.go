@@ -0,0 +1,195 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/internal/shellhistory"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+const suggestAliasesUsage = "usage: llm suggest-aliases [--apply] [-y] [--history N] [--min N] [hint]"
+
+// Which commands llm suggest-aliases sends: at most maxAliasCandidates of
+// those at least minAliasLength long, typed --min times in the last
+// --history commands
+const (
+	defaultAliasHistory = 5000
+	defaultAliasMin     = 3
+	minAliasLength      = 15
+	maxAliasCandidates  = 30
+)
+
+// commandCount is a command from the history and how often it was typed
+type commandCount struct {
+	command string
+	count   int
+}
+
+// runSuggestAliases suggests aliases for the long commands the user types
+// most, and with --apply appends them to the shell's startup file. Nothing
+// from the history is sent until the user has seen it and agreed.
+func runSuggestAliases(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	opts := &options{mode: llm.AliasMode}
+	var apply bool
+	var historyLen, times int
+	flagSet := flag.NewFlagSet("llm suggest-aliases", flag.ContinueOnError)
+	flagSet.BoolVar(&apply, "apply", false, "Append the suggestions to the shell's startup file, after asking")
+	flagSet.BoolVar(&opts.yes, "y", false, "Don't ask before sending the commands or appending the suggestions")
+	flagSet.IntVar(&historyLen, "history", defaultAliasHistory, "Look at this many of the most recent commands")
+	flagSet.IntVar(&times, "min", defaultAliasMin, "Only suggest aliases for commands typed at least this many times")
+	flagSet.BoolVar(&opts.noRedact, "no-redact", false, "Send the commands without redacting secrets")
+	opts.log.register(flagSet)
+	flagSet.Usage = func() {
+		fmt.Fprintln(os.Stderr, suggestAliasesUsage)
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if historyLen <= 0 || times <= 0 {
+		return usageError("--history and --min must be positive")
+	}
+	opts.query = cmp.Or(strings.Join(flagSet.Args(), " "), "Suggest aliases for the commands I type most often")
+
+	sys := llm.DetectSystem()
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	var rcPath string
+	if apply {
+		if rcPath, err = rcFile(sys.Shell, home, os.Getenv); err != nil {
+			return err
+		}
+	}
+	commands, err := shellhistory.Read(sys.Shell, home, os.Getenv, historyLen)
+	if err != nil {
+		return err
+	}
+	frequent := frequentCommands(commands, times)
+	if len(frequent) == 0 {
+		fmt.Fprintf(notices, "No command of %d or more characters was typed %d times in the last %d; try a lower --min\n",
+			minAliasLength, times, len(commands))
+		return nil
+	}
+
+	if err := opts.log.setup(); err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+	var list strings.Builder
+	for _, c := range frequent {
+		fmt.Fprintf(&list, "%4d  %s\n", c.count, c.command)
+	}
+	if !opts.yes {
+		fmt.Fprintf(os.Stderr, "About to send these commands from your %s history to %v:\n%s", sys.Shell, client.Provider, list.String())
+		ok, err := confirm("Continue?")
+		if err != nil {
+			return fmt.Errorf("%v; pass -y to send without confirming", err)
+		}
+		if !ok {
+			return errAborted
+		}
+	}
+	sys.Attachments = []llm.Attachment{{Title: "Frequently typed commands (times typed, command)", Content: list.String()}}
+
+	response, err := ask(context.Background(), cfg, client, opts, sys)
+	if err != nil {
+		return err
+	}
+	response = stripFence(response)
+	printResponse(opts, response)
+	if !apply {
+		return nil
+	}
+	return appendAliases(rcPath, sys.Shell, response, opts.yes)
+}
+
+// frequentCommands returns the one-line commands at least minAliasLength
+// long that appear at least times times, those that would save the most
+// typing first
+func frequentCommands(commands []string, times int) []commandCount {
+	counts := map[string]int{}
+	for _, command := range commands {
+		command = strings.TrimSpace(command)
+		if len(command) >= minAliasLength && !strings.Contains(command, "\n") {
+			counts[command]++
+		}
+	}
+	var frequent []commandCount
+	for command, count := range counts {
+		if count >= times {
+			frequent = append(frequent, commandCount{command, count})
+		}
+	}
+	slices.SortFunc(frequent, func(a, b commandCount) int {
+		return cmp.Or(cmp.Compare(b.count*len(b.command), a.count*len(a.command)), strings.Compare(a.command, b.command))
+	})
+	return frequent[:min(len(frequent), maxAliasCandidates)]
+}
+
+// appendAliases checks that code is valid in shell and appends it to the
+// startup file at path once the user agrees
+func appendAliases(path, shell, code string, yes bool) error {
+	if err := checkSyntax(shell, code); err != nil {
+		return fmt.Errorf("the suggestions aren't valid %s, so they weren't added: %v", shell, err)
+	}
+	if !yes {
+		ok, err := confirm("Append these to " + path + "?")
+		if err != nil {
+			return fmt.Errorf("%v; pass -y to append without confirming", err)
+		}
+		if !ok {
+			return errAborted
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "\n# Added by llm suggest-aliases\n%s\n", strings.TrimSpace(code)); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(notices, "Added them to %s; they take effect in new shells\n", path)
+	return nil
+}
+
+// rcFile returns the startup file an interactive shell reads
+func rcFile(shell, home string, getenv func(string) string) (string, error) {
+	switch shell {
+	case "bash":
+		return filepath.Join(home, ".bashrc"), nil
+	case "zsh":
+		return filepath.Join(cmp.Or(getenv("ZDOTDIR"), home), ".zshrc"), nil
+	case "fish":
+		configHome := getenv("XDG_CONFIG_HOME")
+		if !filepath.IsAbs(configHome) {
+			configHome = filepath.Join(home, ".config")
+		}
+		return filepath.Join(configHome, "fish", "config.fish"), nil
+	}
+	return "", fmt.Errorf("--apply doesn't support %s; add the suggestions to its startup file yourself", shell)
+}
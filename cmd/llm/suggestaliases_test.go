@@ -0,0 +1,67 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFrequentCommands(t *testing.T) {
+	history := []string{
+		"git log --oneline --graph", "ls", "ls", "ls", "ls",
+		"docker compose up -d --build", "git log --oneline --graph",
+		"docker compose up -d --build", "  git log --oneline --graph  ",
+		"kubectl get pods -n staging", "kubectl get pods -n staging",
+		"for f in *; do\n  echo $f\ndone", "for f in *; do\n  echo $f\ndone",
+	}
+	tests := []struct {
+		min  int
+		want []commandCount
+	}{
+		{3, []commandCount{{"git log --oneline --graph", 3}}},
+		{2, []commandCount{
+			{"git log --oneline --graph", 3},
+			{"docker compose up -d --build", 2},
+			{"kubectl get pods -n staging", 2},
+		}},
+		{4, nil},
+	}
+	for _, tt := range tests {
+		if got := frequentCommands(history, tt.min); !slices.Equal(got, tt.want) {
+			t.Errorf("min %d: got %v, want %v", tt.min, got, tt.want)
+		}
+	}
+
+	var many []string
+	for i := range 2 * maxAliasCandidates {
+		command := "echo " + strings.Repeat("x", minAliasLength+i)
+		many = append(many, command, command)
+	}
+	if got := frequentCommands(many, 2); len(got) != maxAliasCandidates || !strings.HasSuffix(got[0].command, strings.Repeat("x", minAliasLength+2*maxAliasCandidates-1)) {
+		t.Errorf("got %d commands, longest first %q", len(got), got[0].command)
+	}
+}
+
+func TestRCFile(t *testing.T) {
+	tests := []struct {
+		shell string
+		env   map[string]string
+		want  string
+	}{
+		{"bash", nil, "/home/me/.bashrc"},
+		{"zsh", nil, "/home/me/.zshrc"},
+		{"zsh", map[string]string{"ZDOTDIR": "/home/me/.config/zsh"}, "/home/me/.config/zsh/.zshrc"},
+		{"fish", nil, "/home/me/.config/fish/config.fish"},
+		{"fish", map[string]string{"XDG_CONFIG_HOME": "/cfg"}, "/cfg/fish/config.fish"},
+	}
+	for _, tt := range tests {
+		got, err := rcFile(tt.shell, "/home/me", func(key string) string { return tt.env[key] })
+		if err != nil || got != filepath.FromSlash(tt.want) {
+			t.Errorf("%s %v: got %q, %v, want %q", tt.shell, tt.env, got, err, tt.want)
+		}
+	}
+	if _, err := rcFile("pwsh", "/home/me", func(string) string { return "" }); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+	"github.com/jamesob/llm-cli/pkg/render"
+)
+
+// printSuggestions writes the preferred command in bold and the
+// alternatives dimmed, each under its label
+func printSuggestions(w io.Writer, suggestions []llm.Suggestion) {
+	for i, s := range suggestions {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		style := render.Bold
+		if !s.Preferred {
+			style = render.Dim
+		}
+		if s.Label != "" {
+			fmt.Fprintf(w, "%s# %s%s\n", render.Dim, s.Label, render.Reset)
+		}
+		fmt.Fprintf(w, "%s%s%s\n", style, s.Command, render.Reset)
+	}
+}
+
+// execSuggestion runs the suggestion the user picks, or the preferred one
+// with yes, in shell, and returns its exit status
+func execSuggestion(suggestions []llm.Suggestion, shell string, yes bool) (int, error) {
+	i := 0
+	if !yes {
+		tty, err := openTTY()
+		if err != nil {
+			return 0, errors.New("--exec needs a terminal to ask on, or -y to run the command without asking")
+		}
+		defer tty.Close()
+		if i, err = chooseSuggestion(tty, os.Stderr, len(suggestions)); err != nil || i < 0 {
+			return 0, err
+		}
+	}
+	return runCommand(shell, suggestions[i].Command)
+}
+
+// chooseSuggestion asks which of n suggestions to run, the first being the
+// preferred one and the default. It returns -1 to run none.
+func chooseSuggestion(r io.Reader, w io.Writer, n int) (int, error) {
+	in := bufio.NewReader(r)
+	for {
+		if n == 1 {
+			fmt.Fprint(w, "Run it? [Y/n] ")
+		} else {
+			fmt.Fprintf(w, "Run which? [1-%d, Enter for 1, n for none] ", n)
+		}
+		line, err := in.ReadString('\n')
+		switch answer := strings.ToLower(strings.TrimSpace(line)); {
+		case answer == "" && err == nil, answer == "y", answer == "yes":
+			return 0, nil
+		case answer == "n", answer == "no":
+			return -1, nil
+		default:
+			if i, convErr := strconv.Atoi(answer); convErr == nil && i >= 1 && i <= n {
+				return i - 1, nil
+			}
+		}
+		if err != nil {
+			return -1, errAborted
+		}
+	}
+}
+
+// runCommand runs command with shell and llm's stdin, stdout and stderr,
+// and returns its exit status
+func runCommand(shell, command string) (int, error) {
+	cmd := userShellCommand(context.Background(), shell, command)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	return 0, err
+}
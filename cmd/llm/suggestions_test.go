@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+	"github.com/jamesob/llm-cli/pkg/render"
+)
+
+func TestPrintSuggestions(t *testing.T) {
+	var b strings.Builder
+	printSuggestions(&b, []llm.Suggestion{
+		{Command: "fd --size +100m", Label: "faster", Preferred: true},
+		{Command: "find . -size +100M"},
+	})
+	want := render.Dim + "# faster" + render.Reset + "\n" + render.Bold + "fd --size +100m" + render.Reset + "\n\n" +
+		render.Dim + "find . -size +100M" + render.Reset + "\n"
+	if b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+}
+
+func TestChooseSuggestion(t *testing.T) {
+	tests := []struct {
+		input string
+		n     int
+		want  int
+		err   error
+	}{
+		{"\n", 3, 0, nil},
+		{"2\n", 3, 1, nil},
+		{"9\n3\n", 3, 2, nil},
+		{"n\n", 3, -1, nil},
+		{"y\n", 1, 0, nil},
+		{"", 2, -1, errAborted},
+	}
+	for _, tt := range tests {
+		var w strings.Builder
+		got, err := chooseSuggestion(strings.NewReader(tt.input), &w, tt.n)
+		if got != tt.want || err != tt.err {
+			t.Errorf("chooseSuggestion(%q, %d) = %d, %v, want %d, %v", tt.input, tt.n, got, err, tt.want, tt.err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// syntaxCheckers are the commands that parse a script on stdin in each
+// shell without running it
+var syntaxCheckers = map[string][]string{
+	"bash": {"bash", "-n"},
+	"dash": {"dash", "-n"},
+	"fish": {"fish", "--no-execute"},
+	"ksh":  {"ksh", "-n"},
+	"sh":   {"sh", "-n"},
+	"zsh":  {"zsh", "-n"},
+}
+
+// syntaxTimeout bounds how long a shell may take to parse a command
+const syntaxTimeout = 5 * time.Second
+
+// verifySyntax has the user's shell parse the suggested commands and, if it
+// rejects any, asks the model for one correction, which isn't saved to the
+// history on its own
+func verifySyntax(ctx context.Context, cfg *config.Config, client *llm.Client, opts *options, sys llm.System, response string) (string, error) {
+	problems := syntaxProblems(sys.Shell, response)
+	if len(problems) == 0 {
+		return response, nil
+	}
+
+	slog.Info("The command isn't valid "+sys.Shell+"; asking for a correction", "error", strings.Join(problems, "; "))
+	sys.Notes = append(sys.Notes, problems...)
+	corrected, _, err := requery(ctx, cfg, client, opts, sys)
+	if err != nil {
+		return "", err
+	}
+	if problems := syntaxProblems(sys.Shell, corrected); len(problems) > 0 {
+		slog.Warn("The corrected command isn't valid either", "error", strings.Join(problems, "; "))
+	}
+	return corrected, nil
+}
+
+// syntaxProblems describes, for the model, the suggestions in response that
+// shell can't parse
+func syntaxProblems(shell, response string) []string {
+	var problems []string
+	for _, s := range llm.ParseSuggestions(response) {
+		if err := checkSyntax(shell, s.Command); err != nil {
+			problems = append(problems, fmt.Sprintf("The command `%s` isn't valid %s: %v", s.Command, shell, err))
+		}
+	}
+	return problems
+}
+
+// checkSyntax parses command with shell without running it, and returns
+// the shell's complaint if it isn't valid. Shells llm can't check with are
+// assumed to accept it.
+func checkSyntax(shell, command string) error {
+	args, ok := syntaxCheckers[shell]
+	if !ok {
+		return nil
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), syntaxTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(command + "\n")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return errors.New(msg)
+		}
+		return err
+	}
+	return nil
+}
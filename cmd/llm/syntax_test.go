@@ -0,0 +1,63 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCheckSyntax(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not installed")
+	}
+	tests := []struct {
+		command string
+		ok      bool
+	}{
+		{"du -sh * | sort -h", true},
+		{"for f in *.txt; do wc -l \"$f\"; done", true},
+		{"rm -rf /definitely/not/here; echo ran > /dev/null", true},
+		{"if true; then echo yes", false},
+		{"echo 'unterminated", false},
+	}
+	for _, tt := range tests {
+		if err := checkSyntax("sh", tt.command); (err == nil) != tt.ok {
+			t.Errorf("checkSyntax(%q) = %v", tt.command, err)
+		}
+	}
+	if err := checkSyntax("nu", "if {"); err != nil {
+		t.Errorf("unknown shells should be accepted, got %v", err)
+	}
+
+	problems := syntaxProblems("sh", "PREFERRED: a\nls -la\nALTERNATIVE: b\nfind . -name (")
+	if len(problems) != 1 || !strings.Contains(problems[0], "find . -name (") {
+		t.Errorf("problems = %q", problems)
+	}
+}
+
+func TestSyntaxCorrectionSavedOnce(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not installed")
+	}
+	answers := []string{"if true; then echo yes", "echo yes"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"choices":[{"message":{"content":"`+answers[0]+`"}}]}`)
+		answers = answers[1:]
+	}))
+	defer srv.Close()
+
+	t.Setenv("SHELL", "/bin/bash")
+	home := t.TempDir()
+	if stdout, stderr, status := runLLMIn(t, home, srv.URL, "say", "yes"); status != exitOK || stdout != "echo yes\n" {
+		t.Fatalf("got %d, %q; stderr:\n%s", status, stdout, stderr)
+	}
+	if stdout, _, _ := runLLMIn(t, home, srv.URL, "history"); strings.Count(stdout, "say yes") != 1 {
+		t.Errorf("history = %q, want one entry", stdout)
+	}
+	if _, stderr, _ := runLLMIn(t, home, srv.URL, "bad"); !strings.Contains(stderr, "(echo yes)") {
+		t.Errorf("llm bad said %q, want it to mark the corrected answer", stderr)
+	}
+}
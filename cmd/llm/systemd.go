@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// systemdTimeout bounds systemd-analyze verify and systemctl daemon-reload
+const systemdTimeout = 10 * time.Second
+
+// unitNameRe matches the names of the unit files llm --systemd writes
+var unitNameRe = regexp.MustCompile(`^[A-Za-z0-9:_.\\@-]+\.(service|timer|socket|path|target|mount)$`)
+
+// systemUserRe matches the setting that makes a unit run as another user,
+// which only system units can do
+var systemUserRe = regexp.MustCompile(`(?m)^\s*User\s*=`)
+
+// verifyUnits splits the answer into its unit files and checks them with
+// systemd-analyze verify, when it's installed. If it finds problems the
+// model can fix, it's asked for one correction. Files that still aren't
+// named like units are an error; other problems, such as programs that
+// aren't installed here, are only warned about, since the units may be
+// meant for another machine.
+func verifyUnits(ctx context.Context, cfg *config.Config, client *llm.Client, opts *options, sys llm.System, response string) (string, []llm.File, error) {
+	files := llm.ParseFiles(response)
+	if files == nil {
+		return "", nil, errors.New("the answer has no unit files")
+	}
+	problems := unitProblems(files)
+	if fixable := fixableProblems(problems); len(fixable) > 0 {
+		slog.Info("The units have problems; asking for a correction", "error", strings.Join(fixable, "; "))
+		sys.Notes = append(sys.Notes, fixable...)
+		corrected, _, err := requery(ctx, cfg, client, opts, sys)
+		if err != nil {
+			return "", nil, err
+		}
+		if files = llm.ParseFiles(corrected); files == nil {
+			return "", nil, errors.New("the corrected answer has no unit files")
+		}
+		response, problems = corrected, unitProblems(files)
+	}
+	if names := nameProblems(files); len(names) > 0 {
+		return "", nil, errors.New(strings.Join(names, "; "))
+	}
+	for _, problem := range problems {
+		slog.Warn(problem)
+	}
+	return response, files, nil
+}
+
+// unitProblems describes, for the model, what's wrong with the unit files:
+// names that aren't unit names, and what systemd-analyze verify reports
+func unitProblems(files []llm.File) []string {
+	if problems := nameProblems(files); len(problems) > 0 {
+		return problems
+	}
+	var problems []string
+	if _, err := exec.LookPath("systemd-analyze"); err != nil {
+		return nil
+	}
+
+	dir, err := os.MkdirTemp("", "llm-systemd-")
+	if err != nil {
+		return nil
+	}
+	defer os.RemoveAll(dir)
+	args := []string{"verify", "--man=no"}
+	for _, f := range files {
+		path := filepath.Join(dir, f.Path)
+		if err := os.WriteFile(path, []byte(f.Content+"\n"), 0644); err != nil {
+			return nil
+		}
+		args = append(args, path)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), systemdTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "systemd-analyze", args...).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line = strings.TrimSpace(strings.ReplaceAll(line, dir+string(filepath.Separator), "")); line != "" {
+			problems = append(problems, line)
+		}
+	}
+	return problems
+}
+
+// nameProblems reports the files whose names aren't those of a unit, which
+// systemd would never load
+func nameProblems(files []llm.File) []string {
+	var problems []string
+	for _, f := range files {
+		if !unitNameRe.MatchString(f.Path) {
+			problems = append(problems, fmt.Sprintf("%q isn't the file name of a unit", f.Path))
+		}
+	}
+	return problems
+}
+
+// fixableProblems leaves out the problems of programs not being installed
+func fixableProblems(problems []string) []string {
+	var fixable []string
+	for _, problem := range problems {
+		if !strings.Contains(problem, "is not executable") {
+			fixable = append(fixable, problem)
+		}
+	}
+	return fixable
+}
+
+// installUnits writes the unit files to the user unit directory, with
+// apply, once the user agrees, unless yes is set, and has systemd reload
+// them. Without apply it says how to.
+func installUnits(files []llm.File, apply, yes, force bool) error {
+	for _, f := range files {
+		if systemUserRe.MatchString(f.Content) {
+			fmt.Fprintf(notices, "%s runs as another user, so it's a system unit: install it in /etc/systemd/system with sudo\n", f.Path)
+			return nil
+		}
+	}
+	dir, err := userUnitDir()
+	if err != nil {
+		return err
+	}
+	if !apply {
+		fmt.Fprintf(notices, "Pass --apply to install the units in %s\n", dir)
+		return nil
+	}
+	if err := writeFiles(dir, files, yes, force); err != nil {
+		return err
+	}
+
+	if _, err := exec.LookPath("systemctl"); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), systemdTimeout)
+		defer cancel()
+		if out, err := exec.CommandContext(ctx, "systemctl", "--user", "daemon-reload").CombinedOutput(); err != nil {
+			slog.Warn("systemctl --user daemon-reload failed", "error", strings.TrimSpace(string(out)))
+		}
+	}
+	fmt.Fprintf(notices, "Start it with: systemctl --user enable --now %s\n", startUnit(files))
+	return nil
+}
+
+// startUnit returns the unit to enable: the timer if there is one, since it
+// starts the service, or the first unit
+func startUnit(files []llm.File) string {
+	for _, f := range files {
+		if strings.HasSuffix(f.Path, ".timer") {
+			return f.Path
+		}
+	}
+	return files[0].Path
+}
+
+// userUnitDir returns where systemd looks for the user's own units,
+// $XDG_CONFIG_HOME/systemd/user
+func userUnitDir() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if !filepath.IsAbs(configHome) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "systemd", "user"), nil
+}
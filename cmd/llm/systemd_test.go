@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+const backupService = `[Unit]
+Description=Nightly backup
+
+[Service]
+Type=oneshot
+ExecStart=/bin/true
+
+[Install]
+WantedBy=default.target`
+
+const backupTimer = `[Unit]
+Description=Run the backup every night
+
+[Timer]
+OnCalendar=*-*-* 02:00:00
+Persistent=true
+
+[Install]
+WantedBy=timers.target`
+
+func TestUnitProblems(t *testing.T) {
+	if problems := unitProblems([]llm.File{{Path: "units/backup", Content: backupService}}); len(problems) != 1 || !strings.Contains(problems[0], "isn't the file name of a unit") {
+		t.Errorf("bad name: %q", problems)
+	}
+	if _, err := exec.LookPath("systemd-analyze"); err != nil {
+		t.Skip("systemd-analyze not installed")
+	}
+	files := []llm.File{{Path: "backup.service", Content: backupService}, {Path: "backup.timer", Content: backupTimer}}
+	if problems := unitProblems(files); problems != nil {
+		t.Errorf("good units: %q", problems)
+	}
+	files[0].Content = strings.Replace(backupService, "/bin/true", "/opt/no-such-dir/backup.sh", 1)
+	problems := unitProblems(files)
+	if len(problems) == 0 || !strings.HasPrefix(problems[len(problems)-1], "backup.service: Command /opt/no-such-dir/backup.sh is not executable") {
+		t.Errorf("missing program: %q", problems)
+	}
+	if fixable := fixableProblems(problems); fixable != nil {
+		t.Errorf("a missing program can't be fixed: %q", fixable)
+	}
+}
+
+func TestInstallUnits(t *testing.T) {
+	config := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config)
+	t.Setenv("PATH", t.TempDir())
+	files := []llm.File{{Path: "backup.service", Content: backupService}, {Path: "backup.timer", Content: backupTimer}}
+	dir := filepath.Join(config, "systemd", "user")
+
+	if err := installUnits(files, false, true, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); err == nil {
+		t.Error("installed without --apply")
+	}
+	if err := installUnits(files, true, true, false); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "backup.timer")); err != nil || string(data) != backupTimer+"\n" {
+		t.Errorf("timer = %q, %v", data, err)
+	}
+	if err := installUnits(files, true, true, false); err == nil {
+		t.Error("replaced the units without --force")
+	}
+	if got := startUnit(files); got != "backup.timer" {
+		t.Errorf("start %s, want the timer", got)
+	}
+
+	system := []llm.File{{Path: "bot.service", Content: strings.Replace(backupService, "[Service]", "[Service]\nUser=foo", 1)}}
+	if err := installUnits(system, true, true, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bot.service")); err == nil {
+		t.Error("installed a system unit as a user unit")
+	}
+}
+
+func TestSystemdOutput(t *testing.T) {
+	answer := "### FILE: backup.service\n" + backupService + "\n\n### FILE: backup.timer\n" + backupTimer
+	content, _ := json.Marshal(answer)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"choices":[{"message":{"content":`+string(content)+`}}]}`)
+	}))
+	defer srv.Close()
+
+	stdout, stderr, status := runLLM(t, srv.URL, "--systemd", "run backup.sh every night at 2am")
+	if status != exitOK || stdout != answer+"\n" || !strings.Contains(stderr, "--apply") {
+		t.Errorf("got %d, %q, %q", status, stdout, stderr)
+	}
+}
+
+func TestFixableProblems(t *testing.T) {
+	problems := []string{"backup.service:5: Unknown key 'Bogus' in section [Service], ignoring.", "backup.service: Command /opt/x is not executable: No such file or directory"}
+	if got, want := fixableProblems(problems), problems[:1]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSystemdBadNameIsNotInstalled(t *testing.T) {
+	answer, _ := json.Marshal("### FILE: backup.conf\n" + backupService)
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		io.WriteString(w, `{"choices":[{"message":{"content":`+string(answer)+`}}]}`)
+	}))
+	defer srv.Close()
+
+	home := t.TempDir()
+	stdout, stderr, status := runLLMIn(t, home, srv.URL, "--systemd", "--apply", "--yes", "run backup.sh every night")
+	if status == exitOK || stdout != "" || !strings.Contains(stderr, `"backup.conf" isn't the file name of a unit`) {
+		t.Errorf("got %d, %q, %q", status, stdout, stderr)
+	}
+	if requests != 2 {
+		t.Errorf("%d requests, want a correction to be asked for", requests)
+	}
+	if _, err := os.Stat(filepath.Join(home, "config", "systemd", "user", "backup.conf")); err == nil {
+		t.Error("installed a file that isn't a unit")
+	}
+}
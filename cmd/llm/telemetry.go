@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/internal/telemetry"
+)
+
+// exporter sends a span for each provider request to an OpenTelemetry
+// collector when OTEL_EXPORTER_OTLP_ENDPOINT is set, and is nil otherwise
+var exporter *telemetry.Exporter
+
+// setupTelemetry configures exporter from the environment
+func setupTelemetry() {
+	e, err := telemetry.FromEnv(os.Getenv, version)
+	if err != nil {
+		slog.Warn("Telemetry is off", "error", err)
+		return
+	}
+	exporter = e
+}
+
+// checkTelemetry turns telemetry off if the collector is somewhere the
+// config doesn't allow requests to, such as off the machine in offline mode
+func checkTelemetry(cfg *config.Config) {
+	if exporter == nil {
+		return
+	}
+	policy := newRequestPolicy(cfg)
+	for _, collector := range exporter.URLs() {
+		u, err := url.Parse(collector)
+		if err == nil {
+			err = policy.check(u)
+		}
+		if err != nil {
+			slog.Warn("Telemetry is off", "error", err)
+			exporter = nil
+			return
+		}
+	}
+}
+
+// flushTelemetry exports the spans recorded so far. It's called on the way
+// out, since llm exits as soon as it's done.
+func flushTelemetry() {
+	if exporter == nil {
+		return
+	}
+	if err := exporter.Flush(context.Background()); err != nil {
+		slog.Warn("Telemetry wasn't exported", "error", err)
+	}
+}
+
+// telemetryTransport records a span for each provider request, with the
+// provider, model, status, latency and token counts
+type telemetryTransport struct {
+	base   http.RoundTripper
+	record func(telemetry.Span)
+}
+
+func (t *telemetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var sent struct {
+		Model string `json:"model"`
+	}
+	if req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			json.NewDecoder(rc).Decode(&sent)
+			rc.Close()
+		}
+	}
+	operation := "chat"
+	if strings.Contains(req.URL.Path, "embed") {
+		operation = "embeddings"
+	}
+	span := telemetry.Span{
+		Name:  strings.TrimSpace(operation + " " + sent.Model),
+		Start: time.Now(),
+		Attributes: map[string]any{
+			"gen_ai.operation.name": operation,
+			"gen_ai.system":         genAISystem(req.URL.Path),
+			"server.address":        req.URL.Hostname(),
+		},
+	}
+	if sent.Model != "" {
+		span.Attributes["gen_ai.request.model"] = sent.Model
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	span.End = time.Now()
+	if err != nil {
+		span.Error = err.Error()
+		span.Attributes["error.type"] = "network"
+		t.record(span)
+		return nil, err
+	}
+
+	span.Attributes["http.response.status_code"] = resp.StatusCode
+	if resp.StatusCode >= 400 {
+		span.Error = resp.Status
+		span.Attributes["error.type"] = strconv.Itoa(resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if input, output, ok := tokenUsage(body); ok {
+		span.Attributes["gen_ai.usage.input_tokens"] = input
+		span.Attributes["gen_ai.usage.output_tokens"] = output
+	}
+	t.record(span)
+	return resp, nil
+}
+
+// genAISystem names the provider an API path belongs to, as OpenTelemetry's
+// gen_ai.system attribute does
+func genAISystem(path string) string {
+	switch {
+	case strings.HasSuffix(path, "/messages"):
+		return "anthropic"
+	case strings.HasPrefix(path, "/api/"):
+		return "ollama"
+	}
+	return "openai"
+}
+
+// tokenUsage reads the tokens used from a Claude, OpenAI or Ollama response
+func tokenUsage(body []byte) (input, output int, ok bool) {
+	var resp struct {
+		Usage *struct {
+			InputTokens      int `json:"input_tokens"`
+			OutputTokens     int `json:"output_tokens"`
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+		PromptEvalCount *int `json:"prompt_eval_count"`
+		EvalCount       int  `json:"eval_count"`
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return 0, 0, false
+	}
+	switch {
+	case resp.Usage != nil:
+		u := resp.Usage
+		return cmp.Or(u.InputTokens, u.PromptTokens), cmp.Or(u.OutputTokens, u.CompletionTokens), true
+	case resp.PromptEvalCount != nil:
+		return *resp.PromptEvalCount, resp.EvalCount, true
+	}
+	return 0, 0, false
+}
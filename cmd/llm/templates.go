@@ -0,0 +1,330 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/internal/paths"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// promptTemplate is a prompt file for -t. Settings in the config file's
+// format come first, then a line of ---, then the prompt:
+//
+//	mode = "code"
+//	lang = "go"
+//
+//	[args]
+//	scope = "cli"
+//	---
+//	Write a table-driven test for the {{.scope}} package.
+//
+// A file without a --- line is all prompt.
+type promptTemplate struct {
+	description string // for llm templates list
+	mode        llm.Mode
+	model       string
+	lang        string            // code language
+	args        map[string]string // defaults
+	prompt      string
+}
+
+// templateModes are the modes a template may choose. The others need
+// options of their own or are subcommands.
+var templateModes = []llm.Mode{
+	llm.CommandMode, llm.CodeMode, llm.ExplainMode, llm.RegexMode, llm.JQMode,
+	llm.SQLMode, llm.CronMode, llm.K8sMode, llm.DockerMode, llm.SedMode,
+	llm.AwkMode, llm.ProofreadMode, llm.PatchMode, llm.RepoMode, llm.AWSMode,
+	llm.GCloudMode, llm.AzureMode, llm.TFMode, llm.SystemdMode,
+}
+
+// parseTemplate parses a template file's contents
+func parseTemplate(data string) (*promptTemplate, error) {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	t := &promptTemplate{prompt: data}
+	i := strings.Index("\n"+data, "\n---\n")
+	if i < 0 {
+		return t, nil
+	}
+	t.prompt = data[i+4:]
+	settings, err := config.Parse(data[:i])
+	if err != nil {
+		return nil, err
+	}
+	if name := settings.String("mode"); name != "" {
+		mode, err := llm.ParseMode(name)
+		if err != nil || !slices.Contains(templateModes, mode) {
+			return nil, fmt.Errorf("mode %q isn't one a template can use", name)
+		}
+		t.mode = mode
+	}
+	t.description = settings.String("description")
+	t.model = settings.String("model")
+	t.lang = settings.String("lang")
+	t.args = settings.Section("args")
+	return t, nil
+}
+
+// templatesDir returns the directory beside the config file that -t looks
+// in and llm templates install adds to
+func templatesDir() (string, error) {
+	dir, err := paths.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "templates"), nil
+}
+
+// templatePath finds the template -t names: a file, or one in the templates
+// directory, with or without its .tmpl extension. A name that isn't there
+// may be that of a template in just one installed pack.
+func templatePath(name string) (string, error) {
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+	dir, err := templatesDir()
+	if err != nil {
+		return "", err
+	}
+	for _, path := range []string{filepath.Join(dir, name), filepath.Join(dir, name+".tmpl")} {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	names, err := listTemplates(dir)
+	if err != nil {
+		return "", err
+	}
+	var found []string
+	for _, n := range names {
+		if path.Base(n) == strings.TrimSuffix(name, ".tmpl") {
+			found = append(found, n)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", usageError(fmt.Sprintf("no template %s, nor one in %s", name, dir))
+	case 1:
+		return filepath.Join(dir, filepath.FromSlash(found[0])+".tmpl"), nil
+	}
+	return "", usageError(fmt.Sprintf("%s could be any of %s", name, strings.Join(found, ", ")))
+}
+
+// listTemplates returns the names of the templates under dir, which are
+// their paths without the .tmpl extension, e.g. llm-prompts/commitmsg
+func listTemplates(dir string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && p == dir {
+			return fs.SkipAll
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && p != dir && strings.HasPrefix(d.Name(), ".") {
+			return fs.SkipDir
+		}
+		if d.IsDir() || filepath.Ext(p) != ".tmpl" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		names = append(names, strings.TrimSuffix(filepath.ToSlash(rel), ".tmpl"))
+		return nil
+	})
+	return names, err
+}
+
+// applyTemplate makes the -t template's prompt the query, filled in from
+// --arg, --ctx and the template's defaults, and takes the template's
+// settings where options don't give them. The words after the options are
+// {{.query}} in the template, or are added after the prompt if it doesn't
+// use them.
+func applyTemplate(opts *options) error {
+	if opts.template == "" {
+		if len(opts.args) > 0 {
+			return usageError("--arg is for -t templates")
+		}
+		return nil
+	}
+	given, err := parseVars(opts.args)
+	if err != nil {
+		return err
+	}
+	path, err := templatePath(opts.template)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	t, err := parseTemplate(string(data))
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	vars := map[string]string{"query": opts.query}
+	maps.Copy(vars, t.args)
+	maps.Copy(vars, opts.ctxVars)
+	maps.Copy(vars, given)
+	name := filepath.Base(path)
+	prompt, err := expandTemplate(name, t.prompt, vars, nil)
+	var missing missingVarError
+	if errors.As(err, &missing) {
+		v := string(missing)
+		return usageError(fmt.Sprintf("%s needs a value for %s: add --arg %s=VALUE", name, v, v))
+	}
+	if err != nil {
+		return err
+	}
+	prompt = strings.TrimSpace(prompt)
+	if opts.query != "" && !strings.Contains(t.prompt, ".query") {
+		prompt += "\n\n" + opts.query
+	}
+	opts.query = prompt
+
+	if opts.mode == llm.CommandMode {
+		opts.mode = t.mode
+	}
+	opts.model = cmp.Or(opts.model, t.model)
+	if opts.codeLang == "" && t.lang != "" {
+		opts.codeLang = t.lang
+		if opts.mode == llm.CommandMode {
+			opts.mode = llm.CodeMode
+		}
+	}
+	return nil
+}
+
+const templatesUsage = `usage: llm templates install <git URL, e.g. github.com/user/llm-prompts>
+       llm templates list`
+
+// runTemplates installs and lists template packs, which are git
+// repositories of templates
+func runTemplates(args []string) error {
+	if len(args) == 0 {
+		return usageError(templatesUsage)
+	}
+	switch args[0] {
+	case "install":
+		flagSet := flag.NewFlagSet("llm templates install", flag.ContinueOnError)
+		if err := flagSet.Parse(args[1:]); err != nil {
+			return usageError(err.Error())
+		}
+		if flagSet.NArg() != 1 {
+			return usageError(templatesUsage)
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		if source := packURL(flagSet.Arg(0)); cfg.Bool("offline") && !isLocalPack(source) {
+			return fmt.Errorf("offline mode doesn't allow cloning %s; install a pack from a local path", source)
+		}
+		return installTemplates(flagSet.Arg(0))
+	case "list":
+		if len(args) > 1 {
+			return usageError(templatesUsage)
+		}
+		return printTemplates()
+	}
+	return usageError(templatesUsage)
+}
+
+// packURL returns the URL to clone for a pack given as, say,
+// github.com/user/llm-prompts. URLs and local paths are cloned as they are.
+func packURL(source string) string {
+	if strings.Contains(source, "://") || strings.HasPrefix(source, "git@") ||
+		filepath.IsAbs(source) || strings.HasPrefix(source, ".") {
+		return source
+	}
+	return "https://" + source
+}
+
+// isLocalPack reports whether the pack at url, as packURL returns it, is on
+// this machine
+func isLocalPack(url string) bool {
+	return filepath.IsAbs(url) || strings.HasPrefix(url, ".") || strings.HasPrefix(url, "file://")
+}
+
+// installTemplates clones the pack at source into the templates directory,
+// named after its last path element, or updates it if it's there already
+func installTemplates(source string) error {
+	name := strings.TrimSuffix(path.Base(filepath.ToSlash(strings.TrimRight(source, "/"))), ".git")
+	if name == "" || name == "." || name == ".." || strings.HasPrefix(name, ".") {
+		return usageError(fmt.Sprintf("can't name a pack after %q", source))
+	}
+	dir, err := templatesDir()
+	if err != nil {
+		return err
+	}
+	dest := filepath.Join(dir, name)
+	if _, err := os.Stat(filepath.Join(dest, ".git")); err == nil {
+		if err := runGit(dest, "pull", "-q", "--ff-only"); err != nil {
+			return err
+		}
+	} else {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+		if err := runGit(dir, "clone", "-q", "--depth", "1", packURL(source), name); err != nil {
+			return err
+		}
+	}
+	names, err := listTemplates(dest)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(notices, "Installed %d templates in %s; see llm templates list\n", len(names), dest)
+	return nil
+}
+
+// runGit runs git in dir, showing its messages
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s failed: %v", args[0], err)
+	}
+	return nil
+}
+
+// printTemplates lists the installed templates and their descriptions
+func printTemplates() error {
+	dir, err := templatesDir()
+	if err != nil {
+		return err
+	}
+	names, err := listTemplates(dir)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		description := ""
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)+".tmpl"))
+		if err == nil {
+			if t, err := parseTemplate(string(data)); err == nil {
+				description = t.description
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\n", name, description)
+	}
+	return w.Flush()
+}
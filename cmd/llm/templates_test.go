@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tmpl, err := parseTemplate("mode = \"code\"\r\nlang = \"go\"\r\n[args]\r\nscope = \"cli\"\r\n---\r\nTest {{.scope}}\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tmpl.mode != llm.CodeMode || tmpl.lang != "go" || tmpl.args["scope"] != "cli" || tmpl.prompt != "Test {{.scope}}\n" {
+		t.Errorf("got %+v", tmpl)
+	}
+
+	tmpl, err = parseTemplate("Just a prompt\nwith = signs\n")
+	if err != nil || tmpl.prompt != "Just a prompt\nwith = signs\n" {
+		t.Errorf("prompt without settings = %+v, %v", tmpl, err)
+	}
+
+	for _, bad := range []string{"mode = \"commit\"\n---\nx", "mode = code\n---\nx"} {
+		if _, err := parseTemplate(bad); err == nil {
+			t.Errorf("parseTemplate(%q) should fail", bad)
+		}
+	}
+}
+
+func TestApplyTemplate(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("APPDATA", dir)
+	configDir := filepath.Join(dir, "llm", "templates")
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"commitmsg.tmpl": "mode = \"explain\"\nmodel = \"gpt-4o-mini\"\n[args]\nstyle = \"short\"\n---\nA {{.style}} message for {{.scope}}\n",
+		"ask.tmpl":       "lang = \"python\"\n---\nAnswer: {{.query}}\n",
+		"runs.tmpl":      "Explain {{sh \"touch pwned\"}}\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(configDir, name), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		args  []string
+		query string
+		mode  llm.Mode
+		model string
+		err   string
+	}{
+		{[]string{"-t", "commitmsg", "--arg", "scope=cli"}, "A short message for cli", llm.ExplainMode, "gpt-4o-mini", ""},
+		{[]string{"-t", "commitmsg.tmpl", "--ctx", "scope=api", "--arg", "style=long", "-m", "llama3", "mind the tests"}, "A long message for api\n\nmind the tests", llm.ExplainMode, "llama3", ""},
+		{[]string{"-t", "commitmsg", "-c"}, "", 0, "", "add --arg scope=VALUE"},
+		{[]string{"--template", "ask", "sort a dict"}, "Answer: sort a dict", llm.CodeMode, "", ""},
+		{[]string{"-t", "ask", "--sql", "q"}, "Answer: q", llm.SQLMode, "", ""},
+		{[]string{"-t", "missing", "q"}, "", 0, "", "no template missing"},
+		{[]string{"--arg", "a=b", "q"}, "", 0, "", "--arg is for -t"},
+		{[]string{"-t", "runs"}, "", 0, "", `function "sh" not defined`},
+	}
+	for _, tt := range tests {
+		opts, err := parseArgs(tt.args, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = applyContextVars(opts); err == nil {
+			err = applyTemplate(opts)
+		}
+		if tt.err != "" {
+			if exitCode(err) != exitUsage || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: error = %v, want %q", tt.args, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || opts.query != tt.query || opts.mode != tt.mode || opts.model != tt.model {
+			t.Errorf("%q: query %q, mode %v, model %q, %v; want %q, %v, %q", tt.args, opts.query, opts.mode, opts.model, err, tt.query, tt.mode, tt.model)
+		}
+	}
+}
+
+func TestInstallTemplates(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("APPDATA", filepath.Join(dir, "config"))
+	pack := filepath.Join(dir, "llm-prompts")
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", pack, "-c", "user.name=t", "-c", "user.email=t@t"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", args[0], err, out)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(pack, "git"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(pack, "git", "commitmsg.tmpl"), []byte("description = \"Commit message\"\n---\nWrite one\n"), 0600)
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "first")
+
+	if err := runTemplates([]string{"install", pack}); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(pack, "review.tmpl"), []byte("Review this\n"), 0600)
+	git("add", ".")
+	git("commit", "-q", "-m", "second")
+	if err := runTemplates([]string{"install", pack}); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := templatesDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, err := listTemplates(templates)
+	if err != nil || !slices.Equal(names, []string{"llm-prompts/git/commitmsg", "llm-prompts/review"}) {
+		t.Errorf("templates = %q, %v", names, err)
+	}
+	for _, name := range []string{"commitmsg", "llm-prompts/review", "llm-prompts/git/commitmsg.tmpl"} {
+		if _, err := templatePath(name); err != nil {
+			t.Errorf("templatePath(%q): %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// terraformShowTimeout bounds terraform show, which may need to load the
+// providers to read a saved plan
+const terraformShowTimeout = 30 * time.Second
+
+// terraformTools run Terraform configurations, in the order they're
+// looked for on the PATH
+var terraformTools = []string{"terraform", "tofu"}
+
+// savedPlanMagic starts the files terraform plan -out writes, which are
+// zip archives
+const savedPlanMagic = "PK\x03\x04"
+
+// prepareTF tells the model which Terraform is installed and, unless some
+// are attached, which .tf files the working directory has. Piped plan
+// output has its colors removed, and saved plans attached with -f are
+// turned into text with terraform show.
+func prepareTF(sys *llm.System) error {
+	tool := terraformTool()
+	if tool != "" {
+		if version := toolVersion(tool); version != "" {
+			sys.Notes = append(sys.Notes, fmt.Sprintf("%s is %s", tool, version))
+		}
+	}
+
+	attachedConfig := false
+	for i, a := range sys.Attachments {
+		switch {
+		case a.Name == "":
+			a.Content = ansiRe.ReplaceAllString(a.Content, "")
+			if isPlanOutput(a.Content) {
+				a.Title = "Output of terraform plan"
+			}
+		case strings.HasPrefix(a.Content, savedPlanMagic):
+			if tool == "" {
+				return fmt.Errorf("%s is a saved plan, and reading it needs terraform or tofu on the PATH", a.Name)
+			}
+			plan, err := terraformShow(tool, a.Name)
+			if err != nil {
+				return err
+			}
+			a.Content, a.Title = plan, fmt.Sprintf("The plan saved in %s (%s show)", a.Name, tool)
+		case filepath.Ext(a.Name) == ".tf":
+			attachedConfig = true
+		}
+		sys.Attachments[i] = a
+	}
+
+	if !attachedConfig {
+		if files, _ := filepath.Glob("*.tf"); len(files) > 0 {
+			sys.Notes = append(sys.Notes, "Terraform files in the current directory, not attached: "+strings.Join(files, ", "))
+		}
+	}
+	return nil
+}
+
+// terraformTool returns the first of terraformTools on the PATH, or "" if
+// neither is installed
+func terraformTool() string {
+	for _, tool := range terraformTools {
+		if _, err := exec.LookPath(tool); err == nil {
+			return tool
+		}
+	}
+	return ""
+}
+
+// isPlanOutput reports whether output looks like it's from terraform plan
+// or apply
+func isPlanOutput(output string) bool {
+	for _, marker := range []string{"Terraform will perform the following actions", "OpenTofu will perform the following actions", "\nPlan: "} {
+		if strings.Contains(output, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// terraformShow returns the saved plan at path as terraform show prints it
+func terraformShow(tool, path string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), terraformShowTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, tool, "show", "-no-color", path)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s show %s: %s", tool, path, msg)
+		}
+		return "", fmt.Errorf("%s show %s: %v", tool, path, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// fakeTerraform puts a terraform on PATH that reports its version and shows
+// any saved plan as one that replaces a database
+func fakeTerraform(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell script")
+	}
+	dir := t.TempDir()
+	script := `#!/bin/sh
+case "$1" in
+--version) echo "Terraform v1.7.5"; echo "on linux_amd64" ;;
+show) echo "  # aws_db_instance.main must be replaced" ;;
+*) echo "unexpected: $*" >&2; exit 1 ;;
+esac
+`
+	os.WriteFile(filepath.Join(dir, "terraform"), []byte(script), 0755)
+	t.Setenv("PATH", dir)
+}
+
+func TestPrepareTF(t *testing.T) {
+	fakeTerraform(t)
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	t.Cleanup(func() { os.Chdir(wd) })
+	os.WriteFile("main.tf", []byte(`resource "aws_db_instance" "main" {}`), 0644)
+	os.WriteFile("variables.tf", nil, 0644)
+
+	sys := llm.System{Attachments: []llm.Attachment{
+		{Content: "\x1b[1mTerraform will perform the following actions:\x1b[0m\n\nPlan: 1 to add, 0 to change, 1 to destroy."},
+		{Name: "tfplan", Content: savedPlanMagic + "\x14\x00"},
+	}}
+	if err := prepareTF(&sys); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"terraform is Terraform v1.7.5", "Terraform files in the current directory, not attached: main.tf, variables.tf"}
+	if !reflect.DeepEqual(sys.Notes, want) {
+		t.Errorf("notes = %q", sys.Notes)
+	}
+	if a := sys.Attachments[0]; a.Title != "Output of terraform plan" || strings.Contains(a.Content, "\x1b") {
+		t.Errorf("piped plan = %+v", a)
+	}
+	if a := sys.Attachments[1]; a.Content != "# aws_db_instance.main must be replaced" {
+		t.Errorf("saved plan = %+v", a)
+	}
+
+	sys = llm.System{Attachments: []llm.Attachment{{Name: "main.tf", Content: "locals {}"}}}
+	if err := prepareTF(&sys); err != nil {
+		t.Fatal(err)
+	}
+	if len(sys.Notes) != 1 {
+		t.Errorf("listed the files with one attached: %q", sys.Notes)
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	sys = llm.System{Attachments: []llm.Attachment{{Name: "tfplan", Content: savedPlanMagic}}}
+	if err := prepareTF(&sys); err == nil {
+		t.Error("read a saved plan without terraform")
+	}
+}
+
+func TestIsPlanOutput(t *testing.T) {
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{"OpenTofu will perform the following actions:\n  + create", true},
+		{"Refreshing state...\n\nPlan: 0 to add, 1 to change, 0 to destroy.", true},
+		{"Error: Unsupported argument", false},
+	}
+	for _, tt := range tests {
+		if got := isPlanOutput(tt.output); got != tt.want {
+			t.Errorf("isPlanOutput(%q) = %v", tt.output, got)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// prepareText sets up sys for --translate and --proofread, which work on
+// the query or on piped or attached text
+func prepareText(sys *llm.System, opts *options) error {
+	if opts.mode == llm.TranslateMode {
+		sys.Notes = append(sys.Notes, "Translate into: "+opts.language)
+	}
+	for i, a := range sys.Attachments {
+		if a.Name == "" {
+			sys.Attachments[i].Title = "Text"
+		}
+	}
+	if opts.query == "" && len(sys.Attachments) == 0 {
+		return errors.New("give the text as the query or pipe it in")
+	}
+	return nil
+}
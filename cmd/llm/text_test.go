@@ -0,0 +1,25 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestPrepareText(t *testing.T) {
+	sys := llm.System{Attachments: []llm.Attachment{{Content: "Hallo Welt"}}}
+	if err := prepareText(&sys, &options{mode: llm.TranslateMode, language: "English"}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sys.Notes, []string{"Translate into: English"}) || sys.Attachments[0].Title != "Text" {
+		t.Errorf("sys = %+v", sys)
+	}
+
+	if err := prepareText(&llm.System{}, &options{mode: llm.ProofreadMode, query: "i has a idea"}); err != nil {
+		t.Errorf("query as text: %v", err)
+	}
+	if err := prepareText(&llm.System{}, &options{mode: llm.ProofreadMode}); err == nil {
+		t.Error("expected an error with no text")
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// clientTLS returns the TLS settings for requests to provider, from its
+// <name>_client_cert, <name>_client_key and <name>_ca_cert settings, for
+// gateways that require mutual TLS. It returns nil if none are set.
+func clientTLS(cfg *config.Config, provider llm.Provider) (*tls.Config, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	name := providerConfigName(provider)
+	certFile := cfg.String(name + "_client_cert")
+	keyFile := cfg.String(name + "_client_key")
+	caFile := cfg.String(name + "_ca_cert")
+	if certFile == "" && keyFile == "" && caFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	switch {
+	case certFile != "" && keyFile != "":
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s_client_cert: %v", name, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	case certFile != "":
+		return nil, fmt.Errorf("%s_client_cert is set without %s_client_key", name, name)
+	case keyFile != "":
+		return nil, fmt.Errorf("%s_client_key is set without %s_client_cert", name, name)
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s_ca_cert: %v", name, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s_ca_cert: no certificates in %s", name, caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
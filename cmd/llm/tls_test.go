@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// testCert issues a certificate signed by parent, or a self-signed CA if
+// parent is nil
+func testCert(t *testing.T, name string, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := tmpl, any(key)
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// writePEM writes cert and its key to PEM files in dir
+func writePEM(t *testing.T, dir, name string, cert tls.Certificate) (certFile, keyFile string) {
+	t.Helper()
+	certFile = filepath.Join(dir, name+".pem")
+	keyFile = filepath.Join(dir, name+"-key.pem")
+	der, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestClientTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := testCert(t, "ca", nil)
+	caFile, _ := writePEM(t, dir, "ca", ca)
+	certFile, keyFile := writePEM(t, dir, "client", testCert(t, "client", &ca))
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"choices":[{"message":{"content":"ok"}}]}`)
+	}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{testCert(t, "gateway", &ca)},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	t.Setenv("LLM_RECORD_DIR", "")
+	t.Setenv("LLM_REPLAY_DIR", "")
+
+	tests := []struct {
+		name, config, wantErr string
+	}{
+		{"mutual TLS", "openai_endpoint = %[4]q\nopenai_client_cert = %[1]q\nopenai_client_key = %[2]q\nopenai_ca_cert = %[3]q", ""},
+		{"no client cert", "openai_ca_cert = %[3]q", "certificate"},
+		{"other provider's cert", "anthropic_client_cert = %[1]q\nanthropic_client_key = %[2]q\nopenai_ca_cert = %[3]q", "certificate"},
+		{"cert without key", "openai_client_cert = %[1]q", "openai_client_cert is set without openai_client_key"},
+		{"missing file", "openai_client_cert = \"/nonexistent\"\nopenai_client_key = %[2]q", "openai_client_cert:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := config.Parse(fmt.Sprintf(tt.config, certFile, keyFile, caFile, srv.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got string
+			c := &llm.Client{Provider: llm.OpenAI, APIKey: "sk-test", Model: "gpt-4o"}
+			if tt.wantErr != "" {
+				c.Endpoint = srv.URL
+			}
+			if err = setupClient(cfg, c); err == nil {
+				got, err = c.Query(context.Background(), "hi")
+			}
+			if tt.wantErr == "" {
+				if err != nil || got != "ok" {
+					t.Errorf("got %q, %v", got, err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+const tmuxUsage = "usage: llm tmux-capture [--pane TARGET] [--lines N] [question about the output]"
+
+// Limits on the scrollback llm tmux-capture sends, unless tmux_lines is set
+const (
+	defaultTmuxLines = 200
+	maxCaptureBytes  = 32 << 10
+)
+
+// runTmuxCapture asks about what's on a tmux pane, by default the one llm
+// runs in, so "what went wrong above?" needs no copying and pasting
+func runTmuxCapture(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	opts := &options{mode: llm.ExplainMode}
+	var pane string
+	var lines int
+	flagSet := flag.NewFlagSet("llm tmux-capture", flag.ContinueOnError)
+	flagSet.StringVar(&pane, "pane", "", "tmux `target` pane to capture, e.g. {last} or 1.2 (default: this pane)")
+	flagSet.IntVar(&lines, "lines", cmp.Or(cfg.Int("tmux_lines"), defaultTmuxLines), "Capture this many lines of scrollback")
+	flagSet.BoolVar(&opts.context, "context", cfg.Bool("context"), "Include project context in the prompt")
+	flagSet.BoolVar(&opts.yes, "y", false, "Don't ask before sending large output")
+	flagSet.BoolVar(&opts.noRedact, "no-redact", false, "Send the output without redacting secrets")
+	opts.log.register(flagSet)
+	flagSet.Usage = func() {
+		fmt.Fprintln(os.Stderr, tmuxUsage)
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if lines <= 0 {
+		return usageError("--lines must be positive")
+	}
+	opts.query = expandAliases(strings.Join(flagSet.Args(), " "), cfg)
+	if opts.query == "" {
+		opts.query = "What went wrong above, and how do I fix it?"
+	}
+
+	ownPane := pane == ""
+	if ownPane {
+		if os.Getenv("TMUX") == "" {
+			return errors.New("not inside tmux; run llm tmux-capture in a tmux pane, or pass --pane")
+		}
+		pane = os.Getenv("TMUX_PANE")
+	}
+	out, err := exec.Command("tmux", captureArgs(pane, lines)...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return fmt.Errorf("tmux capture-pane failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return fmt.Errorf("tmux capture-pane failed: %v", err)
+	}
+	scrollback := trimCapture(string(out), ownPane, maxCaptureBytes)
+	if scrollback == "" {
+		return errors.New("the pane is empty")
+	}
+
+	sys := llm.DetectSystem()
+	sys.Attachments = []llm.Attachment{{Title: "Terminal output (tmux scrollback)", Content: scrollback}}
+	if opts.context {
+		if wd, err := os.Getwd(); err == nil {
+			project := llm.DetectProject(wd)
+			sys.Project = &project
+		}
+	}
+
+	if err := opts.log.setup(); err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+	response, err := ask(context.Background(), cfg, client, opts, sys)
+	if err != nil {
+		return err
+	}
+	printResponse(opts, response)
+	return nil
+}
+
+// captureArgs returns the tmux arguments that print the last lines of
+// pane's scrollback and screen, with wrapped lines joined
+func captureArgs(pane string, lines int) []string {
+	args := []string{"capture-pane", "-p", "-J", "-S", "-" + strconv.Itoa(lines)}
+	if pane != "" {
+		args = append(args, "-t", pane)
+	}
+	return args
+}
+
+// trimCapture drops the blank lines tmux pads the screen with and, for
+// llm's own pane, the line that ran llm, then keeps at most the last max
+// bytes, starting at a line
+func trimCapture(text string, ownPane bool, max int) string {
+	text = strings.TrimRight(text, " \t\n")
+	if ownPane {
+		if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+			text = strings.TrimRight(text[:i], " \t\n")
+		} else {
+			text = ""
+		}
+	}
+	if len(text) <= max {
+		return text
+	}
+	text = text[len(text)-max:]
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[i+1:]
+	}
+	return "...\n" + text
+}
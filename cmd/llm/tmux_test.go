@@ -0,0 +1,42 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCaptureArgs(t *testing.T) {
+	if got, want := captureArgs("%3", 50), []string{"capture-pane", "-p", "-J", "-S", "-50", "-t", "%3"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := captureArgs("", 50); slices.Contains(got, "-t") {
+		t.Errorf("no pane gave %q", got)
+	}
+}
+
+func TestTrimCapture(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		ownPane bool
+		max     int
+		want    string
+	}{
+		{"own pane", "$ make\nmake: *** No rule\n$ llm tmux-capture\n\n\n", true, 100, "$ make\nmake: *** No rule"},
+		{"other pane", "$ make\nmake: *** No rule\n\n", false, 100, "$ make\nmake: *** No rule"},
+		{"only llm", "$ llm tmux-capture\n\n", true, 100, ""},
+		{"capped at a line", "one\ntwo\nthree\nfour\n", false, 12, "...\nthree\nfour"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := trimCapture(tt.text, tt.ownPane, tt.max)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if len(strings.TrimPrefix(got, "...\n")) > tt.max {
+				t.Errorf("%d bytes, over %d", len(got), tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// Limits on what --tools lets the model do
+const (
+	maxToolRounds = 5
+	maxToolOutput = 16 << 10
+	toolTimeout   = 10 * time.Second
+)
+
+// toolsNote tells the model what the tools are for
+const toolsNote = "Tools are available to inspect this machine read-only. Use them only when facts about it would change the answer, and then answer as instructed."
+
+// readOnlyCommands are the programs run_readonly_cmd may run. Programs with
+// subcommands list the ones allowed; the rest may be run with any
+// arguments checkReadOnly doesn't refuse. tool_commands in the config file
+// adds programs.
+var readOnlyCommands = map[string][]string{
+	"df":        nil,
+	"du":        nil,
+	"free":      nil,
+	"id":        nil,
+	"ls":        nil,
+	"lsblk":     nil,
+	"nproc":     nil,
+	"ps":        nil,
+	"ss":        nil,
+	"uname":     nil,
+	"uptime":    nil,
+	"which":     nil,
+	"whoami":    nil,
+	"docker":    {"ps", "images", "version", "info"},
+	"git":       {"status", "log", "diff", "show", "ls-files", "rev-parse"},
+	"kubectl":   {"get", "describe", "version", "top", "api-resources"},
+	"systemctl": {"status", "list-units", "is-active", "is-enabled"},
+}
+
+// ssWriteFlagRe matches the ss options that aren't read-only: -K closes the
+// sockets it lists, and -D writes them to a file. ss takes any unambiguous
+// abbreviation of --kill and --diag, such as --ki.
+var ssWriteFlagRe = regexp.MustCompile(`^(-[A-Za-z]*[KD]|--(k|di))`)
+
+// gitWriteFlagRe matches the git options that write files, such as
+// git diff --output=FILE
+var gitWriteFlagRe = regexp.MustCompile(`^--output`)
+
+// toolbox returns the tools offered to the model with --tools. Every call
+// is shown and, unless opts.yes is set, confirmed first.
+func toolbox(cfg *config.Config, opts *options) []llm.Tool {
+	patterns := slices.Concat(defaultSensitivePatterns, cfg.Strings("sensitive_paths"))
+	extra := cfg.Strings("tool_commands")
+
+	pathParam := map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"path": map[string]any{"type": "string"}},
+		"required":   []string{"path"},
+	}
+	return []llm.Tool{
+		{
+			Name:        "read_file",
+			Description: "Read a text file on the user's machine. Large files are cut short.",
+			Parameters:  pathParam,
+			Run: func(ctx context.Context, raw json.RawMessage) (string, error) {
+				var args struct{ Path string }
+				if err := json.Unmarshal(raw, &args); err != nil || args.Path == "" {
+					return "", errors.New("expected a path")
+				}
+				if isSensitivePath(args.Path, patterns) {
+					return "", errors.New("that file may hold secrets, so it can't be read")
+				}
+				if err := allowTool("read "+args.Path, opts.yes); err != nil {
+					return "", err
+				}
+				data, err := os.ReadFile(args.Path)
+				if err != nil {
+					return "", err
+				}
+				return toolResult(string(data), opts), nil
+			},
+		},
+		{
+			Name:        "list_dir",
+			Description: "List a directory on the user's machine with sizes and types.",
+			Parameters:  pathParam,
+			Run: func(ctx context.Context, raw json.RawMessage) (string, error) {
+				var args struct{ Path string }
+				if err := json.Unmarshal(raw, &args); err != nil || args.Path == "" {
+					return "", errors.New("expected a path")
+				}
+				if err := allowTool("list "+args.Path, opts.yes); err != nil {
+					return "", err
+				}
+				listing, err := llm.ListDir(args.Path, maxListing)
+				if err != nil {
+					return "", err
+				}
+				return toolResult(listing, opts), nil
+			},
+		},
+		{
+			Name: "run_readonly_cmd",
+			Description: "Run a read-only command on the user's machine, without a shell, and return its output. " +
+				"Allowed programs: " + strings.Join(allowedPrograms(extra), ", ") + ".",
+			Parameters: map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"command": map[string]any{"type": "string", "description": "e.g. df -h"}},
+				"required":   []string{"command"},
+			},
+			Run: func(ctx context.Context, raw json.RawMessage) (string, error) {
+				var args struct{ Command string }
+				if err := json.Unmarshal(raw, &args); err != nil || args.Command == "" {
+					return "", errors.New("expected a command")
+				}
+				argv, err := checkReadOnly(args.Command, extra)
+				if err != nil {
+					return "", err
+				}
+				if err := allowTool("run `"+args.Command+"`", opts.yes); err != nil {
+					return "", err
+				}
+				return runReadOnly(ctx, argv, opts)
+			},
+		},
+	}
+}
+
+// allowedPrograms lists the programs run_readonly_cmd accepts
+func allowedPrograms(extra []string) []string {
+	var names []string
+	for name, subcommands := range readOnlyCommands {
+		if subcommands != nil {
+			name += " (" + strings.Join(subcommands, ", ") + ")"
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return append(names, extra...)
+}
+
+// checkReadOnly splits command into arguments, making sure it's a single
+// allowed command
+func checkReadOnly(command string, extra []string) ([]string, error) {
+	argv, err := splitCommand(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(argv) == 0 {
+		return nil, errors.New("empty command")
+	}
+
+	subcommands, ok := readOnlyCommands[argv[0]]
+	switch {
+	case slices.Contains(extra, argv[0]):
+	case !ok:
+		return nil, fmt.Errorf("%s isn't one of the allowed programs", argv[0])
+	case subcommands != nil && (len(argv) < 2 || !slices.Contains(subcommands, argv[1])):
+		return nil, fmt.Errorf("only these %s subcommands are allowed: %s", argv[0], strings.Join(subcommands, ", "))
+	}
+	for _, arg := range argv[1:] {
+		if argv[0] == "git" && gitWriteFlagRe.MatchString(arg) {
+			return nil, fmt.Errorf("git %s isn't read-only", arg)
+		}
+		if argv[0] == "ss" && ssWriteFlagRe.MatchString(arg) {
+			return nil, fmt.Errorf("ss %s isn't read-only", arg)
+		}
+	}
+	return argv, nil
+}
+
+// runReadOnly runs argv with a timeout and returns its combined output
+func runReadOnly(ctx context.Context, argv []string, opts *options) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, toolTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, argv[0], argv[1:]...).CombinedOutput()
+	result := toolResult(string(out), opts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("timed out after %v", toolTimeout)
+		}
+		return "", fmt.Errorf("%v\n%s", err, result)
+	}
+	return result, nil
+}
+
+// toolResult caps and redacts output before it's sent to the model
+func toolResult(output string, opts *options) string {
+	if len(output) > maxToolOutput {
+		output = output[:maxToolOutput] + "\n[... truncated ...]"
+	}
+	if !opts.noRedact {
+		output, _ = llm.Redact(output)
+	}
+	return output
+}
+
+// allowTool shows what the model wants to do and asks before it's done,
+// unless yes is set
+func allowTool(action string, yes bool) error {
+	fmt.Fprintf(os.Stderr, "The model wants to %s\n", action)
+	if yes {
+		return nil
+	}
+	ok, err := confirm("Allow it?")
+	if err != nil {
+		return fmt.Errorf("not allowed: %v", err)
+	}
+	if !ok {
+		return errors.New("the user didn't allow this")
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+func TestCheckReadOnly(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+		err     string
+	}{
+		{"df -h", []string{"df", "-h"}, ""},
+		{"git log --oneline -5", []string{"git", "log", "--oneline", "-5"}, ""},
+		{"kubectl get pods -A", []string{"kubectl", "get", "pods", "-A"}, ""},
+		{"mytool --stats", []string{"mytool", "--stats"}, ""},
+		{"rm -rf /tmp/x", nil, "isn't one of the allowed programs"},
+		{"git push --force", nil, "only these git subcommands"},
+		{"git", nil, "only these git subcommands"},
+		{"git diff --output=/etc/passwd", nil, "isn't read-only"},
+		{"git log --output /etc/passwd", nil, "isn't read-only"},
+		{"df --output=pcent", []string{"df", "--output=pcent"}, ""},
+		{"ss -tlnp", []string{"ss", "-tlnp"}, ""},
+		{"ss -K dport = :22", nil, "isn't read-only"},
+		{"ss -tK", nil, "isn't read-only"},
+		{"ss --kill", nil, "isn't read-only"},
+		{"ss --ki dst 1.2.3.4", nil, "isn't read-only"},
+		{"ss --dia=/tmp/x", nil, "isn't read-only"},
+		{"ss -D /tmp/dump", nil, "isn't read-only"},
+		{"df -h; rm -rf ~", nil, "shell syntax"},
+		{"du -sh $(pwd)", nil, "shell syntax"},
+	}
+	for _, tt := range tests {
+		got, err := checkReadOnly(tt.command, []string{"mytool"})
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("checkReadOnly(%q) error = %v, want %q", tt.command, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("checkReadOnly(%q) = %q, %v", tt.command, got, err)
+		}
+	}
+}
+
+func TestToolbox(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("token = abcdef123456\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("SECRET=1\n"), 0644)
+
+	tools := map[string]llm.Tool{}
+	for _, tool := range toolbox(nil, &options{yes: true}) {
+		tools[tool.Name] = tool
+	}
+	call := func(name string, args any) (string, error) {
+		raw, _ := json.Marshal(args)
+		return tools[name].Run(context.Background(), raw)
+	}
+
+	if out, err := call("read_file", map[string]string{"path": filepath.Join(dir, "notes.txt")}); err != nil || out != "token = [REDACTED]\n" {
+		t.Errorf("read_file = %q, %v", out, err)
+	}
+	if _, err := call("read_file", map[string]string{"path": filepath.Join(dir, ".env")}); err == nil {
+		t.Error("read_file should refuse files that may hold secrets")
+	}
+	if out, err := call("list_dir", map[string]string{"path": dir}); err != nil || !strings.Contains(out, "notes.txt") {
+		t.Errorf("list_dir = %q, %v", out, err)
+	}
+	if _, err := call("run_readonly_cmd", map[string]string{"command": "rm notes.txt"}); err == nil {
+		t.Error("run_readonly_cmd should refuse rm")
+	}
+	if _, err := call("run_readonly_cmd", map[string]string{}); err == nil {
+		t.Error("run_readonly_cmd should need a command")
+	}
+}
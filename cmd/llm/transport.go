@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// unattendedRetries is how many times llm batch and llm agent retry a
+// request that clearly wasn't answered, since no one is watching to run it
+// again
+const unattendedRetries = 2
+
+// setupClient points client at its provider's endpoint from the config, if
+// one is set, adds the headers in the provider's headers section, sends
+// idempotency keys if the provider's gateway honors them, gives it the
+// HTTP client for that provider and applies the policy
+func setupClient(cfg *config.Config, client *llm.Client) error {
+	name := providerConfigName(client.Provider)
+	if endpoint := cfg.String(name + "_endpoint"); endpoint != "" && client.Endpoint == "" {
+		client.Endpoint = endpoint
+	}
+	if headers := cfg.Section(name + "_headers"); len(headers) > 0 {
+		client.Headers = headers
+	}
+	client.IdempotencyKeys = cfg.Bool(name + "_idempotency_keys")
+	var err error
+	if client.HTTPClient, err = newHTTPClient(cfg, client.Provider); err != nil {
+		return err
+	}
+	return checkPolicy(cfg, client)
+}
+
+// providerConfigName returns the prefix of provider's settings in the
+// config, as in anthropic_key_cmd
+func providerConfigName(provider llm.Provider) string {
+	for _, p := range keyProviders {
+		if p.provider == provider {
+			return p.name
+		}
+	}
+	return provider.String()
+}
+
+// newHTTPClient returns the client used for all requests to provider, which
+// go through llm daemon when it's running unless LLM_NO_DAEMON is set or
+// the provider needs a client certificate. LLM_REPLAY_DIR serves responses
+// from previously recorded fixtures instead of the network, and
+// LLM_RECORD_DIR saves every response as a fixture. Providers' rate limits
+// are tracked for real requests, and each request is recorded as a span
+// when OpenTelemetry export is configured, or counted for --stats, and
+// allowed_endpoints and force_redaction in cfg are enforced.
+func newHTTPClient(cfg *config.Config, provider llm.Provider) (*http.Client, error) {
+	checkTelemetry(cfg)
+	tlsConfig, err := clientTLS(cfg, provider)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport
+	if tlsConfig != nil {
+		// The daemon's connections can't present the certificate
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.TLSClientConfig = tlsConfig
+		transport = t
+	} else if socket, err := daemonSocket(); err == nil && os.Getenv("LLM_NO_DAEMON") == "" {
+		if _, err := os.Stat(socket); err == nil {
+			transport = newDaemonTransport(socket, transport)
+		}
+	}
+	if dir := os.Getenv("LLM_RECORD_DIR"); dir != "" {
+		transport = &recordTransport{dir: dir, base: transport}
+	}
+	if dir := os.Getenv("LLM_REPLAY_DIR"); dir != "" {
+		transport = &replayTransport{dir: dir}
+	} else {
+		transport = newRateLimitTransport(transport)
+	}
+	if meter != nil {
+		transport = &meterTransport{meter: meter, base: transport}
+	}
+	if exporter != nil {
+		transport = &telemetryTransport{base: transport, record: exporter.Record}
+	}
+	return &http.Client{Transport: withPolicy(cfg, &debugTransport{base: transport})}, nil
+}
+
+// fixture is a recorded provider exchange. Request headers aren't stored so
+// that fixtures never contain credentials.
+type fixture struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Request string      `json:"request"`
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers"`
+	Body    string      `json:"body"`
+}
+
+// fixturePath returns where the response to req is recorded, keyed by a hash
+// of the method, URL and body. It also returns the request body.
+func fixturePath(dir string, req *http.Request) (string, []byte, error) {
+	var body []byte
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return "", nil, err
+		}
+		defer rc.Close()
+		if body, err = io.ReadAll(rc); err != nil {
+			return "", nil, err
+		}
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", req.Method, req.URL)
+	h.Write(body)
+	name := hex.EncodeToString(h.Sum(nil))[:16] + ".json"
+	return filepath.Join(dir, name), body, nil
+}
+
+// recordTransport saves each response it receives as a fixture
+type recordTransport struct {
+	dir  string
+	base http.RoundTripper
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path, reqBody, err := fixturePath(t.dir, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request for recording: %v", err)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	data, err := json.MarshalIndent(fixture{
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Request: string(reqBody),
+		Status:  resp.StatusCode,
+		Headers: resp.Header,
+		Body:    string(body),
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	// Fixtures hold whole prompts and answers, so are as private as the
+	// history
+	if err := os.MkdirAll(t.dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create record directory: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to record fixture: %v", err)
+	}
+	slog.Debug("recorded fixture", "path", path)
+
+	return resp, nil
+}
+
+// replayTransport answers requests from recorded fixtures without touching
+// the network
+type replayTransport struct {
+	dir string
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path, _, err := fixturePath(t.dir, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request for replay: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no recorded fixture for this request (looked for %s)", path)
+	}
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %v", path, err)
+	}
+	slog.Debug("replaying fixture", "path", path)
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+		StatusCode:    f.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        f.Headers,
+		Body:          io.NopCloser(strings.NewReader(f.Body)),
+		ContentLength: int64(len(f.Body)),
+		Request:       req,
+	}, nil
+}
+
+// debugTransport logs each provider request and response when debug logging
+// is enabled. Credentials are redacted.
+type debugTransport struct {
+	base http.RoundTripper
+}
+
+// Response headers that are useful when diagnosing failed requests
+var debugResponseHeaders = []string{
+	"request-id",
+	"x-request-id",
+	"retry-after",
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return t.base.RoundTrip(req)
+	}
+
+	var body []byte
+	if req.GetBody != nil {
+		if rc, err := req.GetBody(); err == nil {
+			body, _ = io.ReadAll(rc)
+			rc.Close()
+		}
+	}
+	slog.DebugContext(ctx, "sending request", "method", req.Method, "url", req.URL.String(),
+		"headers", redactHeaders(req.Header), "body", string(body))
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start)
+	if err != nil {
+		slog.DebugContext(ctx, "request failed", "elapsed", elapsed, "error", err)
+		return nil, err
+	}
+
+	attrs := []any{"status", resp.StatusCode, "elapsed", elapsed}
+	for name, values := range resp.Header {
+		lower := strings.ToLower(name)
+		if strings.Contains(lower, "ratelimit") || slices.Contains(debugResponseHeaders, lower) {
+			attrs = append(attrs, lower, strings.Join(values, ","))
+		}
+	}
+	slog.DebugContext(ctx, "received response", attrs...)
+
+	return resp, nil
+}
+
+// Words in the names of headers that carry credentials, including those
+// gateways add through the headers sections of the config
+var credentialHeaderWords = []string{"auth", "key", "token", "secret", "cookie", "signature"}
+
+// redactHeaders returns a copy of h with credentials removed
+func redactHeaders(h http.Header) http.Header {
+	redacted := h.Clone()
+	for name := range redacted {
+		lower := strings.ToLower(name)
+		for _, word := range credentialHeaderWords {
+			if strings.Contains(lower, word) {
+				redacted.Set(name, "REDACTED")
+				break
+			}
+		}
+	}
+	return redacted
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/internal/telemetry"
+	"github.com/jamesob/llm-cli/pkg/llm"
+)
+
+// testHTTPClient returns newHTTPClient(cfg, provider), failing the test on
+// an error
+func testHTTPClient(t *testing.T, cfg *config.Config, provider llm.Provider) *http.Client {
+	t.Helper()
+	client, err := newHTTPClient(cfg, provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// mockProvider starts a server that answers every request with status and body
+func mockProvider(t *testing.T, status int, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	srv := mockProvider(t, http.StatusOK, `{"response":"uptime"}`)
+	dir := t.TempDir()
+
+	// Record against the mock server
+	t.Setenv("LLM_REPLAY_DIR", "")
+	t.Setenv("LLM_RECORD_DIR", dir)
+	c := &llm.Client{Provider: llm.Ollama, Model: "llama3", Endpoint: srv.URL, HTTPClient: testHTTPClient(t, nil, llm.Ollama)}
+	if got, err := c.Query(context.Background(), "how long up"); err != nil || got != "uptime" {
+		t.Fatalf("record: got %q, %v", got, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one fixture, got %v (%v)", entries, err)
+	}
+	if info, err := entries[0].Info(); err != nil {
+		t.Fatal(err)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("fixture mode = %v, want 0600", info.Mode())
+	}
+
+	// Replay with the server gone
+	srv.Close()
+	t.Setenv("LLM_RECORD_DIR", "")
+	t.Setenv("LLM_REPLAY_DIR", dir)
+	c.HTTPClient = testHTTPClient(t, nil, llm.Ollama)
+	if got, err := c.Query(context.Background(), "how long up"); err != nil || got != "uptime" {
+		t.Fatalf("replay: got %q, %v", got, err)
+	}
+
+	// A different prompt has no fixture
+	if _, err := c.Query(context.Background(), "something else"); err == nil || !strings.Contains(err.Error(), "no recorded fixture") {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestRecordOmitsCredentials(t *testing.T) {
+	srv := mockProvider(t, http.StatusOK, `{"content":[{"type":"text","text":"ok"}]}`)
+	dir := t.TempDir()
+	t.Setenv("LLM_REPLAY_DIR", "")
+	t.Setenv("LLM_RECORD_DIR", dir)
+
+	c := &llm.Client{Provider: llm.Claude, APIKey: "sk-secret", Endpoint: srv.URL, HTTPClient: testHTTPClient(t, nil, llm.Claude)}
+	if _, err := c.Query(context.Background(), "q"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		data, _ := os.ReadFile(dir + "/" + e.Name())
+		if strings.Contains(string(data), "sk-secret") {
+			t.Errorf("fixture %s contains the API key", e.Name())
+		}
+	}
+}
+
+func TestSetupClient(t *testing.T) {
+	cfg, err := config.Parse(`
+openai_endpoint = "https://gateway.example.com/v1/chat/completions"
+
+[openai_headers]
+X-Org-Id = "acme"
+
+[anthropic_headers]
+X-Team = "infra"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := llm.NewClient(llm.OpenAI, "sk-test", "")
+	if err := setupClient(cfg, c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Endpoint != "https://gateway.example.com/v1/chat/completions" {
+		t.Errorf("endpoint = %q", c.Endpoint)
+	}
+	if len(c.Headers) != 1 || c.Headers["X-Org-Id"] != "acme" {
+		t.Errorf("headers = %v", c.Headers)
+	}
+}
+
+func TestRedactHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("x-api-key", "sk-ant")
+	h.Set("Authorization", "Bearer sk-oai")
+	h.Set("X-Gateway-Token", "gw-secret")
+	h.Set("X-Org-Id", "acme")
+	h.Set("Content-Type", "application/json")
+
+	got := redactHeaders(h)
+	if got.Get("x-api-key") != "REDACTED" || got.Get("Authorization") != "REDACTED" || got.Get("X-Gateway-Token") != "REDACTED" {
+		t.Errorf("credentials not redacted: %v", got)
+	}
+	if got.Get("Content-Type") != "application/json" || got.Get("X-Org-Id") != "acme" {
+		t.Errorf("unrelated header changed: %v", got)
+	}
+	if h.Get("x-api-key") != "sk-ant" {
+		t.Error("original headers were modified")
+	}
+}
+
+func TestTelemetryTransport(t *testing.T) {
+	tests := []struct {
+		name, path, body string
+		status           int
+		system           string
+		input, output    int
+		errorType        string
+	}{
+		{"claude", "/v1/messages", `{"usage":{"input_tokens":12,"output_tokens":30}}`, 200, "anthropic", 12, 30, ""},
+		{"openai", "/v1/chat/completions", `{"usage":{"prompt_tokens":5,"completion_tokens":7}}`, 200, "openai", 5, 7, ""},
+		{"ollama", "/api/chat", `{"prompt_eval_count":3,"eval_count":4}`, 200, "ollama", 3, 4, ""},
+		{"error", "/v1/messages", `{"error":{"message":"slow down"}}`, 429, "anthropic", 0, 0, "429"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := mockProvider(t, tt.status, tt.body)
+			var spans []telemetry.Span
+			client := &http.Client{Transport: &telemetryTransport{
+				base:   http.DefaultTransport,
+				record: func(s telemetry.Span) { spans = append(spans, s) },
+			}}
+			resp, err := client.Post(srv.URL+tt.path, "application/json", strings.NewReader(`{"model":"m1"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if string(body) != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+
+			if len(spans) != 1 {
+				t.Fatalf("recorded %d spans, want 1", len(spans))
+			}
+			s := spans[0]
+			if s.Name != "chat m1" || s.Attributes["gen_ai.request.model"] != "m1" || s.Attributes["gen_ai.system"] != tt.system {
+				t.Errorf("span = %+v", s)
+			}
+			if s.Attributes["http.response.status_code"] != tt.status {
+				t.Errorf("status = %v, want %d", s.Attributes["http.response.status_code"], tt.status)
+			}
+			if tt.errorType == "" {
+				if s.Error != "" || s.Attributes["gen_ai.usage.input_tokens"] != tt.input || s.Attributes["gen_ai.usage.output_tokens"] != tt.output {
+					t.Errorf("span = %+v, want %d and %d tokens", s, tt.input, tt.output)
+				}
+			} else if s.Error == "" || s.Attributes["error.type"] != tt.errorType {
+				t.Errorf("span = %+v, want error %s", s, tt.errorType)
+			}
+		})
+	}
+}
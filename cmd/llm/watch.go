@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jamesob/llm-cli/internal/config"
+	"github.com/jamesob/llm-cli/pkg/llm"
+	"github.com/jamesob/llm-cli/pkg/render"
+)
+
+const watchUsage = "usage: llm watch -f FILE [--mode fix|explain] [--trigger REGEXP] [--from-start] [what the log is from]"
+
+// defaultWatchTrigger matches the lines that start an error block, unless
+// --trigger or watch_trigger is set
+const defaultWatchTrigger = `(?i)\b(error|fatal|panic|exception|traceback|failed)\b`
+
+// How llm watch reads the file and splits it into error blocks: a block
+// starts watchContext lines before a line matching the trigger, and ends
+// once the file has been quiet for watchSettle or it's maxBlockLines long
+const (
+	watchPoll     = 250 * time.Millisecond
+	watchSettle   = time.Second
+	watchContext  = 10
+	maxBlockLines = 100
+)
+
+// runWatch follows a file like tail -f, and asks about each new block of
+// errors in it, printing the answer among the lines it's about
+func runWatch(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	opts := &options{}
+	var path, modeName, trigger string
+	var fromStart bool
+	flagSet := flag.NewFlagSet("llm watch", flag.ContinueOnError)
+	flagSet.StringVar(&path, "f", "", "The `file` to watch")
+	flagSet.StringVar(&modeName, "mode", "fix", "How to answer: fix (diagnose and suggest a fix) or explain")
+	flagSet.StringVar(&trigger, "trigger", cmp.Or(cfg.String("watch_trigger"), defaultWatchTrigger), "Regular expression matching lines that start an error block")
+	flagSet.BoolVar(&fromStart, "from-start", false, "Read the file from the start rather than only new lines")
+	flagSet.BoolVar(&opts.context, "context", cfg.Bool("context"), "Include project context in the prompt")
+	flagSet.BoolVar(&opts.noRedact, "no-redact", false, "Send the lines without redacting secrets")
+	opts.log.register(flagSet)
+	flagSet.Usage = func() {
+		fmt.Fprintln(os.Stderr, watchUsage)
+		flagSet.PrintDefaults()
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return usageError(err.Error())
+	}
+	if path == "" {
+		return usageError(watchUsage)
+	}
+	switch modeName {
+	case "fix":
+		opts.mode = llm.FixMode
+	case "explain":
+		opts.mode = llm.ExplainMode
+	default:
+		return usageError(fmt.Sprintf("unknown mode %q (expected fix or explain)", modeName))
+	}
+	triggerRe, err := regexp.Compile(trigger)
+	if err != nil {
+		return usageError(fmt.Sprintf("invalid --trigger: %v", err))
+	}
+	opts.query = expandAliases(strings.Join(flagSet.Args(), " "), cfg)
+	if opts.query == "" {
+		opts.query = "What went wrong in these lines of the log, and how do I fix it?"
+	}
+	// Blocks are kept small enough to never need confirming
+	opts.yes = true
+
+	sys := llm.DetectSystem()
+	if opts.context {
+		if wd, err := os.Getwd(); err == nil {
+			project := llm.DetectProject(wd)
+			sys.Project = &project
+		}
+	}
+	if err := opts.log.setup(); err != nil {
+		return err
+	}
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+	t, err := openTail(path, fromStart)
+	if err != nil {
+		return err
+	}
+	defer t.close()
+
+	ctx, stop := interruptible(context.Background())
+	defer stop()
+	fmt.Fprintf(notices, "Watching %s for lines matching %s; press Ctrl-C to stop\n", path, trigger)
+	blocks := &blockSplitter{trigger: triggerRe}
+	seen := map[string]bool{}
+	color := isTerminal(os.Stdout)
+	var quietSince time.Time
+	for ctx.Err() == nil {
+		lines, err := t.poll()
+		if err != nil {
+			return err
+		}
+		var done []string
+		for _, line := range lines {
+			fmt.Println(line)
+			if block := blocks.add(line); block != "" {
+				done = append(done, block)
+			}
+		}
+		if len(lines) > 0 {
+			quietSince = time.Now()
+		} else if time.Since(quietSince) >= watchSettle {
+			if block := blocks.flush(); block != "" {
+				done = append(done, block)
+			}
+		}
+
+		for _, block := range done {
+			if seen[block] {
+				fmt.Println(annotate("Same errors as before", color))
+				continue
+			}
+			seen[block] = true
+			sys.Attachments = []llm.Attachment{{Title: "New lines in " + path, Content: block}}
+			answer, err := watchAnswer(ctx, cfg, client, opts, sys)
+			if errors.Is(err, errInterrupted) {
+				return nil
+			}
+			if err != nil {
+				slog.Warn("Couldn't ask about the errors; still watching", "error", err)
+				continue
+			}
+			fmt.Println(annotate(answer, color))
+		}
+		if len(lines) == 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(watchPoll):
+			}
+		}
+	}
+	return nil
+}
+
+// watchAnswer asks about a block of errors and returns the answer as text
+// to print among the log's lines
+func watchAnswer(ctx context.Context, cfg *config.Config, client *llm.Client, opts *options, sys llm.System) (string, error) {
+	response, err := ask(ctx, cfg, client, opts, sys)
+	if err != nil {
+		return "", err
+	}
+	if opts.mode != llm.FixMode {
+		return response, nil
+	}
+	fix, err := llm.ParseFix(response)
+	if err != nil {
+		return "", err
+	}
+	answer := fix.Cause
+	if fix.Command != "" {
+		answer += "\n$ " + fix.Command
+	}
+	if fix.Steps != "" {
+		answer += "\n" + fix.Steps
+	}
+	return answer, nil
+}
+
+// annotate marks each line of an answer as llm's, so it stands out from the
+// log around it
+func annotate(answer string, color bool) string {
+	if color {
+		answer = render.Markdown(answer)
+	}
+	var b strings.Builder
+	for i, line := range strings.Split(strings.TrimRight(answer, "\n"), "\n") {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if color {
+			b.WriteString(render.Cyan + "llm>" + render.Reset + " " + line)
+		} else {
+			b.WriteString("llm> " + line)
+		}
+	}
+	return b.String()
+}
+
+// blockSplitter picks blocks of errors out of a stream of lines. A block
+// starts with the watchContext lines before one matching trigger, and ends
+// when it reaches maxBlockLines or flush is called.
+type blockSplitter struct {
+	trigger *regexp.Regexp
+	recent  []string // the lines before any block, up to watchContext
+	block   []string // the open block, if any
+}
+
+// add adds the next line, returning a block if the line completes one
+func (s *blockSplitter) add(line string) string {
+	if s.block == nil {
+		if !s.trigger.MatchString(line) {
+			s.recent = append(s.recent, line)
+			if len(s.recent) > watchContext {
+				s.recent = s.recent[1:]
+			}
+			return ""
+		}
+		s.block, s.recent = append(s.recent, line), nil
+	} else {
+		s.block = append(s.block, line)
+	}
+	if len(s.block) >= maxBlockLines {
+		return s.flush()
+	}
+	return ""
+}
+
+// flush ends the open block and returns it, or "" if there isn't one
+func (s *blockSplitter) flush() string {
+	if s.block == nil {
+		return ""
+	}
+	block := strings.Join(s.block, "\n") + "\n"
+	s.block = nil
+	return block
+}
+
+// tail reads the lines added to a file, following it when it's truncated
+// or replaced as logs are when they're rotated
+type tail struct {
+	path    string
+	f       *os.File
+	offset  int64
+	partial []byte // the start of a line not yet ended
+}
+
+// openTail opens path to read the lines added from now on, or from the
+// start if fromStart is set
+func openTail(path string, fromStart bool) (*tail, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	t := &tail{path: path, f: f}
+	if !fromStart {
+		if t.offset, err = f.Seek(0, io.SeekEnd); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// poll returns the whole lines added since the last poll
+func (t *tail) poll() ([]string, error) {
+	if info, err := os.Stat(t.path); err == nil {
+		if current, err := t.f.Stat(); err == nil && !os.SameFile(info, current) {
+			// Rotated: finish the old file, then read the new one from
+			// its start
+			lines, err := t.read()
+			f, openErr := os.Open(t.path)
+			if openErr != nil {
+				return lines, err
+			}
+			t.f.Close()
+			t.f, t.offset = f, 0
+			if len(t.partial) > 0 {
+				lines = append(lines, string(t.partial))
+			}
+			t.partial = nil
+			return lines, err
+		}
+		if info.Size() < t.offset {
+			// Truncated
+			t.offset, t.partial = 0, nil
+		}
+	}
+	return t.read()
+}
+
+func (t *tail) read() ([]string, error) {
+	data, err := io.ReadAll(io.NewSectionReader(t.f, t.offset, 1<<62))
+	if err != nil {
+		return nil, err
+	}
+	t.offset += int64(len(data))
+	data = append(t.partial, data...)
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		t.partial = data
+		return nil, nil
+	}
+	t.partial = bytes.Clone(data[end+1:])
+	var lines []string
+	for _, line := range strings.Split(string(data[:end]), "\n") {
+		lines = append(lines, strings.TrimRight(line, "\r"))
+	}
+	return lines, nil
+}
+
+func (t *tail) close() {
+	t.f.Close()
+}
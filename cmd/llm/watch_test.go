@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBlockSplitter(t *testing.T) {
+	s := &blockSplitter{trigger: regexp.MustCompile(defaultWatchTrigger)}
+	for i := range watchContext + 5 {
+		if block := s.add(fmt.Sprintf("compiling %d", i)); block != "" {
+			t.Fatalf("block without an error: %q", block)
+		}
+	}
+	if s.flush() != "" {
+		t.Error("flush without an error returned a block")
+	}
+	s.add("main.go:3: error: undefined x")
+	s.add("  note: did you mean y")
+	block := s.flush()
+	lines := strings.Split(strings.TrimSuffix(block, "\n"), "\n")
+	if len(lines) != watchContext+2 || lines[0] != "compiling 5" || lines[watchContext] != "main.go:3: error: undefined x" {
+		t.Errorf("block = %q", block)
+	}
+
+	// Context doesn't carry over from one block to the next, and a long
+	// block is cut off
+	s.add("Traceback (most recent call last):")
+	var got string
+	for i := 0; got == ""; i++ {
+		got = s.add(fmt.Sprintf("  frame %d", i))
+	}
+	if n := strings.Count(got, "\n"); n != maxBlockLines || !strings.HasPrefix(got, "Traceback") {
+		t.Errorf("long block has %d lines, starting %q", n, got[:20])
+	}
+}
+
+func TestTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "build.log")
+	write := func(flag int, text string) {
+		f, err := os.OpenFile(path, flag|os.O_WRONLY|os.O_CREATE, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.WriteString(text)
+		f.Close()
+	}
+	expect := func(tl *tail, want ...string) {
+		t.Helper()
+		got, err := tl.poll()
+		if err != nil || !slices.Equal(got, want) {
+			t.Errorf("poll() = %q, %v, want %q", got, err, want)
+		}
+	}
+
+	write(os.O_TRUNC, "old line\n")
+	tl, err := openTail(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tl.close()
+	expect(tl)
+	write(os.O_APPEND, "one\r\ntw")
+	expect(tl, "one")
+	write(os.O_APPEND, "o\nthree\n")
+	expect(tl, "two", "three")
+
+	write(os.O_TRUNC, "after truncation\n")
+	expect(tl, "after truncation")
+
+	os.Rename(path, path+".1")
+	write(os.O_APPEND, "rotated\n")
+	expect(tl)
+	expect(tl, "rotated")
+
+	fromStart, err := openTail(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fromStart.close()
+	expect(fromStart, "rotated")
+}
+
+func TestAnnotate(t *testing.T) {
+	got := annotate("The build needs Go 1.22\n$ go install golang.org/dl/go1.22@latest\n", false)
+	want := "llm> The build needs Go 1.22\nllm> $ go install golang.org/dl/go1.22@latest"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
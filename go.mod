@@ -0,0 +1,3 @@
+module github.com/jamesob/llm-cli
+
+go 1.22
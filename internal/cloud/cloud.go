@@ -0,0 +1,206 @@
+// Package cloud reads the account the aws, gcloud and az command-line tools
+// are set up to use (the profile, project or subscription, and region) from
+// their config files, without running them. Credentials are never read.
+package cloud
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Account is what a cloud CLI's commands apply to when no flags say
+// otherwise. Fields the config doesn't set are empty.
+type Account struct {
+	// Profile is the AWS profile or gcloud configuration
+	Profile string
+
+	// ID is the AWS account ID (when the profile uses SSO), the Google
+	// Cloud project or the Azure subscription
+	ID string
+
+	// Name is the Azure subscription's name
+	Name string
+
+	// User is the gcloud or az account logged in
+	User string
+
+	// Region is the default region, or the Azure location
+	Region string
+
+	// Zone is gcloud's default zone
+	Zone string
+
+	// Group is az's default resource group
+	Group string
+}
+
+// Notes describes the account as lines for the prompt, with the tool's own
+// names for its settings
+func (a Account) Notes(tool string) []string {
+	var notes []string
+	add := func(label, value string) {
+		if value != "" {
+			notes = append(notes, label+": "+value)
+		}
+	}
+	switch tool {
+	case "aws":
+		add("AWS profile", a.Profile)
+		add("AWS account ID", a.ID)
+		add("AWS region", a.Region)
+	case "gcloud":
+		add("gcloud configuration", a.Profile)
+		add("Google Cloud project", a.ID)
+		add("gcloud account", a.User)
+		add("Default region", a.Region)
+		add("Default zone", a.Zone)
+	case "az":
+		add("Azure subscription", a.Name)
+		add("Azure subscription ID", a.ID)
+		add("Azure account", a.User)
+		add("Default location", a.Region)
+		add("Default resource group", a.Group)
+	}
+	return notes
+}
+
+// AWS returns the profile aws uses, from $AWS_PROFILE, and its region and
+// SSO account from ~/.aws/config ($AWS_CONFIG_FILE), which $AWS_REGION
+// overrides
+func AWS(home string, getenv func(string) string) (Account, error) {
+	a := Account{Profile: cmp.Or(getenv("AWS_PROFILE"), getenv("AWS_DEFAULT_PROFILE"), "default")}
+	path := cmp.Or(getenv("AWS_CONFIG_FILE"), filepath.Join(home, ".aws", "config"))
+	sections, err := readINI(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return a, err
+	}
+	section := "profile " + a.Profile
+	if a.Profile == "default" && sections["default"] != nil {
+		section = "default"
+	}
+	profile := sections[section]
+	a.Region = cmp.Or(getenv("AWS_REGION"), getenv("AWS_DEFAULT_REGION"), profile["region"])
+	a.ID = profile["sso_account_id"]
+	return a, nil
+}
+
+// GCloud returns gcloud's active configuration and the project, account,
+// region and zone it sets, which $CLOUDSDK_CORE_PROJECT and the like
+// override
+func GCloud(home string, getenv func(string) string) (Account, error) {
+	dir := getenv("CLOUDSDK_CONFIG")
+	if dir == "" {
+		if appData := getenv("APPDATA"); appData != "" {
+			dir = filepath.Join(appData, "gcloud")
+		} else {
+			dir = filepath.Join(home, ".config", "gcloud")
+		}
+	}
+	name := getenv("CLOUDSDK_ACTIVE_CONFIG_NAME")
+	if name == "" {
+		data, err := os.ReadFile(filepath.Join(dir, "active_config"))
+		if errors.Is(err, os.ErrNotExist) {
+			return Account{}, errors.New("gcloud isn't set up; run gcloud init")
+		} else if err != nil {
+			return Account{}, err
+		}
+		name = strings.TrimSpace(string(data))
+	}
+	sections, err := readINI(filepath.Join(dir, "configurations", "config_"+name))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Account{}, err
+	}
+	return Account{
+		Profile: name,
+		ID:      cmp.Or(getenv("CLOUDSDK_CORE_PROJECT"), sections["core"]["project"]),
+		User:    cmp.Or(getenv("CLOUDSDK_CORE_ACCOUNT"), sections["core"]["account"]),
+		Region:  cmp.Or(getenv("CLOUDSDK_COMPUTE_REGION"), sections["compute"]["region"]),
+		Zone:    cmp.Or(getenv("CLOUDSDK_COMPUTE_ZONE"), sections["compute"]["zone"]),
+	}, nil
+}
+
+// azureProfile is the part of az's azureProfile.json naming the
+// subscriptions logged in to
+type azureProfile struct {
+	Subscriptions []struct {
+		ID        string `json:"id"`
+		Name      string `json:"name"`
+		IsDefault bool   `json:"isDefault"`
+		User      struct {
+			Name string `json:"name"`
+		} `json:"user"`
+	} `json:"subscriptions"`
+}
+
+// Azure returns az's default subscription from azureProfile.json, and its
+// default location and resource group from its config file, in
+// ~/.azure ($AZURE_CONFIG_DIR)
+func Azure(home string, getenv func(string) string) (Account, error) {
+	dir := cmp.Or(getenv("AZURE_CONFIG_DIR"), filepath.Join(home, ".azure"))
+	data, err := os.ReadFile(filepath.Join(dir, "azureProfile.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return Account{}, errors.New("az isn't logged in; run az login")
+	} else if err != nil {
+		return Account{}, err
+	}
+	var profile azureProfile
+	// az writes the file with a byte order mark
+	if err := json.Unmarshal(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), &profile); err != nil {
+		return Account{}, fmt.Errorf("failed to read azureProfile.json: %v", err)
+	}
+	var a Account
+	for _, s := range profile.Subscriptions {
+		if s.IsDefault {
+			a.ID, a.Name, a.User = s.ID, s.Name, s.User.Name
+		}
+	}
+	sections, err := readINI(filepath.Join(dir, "config"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return a, err
+	}
+	a.Region = cmp.Or(getenv("AZURE_DEFAULTS_LOCATION"), sections["defaults"]["location"])
+	a.Group = cmp.Or(getenv("AZURE_DEFAULTS_GROUP"), sections["defaults"]["group"])
+	return a, nil
+}
+
+// readINI reads the settings in an INI file by section and key. Comments
+// start with # or ;, and keys before any section are in the "" section.
+func readINI(path string) (map[string]map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseINI(string(data)), nil
+}
+
+// parseINI parses the contents of an INI file, as readINI
+func parseINI(data string) map[string]map[string]string {
+	sections := map[string]map[string]string{}
+	section := ""
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+		case line[0] == '[' && line[len(line)-1] == ']':
+			section = strings.Join(strings.Fields(line[1:len(line)-1]), " ")
+		default:
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			if sections[section] == nil {
+				sections[section] = map[string]string{}
+			}
+			sections[section][strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return sections
+}
@@ -0,0 +1,101 @@
+package cloud
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// env returns a getenv that looks variables up in vars
+func env(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+// writeFile writes data to path under dir, creating its directory
+func writeFile(t *testing.T, dir, path, data string) {
+	t.Helper()
+	path = filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAWS(t *testing.T) {
+	home := t.TempDir()
+	writeFile(t, home, ".aws/config", `[default]
+region = us-east-1
+
+# Production, through SSO
+[profile prod]
+sso_account_id = 123456789012
+region=eu-west-2
+`)
+	tests := []struct {
+		vars map[string]string
+		want Account
+	}{
+		{nil, Account{Profile: "default", Region: "us-east-1"}},
+		{map[string]string{"AWS_PROFILE": "prod"}, Account{Profile: "prod", ID: "123456789012", Region: "eu-west-2"}},
+		{map[string]string{"AWS_PROFILE": "prod", "AWS_REGION": "ap-south-1"}, Account{Profile: "prod", ID: "123456789012", Region: "ap-south-1"}},
+		{map[string]string{"AWS_PROFILE": "missing"}, Account{Profile: "missing"}},
+		{map[string]string{"AWS_CONFIG_FILE": filepath.Join(home, "none")}, Account{Profile: "default"}},
+	}
+	for _, tt := range tests {
+		if got, err := AWS(home, env(tt.vars)); err != nil || got != tt.want {
+			t.Errorf("%v: got %+v, %v, want %+v", tt.vars, got, err, tt.want)
+		}
+	}
+}
+
+func TestGCloud(t *testing.T) {
+	home := t.TempDir()
+	if _, err := GCloud(home, env(nil)); err == nil {
+		t.Error("no error when gcloud isn't set up")
+	}
+
+	writeFile(t, home, ".config/gcloud/active_config", "work\n")
+	writeFile(t, home, ".config/gcloud/configurations/config_work", `[core]
+account = me@example.com
+project = shop-prod
+
+[compute]
+region = europe-west1
+zone = europe-west1-b
+`)
+	want := Account{Profile: "work", ID: "shop-prod", User: "me@example.com", Region: "europe-west1", Zone: "europe-west1-b"}
+	if got, err := GCloud(home, env(nil)); err != nil || got != want {
+		t.Errorf("got %+v, %v, want %+v", got, err, want)
+	}
+	want.ID = "shop-staging"
+	if got, _ := GCloud(home, env(map[string]string{"CLOUDSDK_CORE_PROJECT": "shop-staging"})); got != want {
+		t.Errorf("CLOUDSDK_CORE_PROJECT: got %+v", got)
+	}
+}
+
+func TestAzure(t *testing.T) {
+	home := t.TempDir()
+	if _, err := Azure(home, env(nil)); err == nil {
+		t.Error("no error when az isn't logged in")
+	}
+
+	writeFile(t, home, ".azure/azureProfile.json", "\xef\xbb\xbf"+`{"installationId": "x", "subscriptions": [
+	{"id": "1111", "name": "Dev", "isDefault": false, "user": {"name": "me@example.com", "type": "user"}},
+	{"id": "2222", "name": "Production", "isDefault": true, "user": {"name": "me@example.com", "type": "user"}}
+]}`)
+	writeFile(t, home, ".azure/config", "[defaults]\nlocation = westeurope\ngroup = web-rg\n")
+	want := Account{ID: "2222", Name: "Production", User: "me@example.com", Region: "westeurope", Group: "web-rg"}
+	if got, err := Azure(home, env(nil)); err != nil || got != want {
+		t.Errorf("got %+v, %v, want %+v", got, err, want)
+	}
+}
+
+func TestNotes(t *testing.T) {
+	a := Account{Profile: "prod", Region: "eu-west-2"}
+	if got, want := a.Notes("aws"), []string{"AWS profile: prod", "AWS region: eu-west-2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
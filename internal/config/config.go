@@ -0,0 +1,431 @@
+// Package config reads llm's settings file, and the system-wide policy file
+// whose settings take precedence over it.
+//
+// Both files use a small subset of TOML: comments, [section] headers, and
+// key = value pairs where values are strings, integers, floats, booleans or
+// arrays of those. Keys inside a section are addressed as "section.key".
+package config
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jamesob/llm-cli/internal/paths"
+)
+
+// Config holds the parsed settings. A nil *Config behaves like an empty file.
+type Config struct {
+	path   string
+	values map[string]any
+}
+
+// DefaultPath returns the location of the user's config file
+func DefaultPath() (string, error) {
+	dir, err := paths.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.toml"), nil
+}
+
+// Load reads the user's config file, with the settings in the system-wide
+// policy file in place of the user's. A missing file is not an error.
+func Load() (*Config, error) {
+	c := &Config{values: map[string]any{}}
+	if path, err := DefaultPath(); err == nil {
+		if c, err = LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	policy, err := LoadFile(paths.PolicyFile())
+	if err != nil {
+		return nil, err
+	}
+	c.Enforce(policy)
+	return c, nil
+}
+
+// Stamp identifies the versions of the user's config file and the policy
+// file on disk, so that a config kept loaded can be reloaded when either
+// changes
+func Stamp() string {
+	var b strings.Builder
+	path, _ := DefaultPath()
+	for _, p := range []string{path, paths.PolicyFile()} {
+		if info, err := os.Stat(p); err == nil {
+			fmt.Fprintf(&b, "%s %d %d;", p, info.ModTime().UnixNano(), info.Size())
+		} else {
+			fmt.Fprintf(&b, "%s -;", p)
+		}
+	}
+	return b.String()
+}
+
+func init() {
+	// Arrays are held as []any, which gob must know to send them
+	gob.Register([]any{})
+}
+
+// encodedConfig is a Config as Encode writes it
+type encodedConfig struct {
+	Path   string
+	Values map[string]any
+}
+
+// Encode writes c in the form Decode reads, which llm daemon uses to hand
+// its loaded config to each run
+func (c *Config) Encode(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(encodedConfig{Path: c.path, Values: c.values})
+}
+
+// Decode reads a config written by Encode
+func Decode(r io.Reader) (*Config, error) {
+	var e encodedConfig
+	if err := gob.NewDecoder(r).Decode(&e); err != nil {
+		return nil, err
+	}
+	if e.Values == nil {
+		e.Values = map[string]any{}
+	}
+	return &Config{path: e.Path, values: e.Values}, nil
+}
+
+// Enforce replaces c's settings with those in policy, so that they can't be
+// overridden
+func (c *Config) Enforce(policy *Config) {
+	for key, v := range policy.values {
+		if prev, ok := c.values[key]; ok && !reflect.DeepEqual(prev, v) {
+			slog.Debug("config setting overridden by policy", "key", key, "policy", policy.path)
+		}
+		c.values[key] = v
+	}
+}
+
+// LoadFile reads the config file at path. A missing file is not an error.
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Config{path: path, values: map[string]any{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+
+	c, err := Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	c.path = path
+	return c, nil
+}
+
+// Parse parses config file contents
+func Parse(data string) (*Config, error) {
+	c := &Config{values: map[string]any{}}
+	section := ""
+
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(stripComment(lines[i]))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: malformed section header", lineNo)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section == "" {
+				return nil, fmt.Errorf("line %d: empty section name", lineNo)
+			}
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		key, err := parseKey(strings.TrimSpace(key))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNo, err)
+		}
+		raw = strings.TrimSpace(raw)
+
+		// Arrays may span several lines
+		for strings.HasPrefix(raw, "[") && !arrayClosed(raw) && i+1 < len(lines) {
+			i++
+			raw += " " + strings.TrimSpace(stripComment(lines[i]))
+		}
+
+		value, err := parseValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNo, err)
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+		c.values[key] = value
+	}
+
+	return c, nil
+}
+
+// Path returns the file the config was loaded from, if any
+func (c *Config) Path() string {
+	if c == nil {
+		return ""
+	}
+	return c.path
+}
+
+// Set sets key for this run only, as command-line options do
+func (c *Config) Set(key string, value any) {
+	c.values[key] = value
+}
+
+// Has reports whether key is set
+func (c *Config) Has(key string) bool {
+	if c == nil {
+		return false
+	}
+	_, ok := c.values[key]
+	return ok
+}
+
+// String returns the string value of key, or "" if unset
+func (c *Config) String(key string) string {
+	v, _ := lookup[string](c, key, "a string")
+	return v
+}
+
+// Bool returns the boolean value of key, or false if unset
+func (c *Config) Bool(key string) bool {
+	v, _ := lookup[bool](c, key, "true or false")
+	return v
+}
+
+// Int returns the integer value of key, or 0 if unset
+func (c *Config) Int(key string) int {
+	v, _ := lookup[int64](c, key, "an integer")
+	return int(v)
+}
+
+// Strings returns the string array value of key. A single string is treated
+// as a one-element array.
+func (c *Config) Strings(key string) []string {
+	if c == nil {
+		return nil
+	}
+	switch v := c.values[key].(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var out []string
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				slog.Warn("ignoring config value", "key", key, "reason", "expected an array of strings")
+				return nil
+			}
+			out = append(out, s)
+		}
+		return out
+	}
+	return nil
+}
+
+// Section returns the string values directly under [name], keyed without the
+// section prefix
+func (c *Config) Section(name string) map[string]string {
+	out := map[string]string{}
+	if c == nil {
+		return out
+	}
+	prefix := name + "."
+	for key, v := range c.values {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok || strings.Contains(rest, ".") {
+			continue
+		}
+		if s, ok := v.(string); ok {
+			out[rest] = s
+		}
+	}
+	return out
+}
+
+func lookup[T any](c *Config, key, want string) (T, bool) {
+	var zero T
+	if c == nil {
+		return zero, false
+	}
+	v, ok := c.values[key]
+	if !ok {
+		return zero, false
+	}
+	t, ok := v.(T)
+	if !ok {
+		slog.Warn("ignoring config value", "key", key, "reason", "expected "+want)
+		return zero, false
+	}
+	return t, true
+}
+
+// stripComment removes a trailing # comment that isn't inside a string
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++ // skip the escaped character
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseKey(key string) (string, error) {
+	if strings.HasPrefix(key, "\"") {
+		k, err := strconv.Unquote(key)
+		if err != nil {
+			return "", fmt.Errorf("malformed key %s", key)
+		}
+		return k, nil
+	}
+	if key == "" {
+		return "", errors.New("missing key")
+	}
+	for _, r := range key {
+		if !(r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return "", fmt.Errorf("invalid character %q in key %q", r, key)
+		}
+	}
+	return key, nil
+}
+
+func parseValue(raw string) (any, error) {
+	switch {
+	case raw == "":
+		return nil, errors.New("missing value")
+	case raw == "true":
+		return true, nil
+	case raw == "false":
+		return false, nil
+	case strings.HasPrefix(raw, "\""):
+		s, err := strconv.Unquote(raw)
+		if err != nil {
+			return nil, fmt.Errorf("malformed string %s", raw)
+		}
+		return s, nil
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") {
+			return nil, fmt.Errorf("malformed string %s", raw)
+		}
+		return raw[1 : len(raw)-1], nil
+	case strings.HasPrefix(raw, "["):
+		return parseArray(raw)
+	}
+
+	clean := strings.ReplaceAll(raw, "_", "")
+	if n, err := strconv.ParseInt(clean, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(clean, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("unrecognized value %s", raw)
+}
+
+func arrayClosed(raw string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		}
+	}
+	return depth == 0
+}
+
+func parseArray(raw string) ([]any, error) {
+	if !strings.HasSuffix(raw, "]") || !arrayClosed(raw) {
+		return nil, fmt.Errorf("unterminated array %s", raw)
+	}
+	inner := raw[1 : len(raw)-1]
+
+	var items []any
+	start := 0
+	flush := func(end int) error {
+		item := strings.TrimSpace(inner[start:end])
+		if item == "" {
+			// Allow a trailing comma
+			return nil
+		}
+		v, err := parseValue(item)
+		if err != nil {
+			return err
+		}
+		items = append(items, v)
+		return nil
+	}
+
+	var quote byte
+	depth := 0
+	for i := 0; i < len(inner); i++ {
+		c := inner[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == ',' && depth == 0:
+			if err := flush(i); err != nil {
+				return nil, err
+			}
+			start = i + 1
+		}
+	}
+	if err := flush(len(inner)); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
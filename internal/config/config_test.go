@@ -0,0 +1,167 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	c, err := Parse(`
+# top-level settings
+context = true
+name = "llm # not a comment"
+literal = 'C:\path'
+escaped = "say \"hi\"" # trailing comment
+count = 1_000
+ratio = 0.5
+tags = ["a", "b,c"]
+multi = [
+  "x",  # first
+  "y",
+]
+
+[anthropic]
+key_cmd = "pass show anthropic/api"
+
+[headers.openai]
+"X-Org-Id" = "org-123"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.Bool("context") {
+		t.Error("context should be true")
+	}
+	if got := c.String("name"); got != "llm # not a comment" {
+		t.Errorf("name = %q", got)
+	}
+	if got := c.String("literal"); got != `C:\path` {
+		t.Errorf("literal = %q", got)
+	}
+	if got := c.String("escaped"); got != `say "hi"` {
+		t.Errorf("escaped = %q", got)
+	}
+	if got := c.Int("count"); got != 1000 {
+		t.Errorf("count = %d", got)
+	}
+	if got := c.Strings("tags"); !reflect.DeepEqual(got, []string{"a", "b,c"}) {
+		t.Errorf("tags = %q", got)
+	}
+	if got := c.Strings("multi"); !reflect.DeepEqual(got, []string{"x", "y"}) {
+		t.Errorf("multi = %q", got)
+	}
+	if got := c.String("anthropic.key_cmd"); got != "pass show anthropic/api" {
+		t.Errorf("anthropic.key_cmd = %q", got)
+	}
+	if got := c.Section("headers.openai"); !reflect.DeepEqual(got, map[string]string{"X-Org-Id": "org-123"}) {
+		t.Errorf("headers.openai = %v", got)
+	}
+	if !c.Has("ratio") || c.Has("missing") {
+		t.Error("Has() is wrong")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, data := range []string{
+		"novalue",
+		"key =",
+		"[unterminated",
+		"[]",
+		`s = "unterminated`,
+		"a = [1, 2",
+		"bad key = 1",
+		"x = nope",
+	} {
+		if _, err := Parse(data); err == nil {
+			t.Errorf("Parse(%q) should fail", data)
+		}
+	}
+}
+
+func TestWrongTypeIsIgnored(t *testing.T) {
+	c, err := Parse(`context = "yes"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Bool("context") {
+		t.Error("a string shouldn't read as true")
+	}
+	if got := c.Strings("context"); !reflect.DeepEqual(got, []string{"yes"}) {
+		t.Errorf("Strings = %q", got)
+	}
+}
+
+func TestNilConfig(t *testing.T) {
+	var c *Config
+	if c.Bool("x") || c.String("x") != "" || c.Int("x") != 0 || c.Strings("x") != nil || c.Has("x") {
+		t.Error("nil config should be empty")
+	}
+	if len(c.Section("x")) != 0 {
+		t.Error("nil config should have no sections")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := LoadFile(filepath.Join(dir, "missing.toml"))
+	if err != nil {
+		t.Fatalf("missing file should not be an error: %v", err)
+	}
+	if c.Has("anything") {
+		t.Error("missing file should be empty")
+	}
+
+	path := filepath.Join(dir, "config.toml")
+	os.WriteFile(path, []byte("context = true\n"), 0644)
+	c, err = LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Bool("context") || c.Path() != path {
+		t.Errorf("got %+v", c)
+	}
+
+	os.WriteFile(path, []byte("oops\n"), 0644)
+	if _, err := LoadFile(path); err == nil {
+		t.Error("expected a parse error")
+	}
+}
+
+func TestEncode(t *testing.T) {
+	c, err := Parse("count = 3\nratio = 0.5\nname = \"x\"\nfast = true\ntags = [\"a\", [\"b\"]]\n[headers]\nX-Team = \"infra\"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.path = "/etc/llm.toml"
+	var b bytes.Buffer
+	if err := c.Encode(&b); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, c) || got.Int("count") != 3 {
+		t.Errorf("Decode() = %+v, want %+v", got, c)
+	}
+}
+
+func TestEnforce(t *testing.T) {
+	c, _ := Parse("history = true\ncontext = true\n[aliases]\nprod = \"mine\"\n")
+	policy, _ := Parse("history = false\nallowed_providers = [\"ollama\"]\n[aliases]\nprod = \"theirs\"\n")
+	c.Enforce(policy)
+	if c.Bool("history") || !c.Bool("context") {
+		t.Errorf("history = %v, context = %v", c.Bool("history"), c.Bool("context"))
+	}
+	if got := c.Strings("allowed_providers"); !reflect.DeepEqual(got, []string{"ollama"}) {
+		t.Errorf("allowed_providers = %v", got)
+	}
+	if got := c.Section("aliases")["prod"]; got != "theirs" {
+		t.Errorf("aliases.prod = %q", got)
+	}
+}
@@ -0,0 +1,72 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// A Friday
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{"30 6 * * 1-5", []string{"2026-10-19 06:30", "2026-10-20 06:30", "2026-10-21 06:30"}},
+		{"*/20 12 * * *", []string{"2026-10-16 12:20", "2026-10-16 12:40", "2026-10-17 12:00"}},
+		{"0 9 1 jan,jul *", []string{"2027-01-01 09:00", "2027-07-01 09:00", "2028-01-01 09:00"}},
+		{"0 0 13 * fri", []string{"2026-10-23 00:00", "2026-10-30 00:00", "2026-11-06 00:00"}},
+		{"0 0 * * 7", []string{"2026-10-18 00:00", "2026-10-25 00:00", "2026-11-01 00:00"}},
+		{"0 0 29 2 *", []string{"2028-02-29 00:00", "2032-02-29 00:00", "2036-02-29 00:00"}},
+		{"15 10/6 * * *", []string{"2026-10-16 16:15", "2026-10-16 22:15", "2026-10-17 10:15"}},
+		{"@hourly", []string{"2026-10-16 13:00", "2026-10-16 14:00", "2026-10-16 15:00"}},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.expr, err)
+		}
+		next := start
+		for _, want := range tt.want {
+			if next, err = s.Next(next); err != nil {
+				t.Fatalf("%q: %v", tt.expr, err)
+			}
+			if got := next.Format("2006-01-02 15:04"); got != want {
+				t.Errorf("%q: got %s, want %s", tt.expr, got, want)
+				break
+			}
+		}
+	}
+}
+
+func TestNever(t *testing.T) {
+	s, err := Parse("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Next(time.Now()); !errors.Is(err, ErrNever) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"* * * * * /bin/true",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) should fail", expr)
+		}
+	}
+}
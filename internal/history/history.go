@@ -0,0 +1,350 @@
+// Package history records queries and responses in a JSON lines file,
+// optionally sealing each entry with AES-GCM so transcripts aren't readable
+// at rest.
+package history
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// KeySize is the length of encryption keys in bytes (AES-256)
+const KeySize = 32
+
+// ErrNoKey is returned when reading encrypted entries without a key
+var ErrNoKey = errors.New("history is encrypted but no key is available")
+
+// lockWait is how long a writer waits for another to finish, and lockStale
+// how old a lock must be to be taken as left behind by one that crashed
+const (
+	lockWait  = 10 * time.Second
+	lockStale = 30 * time.Second
+)
+
+// Entry is one query and its response
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Mode     string    `json:"mode,omitempty"`
+	Provider string    `json:"provider,omitempty"`
+	Model    string    `json:"model,omitempty"`
+	Query    string    `json:"query,omitempty"`
+	Response string    `json:"response,omitempty"`
+
+	// Session links the steps of a chain of answers built on each other
+	// with --pipe. It's the time of the chain's first entry, which doesn't
+	// record it.
+	Session string `json:"session,omitempty"`
+
+	// Feedback is "good" or "bad" once the user has said which, with an
+	// optional reason
+	Feedback string `json:"feedback,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// record is a line of the history file. Encrypted entries keep only their
+// time in the clear, so they can be purged without the key.
+type record struct {
+	Entry
+	Sealed []byte `json:"sealed,omitempty"`
+}
+
+// Store is a history file
+type Store struct {
+	path string
+	aead cipher.AEAD
+}
+
+// Open returns the store at path. If key is non-nil new entries are
+// encrypted with it, and it's used to read encrypted ones.
+func Open(path string, key []byte) (*Store, error) {
+	s := &Store{path: path}
+	if key != nil {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid history key: %v", err)
+		}
+		if s.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// NewKey returns a random key for Open
+func NewKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Append adds e to the end of the history. With a key, entries written
+// before encryption was turned on are sealed too.
+func (s *Store) Append(e Entry) error {
+	rec, err := s.seal(e)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if s.aead != nil {
+		records, err := s.records()
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(records, func(r record) bool { return r.Sealed == nil }) {
+			for i, r := range records {
+				if r.Sealed == nil {
+					if records[i], err = s.seal(r.Entry); err != nil {
+						return err
+					}
+				}
+			}
+			return s.rewrite(append(records, rec))
+		}
+	}
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %v", err)
+	}
+	return nil
+}
+
+// lock takes the history's lock file, so that runs writing the history at
+// the same time don't lose each other's entries, and returns the function
+// that releases it
+func (s *Store) lock() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %v", err)
+	}
+	path := s.path + ".lock"
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to lock history: %v", err)
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New("timed out waiting for another llm to finish writing the history")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// UpdateLast changes the most recent entry with update and returns it
+func (s *Store) UpdateLast(update func(*Entry)) (Entry, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return Entry{}, err
+	}
+	defer unlock()
+	records, err := s.records()
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(records) == 0 {
+		return Entry{}, errors.New("the history is empty")
+	}
+
+	last := &records[len(records)-1]
+	e := last.Entry
+	if last.Sealed != nil {
+		if s.aead == nil {
+			return Entry{}, ErrNoKey
+		}
+		if e, err = s.open(last.Sealed); err != nil {
+			return Entry{}, err
+		}
+	}
+	update(&e)
+	if *last, err = s.seal(e); err != nil {
+		return Entry{}, err
+	}
+	return e, s.rewrite(records)
+}
+
+// seal returns the record for e, encrypting it if the store has a key
+func (s *Store) seal(e Entry) (record, error) {
+	if s.aead == nil {
+		return record{Entry: e}, nil
+	}
+	plain, err := json.Marshal(e)
+	if err != nil {
+		return record{}, err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return record{}, err
+	}
+	return record{
+		Entry:  Entry{Time: e.Time},
+		Sealed: s.aead.Seal(nonce, nonce, plain, nil),
+	}, nil
+}
+
+// Entries returns the history, oldest first
+func (s *Store) Entries() ([]Entry, error) {
+	records, err := s.records()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(records))
+	for _, rec := range records {
+		if rec.Sealed == nil {
+			entries = append(entries, rec.Entry)
+			continue
+		}
+		if s.aead == nil {
+			return nil, ErrNoKey
+		}
+		e, err := s.open(rec.Sealed)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// open decrypts a sealed entry
+func (s *Store) open(sealed []byte) (Entry, error) {
+	n := s.aead.NonceSize()
+	if len(sealed) < n {
+		return Entry{}, errors.New("corrupt history entry")
+	}
+	plain, err := s.aead.Open(nil, sealed[:n], sealed[n:], nil)
+	if err != nil {
+		return Entry{}, errors.New("failed to decrypt history; was it written with a different key?")
+	}
+	var e Entry
+	if err := json.Unmarshal(plain, &e); err != nil {
+		return Entry{}, fmt.Errorf("corrupt history entry: %v", err)
+	}
+	return e, nil
+}
+
+// Purge removes entries from before cutoff, or every entry if cutoff is
+// zero, and returns how many were removed
+func (s *Store) Purge(cutoff time.Time) (int, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	if cutoff.IsZero() {
+		records, err := s.records()
+		if err != nil {
+			return 0, err
+		}
+		if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return 0, fmt.Errorf("failed to remove history: %v", err)
+		}
+		return len(records), nil
+	}
+
+	records, err := s.records()
+	if err != nil {
+		return 0, err
+	}
+	var kept []record
+	for _, rec := range records {
+		if !rec.Time.Before(cutoff) {
+			kept = append(kept, rec)
+		}
+	}
+	removed := len(records) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, s.rewrite(kept)
+}
+
+// records reads every line of the history file
+func (s *Store) records() ([]record, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %v", err)
+	}
+	defer f.Close()
+
+	var records []record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", s.path, line, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %v", err)
+	}
+	return records, nil
+}
+
+// rewrite replaces the history file with records
+func (s *Store) rewrite(records []record) error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".history-*")
+	if err != nil {
+		return fmt.Errorf("failed to rewrite history: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for _, rec := range records {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		w.Write(append(line, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to rewrite history: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to rewrite history: %v", err)
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
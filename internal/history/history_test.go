@@ -0,0 +1,208 @@
+package history
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAppendAndEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "history.jsonl")
+	s, _ := Open(path, nil)
+
+	if entries, err := s.Entries(); err != nil || len(entries) != 0 {
+		t.Fatalf("empty history: %v, %v", entries, err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, q := range []string{"first", "second"} {
+		if err := s.Append(Entry{Time: now, Query: q, Response: "ls"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := s.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Query != "first" || entries[1].Query != "second" {
+		t.Errorf("entries = %+v", entries)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v", info.Mode())
+	}
+}
+
+func TestEncryption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Entries written before encryption was turned on stay readable
+	plain, _ := Open(path, nil)
+	plain.Append(Entry{Time: time.Now(), Query: "before"})
+
+	s, err := Open(path, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append(Entry{Time: time.Now(), Query: "secret query", Response: "secret answer"}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if bytes.Contains(data, []byte("secret")) {
+		t.Errorf("history file contains plaintext: %s", data)
+	}
+
+	entries, err := s.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Query != "before" || entries[1].Response != "secret answer" {
+		t.Errorf("entries = %+v", entries)
+	}
+
+	if _, err := plain.Entries(); !errors.Is(err, ErrNoKey) {
+		t.Errorf("reading without a key: %v", err)
+	}
+	other, _ := NewKey()
+	wrong, _ := Open(path, other)
+	if _, err := wrong.Entries(); err == nil {
+		t.Error("reading with the wrong key should fail")
+	}
+}
+
+func TestEncryptionSealsOldEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	key, err := NewKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _ := Open(path, nil)
+	plain.Append(Entry{Time: time.Now(), Query: "old secret", Response: "old answer"})
+
+	s, _ := Open(path, key)
+	if err := s.Append(Entry{Time: time.Now(), Query: "new"}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if bytes.Contains(data, []byte("secret")) || bytes.Contains(data, []byte("old answer")) {
+		t.Errorf("entries from before encryption are still in the clear: %s", data)
+	}
+	entries, err := s.Entries()
+	if err != nil || len(entries) != 2 || entries[0].Query != "old secret" || entries[1].Query != "new" {
+		t.Errorf("entries = %+v, %v", entries, err)
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	s, _ := Open(path, nil)
+	s.Append(Entry{Time: time.Now(), Query: "first"})
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := s.Append(Entry{Time: time.Now(), Query: fmt.Sprint(i)}); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := s.UpdateLast(func(e *Entry) { e.Feedback = "good" }); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if entries, err := s.Entries(); err != nil || len(entries) != n+1 {
+		t.Errorf("got %d entries, %v, want %d", len(entries), err, n+1)
+	}
+	if _, err := os.Stat(path + ".lock"); err == nil {
+		t.Error("the lock was left behind")
+	}
+}
+
+func TestPurge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	key, _ := NewKey()
+	s, _ := Open(path, key)
+
+	now := time.Now()
+	for _, age := range []time.Duration{72 * time.Hour, 48 * time.Hour, time.Hour} {
+		s.Append(Entry{Time: now.Add(-age), Query: age.String()})
+	}
+
+	// Encrypted entries can be purged without the key
+	nokey, _ := Open(path, nil)
+	n, err := nokey.Purge(now.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("purged %d entries, want 2", n)
+	}
+	entries, err := s.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Query != "1h0m0s" {
+		t.Errorf("entries = %+v", entries)
+	}
+
+	if n, err := s.Purge(time.Time{}); err != nil || n != 1 {
+		t.Errorf("purge all = %d, %v", n, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("purging everything should remove the file")
+	}
+	if n, err := s.Purge(time.Time{}); err != nil || n != 0 {
+		t.Errorf("purging an empty history = %d, %v", n, err)
+	}
+}
+
+func TestUpdateLast(t *testing.T) {
+	key, _ := NewKey()
+	for _, k := range [][]byte{nil, key} {
+		path := filepath.Join(t.TempDir(), "history.jsonl")
+		s, _ := Open(path, k)
+
+		if _, err := s.UpdateLast(func(e *Entry) {}); err == nil {
+			t.Error("expected an error for an empty history")
+		}
+		s.Append(Entry{Query: "first", Response: "ls"})
+		s.Append(Entry{Query: "second", Response: "du"})
+
+		got, err := s.UpdateLast(func(e *Entry) { e.Feedback, e.Reason = "bad", "wrong flag" })
+		if err != nil || got.Query != "second" || got.Feedback != "bad" {
+			t.Fatalf("UpdateLast() = %+v, %v", got, err)
+		}
+		entries, err := s.Entries()
+		if err != nil || len(entries) != 2 || entries[0].Feedback != "" || entries[1].Reason != "wrong flag" {
+			t.Errorf("entries = %+v, %v", entries, err)
+		}
+
+		if k != nil {
+			// Sealed entries can't be changed without the key
+			locked, _ := Open(path, nil)
+			if _, err := locked.UpdateLast(func(e *Entry) {}); !errors.Is(err, ErrNoKey) {
+				t.Errorf("UpdateLast without the key: %v", err)
+			}
+		}
+	}
+}
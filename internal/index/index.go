@@ -0,0 +1,175 @@
+// Package index keeps embeddings of chunks of local text files in a JSON
+// file, and finds the chunks closest to a query's embedding.
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Chunk is a piece of a file and its embedding
+type Chunk struct {
+	Path   string    `json:"path"`
+	Line   int       `json:"line"`
+	Text   string    `json:"text"`
+	Vector []float32 `json:"vector"`
+}
+
+// Index is the set of indexed chunks
+type Index struct {
+	// Model is the embedding model the vectors came from. Vectors from
+	// different models can't be compared.
+	Model string `json:"model"`
+
+	// Files records when each indexed file was last modified, so unchanged
+	// files can be skipped
+	Files map[string]time.Time `json:"files"`
+
+	Chunks []Chunk `json:"chunks"`
+}
+
+// Load reads the index at path, returning an empty one if it doesn't exist
+func Load(path string) (*Index, error) {
+	ix := &Index{Files: map[string]time.Time{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ix, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %v", err)
+	}
+	if err := json.Unmarshal(data, ix); err != nil {
+		return nil, fmt.Errorf("failed to parse index %s: %v", path, err)
+	}
+	if ix.Files == nil {
+		ix.Files = map[string]time.Time{}
+	}
+	return ix, nil
+}
+
+// Save writes the index to path, replacing it atomically
+func (ix *Index) Save(path string) error {
+	data, err := json.Marshal(ix)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create index directory: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write index: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write index: %v", err)
+	}
+	return nil
+}
+
+// Remove drops path and its chunks from the index
+func (ix *Index) Remove(path string) {
+	delete(ix.Files, path)
+	ix.Chunks = slices.DeleteFunc(ix.Chunks, func(c Chunk) bool { return c.Path == path })
+}
+
+// Result is a chunk found by Search
+type Result struct {
+	Chunk
+	Score float64
+}
+
+// Search returns the k chunks most similar to vector by cosine similarity,
+// best first
+func (ix *Index) Search(vector []float32, k int) []Result {
+	var results []Result
+	for _, c := range ix.Chunks {
+		results = append(results, Result{Chunk: c, Score: cosine(vector, c.Vector)})
+	}
+	slices.SortStableFunc(results, func(a, b Result) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	return results[:min(k, len(results))]
+}
+
+// cosine returns the cosine similarity of a and b, or 0 if they differ in
+// length or either is zero
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// Piece is a chunk of a file before it's embedded
+type Piece struct {
+	Line int
+	Text string
+}
+
+// Split breaks text into pieces of whole paragraphs of up to about size
+// bytes, so that each is about one thing. Longer paragraphs are split by
+// lines, and longer lines are cut.
+func Split(text string, size int) []Piece {
+	var pieces []Piece
+	var cur strings.Builder
+	start := 0
+	flush := func() {
+		if t := strings.TrimSpace(cur.String()); t != "" {
+			pieces = append(pieces, Piece{Line: start, Text: t})
+		}
+		cur.Reset()
+		start = 0
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		blank := strings.TrimSpace(line) == ""
+		if blank && cur.Len() >= size/2 {
+			// A paragraph break after a decent amount of text
+			flush()
+			continue
+		}
+		if cur.Len() > 0 && cur.Len()+len(line) > size {
+			flush()
+		}
+		for len(line) > size {
+			if start == 0 {
+				start = i + 1
+			}
+			cur.WriteString(line[:size])
+			line = line[size:]
+			flush()
+		}
+		if start == 0 && !blank {
+			start = i + 1
+		}
+		if start != 0 {
+			cur.WriteString(line + "\n")
+		}
+	}
+	flush()
+	return pieces
+}
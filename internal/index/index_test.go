@@ -0,0 +1,86 @@
+package index
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplit(t *testing.T) {
+	text := "# Title\n\nFirst paragraph\nstill first.\n\nSecond paragraph.\n"
+	pieces := Split(text, 30)
+	want := []Piece{
+		{1, "# Title\n\nFirst paragraph"},
+		{4, "still first."},
+		{6, "Second paragraph."},
+	}
+	if len(pieces) != len(want) {
+		t.Fatalf("got %d pieces %+v, want %d", len(pieces), pieces, len(want))
+	}
+	for i := range want {
+		if pieces[i] != want[i] {
+			t.Errorf("piece %d = %+v, want %+v", i, pieces[i], want[i])
+		}
+	}
+
+	long := strings.Repeat("x", 25)
+	pieces = Split(long, 10)
+	if len(pieces) != 3 || pieces[2].Text != "xxxxx" || pieces[0].Line != 1 {
+		t.Errorf("long line split into %+v", pieces)
+	}
+	if got := Split("\n\n  \n", 10); len(got) != 0 {
+		t.Errorf("blank text split into %+v", got)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ix := &Index{Chunks: []Chunk{
+		{Path: "a", Vector: []float32{1, 0}},
+		{Path: "b", Vector: []float32{0, 1}},
+		{Path: "c", Vector: []float32{1, 1}},
+		{Path: "d", Vector: []float32{1}},
+	}}
+	results := ix.Search([]float32{1, 0.1}, 2)
+	if len(results) != 2 || results[0].Path != "a" || results[1].Path != "c" {
+		t.Errorf("got %+v", results)
+	}
+	if got := ix.Search([]float32{1, 0}, 10); len(got) != 4 {
+		t.Errorf("got %d results, want all 4", len(got))
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "index.json")
+	ix, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ix.Chunks) != 0 || ix.Files == nil {
+		t.Fatalf("missing index loaded as %+v", ix)
+	}
+
+	mod := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ix.Model = "nomic-embed-text"
+	ix.Files["notes/a.md"] = mod
+	ix.Files["notes/b.md"] = mod
+	ix.Chunks = []Chunk{
+		{Path: "notes/a.md", Line: 3, Text: "alpha", Vector: []float32{0.5, 0.25}},
+		{Path: "notes/b.md", Line: 1, Text: "beta", Vector: []float32{1, 0}},
+	}
+	ix.Remove("notes/b.md")
+	if err := ix.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Model != ix.Model || len(got.Files) != 1 || !got.Files["notes/a.md"].Equal(mod) {
+		t.Errorf("loaded %+v", got)
+	}
+	if len(got.Chunks) != 1 || got.Chunks[0].Text != "alpha" || got.Chunks[0].Vector[1] != 0.25 {
+		t.Errorf("chunks = %+v", got.Chunks)
+	}
+}
@@ -0,0 +1,29 @@
+// Package keyring stores secrets in the operating system's credential store:
+// the login keychain on macOS, the Secret Service (GNOME Keyring, KWallet)
+// via secret-tool on Linux and other Unixes, and Credential Manager on
+// Windows.
+package keyring
+
+import "errors"
+
+// service groups everything llm stores in the credential store
+const service = "llm"
+
+// ErrNotFound is returned by Get and Delete when nothing is stored under a
+// name
+var ErrNotFound = errors.New("not found in the keychain")
+
+// Get returns the secret stored under name
+func Get(name string) (string, error) {
+	return get(name)
+}
+
+// Set stores secret under name, replacing any existing value
+func Set(name, secret string) error {
+	return set(name, secret)
+}
+
+// Delete removes the secret stored under name
+func Delete(name string) error {
+	return del(name)
+}
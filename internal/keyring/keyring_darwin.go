@@ -0,0 +1,70 @@
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// errItemNotFound is the exit status of security(1) for a missing item
+const errItemNotFound = 44
+
+func get(name string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", name, "-w").Output()
+	if err != nil {
+		return "", securityError(err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func set(name, secret string) error {
+	cmd, err := setCommand(name, secret)
+	if err != nil {
+		return err
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to store key: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	// security -i carries on after a command fails, so check it was stored
+	if stored, err := get(name); err != nil || stored != secret {
+		return fmt.Errorf("failed to store key: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// setCommand returns the command storing secret under name. It's given to
+// security -i on stdin, so the secret doesn't show up in ps. -U updates an
+// existing item instead of failing.
+func setCommand(name, secret string) (*exec.Cmd, error) {
+	if strings.ContainsAny(secret, "\r\n") {
+		return nil, errors.New("the key can't contain a line break")
+	}
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		securityQuote(service), securityQuote(name), securityQuote(secret)))
+	return cmd, nil
+}
+
+// securityQuote quotes s as one argument of a security -i command
+func securityQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func del(name string) error {
+	if err := exec.Command("security", "delete-generic-password", "-s", service, "-a", name).Run(); err != nil {
+		return securityError(err)
+	}
+	return nil
+}
+
+// securityError maps a failed security(1) command to ErrNotFound where
+// appropriate
+func securityError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == errItemNotFound {
+		return ErrNotFound
+	}
+	return fmt.Errorf("keychain access failed: %v", err)
+}
@@ -0,0 +1,28 @@
+package keyring
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSetCommand(t *testing.T) {
+	const secret = `sk-ant-"quoted"\key`
+	cmd, err := setCommand("anthropic", secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, arg := range cmd.Args {
+		if strings.Contains(arg, "sk-ant") {
+			t.Errorf("the secret is in the arguments: %q", cmd.Args)
+		}
+	}
+	input, _ := io.ReadAll(cmd.Stdin)
+	if want := `add-generic-password -U -s "llm" -a "anthropic" -w "sk-ant-\"quoted\"\\key"` + "\n"; string(input) != want {
+		t.Errorf("stdin = %q, want %q", input, want)
+	}
+
+	if _, err := setCommand("anthropic", "sk-ant\n-w other"); err == nil {
+		t.Error("accepted a key with a line break")
+	}
+}
@@ -0,0 +1,54 @@
+//go:build !darwin && !windows
+
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// secretTool runs secret-tool(1) from libsecret, which talks to whichever
+// Secret Service is running (GNOME Keyring, KWallet, KeePassXC)
+func secretTool(stdin string, args ...string) (string, error) {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return "", errors.New("secret-tool not found; install libsecret-tools (Debian, Ubuntu) or libsecret (Fedora, Arch)")
+	}
+	cmd := exec.Command("secret-tool", args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("keychain access failed: %s", msg)
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+func get(name string) (string, error) {
+	out, err := secretTool("", "lookup", "service", service, "account", name)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) || (err == nil && out == "") {
+		// lookup exits 1 without a message when nothing matches
+		return "", ErrNotFound
+	}
+	return strings.TrimSuffix(out, "\n"), err
+}
+
+func set(name, secret string) error {
+	// The secret is read from stdin so it doesn't show up in ps
+	_, err := secretTool(secret, "store", "--label", service+" "+name, "service", service, "account", name)
+	return err
+}
+
+func del(name string) error {
+	if _, err := get(name); err != nil {
+		return err
+	}
+	_, err := secretTool("", "clear", "service", service, "account", name)
+	return err
+}
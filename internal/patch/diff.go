@@ -0,0 +1,170 @@
+package patch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is how many unchanged lines Diff shows around each change
+const contextLines = 3
+
+// maxCells bounds the table Diff fills in to find the longest common
+// subsequence of the changed lines. Beyond it the changed region is shown as
+// removed and re-added whole, which is still a correct diff.
+const maxCells = 1 << 22
+
+// Diff returns a unified diff from old to new, with name in its headers, or
+// "" if they're the same
+func Diff(name, old, new string) string {
+	ops := diffLines(splitLines(old), splitLines(new))
+
+	var b strings.Builder
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// The hunk runs from a few lines before this change to a few
+		// lines after the last change that's close enough to join it
+		start := max(i-contextLines, 0)
+		end := i
+		for j := i; j < len(ops) && j <= end+2*contextLines+1; j++ {
+			if ops[j].kind != ' ' {
+				end = j
+			}
+		}
+		end = min(end+contextLines+1, len(ops))
+
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", name, name)
+		}
+		oldLen, newLen := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				oldLen++
+			}
+			if op.kind != '-' {
+				newLen++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(ops[start].oldLine, oldLen), hunkRange(ops[start].newLine, newLen))
+		for _, op := range ops[start:end] {
+			b.WriteString(string(op.kind) + op.text + "\n")
+		}
+		i = end
+	}
+	return b.String()
+}
+
+// hunkRange formats the start and length of one side of a hunk. An empty
+// side starts at the line before it, as in diff -u.
+func hunkRange(start, n int) string {
+	if n == 0 {
+		start--
+	}
+	if n == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, n)
+}
+
+// diffOp is a line of a diff: kept (' '), removed ('-') or added ('+'), with
+// where it is in the old and new files, counting from 1
+type diffOp struct {
+	kind             byte
+	text             string
+	oldLine, newLine int
+}
+
+// diffLines returns the edit script from a to b
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var kinds []byte
+	for range prefix {
+		kinds = append(kinds, ' ')
+	}
+	kinds = append(kinds, middleOps(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for range suffix {
+		kinds = append(kinds, ' ')
+	}
+
+	ops := make([]diffOp, len(kinds))
+	i, j := 0, 0
+	for k, kind := range kinds {
+		ops[k] = diffOp{kind: kind, oldLine: i + 1, newLine: j + 1}
+		switch kind {
+		case ' ':
+			ops[k].text = a[i]
+			i, j = i+1, j+1
+		case '-':
+			ops[k].text = a[i]
+			i++
+		case '+':
+			ops[k].text = b[j]
+			j++
+		}
+	}
+	return ops
+}
+
+// middleOps returns the kinds of the lines of a shortest edit script from a
+// to b, using their longest common subsequence
+func middleOps(a, b []string) []byte {
+	var kinds []byte
+	if len(a)*len(b) > maxCells {
+		for range a {
+			kinds = append(kinds, '-')
+		}
+		for range b {
+			kinds = append(kinds, '+')
+		}
+		return kinds
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			kinds = append(kinds, ' ')
+			i, j = i+1, j+1
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			kinds = append(kinds, '-')
+			i++
+		default:
+			kinds = append(kinds, '+')
+			j++
+		}
+	}
+	return kinds
+}
+
+// splitLines splits text into lines, without their newlines
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
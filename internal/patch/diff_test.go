@@ -0,0 +1,88 @@
+package patch
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	var long []string
+	for i := 1; i <= 20; i++ {
+		long = append(long, fmt.Sprint("line ", i))
+	}
+	longText := strings.Join(long, "\n") + "\n"
+
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{name: "same", old: original, new: original},
+		{
+			name: "one line",
+			old:  original,
+			new:  strings.Replace(original, "hello", "goodbye", 1),
+			want: `--- a/main.go
++++ b/main.go
+@@ -3,5 +3,5 @@
+ import "fmt"
+ 
+ func main() {
+-	fmt.Println("hello")
++	fmt.Println("goodbye")
+ }
+`,
+		},
+		{
+			name: "new file",
+			new:  "a\nb\n",
+			want: "--- a/main.go\n+++ b/main.go\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "separate hunks",
+			old:  longText,
+			new:  strings.Replace(strings.Replace(longText, "line 2\n", "two\n", 1), "line 18\n", "", 1),
+			want: `--- a/main.go
++++ b/main.go
+@@ -1,5 +1,5 @@
+ line 1
+-line 2
++two
+ line 3
+ line 4
+ line 5
+@@ -15,6 +15,5 @@
+ line 15
+ line 16
+ line 17
+-line 18
+ line 19
+ line 20
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Diff("main.go", tt.old, tt.new)
+			if got != tt.want {
+				t.Errorf("Diff() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if got == "" {
+				return
+			}
+			// The diff must apply back to the new text
+			files, err := Parse(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			applied, err := files[0].Apply(tt.old)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if applied != tt.new {
+				t.Errorf("applying the diff gives\n%s\nwant\n%s", applied, tt.new)
+			}
+		})
+	}
+}
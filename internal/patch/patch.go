@@ -0,0 +1,224 @@
+// Package patch parses unified diffs and applies them to file contents.
+//
+// Diffs written by language models often have wrong line numbers and hunk
+// counts, so hunks are located by their context and removed lines, nearest
+// the stated position first, and the counts are ignored. The context must
+// match exactly; there is no fuzz.
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DevNull names the missing side of a diff that creates or deletes a file
+const DevNull = "/dev/null"
+
+// File is the part of a diff that changes one file
+type File struct {
+	// OldName and NewName are the paths from the --- and +++ lines, without
+	// a/ and b/ prefixes. One is DevNull if the file is created or deleted.
+	OldName, NewName string
+
+	Hunks []Hunk
+}
+
+// Hunk is one @@ section of a diff
+type Hunk struct {
+	// OldStart is the line number the hunk claims to start at, from 1
+	OldStart int
+
+	// Lines are the hunk's lines, each starting with ' ', '-' or '+'
+	Lines []string
+}
+
+// Name returns the path the diff applies to
+func (f File) Name() string {
+	if f.NewName == DevNull {
+		return f.OldName
+	}
+	return f.NewName
+}
+
+// Parse parses a unified diff, ignoring text outside of file sections such
+// as git's diff and index lines
+func Parse(diff string) ([]File, error) {
+	lines := strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n")
+	var files []File
+	var hunk *Hunk
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			closeHunk(hunk)
+			files = append(files, File{
+				OldName: fileName(line[4:]),
+				NewName: fileName(lines[i+1][4:]),
+			})
+			hunk = nil
+			i++
+		case strings.HasPrefix(line, "@@"):
+			if len(files) == 0 {
+				return nil, fmt.Errorf("line %d: hunk before a --- and +++ header", i+1)
+			}
+			start, err := hunkStart(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+			closeHunk(hunk)
+			file := &files[len(files)-1]
+			file.Hunks = append(file.Hunks, Hunk{OldStart: start})
+			hunk = &file.Hunks[len(file.Hunks)-1]
+		case hunk == nil:
+			// Text between files, e.g. "diff --git" and "index" lines
+		case line == "" || line[0] == ' ' || line[0] == '-' || line[0] == '+':
+			hunk.Lines = append(hunk.Lines, line)
+		case line[0] == '\\':
+			// "\ No newline at end of file"
+		default:
+			closeHunk(hunk)
+			hunk = nil
+		}
+	}
+	closeHunk(hunk)
+
+	if len(files) == 0 {
+		return nil, errors.New("no --- and +++ file headers found")
+	}
+	for _, f := range files {
+		if len(f.Hunks) == 0 {
+			return nil, fmt.Errorf("%s: no hunks", f.Name())
+		}
+	}
+	return files, nil
+}
+
+// closeHunk tidies up a hunk once all its lines are read. Blank context
+// lines often lose their leading space, but blank lines at the end are
+// taken to separate the hunk from what follows.
+func closeHunk(h *Hunk) {
+	if h == nil {
+		return
+	}
+	for len(h.Lines) > 0 && h.Lines[len(h.Lines)-1] == "" {
+		h.Lines = h.Lines[:len(h.Lines)-1]
+	}
+	for i, line := range h.Lines {
+		if line == "" {
+			h.Lines[i] = " "
+		}
+	}
+}
+
+// fileName returns the path from a --- or +++ line, without the timestamp
+// that diff adds or git's a/ and b/ prefixes
+func fileName(s string) string {
+	s, _, _ = strings.Cut(s, "\t")
+	s = strings.TrimSpace(s)
+	if s == DevNull {
+		return s
+	}
+	for _, prefix := range []string{"a/", "b/"} {
+		if strings.HasPrefix(s, prefix) {
+			return s[len(prefix):]
+		}
+	}
+	return s
+}
+
+// hunkStart returns the old start line from a header like "@@ -12,7 +12,8 @@"
+func hunkStart(header string) (int, error) {
+	fields := strings.Fields(header)
+	if len(fields) < 2 || !strings.HasPrefix(fields[1], "-") {
+		// Models sometimes write a bare "@@"; search the whole file
+		return 1, nil
+	}
+	start, _, _ := strings.Cut(fields[1][1:], ",")
+	n, err := strconv.Atoi(start)
+	if err != nil {
+		return 0, fmt.Errorf("bad hunk header %q", header)
+	}
+	return max(n, 1), nil
+}
+
+// Apply returns content with the changes in f made, or an error naming the
+// first hunk that doesn't match
+func (f File) Apply(content string) (string, error) {
+	if f.OldName == DevNull && content != "" {
+		return "", fmt.Errorf("%s: already exists", f.Name())
+	}
+
+	trailingNewline := content == "" || strings.HasSuffix(content, "\n")
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	var out []string
+	next := 0 // first line of lines not yet copied to out
+	for n, h := range f.Hunks {
+		var old, new []string
+		for _, line := range h.Lines {
+			if line[0] != '+' {
+				old = append(old, line[1:])
+			}
+			if line[0] != '-' {
+				new = append(new, line[1:])
+			}
+		}
+
+		at := find(lines, old, next, h.OldStart-1)
+		if at < 0 {
+			return "", fmt.Errorf("%s: hunk %d (at line %d) doesn't match the file", f.Name(), n+1, h.OldStart)
+		}
+		out = append(out, lines[next:at]...)
+		out = append(out, new...)
+		next = at + len(old)
+	}
+	out = append(out, lines[next:]...)
+
+	if len(out) == 0 {
+		return "", nil
+	}
+	result := strings.Join(out, "\n")
+	if trailingNewline {
+		result += "\n"
+	}
+	return result, nil
+}
+
+// find returns where want occurs in lines at or after from, choosing the
+// occurrence nearest to near, or -1 if it doesn't occur
+func find(lines, want []string, from, near int) int {
+	if len(want) == 0 {
+		return min(max(near, from), len(lines))
+	}
+	best := -1
+	for i := from; i+len(want) <= len(lines); i++ {
+		if !matches(lines[i:], want) {
+			continue
+		}
+		if best < 0 || abs(i-near) < abs(best-near) {
+			best = i
+		}
+	}
+	return best
+}
+
+func matches(lines, want []string) bool {
+	for i, w := range want {
+		if lines[i] != w {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
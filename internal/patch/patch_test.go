@@ -0,0 +1,139 @@
+package patch
+
+import (
+	"strings"
+	"testing"
+)
+
+const original = `package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("hello")
+}
+`
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		want string
+		err  string
+	}{
+		{
+			name: "exact",
+			diff: `--- a/main.go
++++ b/main.go
+@@ -5,3 +5,3 @@
+ func main() {
+-	fmt.Println("hello")
++	fmt.Println("goodbye")
+ }
+`,
+			want: strings.Replace(original, "hello", "goodbye", 1),
+		},
+		{
+			name: "wrong line numbers and counts, blank context without a space",
+			diff: `diff --git a/main.go b/main.go
+index 1234567..89abcde 100644
+--- a/main.go
++++ b/main.go
+@@ -40,9 +40,2 @@ func main() {
+ import "fmt"
+
++import "os"
++
+ func main() {
+`,
+			want: strings.Replace(original, "import \"fmt\"\n", "import \"fmt\"\n\nimport \"os\"\n", 1),
+		},
+		{
+			name: "two hunks",
+			diff: "--- main.go\n+++ main.go\n@@ -1 +1 @@\n-package main\n+package app\n@@ -6 +6 @@\n-\tfmt.Println(\"hello\")\n+\tfmt.Println(\"hi\")\n",
+			want: strings.Replace(strings.Replace(original, "main\n", "app\n", 1), "hello", "hi", 1),
+		},
+		{
+			name: "context doesn't match",
+			diff: "--- a/main.go\n+++ b/main.go\n@@ -5,2 +5,2 @@\n func main() {\n-\tfmt.Println(\"bye\")\n+\tfmt.Println(\"hi\")\n",
+			err:  "main.go: hunk 1 (at line 5) doesn't match the file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := Parse(tt.diff)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 1 || files[0].Name() != "main.go" {
+				t.Fatalf("files = %+v", files)
+			}
+			got, err := files[0].Apply(original)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyNearest(t *testing.T) {
+	content := "x\na\nx\nb\nx\nc\n"
+	files, err := Parse("--- f\n+++ f\n@@ -5,1 +5,1 @@\n-x\n+y\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := files[0].Apply(content)
+	if err != nil || got != "x\na\nx\nb\ny\nc\n" {
+		t.Errorf("Apply() = %q, %v", got, err)
+	}
+}
+
+func TestParseFiles(t *testing.T) {
+	diff := `Here is the change:
+
+--- /dev/null
++++ b/docs/new.md
+@@ -0,0 +1,2 @@
++# New
++Text
+
+--- a/old.txt
++++ /dev/null
+@@ -1,1 +0,0 @@
+-gone
+`
+	files, err := Parse(diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Name() != "docs/new.md" || files[1].Name() != "old.txt" {
+		t.Fatalf("files = %+v", files)
+	}
+
+	created, err := files[0].Apply("")
+	if err != nil || created != "# New\nText\n" {
+		t.Errorf("created = %q, %v", created, err)
+	}
+	if _, err := files[0].Apply("exists\n"); err == nil {
+		t.Error("expected an error creating a file that exists")
+	}
+	deleted, err := files[1].Apply("gone\n")
+	if err != nil || deleted != "" {
+		t.Errorf("deleted = %q, %v", deleted, err)
+	}
+
+	for _, bad := range []string{"no diff here", "--- a\n+++ b\n", "@@ -1 +1 @@\n-a\n+b\n"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) succeeded", bad)
+		}
+	}
+}
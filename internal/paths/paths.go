@@ -0,0 +1,178 @@
+// Package paths locates llm's config, cache and data directories following
+// the XDG Base Directory specification, and moves files from where older
+// versions kept them.
+//
+// On Unix, including macOS, the directories are $XDG_CONFIG_HOME/llm,
+// $XDG_CACHE_HOME/llm and $XDG_DATA_HOME/llm, defaulting to ~/.config/llm,
+// ~/.cache/llm and ~/.local/share/llm. On Windows they're under %APPDATA%
+// and %LOCALAPPDATA%. Sockets go in $XDG_RUNTIME_DIR/llm where that's set,
+// and in the cache directory otherwise. The policy file is system-wide.
+package paths
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// app is the directory name used under each base directory
+const app = "llm"
+
+// ConfigDir returns the directory holding config.toml
+func ConfigDir() (string, error) {
+	if runtime.GOOS == "windows" {
+		return userDir(os.UserConfigDir)
+	}
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// PolicyFile returns the system-wide policy file, which admins use to
+// enforce settings: /etc/llm/policy.toml, or %ProgramData%\llm\policy.toml
+// on Windows
+func PolicyFile() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(cmp.Or(os.Getenv("ProgramData"), `C:\ProgramData`), app, "policy.toml")
+	}
+	return filepath.Join("/etc", app, "policy.toml")
+}
+
+// CacheDir returns the directory for files that can be regenerated
+func CacheDir() (string, error) {
+	if runtime.GOOS == "windows" {
+		return userDir(os.UserCacheDir)
+	}
+	return xdgDir("XDG_CACHE_HOME", ".cache")
+}
+
+// DataDir returns the directory for history and other state worth keeping
+func DataDir() (string, error) {
+	if runtime.GOOS == "windows" {
+		return userDir(os.UserConfigDir)
+	}
+	return xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+}
+
+// RuntimeDir returns the directory for sockets, which is
+// $XDG_RUNTIME_DIR/llm, or the cache directory where that isn't set
+func RuntimeDir() (string, error) {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); runtime.GOOS != "windows" && filepath.IsAbs(dir) {
+		return filepath.Join(dir, app), nil
+	}
+	return CacheDir()
+}
+
+// xdgDir returns $env/llm, or ~/fallback/llm if env is unset. Relative
+// paths in env are ignored, as the spec requires.
+func xdgDir(env, fallback string) (string, error) {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return filepath.Join(dir, app), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, fallback, app), nil
+}
+
+func userDir(base func() (string, error)) (string, error) {
+	dir, err := base()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, app), nil
+}
+
+// Move describes a file moved by Migrate
+type Move struct {
+	From, To string
+}
+
+// Migrate moves files from the locations used by older versions to the
+// current ones, skipping any whose new location already exists
+func Migrate() ([]Move, error) {
+	var moves []Move
+	for _, m := range legacyFiles() {
+		if _, err := os.Lstat(m.From); err != nil {
+			continue
+		}
+		if _, err := os.Lstat(m.To); err == nil {
+			continue
+		}
+		if err := moveFile(m.From, m.To); err != nil {
+			return moves, fmt.Errorf("failed to move %s to %s: %v", m.From, m.To, err)
+		}
+		moves = append(moves, m)
+	}
+	return moves, nil
+}
+
+// legacyFiles lists where files used to live and where they belong now.
+// Older versions kept everything in os.UserConfigDir()/llm, which is
+// ~/Library/Application Support on macOS.
+func legacyFiles() []Move {
+	legacy, err := userDir(os.UserConfigDir)
+	if err != nil {
+		return nil
+	}
+	configDir, err := ConfigDir()
+	if err != nil {
+		return nil
+	}
+	dataDir, err := DataDir()
+	if err != nil {
+		return nil
+	}
+
+	var moves []Move
+	for _, m := range []Move{
+		{filepath.Join(legacy, "config.toml"), filepath.Join(configDir, "config.toml")},
+		{filepath.Join(legacy, "history.jsonl"), filepath.Join(dataDir, "history.jsonl")},
+	} {
+		if m.From != m.To {
+			moves = append(moves, m)
+		}
+	}
+	return moves
+}
+
+// moveFile renames from to to, copying if they're on different filesystems
+func moveFile(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0700); err != nil {
+		return err
+	}
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(to)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(to)
+		return err
+	}
+	if err := os.Remove(from); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestXDGDirs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("XDG variables aren't used on Windows")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("XDG_DATA_HOME", "relative/ignored")
+	t.Setenv("XDG_RUNTIME_DIR", "")
+
+	for _, tt := range []struct {
+		name string
+		dir  func() (string, error)
+		want string
+	}{
+		{"config", ConfigDir, "/xdg/config/llm"},
+		{"cache", CacheDir, filepath.Join(home, ".cache", "llm")},
+		{"data", DataDir, filepath.Join(home, ".local", "share", "llm")},
+		{"runtime", RuntimeDir, filepath.Join(home, ".cache", "llm")},
+	} {
+		if got, err := tt.dir(); err != nil || got != tt.want {
+			t.Errorf("%s dir = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("data and config share a directory on Windows")
+	}
+	tmp := t.TempDir()
+	t.Setenv("HOME", tmp)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(tmp, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tmp, "data"))
+
+	legacy, err := userDir(os.UserConfigDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(legacy, 0700)
+	os.WriteFile(filepath.Join(legacy, "history.jsonl"), []byte("{}\n"), 0600)
+
+	moves, err := Migrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved := filepath.Join(tmp, "data", "llm", "history.jsonl")
+	if data, err := os.ReadFile(moved); err != nil || string(data) != "{}\n" {
+		t.Errorf("history wasn't moved: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(legacy, "history.jsonl")); !os.IsNotExist(err) {
+		t.Error("legacy history still exists")
+	}
+	if len(moves) == 0 || moves[len(moves)-1].To != moved {
+		t.Errorf("moves = %v", moves)
+	}
+
+	// An existing file is never overwritten
+	os.WriteFile(filepath.Join(legacy, "history.jsonl"), []byte("old\n"), 0600)
+	if moves, err := Migrate(); err != nil || len(moves) != 0 {
+		t.Errorf("second migration = %v, %v", moves, err)
+	}
+	if data, _ := os.ReadFile(moved); string(data) != "{}\n" {
+		t.Error("migration overwrote the new history")
+	}
+}
@@ -0,0 +1,127 @@
+package shellhistory
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Entry is a command from the history, with what's known about it
+type Entry struct {
+	Time    time.Time // zero if unknown
+	Dir     string    // "" if unknown
+	Command string
+	Exit    int // -1 if unknown
+}
+
+// database is a history database that records more than history files do
+type database struct {
+	name  string
+	path  func(home string, getenv func(string) string) string
+	query string // with %d for how many commands
+}
+
+// databases are tried in order before the shell's history file
+var databases = []database{
+	{
+		name: "Atuin",
+		path: func(home string, getenv func(string) string) string {
+			if path := getenv("ATUIN_DB_PATH"); path != "" {
+				return path
+			}
+			dataHome := getenv("XDG_DATA_HOME")
+			if !filepath.IsAbs(dataHome) {
+				dataHome = filepath.Join(home, ".local", "share")
+			}
+			return filepath.Join(dataHome, "atuin", "history.db")
+		},
+		query: `SELECT command, cwd AS dir, exit, timestamp / 1000000000 AS time
+FROM history WHERE deleted_at IS NULL ORDER BY timestamp DESC LIMIT %d`,
+	},
+	{
+		name: "zsh-histdb",
+		path: func(home string, getenv func(string) string) string {
+			return cmp.Or(getenv("HISTDB_FILE"), filepath.Join(home, ".histdb", "zsh-history.db"))
+		},
+		query: `SELECT commands.argv AS command, places.dir AS dir, history.exit_status AS exit, history.start_time AS time
+FROM history JOIN commands ON history.command_id = commands.id JOIN places ON history.place_id = places.id
+ORDER BY history.start_time DESC LIMIT %d`,
+	},
+}
+
+// Recent returns the last n commands, oldest first, and where they came
+// from: Atuin's or zsh-histdb's database if there is one and sqlite3 is
+// installed to read it, and otherwise shell's history file
+func Recent(shell, home string, getenv func(string) string, n int) ([]Entry, string, error) {
+	for _, db := range databases {
+		path := db.path(home, getenv)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		entries, err := queryDatabase(path, fmt.Sprintf(db.query, n))
+		if errors.Is(err, exec.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read %s's history: %v", db.name, err)
+		}
+		return entries, db.name, nil
+	}
+
+	commands, err := Read(shell, home, getenv, n)
+	if err != nil {
+		return nil, "", err
+	}
+	entries := make([]Entry, len(commands))
+	for i, command := range commands {
+		entries[i] = Entry{Command: command, Exit: -1}
+	}
+	return entries, shell + " history", nil
+}
+
+// queryDatabase runs query, which selects command, dir, exit and time
+// columns newest first, on the SQLite database at path without writing to it
+func queryDatabase(path, query string) ([]Entry, error) {
+	cmd := exec.Command("sqlite3", "-readonly", "-json", path, query)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.New(msg)
+		}
+		return nil, err
+	}
+	if len(strings.TrimSpace(string(out))) == 0 {
+		return nil, nil
+	}
+
+	var rows []struct {
+		Command string `json:"command"`
+		Dir     string `json:"dir"`
+		Exit    *int   `json:"exit"`
+		Time    int64  `json:"time"`
+	}
+	if err := json.Unmarshal(out, &rows); err != nil {
+		return nil, fmt.Errorf("unexpected output from sqlite3: %v", err)
+	}
+	entries := make([]Entry, len(rows))
+	for i, row := range rows {
+		entries[i] = Entry{Command: row.Command, Dir: row.Dir, Exit: -1}
+		if row.Exit != nil {
+			entries[i].Exit = *row.Exit
+		}
+		if row.Time > 0 {
+			entries[i].Time = time.Unix(row.Time, 0)
+		}
+	}
+	slices.Reverse(entries)
+	return entries, nil
+}
@@ -0,0 +1,72 @@
+package shellhistory
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRecent(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("sqlite3 not installed")
+	}
+	getenv := func(string) string { return "" }
+
+	home := t.TempDir()
+	os.WriteFile(filepath.Join(home, ".zsh_history"), []byte("ls\nmake test\n"), 0600)
+	entries, source, err := Recent("zsh", home, getenv, 5)
+	if err != nil || source != "zsh history" || len(entries) != 2 || entries[1] != (Entry{Command: "make test", Exit: -1}) {
+		t.Fatalf("history file: got %v, %q, %v", entries, source, err)
+	}
+
+	histdb := filepath.Join(home, ".histdb", "zsh-history.db")
+	os.MkdirAll(filepath.Dir(histdb), 0700)
+	sqlite(t, histdb, `CREATE TABLE commands (id INTEGER PRIMARY KEY, argv TEXT);
+CREATE TABLE places (id INTEGER PRIMARY KEY, host TEXT, dir TEXT);
+CREATE TABLE history (id INTEGER PRIMARY KEY, session INT, command_id INT, place_id INT, exit_status INT, start_time INT, duration INT);
+INSERT INTO commands VALUES (1, 'git pull'), (2, 'go test ./...');
+INSERT INTO places VALUES (1, 'laptop', '/src/app');
+INSERT INTO history VALUES (1, 1, 1, 1, 0, 1700000000, 1), (2, 1, 2, 1, 1, 1700000060, 9), (3, 1, 1, 1, NULL, 1700000120, NULL);`)
+	entries, source, err = Recent("zsh", home, getenv, 2)
+	if err != nil || source != "zsh-histdb" {
+		t.Fatalf("zsh-histdb: got %v, %q, %v", entries, source, err)
+	}
+	if got := []string{entries[0].Command, entries[1].Command}; !slices.Equal(got, []string{"go test ./...", "git pull"}) {
+		t.Errorf("zsh-histdb commands = %q", got)
+	}
+	if e := entries[0]; e.Dir != "/src/app" || e.Exit != 1 || e.Time.Unix() != 1700000060 || entries[1].Exit != -1 {
+		t.Errorf("zsh-histdb entries = %+v", entries)
+	}
+
+	atuin := filepath.Join(home, ".local", "share", "atuin", "history.db")
+	os.MkdirAll(filepath.Dir(atuin), 0700)
+	sqlite(t, atuin, `CREATE TABLE history (id TEXT PRIMARY KEY, timestamp INTEGER, duration INTEGER, exit INTEGER, command TEXT, cwd TEXT, session TEXT, hostname TEXT, deleted_at INTEGER);
+INSERT INTO history VALUES ('a', 1700000000000000000, 5, 0, 'cd /src/app', '/src', 's', 'h', NULL),
+ ('b', 1700000030000000000, 5, 0, 'cat .env', '/src/app', 's', 'h', 1700000040000000000),
+ ('c', 1700000060000000000, 5, 2, 'make build', '/src/app', 's', 'h', NULL);`)
+	entries, source, err = Recent("zsh", home, getenv, 10)
+	want := []Entry{
+		{Command: "cd /src/app", Dir: "/src", Exit: 0},
+		{Command: "make build", Dir: "/src/app", Exit: 2},
+	}
+	if err != nil || source != "Atuin" || len(entries) != len(want) {
+		t.Fatalf("Atuin: got %v, %q, %v", entries, source, err)
+	}
+	for i, e := range entries {
+		if e.Command != want[i].Command || e.Dir != want[i].Dir || e.Exit != want[i].Exit {
+			t.Errorf("Atuin entry %d = %+v, want %+v", i, e, want[i])
+		}
+	}
+	if entries[1].Time.Unix() != 1700000060 {
+		t.Errorf("Atuin time = %v", entries[1].Time)
+	}
+}
+
+func sqlite(t *testing.T, path, sql string) {
+	t.Helper()
+	if out, err := exec.Command("sqlite3", path, sql).CombinedOutput(); err != nil {
+		t.Fatalf("sqlite3: %v: %s", err, out)
+	}
+}
@@ -0,0 +1,141 @@
+// Package shellhistory reads the commands the user has typed from the
+// history files of bash, zsh and fish, or from Atuin's or zsh-histdb's
+// database.
+package shellhistory
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File returns shell's history file: $HISTFILE for bash and zsh if it's set
+// in the environment, and otherwise where the shell keeps it by default
+func File(shell, home string, getenv func(string) string) (string, error) {
+	switch shell {
+	case "bash":
+		return cmp.Or(getenv("HISTFILE"), filepath.Join(home, ".bash_history")), nil
+	case "zsh":
+		return cmp.Or(getenv("HISTFILE"), filepath.Join(cmp.Or(getenv("ZDOTDIR"), home), ".zsh_history")), nil
+	case "fish":
+		dataHome := getenv("XDG_DATA_HOME")
+		if !filepath.IsAbs(dataHome) {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+		return filepath.Join(dataHome, "fish", "fish_history"), nil
+	}
+	return "", fmt.Errorf("reading %s history isn't supported; use bash, zsh or fish", shell)
+}
+
+// Read returns the last n commands in shell's history, oldest first, or all
+// of them if n is 0
+func Read(shell, home string, getenv func(string) string, n int) ([]string, error) {
+	path, err := File(shell, home, getenv)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s history: %v", shell, err)
+	}
+	commands := Parse(shell, data)
+	if n > 0 && len(commands) > n {
+		commands = commands[len(commands)-n:]
+	}
+	return commands, nil
+}
+
+// Parse returns the commands in the contents of shell's history file,
+// oldest first
+func Parse(shell string, data []byte) []string {
+	switch shell {
+	case "zsh":
+		return parseZsh(unmetafy(data))
+	case "fish":
+		return parseFish(string(data))
+	}
+	return parseBash(string(data))
+}
+
+// parseBash reads one command per line, skipping the "#1700000000" lines
+// that hold timestamps when HISTTIMEFORMAT is set
+func parseBash(data string) []string {
+	var commands []string
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || isTimestamp(line) {
+			continue
+		}
+		commands = append(commands, line)
+	}
+	return commands
+}
+
+func isTimestamp(line string) bool {
+	if len(line) < 2 || line[0] != '#' {
+		return false
+	}
+	for _, c := range line[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseZsh reads plain lines, and the ": 1700000000:0;command" lines
+// EXTENDED_HISTORY writes. A command spanning lines ends each but the last
+// with a backslash.
+func parseZsh(data string) []string {
+	var commands []string
+	var current strings.Builder
+	for _, line := range strings.Split(data, "\n") {
+		if current.Len() == 0 && strings.HasPrefix(line, ": ") {
+			if _, command, ok := strings.Cut(line, ";"); ok {
+				line = command
+			}
+		}
+		if rest, ok := strings.CutSuffix(line, `\`); ok {
+			current.WriteString(rest + "\n")
+			continue
+		}
+		current.WriteString(line)
+		if command := current.String(); strings.TrimSpace(command) != "" {
+			commands = append(commands, command)
+		}
+		current.Reset()
+	}
+	return commands
+}
+
+// unmetafy undoes zsh's escaping of bytes in its history file: each byte
+// from 0x83 to 0xa2 is written as 0x83 then the byte XOR 32
+func unmetafy(data []byte) string {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		if data[i] == 0x83 && i+1 < len(data) {
+			i++
+			out = append(out, data[i]^32)
+			continue
+		}
+		out = append(out, data[i])
+	}
+	return string(out)
+}
+
+// parseFish reads the "- cmd: command" lines of fish's YAML-like history,
+// in which backslashes and newlines are escaped
+func parseFish(data string) []string {
+	var commands []string
+	for _, line := range strings.Split(data, "\n") {
+		command, ok := strings.CutPrefix(line, "- cmd: ")
+		if !ok {
+			continue
+		}
+		command = strings.NewReplacer(`\\`, `\`, `\n`, "\n").Replace(command)
+		commands = append(commands, command)
+	}
+	return commands
+}
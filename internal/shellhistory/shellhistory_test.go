@@ -0,0 +1,72 @@
+package shellhistory
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		shell string
+		data  string
+		want  []string
+	}{
+		{"bash", "ls -la\n#1700000000\ngit status\n\n", []string{"ls -la", "git status"}},
+		{"zsh", ": 1700000000:0;git log --oneline\nls\n: 1700000001:2;for f in *; do\\\n  echo $f\\\ndone\n",
+			[]string{"git log --oneline", "ls", "for f in *; do\n  echo $f\ndone"}},
+		{"zsh", "echo a \xe2\x80\x83\xb4 b\n", []string{"echo a — b"}},
+		{"fish", "- cmd: git push\n  when: 1700000000\n- cmd: printf 'a\\\\nb'\\nls\n  when: 1700000001\n",
+			[]string{"git push", "printf 'a\\nb'\nls"}},
+	}
+	for _, tt := range tests {
+		if got := Parse(tt.shell, []byte(tt.data)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.shell, got, tt.want)
+		}
+	}
+}
+
+func TestFile(t *testing.T) {
+	env := map[string]string{}
+	getenv := func(key string) string { return env[key] }
+
+	tests := []struct {
+		shell string
+		env   map[string]string
+		want  string
+	}{
+		{"bash", nil, "/home/me/.bash_history"},
+		{"bash", map[string]string{"HISTFILE": "/tmp/h"}, "/tmp/h"},
+		{"zsh", map[string]string{"ZDOTDIR": "/home/me/.config/zsh"}, "/home/me/.config/zsh/.zsh_history"},
+		{"fish", map[string]string{"XDG_DATA_HOME": "/data"}, "/data/fish/fish_history"},
+		{"fish", map[string]string{"XDG_DATA_HOME": "relative"}, "/home/me/.local/share/fish/fish_history"},
+	}
+	for _, tt := range tests {
+		env = tt.env
+		got, err := File(tt.shell, "/home/me", getenv)
+		if err != nil || got != filepath.FromSlash(tt.want) {
+			t.Errorf("%s %v: got %q, %v, want %q", tt.shell, tt.env, got, err, tt.want)
+		}
+	}
+	if _, err := File("pwsh", "/home/me", getenv); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
+
+func TestRead(t *testing.T) {
+	home := t.TempDir()
+	os.WriteFile(filepath.Join(home, ".bash_history"), []byte("one\ntwo\nthree\n"), 0600)
+	getenv := func(string) string { return "" }
+
+	got, err := Read("bash", home, getenv, 2)
+	if err != nil || !slices.Equal(got, []string{"two", "three"}) {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if got, _ := Read("bash", home, getenv, 0); len(got) != 3 {
+		t.Errorf("n = 0 gave %q", got)
+	}
+	if _, err := Read("zsh", home, getenv, 0); err == nil {
+		t.Error("expected an error for a missing history file")
+	}
+}
@@ -0,0 +1,378 @@
+// Package telemetry exports spans and metrics for provider requests to an
+// OpenTelemetry collector over OTLP/HTTP with JSON encoding, configured by
+// the standard OTEL_* environment variables.
+package telemetry
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTimeout is how long an export may take, as the spec sets
+const defaultTimeout = 10 * time.Second
+
+// Span is one provider request
+type Span struct {
+	Name       string
+	Start, End time.Time
+	Attributes map[string]any // string, int or bool values
+	Error      string         // "" if the request succeeded
+}
+
+// Exporter collects spans and sends them, and metrics summing them, when
+// flushed
+type Exporter struct {
+	tracesURL  string // "" if traces aren't exported
+	metricsURL string // "" if metrics aren't exported
+	headers    map[string]string
+	resource   map[string]any
+	version    string
+	timeout    time.Duration
+	client     *http.Client
+	started    time.Time
+
+	mu    sync.Mutex
+	spans []Span
+}
+
+// FromEnv returns an exporter configured by OTEL_EXPORTER_OTLP_ENDPOINT and
+// the related variables, or nil if export isn't configured or is disabled.
+// version is reported as the service version.
+func FromEnv(getenv func(string) string, version string) (*Exporter, error) {
+	if strings.EqualFold(getenv("OTEL_SDK_DISABLED"), "true") {
+		return nil, nil
+	}
+	base := strings.TrimSuffix(getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "/")
+	e := &Exporter{
+		headers:  map[string]string{},
+		resource: map[string]any{},
+		version:  version,
+		timeout:  defaultTimeout,
+		client:   http.DefaultClient,
+		started:  time.Now(),
+	}
+	for _, signal := range []struct {
+		name, path string
+		url        *string
+	}{
+		{"TRACES", "/v1/traces", &e.tracesURL},
+		{"METRICS", "/v1/metrics", &e.metricsURL},
+	} {
+		if getenv("OTEL_"+signal.name+"_EXPORTER") == "none" {
+			continue
+		}
+		endpoint := getenv("OTEL_EXPORTER_OTLP_" + signal.name + "_ENDPOINT")
+		if endpoint == "" && base != "" {
+			endpoint = base + signal.path
+		}
+		if endpoint == "" {
+			continue
+		}
+		protocol := cmp.Or(getenv("OTEL_EXPORTER_OTLP_"+signal.name+"_PROTOCOL"), getenv("OTEL_EXPORTER_OTLP_PROTOCOL"), "http/json")
+		if protocol != "http/json" {
+			return nil, fmt.Errorf("OTLP protocol %q isn't supported; set OTEL_EXPORTER_OTLP_PROTOCOL=http/json", protocol)
+		}
+		*signal.url = endpoint
+	}
+	if e.tracesURL == "" && e.metricsURL == "" {
+		return nil, nil
+	}
+
+	if err := parsePairs(getenv("OTEL_EXPORTER_OTLP_HEADERS"), func(k, v string) { e.headers[k] = v }); err != nil {
+		return nil, fmt.Errorf("OTEL_EXPORTER_OTLP_HEADERS: %v", err)
+	}
+	if err := parsePairs(getenv("OTEL_RESOURCE_ATTRIBUTES"), func(k, v string) { e.resource[k] = v }); err != nil {
+		return nil, fmt.Errorf("OTEL_RESOURCE_ATTRIBUTES: %v", err)
+	}
+	e.resource["service.name"] = cmp.Or(getenv("OTEL_SERVICE_NAME"), "llm-cli")
+	e.resource["service.version"] = version
+	if ms, err := strconv.Atoi(getenv("OTEL_EXPORTER_OTLP_TIMEOUT")); err == nil && ms > 0 {
+		e.timeout = time.Duration(ms) * time.Millisecond
+	}
+	return e, nil
+}
+
+// parsePairs calls set for each key=value in a comma-separated list, with
+// the values URL-decoded
+func parsePairs(list string, set func(key, value string)) error {
+	for _, pair := range strings.Split(list, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("%q isn't key=value", pair)
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return err
+		}
+		set(strings.TrimSpace(key), value)
+	}
+	return nil
+}
+
+// Record adds a span to be exported
+func (e *Exporter) Record(span Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// URLs returns the collector URLs spans and metrics are exported to
+func (e *Exporter) URLs() []string {
+	var urls []string
+	for _, u := range []string{e.tracesURL, e.metricsURL} {
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// Flush exports the spans recorded so far, and metrics for them
+func (e *Exporter) Flush(ctx context.Context) error {
+	e.mu.Lock()
+	spans := e.spans
+	e.spans = nil
+	e.mu.Unlock()
+	if len(spans) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	if e.tracesURL != "" {
+		if err := e.post(ctx, e.tracesURL, e.traces(spans)); err != nil {
+			return fmt.Errorf("failed to export traces: %v", err)
+		}
+	}
+	if e.metricsURL != "" {
+		if err := e.post(ctx, e.metricsURL, e.metrics(spans, time.Now())); err != nil {
+			return fmt.Errorf("failed to export metrics: %v", err)
+		}
+	}
+	return nil
+}
+
+func (e *Exporter) post(ctx context.Context, url string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range e.headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector returned status %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// OTLP's JSON encoding, as far as it's needed here
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"` // int64s are strings in OTLP JSON
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID    string     `json:"traceId"`
+	SpanID     string     `json:"spanId"`
+	Name       string     `json:"name"`
+	Kind       int        `json:"kind"`
+	Start      string     `json:"startTimeUnixNano"`
+	End        string     `json:"endTimeUnixNano"`
+	Attributes []keyValue `json:"attributes"`
+	Status     spanStatus `json:"status"`
+}
+
+type spanStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// Span kinds and status codes
+const (
+	spanKindClient = 3
+	statusOK       = 1
+	statusError    = 2
+)
+
+type dataPoint struct {
+	Attributes []keyValue `json:"attributes"`
+	Start      string     `json:"startTimeUnixNano"`
+	Time       string     `json:"timeUnixNano"`
+	AsInt      string     `json:"asInt"`
+}
+
+type metric struct {
+	Name string `json:"name"`
+	Unit string `json:"unit"`
+	Sum  struct {
+		DataPoints  []dataPoint `json:"dataPoints"`
+		Temporality int         `json:"aggregationTemporality"`
+		Monotonic   bool        `json:"isMonotonic"`
+	} `json:"sum"`
+}
+
+// temporalityDelta means each export counts only what's new since the last
+const temporalityDelta = 1
+
+func attributes(attrs map[string]any) []keyValue {
+	kvs := []keyValue{}
+	for key, v := range attrs {
+		kv := keyValue{Key: key}
+		switch v := v.(type) {
+		case string:
+			kv.Value.StringValue = &v
+		case int:
+			s := strconv.Itoa(v)
+			kv.Value.IntValue = &s
+		case bool:
+			kv.Value.BoolValue = &v
+		default:
+			s := fmt.Sprint(v)
+			kv.Value.StringValue = &s
+		}
+		kvs = append(kvs, kv)
+	}
+	// Map order is random; sorted attributes are easier to read and test
+	slices.SortFunc(kvs, func(a, b keyValue) int { return strings.Compare(a.Key, b.Key) })
+	return kvs
+}
+
+func nanos(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// randomHex returns n random bytes in hex, for trace and span IDs
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// traces returns the OTLP request for spans, which share a trace as parts of
+// one run of llm
+func (e *Exporter) traces(spans []Span) map[string]any {
+	traceID := randomHex(16)
+	var out []otlpSpan
+	for _, s := range spans {
+		status := spanStatus{Code: statusOK}
+		if s.Error != "" {
+			status = spanStatus{Code: statusError, Message: s.Error}
+		}
+		out = append(out, otlpSpan{
+			TraceID:    traceID,
+			SpanID:     randomHex(8),
+			Name:       s.Name,
+			Kind:       spanKindClient,
+			Start:      nanos(s.Start),
+			End:        nanos(s.End),
+			Attributes: attributes(s.Attributes),
+			Status:     status,
+		})
+	}
+	return map[string]any{"resourceSpans": []any{map[string]any{
+		"resource":   resource{Attributes: attributes(e.resource)},
+		"scopeSpans": []any{map[string]any{"scope": scope{Name: "llm-cli", Version: e.version}, "spans": out}},
+	}}}
+}
+
+// metricKeys are the span attributes metrics are broken down by
+var metricKeys = []string{"gen_ai.system", "gen_ai.request.model", "error.type"}
+
+// metrics returns the OTLP request counting requests and tokens in spans
+func (e *Exporter) metrics(spans []Span, now time.Time) map[string]any {
+	requests := map[string]*dataPoint{}
+	tokens := map[string]*dataPoint{}
+	var requestOrder, tokenOrder []string
+	add := func(points map[string]*dataPoint, order *[]string, attrs map[string]any, n int) {
+		key := fmt.Sprint(attrs)
+		p, ok := points[key]
+		if !ok {
+			p = &dataPoint{Attributes: attributes(attrs), Start: nanos(e.started), Time: nanos(now), AsInt: "0"}
+			points[key] = p
+			*order = append(*order, key)
+		}
+		total, _ := strconv.Atoi(p.AsInt)
+		p.AsInt = strconv.Itoa(total + n)
+	}
+
+	for _, s := range spans {
+		attrs := map[string]any{}
+		for _, key := range metricKeys {
+			if v, ok := s.Attributes[key]; ok {
+				attrs[key] = v
+			}
+		}
+		add(requests, &requestOrder, attrs, 1)
+		for _, kind := range []string{"input", "output"} {
+			if n, ok := s.Attributes["gen_ai.usage."+kind+"_tokens"].(int); ok {
+				tokenAttrs := map[string]any{"gen_ai.token.type": kind}
+				for k, v := range attrs {
+					tokenAttrs[k] = v
+				}
+				add(tokens, &tokenOrder, tokenAttrs, n)
+			}
+		}
+	}
+
+	sum := func(name, unit string, points map[string]*dataPoint, order []string) metric {
+		m := metric{Name: name, Unit: unit}
+		m.Sum.Temporality = temporalityDelta
+		m.Sum.Monotonic = true
+		for _, key := range order {
+			m.Sum.DataPoints = append(m.Sum.DataPoints, *points[key])
+		}
+		return m
+	}
+	metrics := []metric{sum("llm.requests", "{request}", requests, requestOrder)}
+	if len(tokenOrder) > 0 {
+		metrics = append(metrics, sum("llm.tokens", "{token}", tokens, tokenOrder))
+	}
+	return map[string]any{"resourceMetrics": []any{map[string]any{
+		"resource":     resource{Attributes: attributes(e.resource)},
+		"scopeMetrics": []any{map[string]any{"scope": scope{Name: "llm-cli", Version: e.version}, "metrics": metrics}},
+	}}}
+}
@@ -0,0 +1,130 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name                 string
+		env                  map[string]string
+		traces, metrics, err string
+	}{
+		{"unset", nil, "", "", ""},
+		{"endpoint", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://collector:4318/"},
+			"http://collector:4318/v1/traces", "http://collector:4318/v1/metrics", ""},
+		{"signal endpoint", map[string]string{"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "http://collector/traces"},
+			"http://collector/traces", "", ""},
+		{"no metrics", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://c", "OTEL_METRICS_EXPORTER": "none"},
+			"http://c/v1/traces", "", ""},
+		{"disabled", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://c", "OTEL_SDK_DISABLED": "true"}, "", "", ""},
+		{"grpc", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://c", "OTEL_EXPORTER_OTLP_PROTOCOL": "grpc"},
+			"", "", "isn't supported"},
+		{"bad headers", map[string]string{"OTEL_EXPORTER_OTLP_ENDPOINT": "http://c", "OTEL_EXPORTER_OTLP_HEADERS": "nokey"},
+			"", "", "isn't key=value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := FromEnv(func(k string) string { return tt.env[k] }, "1.0")
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.traces == "" && tt.metrics == "" {
+				if e != nil {
+					t.Fatalf("got an exporter, want none")
+				}
+				return
+			}
+			if e.tracesURL != tt.traces || e.metricsURL != tt.metrics {
+				t.Errorf("urls = %q, %q, want %q, %q", e.tracesURL, e.metricsURL, tt.traces, tt.metrics)
+			}
+		})
+	}
+}
+
+func TestFlush(t *testing.T) {
+	var mu sync.Mutex
+	got := map[string]map[string]any{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t" {
+			t.Errorf("%s: Authorization = %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("%s: %v", r.URL.Path, err)
+		}
+		mu.Lock()
+		got[r.URL.Path] = body
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	env := map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT": server.URL,
+		"OTEL_EXPORTER_OTLP_HEADERS":  "Authorization=Bearer%20t",
+		"OTEL_RESOURCE_ATTRIBUTES":    "deployment.environment=test",
+	}
+	e, err := FromEnv(func(k string) string { return env[k] }, "1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(1700000000, 0)
+	e.Record(Span{Name: "chat claude", Start: start, End: start.Add(time.Second), Attributes: map[string]any{
+		"gen_ai.system":              "anthropic",
+		"gen_ai.request.model":       "claude",
+		"gen_ai.usage.input_tokens":  12,
+		"gen_ai.usage.output_tokens": 30,
+	}})
+	e.Record(Span{Name: "chat claude", Start: start, End: start.Add(time.Second), Error: "429 Too Many Requests",
+		Attributes: map[string]any{"gen_ai.system": "anthropic", "gen_ai.request.model": "claude", "error.type": "429"}})
+	if err := e.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	traces, _ := json.Marshal(got["/v1/traces"])
+	for _, want := range []string{
+		`{"key":"deployment.environment","value":{"stringValue":"test"}}`,
+		`{"key":"service.name","value":{"stringValue":"llm-cli"}}`,
+		`{"key":"gen_ai.usage.input_tokens","value":{"intValue":"12"}}`,
+		`"startTimeUnixNano":"1700000000000000000"`,
+		`"status":{"code":1}`,
+		`"status":{"code":2,"message":"429 Too Many Requests"}`,
+	} {
+		if !strings.Contains(string(traces), want) {
+			t.Errorf("traces lack %s:\n%s", want, traces)
+		}
+	}
+
+	metrics, _ := json.Marshal(got["/v1/metrics"])
+	for _, want := range []string{
+		`"name":"llm.requests"`,
+		`"name":"llm.tokens"`,
+		`{"key":"gen_ai.token.type","value":{"stringValue":"output"}}`,
+		`"asInt":"30"`,
+		`"asInt":"12"`,
+		`{"key":"error.type","value":{"stringValue":"429"}}`,
+	} {
+		if !strings.Contains(string(metrics), want) {
+			t.Errorf("metrics lack %s:\n%s", want, metrics)
+		}
+	}
+
+	// Spans are only sent once
+	got = map[string]map[string]any{}
+	if err := e.Flush(context.Background()); err != nil || len(got) != 0 {
+		t.Errorf("second flush sent %v, err %v", got, err)
+	}
+}
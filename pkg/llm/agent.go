@@ -0,0 +1,33 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// AgentStep is the model's next move towards an agent goal
+type AgentStep struct {
+	// Done is set once the goal is met, or can't be
+	Done bool `json:"done"`
+
+	// Thought says why Command is the next step
+	Thought string `json:"thought"`
+
+	// Command is a shell command to run next, unless Done
+	Command string `json:"command"`
+
+	// Summary describes the outcome once Done
+	Summary string `json:"summary"`
+}
+
+// ParseAgentStep parses an AgentMode response
+func ParseAgentStep(response string) (*AgentStep, error) {
+	var s AgentStep
+	if err := parseJSON(response, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse agent step: %v", err)
+	}
+	if !s.Done && s.Command == "" {
+		return nil, errors.New("failed to parse agent step: no command")
+	}
+	return &s, nil
+}
@@ -0,0 +1,21 @@
+package llm
+
+import "testing"
+
+func TestParseAgentStep(t *testing.T) {
+	s, err := ParseAgentStep("Next:\n" + `{"done": false, "thought": "check space", "command": "df -h"}`)
+	if err != nil || s.Done || s.Command != "df -h" || s.Thought != "check space" {
+		t.Errorf("ParseAgentStep() = %+v, %v", s, err)
+	}
+
+	s, err = ParseAgentStep(`{"done": true, "summary": "Freed 2GB"}`)
+	if err != nil || !s.Done || s.Summary != "Freed 2GB" {
+		t.Errorf("ParseAgentStep() = %+v, %v", s, err)
+	}
+
+	for _, bad := range []string{"df -h", `{"done": false, "thought": "hmm"}`} {
+		if _, err := ParseAgentStep(bad); err == nil {
+			t.Errorf("ParseAgentStep(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package llm
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// QueryN returns up to n distinct answers to prompt. OpenAI is asked for
+// them all in one request; other providers are sent the prompt n times at
+// once. Failed requests are skipped unless they all fail.
+func (c *Client) QueryN(ctx context.Context, prompt string, n int) ([]string, error) {
+	if c.Provider == OpenAI && c.ModelName() != "" {
+		answers, err := c.chatOpenAI(ctx, []Message{{Role: "user", Content: prompt}}, n)
+		if err != nil {
+			return nil, err
+		}
+		return distinct(answers), nil
+	}
+
+	answers := make([]string, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			answers[i], errs[i] = c.Query(ctx, prompt)
+		}()
+	}
+	wg.Wait()
+
+	var ok []string
+	for i, answer := range answers {
+		if errs[i] == nil {
+			ok = append(ok, answer)
+		}
+	}
+	if len(ok) == 0 {
+		return nil, errs[0]
+	}
+	return distinct(ok), nil
+}
+
+// distinct returns answers without repeats, in order
+func distinct(answers []string) []string {
+	var unique []string
+	for _, a := range answers {
+		if !slices.Contains(unique, a) {
+			unique = append(unique, a)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,55 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestQueryNOpenAI(t *testing.T) {
+	srv, _, body := mockProvider(t, http.StatusOK, `{"choices":[
+		{"message":{"content":"ls -la"}},
+		{"message":{"content":" ls -la "}},
+		{"message":{"content":"ls -al"}}]}`)
+
+	c := &Client{Provider: OpenAI, APIKey: "sk", Endpoint: srv.URL}
+	got, err := c.QueryN(context.Background(), "list files", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ls -la", "ls -al"}; !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !strings.Contains(string(*body), `"n":3`) {
+		t.Errorf("request doesn't ask for 3 choices: %s", *body)
+	}
+}
+
+func TestQueryNSampled(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		io.WriteString(w, `{"content":[{"type":"text","text":"ls"}]}`)
+	}))
+	defer srv.Close()
+
+	c := &Client{Provider: Claude, APIKey: "sk-ant", Endpoint: srv.URL}
+	got, err := c.QueryN(context.Background(), "list files", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 3 {
+		t.Errorf("sent %d requests, want 3", requests.Load())
+	}
+	if !slices.Equal(got, []string{"ls"}) {
+		t.Errorf("got %q, want one distinct answer", got)
+	}
+}
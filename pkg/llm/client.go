@@ -0,0 +1,361 @@
+// Package llm sends prompts to Claude, OpenAI and Ollama through a common
+// client, and builds the prompts used by the llm command.
+package llm
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// MaxOutputTokens caps the length of answers
+const MaxOutputTokens = 1000
+
+// Client sends prompts to a single provider
+type Client struct {
+	Provider Provider
+
+	// APIKey authenticates with Claude and OpenAI. Ollama doesn't need one.
+	APIKey string
+
+	// Model overrides the provider's default model. It is required for Ollama.
+	Model string
+
+	// Endpoint overrides the provider's default API URL
+	Endpoint string
+
+	// Temperature overrides the provider's default sampling temperature
+	// when it isn't zero
+	Temperature float64
+
+	// Stop ends answers early at any of these strings, which aren't
+	// included in the answer
+	Stop []string
+
+	// Seed makes sampling repeatable when it isn't zero. Claude has no
+	// seed, so it is ignored there.
+	Seed int
+
+	// MaxTokens lowers the cap on the length of answers from
+	// MaxOutputTokens when it isn't zero
+	MaxTokens int
+
+	// Retries is how many more times a request is sent after a failure that
+	// shows it wasn't answered: it never reached the provider, or was
+	// refused as rate limited or overloaded. Other failures aren't retried,
+	// since Claude and OpenAI don't deduplicate requests and may already
+	// have answered, and billed for, the request.
+	Retries int
+
+	// IdempotencyKeys sends each request with an Idempotency-Key header
+	// that its retries reuse, for gateways that deduplicate requests by it
+	IdempotencyKeys bool
+
+	// Headers are added to every request, replacing the provider's own
+	// headers of the same name, for gateways and proxies that need them
+	Headers map[string]string
+
+	// HTTPClient is used for all requests, defaulting to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for provider. An empty model selects the
+// provider's default.
+func NewClient(provider Provider, apiKey, model string) *Client {
+	return &Client{Provider: provider, APIKey: apiKey, Model: model}
+}
+
+// ModelName returns the model that will answer this client's queries
+func (c *Client) ModelName() string {
+	if c.Model != "" {
+		return c.Model
+	}
+	return DefaultModel(c.Provider)
+}
+
+// maxTokens returns the most tokens an answer may have
+func (c *Client) maxTokens() int {
+	if c.MaxTokens > 0 && c.MaxTokens < MaxOutputTokens {
+		return c.MaxTokens
+	}
+	return MaxOutputTokens
+}
+
+// Query sends prompt to the provider and returns the trimmed answer
+func (c *Client) Query(ctx context.Context, prompt string) (string, error) {
+	return c.Chat(ctx, []Message{{Role: "user", Content: prompt}})
+}
+
+// Chat sends a conversation to the provider and returns the trimmed answer.
+// Roles are "system", "user" and "assistant", as in OpenAI's API.
+func (c *Client) Chat(ctx context.Context, messages []Message) (string, error) {
+	if c.ModelName() == "" {
+		return "", fmt.Errorf("no model configured for %v", c.Provider)
+	}
+
+	switch c.Provider {
+	case Claude:
+		return c.queryClaude(ctx, messages)
+	case OpenAI:
+		return c.queryOpenAI(ctx, messages)
+	case Ollama:
+		return c.queryOllama(ctx, messages)
+	}
+	return "", fmt.Errorf("unknown provider %v", c.Provider)
+}
+
+// splitSystem separates system messages, which Claude and Ollama take
+// apart from the conversation, from the rest
+func splitSystem(messages []Message) (string, []Message) {
+	var system []string
+	var rest []Message
+	for _, m := range messages {
+		if m.Role == "system" {
+			system = append(system, m.Content)
+		} else {
+			rest = append(rest, m)
+		}
+	}
+	return strings.Join(system, "\n\n"), rest
+}
+
+// URL returns the URL chat requests are sent to
+func (c *Client) URL() string {
+	switch c.Provider {
+	case Claude:
+		return c.endpoint(claudeAPIURL)
+	case OpenAI:
+		return c.endpoint(openaiAPIURL)
+	case Ollama:
+		return c.endpoint(ollamaAPIURL)
+	}
+	return c.Endpoint
+}
+
+func (c *Client) endpoint(defaultURL string) string {
+	if c.Endpoint != "" {
+		return c.Endpoint
+	}
+	return defaultURL
+}
+
+// postJSON sends reqBody as JSON to url with the given headers and decodes a
+// successful response into respBody, retrying as c.Retries allows
+func (c *Client) postJSON(ctx context.Context, url string, headers map[string]string, reqBody, respBody any) error {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %v", err)
+	}
+	key := ""
+	if c.IdempotencyKeys {
+		if key, err = newIdempotencyKey(); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = c.post(ctx, url, headers, key, jsonData, respBody)
+		if err == nil || attempt >= c.Retries || !retrySafe(err) {
+			return err
+		}
+		if err := waitToRetry(ctx, attempt); err != nil {
+			return err
+		}
+	}
+}
+
+// post makes one attempt at a postJSON request
+func (c *Client) post(ctx context.Context, url string, headers map[string]string, key string, jsonData []byte, respBody any) error {
+	// Create HTTP request
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+
+	// Set headers
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(idempotencyHeader, key)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	for name, value := range c.Headers {
+		req.Header.Set(name, value)
+	}
+
+	// Make the request
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return &NetworkError{Provider: c.Provider, Err: err}
+	}
+	defer resp.Body.Close()
+
+	// Read response
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %v", err)
+	}
+
+	// Check for HTTP errors
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{
+			Provider:   c.Provider,
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			RequestID:  cmp.Or(resp.Header.Get("request-id"), resp.Header.Get("x-request-id")),
+		}
+	}
+
+	// Parse response
+	if err := json.Unmarshal(body, respBody); err != nil {
+		return fmt.Errorf("failed to parse response: %v", err)
+	}
+	return nil
+}
+
+func (c *Client) queryClaude(ctx context.Context, messages []Message) (string, error) {
+	// Prepare request body
+	system, rest := splitSystem(messages)
+	reqBody := ClaudeRequest{
+		Model:         c.ModelName(),
+		MaxTokens:     c.maxTokens(),
+		Temperature:   c.Temperature,
+		StopSequences: c.Stop,
+		System:        system,
+		Messages:      rest,
+	}
+
+	var claudeResp ClaudeResponse
+	err := c.postJSON(ctx, c.endpoint(claudeAPIURL), map[string]string{
+		"x-api-key":         c.APIKey,
+		"anthropic-version": "2023-06-01",
+	}, reqBody, &claudeResp)
+	if err != nil {
+		return "", err
+	}
+
+	// Check for API errors
+	if claudeResp.Error != nil {
+		return "", claudeResp.Error
+	}
+
+	// Extract the command from response
+	if len(claudeResp.Content) == 0 {
+		return "", fmt.Errorf("no content in response")
+	}
+
+	command := strings.TrimSpace(claudeResp.Content[0].Text)
+	if command == "" {
+		return "", fmt.Errorf("empty response from API")
+	}
+
+	return command, nil
+}
+
+func (c *Client) queryOpenAI(ctx context.Context, messages []Message) (string, error) {
+	choices, err := c.chatOpenAI(ctx, messages, 0)
+	if err != nil {
+		return "", err
+	}
+	return choices[0], nil
+}
+
+// chatOpenAI asks OpenAI for n choices, or its default of one if n is 0,
+// and returns the non-empty ones
+func (c *Client) chatOpenAI(ctx context.Context, messages []Message, n int) ([]string, error) {
+	// Prepare request body
+	reqBody := OpenAIRequest{
+		Model:       c.ModelName(),
+		MaxTokens:   c.maxTokens(),
+		Temperature: cmp.Or(c.Temperature, 0.1),
+		N:           n,
+		Stop:        c.Stop,
+		Seed:        c.Seed,
+	}
+	for _, m := range messages {
+		reqBody.Messages = append(reqBody.Messages, OpenAIMessage{Role: m.Role, Content: m.Content})
+	}
+
+	var openaiResp OpenAIResponse
+	err := c.postJSON(ctx, c.endpoint(openaiAPIURL), map[string]string{
+		"Authorization": "Bearer " + c.APIKey,
+	}, reqBody, &openaiResp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for API errors
+	if openaiResp.Error != nil {
+		return nil, openaiResp.Error
+	}
+
+	// Extract the commands from response
+	if len(openaiResp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in response")
+	}
+
+	var commands []string
+	for _, choice := range openaiResp.Choices {
+		if command := strings.TrimSpace(choice.Message.Content); command != "" {
+			commands = append(commands, command)
+		}
+	}
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("empty response from API")
+	}
+
+	return commands, nil
+}
+
+func (c *Client) queryOllama(ctx context.Context, messages []Message) (string, error) {
+	// Prepare request body. The generate endpoint takes a single prompt, so
+	// earlier turns are written out in it.
+	system, rest := splitSystem(messages)
+	reqBody := OllamaRequest{
+		Model:  c.ModelName(),
+		System: system,
+		Stream: false,
+	}
+	if c.Temperature != 0 || len(c.Stop) > 0 || c.Seed != 0 || c.MaxTokens != 0 {
+		reqBody.Options = &OllamaOptions{Temperature: c.Temperature, Stop: c.Stop, Seed: c.Seed}
+		if c.MaxTokens != 0 {
+			reqBody.Options.NumPredict = c.maxTokens()
+		}
+	}
+	if len(rest) == 1 {
+		reqBody.Prompt = rest[0].Content
+	} else {
+		var turns []string
+		for _, m := range rest {
+			turns = append(turns, m.Role+": "+m.Content)
+		}
+		reqBody.Prompt = strings.Join(turns, "\n\n") + "\n\nassistant:"
+	}
+
+	var ollamaResp OllamaResponse
+	if err := c.postJSON(ctx, c.endpoint(ollamaAPIURL), nil, reqBody, &ollamaResp); err != nil {
+		return "", err
+	}
+
+	// Check for API errors
+	if ollamaResp.Error != nil {
+		return "", ollamaResp.Error
+	}
+
+	// Extract the command from response
+	if ollamaResp.Response == "" {
+		return "", fmt.Errorf("empty response from API")
+	}
+
+	return strings.TrimSpace(ollamaResp.Response), nil
+}
@@ -0,0 +1,370 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// mockProvider starts a server that records the request it receives and
+// answers with status and body
+func mockProvider(t *testing.T, status int, body string) (*httptest.Server, *http.Request, *[]byte) {
+	t.Helper()
+	var gotReq http.Request
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotReq = *r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &gotReq, &gotBody
+}
+
+func TestClaudeRequest(t *testing.T) {
+	srv, req, body := mockProvider(t, http.StatusOK,
+		`{"content":[{"type":"text","text":"  ls -la\n"}]}`)
+
+	c := &Client{Provider: Claude, APIKey: "sk-ant", Endpoint: srv.URL}
+	got, err := c.Query(context.Background(), "list files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "ls -la" {
+		t.Errorf("got %q, want trimmed answer", got)
+	}
+
+	if req.Method != "POST" {
+		t.Errorf("method = %s", req.Method)
+	}
+	if v := req.Header.Get("x-api-key"); v != "sk-ant" {
+		t.Errorf("x-api-key = %q", v)
+	}
+	if v := req.Header.Get("anthropic-version"); v != "2023-06-01" {
+		t.Errorf("anthropic-version = %q", v)
+	}
+	if v := req.Header.Get("Content-Type"); v != "application/json" {
+		t.Errorf("Content-Type = %q", v)
+	}
+
+	var sent ClaudeRequest
+	if err := json.Unmarshal(*body, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Model != claudeModel || sent.MaxTokens != 1000 {
+		t.Errorf("unexpected request %+v", sent)
+	}
+	if len(sent.Messages) != 1 || sent.Messages[0].Role != "user" || sent.Messages[0].Content != "list files" {
+		t.Errorf("unexpected messages %+v", sent.Messages)
+	}
+}
+
+func TestOpenAIRequest(t *testing.T) {
+	srv, req, body := mockProvider(t, http.StatusOK,
+		`{"choices":[{"message":{"role":"assistant","content":"du -sh *"}}]}`)
+
+	c := &Client{Provider: OpenAI, APIKey: "sk-oai", Endpoint: srv.URL}
+	got, err := c.Query(context.Background(), "disk usage")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "du -sh *" {
+		t.Errorf("got %q", got)
+	}
+	if v := req.Header.Get("Authorization"); v != "Bearer sk-oai" {
+		t.Errorf("Authorization = %q", v)
+	}
+
+	var sent OpenAIRequest
+	if err := json.Unmarshal(*body, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Model != openaiModel || sent.Temperature != 0.1 {
+		t.Errorf("unexpected request %+v", sent)
+	}
+	if len(sent.Messages) != 1 || sent.Messages[0].Content != "disk usage" {
+		t.Errorf("unexpected messages %+v", sent.Messages)
+	}
+}
+
+func TestOllamaRequest(t *testing.T) {
+	srv, req, body := mockProvider(t, http.StatusOK, `{"response":"df -h\n"}`)
+
+	c := &Client{Provider: Ollama, Model: "llama3", Endpoint: srv.URL}
+	got, err := c.Query(context.Background(), "free space")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "df -h" {
+		t.Errorf("got %q", got)
+	}
+	if v := req.Header.Get("Authorization"); v != "" {
+		t.Errorf("unexpected Authorization header %q", v)
+	}
+
+	var sent OllamaRequest
+	if err := json.Unmarshal(*body, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Model != "llama3" || sent.Prompt != "free space" || sent.Stream {
+		t.Errorf("unexpected request %+v", sent)
+	}
+}
+
+func TestStopAndSeed(t *testing.T) {
+	tests := []struct {
+		provider Provider
+		want     string
+	}{
+		{Claude, `"stop_sequences":["\n\n"]`},
+		{OpenAI, `"stop":["\n\n"],"seed":42`},
+		{Ollama, `"options":{"stop":["\n\n"],"seed":42}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider.String(), func(t *testing.T) {
+			srv, _, body := mockProvider(t, http.StatusOK,
+				`{"content":[{"text":"ls"}],"choices":[{"message":{"content":"ls"}}],"response":"ls"}`)
+			c := &Client{Provider: tt.provider, Model: "m", Endpoint: srv.URL, Stop: []string{"\n\n"}, Seed: 42}
+			if _, err := c.Query(context.Background(), "list files"); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(*body), tt.want) {
+				t.Errorf("request %s doesn't contain %s", *body, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxTokens(t *testing.T) {
+	tests := []struct {
+		provider  Provider
+		maxTokens int
+		want      string
+	}{
+		{Claude, 200, `"max_tokens":200`},
+		{OpenAI, 200, `"max_tokens":200`},
+		{Ollama, 200, `"num_predict":200`},
+		{Claude, 5000, `"max_tokens":1000`},
+		{Ollama, 5000, `"num_predict":1000`},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.provider, tt.maxTokens), func(t *testing.T) {
+			srv, _, body := mockProvider(t, http.StatusOK,
+				`{"content":[{"text":"ls"}],"choices":[{"message":{"content":"ls"}}],"response":"ls"}`)
+			c := &Client{Provider: tt.provider, Model: "m", Endpoint: srv.URL, MaxTokens: tt.maxTokens}
+			if _, err := c.Query(context.Background(), "list files"); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(*body), tt.want) {
+				t.Errorf("request %s doesn't contain %s", *body, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	srv, req, _ := mockProvider(t, http.StatusOK, `{"content":[{"text":"ls"}]}`)
+	c := &Client{Provider: Claude, APIKey: "sk-ant", Endpoint: srv.URL, Headers: map[string]string{
+		"X-Org-Id":          "acme",
+		"anthropic-version": "2024-01-01",
+	}}
+	if _, err := c.Query(context.Background(), "list files"); err != nil {
+		t.Fatal(err)
+	}
+	if v := req.Header.Get("X-Org-Id"); v != "acme" {
+		t.Errorf("X-Org-Id = %q", v)
+	}
+	if v := req.Header.Get("anthropic-version"); v != "2024-01-01" {
+		t.Errorf("anthropic-version = %q", v)
+	}
+	if v := req.Header.Get("x-api-key"); v != "sk-ant" {
+		t.Errorf("x-api-key = %q", v)
+	}
+}
+
+func TestChatSystemMessages(t *testing.T) {
+	conversation := []Message{
+		{Role: "system", Content: "Be brief."},
+		{Role: "user", Content: "hi"},
+		{Role: "assistant", Content: "Hello."},
+		{Role: "user", Content: "list files"},
+	}
+
+	srv, _, body := mockProvider(t, http.StatusOK, `{"content":[{"type":"text","text":"ls"}]}`)
+	c := &Client{Provider: Claude, APIKey: "sk-ant", Endpoint: srv.URL}
+	if _, err := c.Chat(context.Background(), conversation); err != nil {
+		t.Fatal(err)
+	}
+	var claude ClaudeRequest
+	if err := json.Unmarshal(*body, &claude); err != nil {
+		t.Fatal(err)
+	}
+	if claude.System != "Be brief." || len(claude.Messages) != 3 || claude.Messages[1].Role != "assistant" {
+		t.Errorf("unexpected Claude request %+v", claude)
+	}
+
+	srv, _, body = mockProvider(t, http.StatusOK, `{"response":"ls"}`)
+	c = &Client{Provider: Ollama, Model: "llama3", Endpoint: srv.URL}
+	if _, err := c.Chat(context.Background(), conversation); err != nil {
+		t.Fatal(err)
+	}
+	var ollama OllamaRequest
+	if err := json.Unmarshal(*body, &ollama); err != nil {
+		t.Fatal(err)
+	}
+	wantPrompt := "user: hi\n\nassistant: Hello.\n\nuser: list files\n\nassistant:"
+	if ollama.System != "Be brief." || ollama.Prompt != wantPrompt {
+		t.Errorf("unexpected Ollama request %+v", ollama)
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider Provider
+		status   int
+		body     string
+		wantErr  string
+	}{
+		{"http status", Claude, http.StatusUnauthorized, `{"error":"bad key"}`, "status 401"},
+		{"malformed json", Claude, http.StatusOK, `not json`, "failed to parse response"},
+		{"claude api error", Claude, http.StatusOK, `{"error":{"type":"x","message":"overloaded"}}`, "API error: overloaded"},
+		{"claude no content", Claude, http.StatusOK, `{"content":[]}`, "no content"},
+		{"claude blank", Claude, http.StatusOK, `{"content":[{"type":"text","text":"  "}]}`, "empty response"},
+		{"openai api error", OpenAI, http.StatusOK, `{"error":{"message":"quota"}}`, "API error: quota"},
+		{"openai no choices", OpenAI, http.StatusOK, `{"choices":[]}`, "no choices"},
+		{"openai blank", OpenAI, http.StatusOK, `{"choices":[{"message":{"content":""}}]}`, "empty response"},
+		{"ollama api error", Ollama, http.StatusOK, `{"error":{"message":"no model"}}`, "API error: no model"},
+		{"ollama blank", Ollama, http.StatusOK, `{"response":""}`, "empty response"},
+		{"ollama 500", Ollama, http.StatusInternalServerError, `boom`, "status 500: boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _, _ := mockProvider(t, tt.status, tt.body)
+			c := &Client{Provider: tt.provider, APIKey: "k", Model: "m", Endpoint: srv.URL}
+			_, err := c.Query(context.Background(), "prompt")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQueryConnectionError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	c := &Client{Provider: OpenAI, APIKey: "k", Endpoint: srv.URL}
+	if _, err := c.Query(context.Background(), "prompt"); err == nil || !strings.Contains(err.Error(), "failed to make request") {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestQueryModelOverride(t *testing.T) {
+	srv, _, body := mockProvider(t, http.StatusOK, `{"content":[{"type":"text","text":"ok"}]}`)
+
+	c := &Client{Provider: Claude, APIKey: "k", Model: "claude-opus", Endpoint: srv.URL}
+	if _, err := c.Query(context.Background(), "q"); err != nil {
+		t.Fatal(err)
+	}
+	var sent ClaudeRequest
+	if err := json.Unmarshal(*body, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Model != "claude-opus" {
+		t.Errorf("model = %q", sent.Model)
+	}
+}
+
+func TestQueryOllamaRequiresModel(t *testing.T) {
+	c := &Client{Provider: Ollama}
+	if _, err := c.Query(context.Background(), "q"); err == nil {
+		t.Error("expected an error without a model")
+	}
+}
+
+func TestQueryCanceled(t *testing.T) {
+	srv, _, _ := mockProvider(t, http.StatusOK, `{"response":"x"}`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := &Client{Provider: Ollama, Model: "m", Endpoint: srv.URL}
+	if _, err := c.Query(ctx, "q"); err == nil || !strings.Contains(err.Error(), "context canceled") {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		want      Provider
+		wantKey   string
+		wantModel string
+	}{
+		{"claude first", map[string]string{"ANTHROPIC_API_KEY": "a", "OPENAI_API_KEY": "o", "OLLAMA_MODEL": "m"}, Claude, "a", claudeModel},
+		{"openai over ollama", map[string]string{"OPENAI_API_KEY": "o", "OLLAMA_MODEL": "m"}, OpenAI, "o", openaiModel},
+		{"ollama", map[string]string{"OLLAMA_MODEL": "m"}, Ollama, "", "m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"ANTHROPIC_API_KEY", "OPENAI_API_KEY", "OLLAMA_MODEL"} {
+				t.Setenv(name, tt.env[name])
+			}
+			c, err := FromEnv()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Provider != tt.want || c.APIKey != tt.wantKey || c.ModelName() != tt.wantModel {
+				t.Errorf("got %v %q %q, want %v %q %q", c.Provider, c.APIKey, c.ModelName(),
+					tt.want, tt.wantKey, tt.wantModel)
+			}
+		})
+	}
+}
+
+func TestFromEnvNothingConfigured(t *testing.T) {
+	for _, name := range []string{"ANTHROPIC_API_KEY", "OPENAI_API_KEY", "OLLAMA_MODEL"} {
+		t.Setenv(name, "")
+	}
+	if _, err := FromEnv(); !errors.Is(err, ErrNoProvider) {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestResolveModel(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider Provider
+		model    string
+	}{
+		{"claude-sonnet", Claude, claudeModel},
+		{"claude-3-5-haiku-latest", Claude, "claude-3-5-haiku-latest"},
+		{"gpt-4o-mini", OpenAI, "gpt-4o-mini"},
+		{"o3-mini", OpenAI, "o3-mini"},
+		{"o1", OpenAI, "o1"},
+		{"text-embedding-3-small", OpenAI, "text-embedding-3-small"},
+		{"llama3", Ollama, "llama3"},
+		{"llama3:8b", Ollama, "llama3:8b"},
+		{"openai:my-finetune", OpenAI, "my-finetune"},
+		{"ollama:gpt-oss", Ollama, "gpt-oss"},
+		{"claude:claude-sonnet", Claude, claudeModel},
+	}
+	for _, tt := range tests {
+		p, m := ResolveModel(tt.name)
+		if p != tt.provider || m != tt.model {
+			t.Errorf("ResolveModel(%q) = %v, %q, want %v, %q", tt.name, p, m, tt.provider, tt.model)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Project describes the directory the user is working in
+type Project struct {
+	// Dir is the base name of the working directory
+	Dir string
+
+	// Branch and Status summarize the git checkout, if there is one
+	Branch string
+	Status string
+
+	// Types lists detected project kinds, e.g. "Go (go.mod)"
+	Types []string
+}
+
+// projectMarkers maps files found at a project root to the kind of project
+// they indicate, in the order they're reported
+var projectMarkers = []struct {
+	file string
+	kind string
+}{
+	{"go.mod", "Go"},
+	{"Cargo.toml", "Rust"},
+	{"package.json", "Node.js"},
+	{"pnpm-lock.yaml", "pnpm"},
+	{"yarn.lock", "Yarn"},
+	{"pyproject.toml", "Python"},
+	{"requirements.txt", "Python"},
+	{"setup.py", "Python"},
+	{"Pipfile", "Python (Pipenv)"},
+	{"Gemfile", "Ruby"},
+	{"pom.xml", "Java (Maven)"},
+	{"build.gradle", "JVM (Gradle)"},
+	{"build.gradle.kts", "JVM (Gradle)"},
+	{"mix.exs", "Elixir"},
+	{"composer.json", "PHP"},
+	{"CMakeLists.txt", "C/C++ (CMake)"},
+	{"Makefile", "Make"},
+	{"Dockerfile", "Docker"},
+}
+
+// gitTimeout bounds how long git may take, so huge repos don't stall a query
+const gitTimeout = 2 * time.Second
+
+// DetectProject inspects dir for version control state and project files.
+// Markers are looked for in dir and, inside a git checkout, at its root.
+func DetectProject(dir string) Project {
+	p := Project{Dir: filepath.Base(dir)}
+
+	root := git(dir, "rev-parse", "--show-toplevel")
+	if root != "" {
+		p.Branch = git(dir, "symbolic-ref", "--short", "-q", "HEAD")
+		if p.Branch == "" {
+			if sha := git(dir, "rev-parse", "--short", "HEAD"); sha != "" {
+				p.Branch = "detached at " + sha
+			}
+		}
+		p.Status = summarizeGitStatus(gitLines(dir, "status", "--porcelain"))
+	}
+
+	seen := map[string]bool{}
+	for _, d := range []string{dir, root} {
+		if d == "" {
+			continue
+		}
+		for _, m := range projectMarkers {
+			if _, err := os.Stat(filepath.Join(d, m.file)); err != nil {
+				continue
+			}
+			kind := fmt.Sprintf("%s (%s)", m.kind, m.file)
+			if !seen[kind] {
+				seen[kind] = true
+				p.Types = append(p.Types, kind)
+			}
+		}
+	}
+
+	return p
+}
+
+// summarizeGitStatus condenses `git status --porcelain` output
+func summarizeGitStatus(lines []string) string {
+	var changed, untracked int
+	for _, line := range lines {
+		if strings.HasPrefix(line, "??") {
+			untracked++
+		} else if line != "" {
+			changed++
+		}
+	}
+	if changed == 0 && untracked == 0 {
+		return "clean"
+	}
+
+	var parts []string
+	if changed > 0 {
+		parts = append(parts, fmt.Sprintf("%d changed", changed))
+	}
+	if untracked > 0 {
+		parts = append(parts, fmt.Sprintf("%d untracked", untracked))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// contextLines returns the project details worth telling the model
+func (p Project) contextLines() []string {
+	var lines []string
+	if p.Dir != "" {
+		lines = append(lines, "Working directory: "+p.Dir)
+	}
+	if p.Branch != "" {
+		lines = append(lines, fmt.Sprintf("Git branch: %s (%s)", p.Branch, p.Status))
+	}
+	if len(p.Types) > 0 {
+		lines = append(lines, "Project type: "+strings.Join(p.Types, ", "))
+	}
+	return lines
+}
+
+// git runs a git command in dir and returns its trimmed output, or "" if it
+// failed
+func git(dir string, args ...string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func gitLines(dir string, args ...string) []string {
+	out := git(dir, args...)
+	if out == "" {
+		return nil
+	}
+	return strings.Split(out, "\n")
+}
+
+// ListDir returns an ls-style listing of dir with sizes and types, trimmed to
+// at most max entries
+func ListDir(dir string, max int) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i, e := range entries {
+		if i == max {
+			fmt.Fprintf(&b, "... and %d more\n", len(entries)-max)
+			break
+		}
+
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		switch {
+		case e.IsDir():
+			fmt.Fprintf(&b, "%8s  %s/\n", "-", e.Name())
+		case info.Mode()&os.ModeSymlink != 0:
+			target, _ := os.Readlink(filepath.Join(dir, e.Name()))
+			fmt.Fprintf(&b, "%8s  %s -> %s\n", "-", e.Name(), target)
+		default:
+			fmt.Fprintf(&b, "%8s  %s\n", humanSize(info.Size()), e.Name())
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// humanSize formats n bytes like ls -h
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(n)/float64(div), "KMGTPE"[exp])
+}
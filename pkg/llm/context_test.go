@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectProjectTypes(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"go.mod", "Makefile"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+
+	p := DetectProject(dir)
+	if p.Dir != filepath.Base(dir) {
+		t.Errorf("Dir = %q", p.Dir)
+	}
+	if want := []string{"Go (go.mod)", "Make (Makefile)"}; !reflect.DeepEqual(p.Types, want) {
+		t.Errorf("Types = %q, want %q", p.Types, want)
+	}
+}
+
+func TestDetectProjectGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	root := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q", "-b", "trunk")
+	os.WriteFile(filepath.Join(root, "package.json"), []byte("{}"), 0644)
+	sub := filepath.Join(root, "src")
+	os.Mkdir(sub, 0755)
+
+	// Markers at the repository root are found from subdirectories
+	p := DetectProject(sub)
+	if p.Dir != "src" || p.Branch != "trunk" || p.Status != "1 untracked" {
+		t.Errorf("got %+v", p)
+	}
+	if want := []string{"Node.js (package.json)"}; !reflect.DeepEqual(p.Types, want) {
+		t.Errorf("Types = %q, want %q", p.Types, want)
+	}
+}
+
+func TestSummarizeGitStatus(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  string
+	}{
+		{nil, "clean"},
+		{[]string{" M a.go", "A  b.go", "?? c.go"}, "2 changed, 1 untracked"},
+		{[]string{"?? x"}, "1 untracked"},
+	}
+	for _, tt := range tests {
+		if got := summarizeGitStatus(tt.lines); got != tt.want {
+			t.Errorf("summarizeGitStatus(%q) = %q, want %q", tt.lines, got, tt.want)
+		}
+	}
+}
+
+func TestListDir(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "app.log"), make([]byte, 2048), 0644)
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("hi"), 0644)
+	os.Mkdir(filepath.Join(dir, "src"), 0755)
+	os.Symlink("b.txt", filepath.Join(dir, "link"))
+
+	got, err := ListDir(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "    2.0K  app.log\n       2  b.txt\n       -  link -> b.txt\n       -  src/"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	got, _ = ListDir(dir, 2)
+	if !strings.HasSuffix(got, "... and 2 more") {
+		t.Errorf("listing wasn't trimmed:\n%s", got)
+	}
+}
+
+func TestHumanSize(t *testing.T) {
+	for n, want := range map[int64]string{0: "0", 1023: "1023", 1536: "1.5K", 5 << 20: "5.0M", 3 << 30: "3.0G"} {
+		if got := humanSize(n); got != want {
+			t.Errorf("humanSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package llm
+
+import "regexp"
+
+// dangerRule flags commands matching re as destructive for reason
+type dangerRule struct {
+	re     *regexp.Regexp
+	reason string
+}
+
+// dangerRules recognize commands that destroy data or disrupt systems in
+// ways that are hard to undo
+var dangerRules = []dangerRule{
+	// Files and disks
+	{regexp.MustCompile(`\brm\s+(-[a-zA-Z]*[rR][a-zA-Z]*|--recursive)\b`), "deletes files recursively"},
+	{regexp.MustCompile(`\b(dd|shred|wipefs)\s`), "overwrites data on disk"},
+	{regexp.MustCompile(`\bmkfs(\.\w+)?\s`), "formats a filesystem"},
+	{regexp.MustCompile(`\b(fdisk|parted|sgdisk)\s`), "changes disk partitions"},
+	{regexp.MustCompile(`>\s*/dev/(sd|nvme|hd|vd|disk)`), "writes to a raw disk"},
+	{regexp.MustCompile(`\bchmod\s+(-R\s+)?[0-7]*777\s+/`), "makes system files writable by everyone"},
+	{regexp.MustCompile(`\bchown\s+-R\s+\S+\s+/(\s|$)`), "changes the owner of every file"},
+	{regexp.MustCompile(`:\(\)\s*\{\s*:\|:&\s*\};:`), "is a fork bomb"},
+
+	// Version control
+	{regexp.MustCompile(`\bgit\s+push\s+.*(--force\b|-f\b)`), "overwrites remote history"},
+	{regexp.MustCompile(`\bgit\s+reset\s+--hard\b`), "discards uncommitted changes"},
+	{regexp.MustCompile(`\bgit\s+clean\s+-[a-zA-Z]*f`), "deletes untracked files"},
+
+	// Databases
+	{regexp.MustCompile(`(?i)\b(DROP\s+(TABLE|DATABASE|SCHEMA)|TRUNCATE\s+TABLE)\b`), "deletes database objects"},
+	{regexp.MustCompile(`(?i)\bDELETE\s+FROM\s+\w+\s*(;|$)`), "deletes every row of a table"},
+
+	// Kubernetes
+	{regexp.MustCompile(`\bkubectl\s+(.*\s)?delete\s`), "deletes Kubernetes resources"},
+	{regexp.MustCompile(`\bkubectl\s+(.*\s)?drain\s`), "evicts every pod from a node"},
+	{regexp.MustCompile(`\bkubectl\s+(.*\s)?(cordon|taint)\s`), "stops pods being scheduled on a node"},
+	{regexp.MustCompile(`\bkubectl\s+(.*\s)?scale\s.*--replicas[= ]0\b`), "scales a workload to zero"},
+	{regexp.MustCompile(`\bkubectl\s+(.*\s)?(apply|replace)\s.*(--prune|--force)\b`), "may delete or recreate resources"},
+	{regexp.MustCompile(`\bkubectl\s+(.*\s)?rollout\s+(restart|undo)\s`), "restarts running pods"},
+	{regexp.MustCompile(`\bhelm\s+(uninstall|delete|rollback)\s`), "removes or rolls back a release"},
+
+	// Cloud providers
+	{regexp.MustCompile(`\baws\s+(.*\s)?[a-z0-9-]+\s+(delete|terminate|deregister)-[a-z-]+`), "deletes AWS resources"},
+	{regexp.MustCompile(`\baws\s+(.*\s)?s3\s+(rm\s.*--recursive|rb\s.*--force)\b`), "deletes everything in an S3 bucket"},
+	{regexp.MustCompile(`\bgcloud\s+(.*\s)?delete\b`), "deletes Google Cloud resources"},
+	{regexp.MustCompile(`\b(gsutil|gcloud\s+storage)\s+(.*\s)?(rm|rb)\s.*(-r|--recursive)\b`), "deletes Cloud Storage objects recursively"},
+	{regexp.MustCompile(`\baz\s+(.*\s)?(delete|purge)\b`), "deletes Azure resources"},
+}
+
+// Dangers returns the reasons command looks destructive, if any
+func Dangers(command string) []string {
+	var reasons []string
+	for _, r := range dangerRules {
+		if r.re.MatchString(command) {
+			reasons = append(reasons, r.reason)
+		}
+	}
+	return reasons
+}
@@ -0,0 +1,43 @@
+package llm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDangers(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"ls -la", nil},
+		{"rm file.txt", nil},
+		{"rm -rf build/", []string{"deletes files recursively"}},
+		{"sudo dd if=ubuntu.iso of=/dev/sdb bs=4M", []string{"overwrites data on disk"}},
+		{"git push --force origin main", []string{"overwrites remote history"}},
+		{"git push origin feature-fix", nil},
+		{"psql -c 'DROP TABLE users'", []string{"deletes database objects"}},
+		{"kubectl get pods -n prod", nil},
+		{"kubectl -n prod delete pod web-0", []string{"deletes Kubernetes resources"}},
+		{"kubectl drain node-1 --ignore-daemonsets", []string{"evicts every pod from a node"}},
+		{"kubectl scale deploy/web --replicas=0", []string{"scales a workload to zero"}},
+		{"kubectl scale deploy/web --replicas=3", nil},
+		{"kubectl apply -f . --prune -l app=web", []string{"may delete or recreate resources"}},
+		{"helm uninstall web", []string{"removes or rolls back a release"}},
+		{"aws ec2 describe-instances --filters Name=tag:env,Values=prod", nil},
+		{"aws ec2 terminate-instances --instance-ids i-0abc", []string{"deletes AWS resources"}},
+		{"aws --profile prod s3api delete-bucket --bucket logs", []string{"deletes AWS resources"}},
+		{"aws s3 rm s3://logs/ --recursive", []string{"deletes everything in an S3 bucket"}},
+		{"aws s3 rm s3://logs/today.txt", nil},
+		{"gcloud compute instances list", nil},
+		{"gcloud compute instances delete web-1 --zone us-central1-a", []string{"deletes Google Cloud resources"}},
+		{"gsutil -m rm -r gs://logs", []string{"deletes files recursively", "deletes Cloud Storage objects recursively"}},
+		{"az vm list -o table", nil},
+		{"az group delete --name staging-rg", []string{"deletes Azure resources"}},
+	}
+	for _, tt := range tests {
+		if got := Dangers(tt.command); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Dangers(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	openaiEmbedURL = "https://api.openai.com/v1/embeddings"
+	ollamaEmbedURL = "http://localhost:11434/api/embed"
+
+	openaiEmbeddingModel = "text-embedding-3-small"
+	ollamaEmbeddingModel = "nomic-embed-text"
+)
+
+// OpenAI embeddings structs
+type OpenAIEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type OpenAIEmbedResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Error *APIError `json:"error,omitempty"`
+}
+
+// Ollama embeddings structs
+type OllamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type OllamaEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+	Error      *APIError   `json:"error,omitempty"`
+}
+
+// DefaultEmbeddingModel returns the embedding model used for p, or "" for
+// Claude, which has no embeddings API
+func DefaultEmbeddingModel(p Provider) string {
+	switch p {
+	case OpenAI:
+		return openaiEmbeddingModel
+	case Ollama:
+		return ollamaEmbeddingModel
+	}
+	return ""
+}
+
+// Embed returns an embedding vector for each of texts, in order. The
+// client's model must be an embedding model, such as the one
+// DefaultEmbeddingModel returns.
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var vectors [][]float32
+	switch c.Provider {
+	case OpenAI:
+		var resp OpenAIEmbedResponse
+		err := c.postJSON(ctx, c.embedEndpoint("/chat/completions", "/embeddings", openaiEmbedURL), map[string]string{
+			"Authorization": "Bearer " + c.APIKey,
+		}, OpenAIEmbedRequest{Model: c.Model, Input: texts}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		vectors = make([][]float32, len(resp.Data))
+		for _, d := range resp.Data {
+			if d.Index < 0 || d.Index >= len(vectors) {
+				return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+			}
+			vectors[d.Index] = d.Embedding
+		}
+	case Ollama:
+		var resp OllamaEmbedResponse
+		err := c.postJSON(ctx, c.embedEndpoint("/api/generate", "/api/embed", ollamaEmbedURL), nil,
+			OllamaEmbedRequest{Model: c.Model, Input: texts}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		vectors = resp.Embeddings
+	default:
+		return nil, fmt.Errorf("%v has no embeddings API; use an OpenAI or Ollama embedding model", c.Provider)
+	}
+
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d texts", len(vectors), len(texts))
+	}
+	return vectors, nil
+}
+
+// embedEndpoint returns the embeddings URL. A custom endpoint for chat is
+// assumed to serve embeddings alongside, replacing chatPath with embedPath.
+func (c *Client) embedEndpoint(chatPath, embedPath, defaultURL string) string {
+	if c.Endpoint == "" {
+		return defaultURL
+	}
+	if base, ok := strings.CutSuffix(c.Endpoint, chatPath); ok {
+		return base + embedPath
+	}
+	return c.Endpoint
+}
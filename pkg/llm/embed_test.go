@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEmbed(t *testing.T) {
+	tests := []struct {
+		provider Provider
+		path     string
+		response string
+	}{
+		{OpenAI, "/v1/embeddings", `{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`},
+		{Ollama, "/api/embed", `{"embeddings":[[1,0],[0,1]]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider.String(), func(t *testing.T) {
+			srv, req, body := mockProvider(t, http.StatusOK, tt.response)
+			chatPath := "/v1/chat/completions"
+			if tt.provider == Ollama {
+				chatPath = "/api/generate"
+			}
+			c := &Client{Provider: tt.provider, Model: "embedder", Endpoint: srv.URL + chatPath}
+			got, err := c.Embed(context.Background(), []string{"first", "second"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 2 || !slices.Equal(got[0], []float32{1, 0}) || !slices.Equal(got[1], []float32{0, 1}) {
+				t.Errorf("got %v", got)
+			}
+			if req.URL.Path != tt.path {
+				t.Errorf("path = %s, want %s", req.URL.Path, tt.path)
+			}
+			if !strings.Contains(string(*body), `"input":["first","second"]`) {
+				t.Errorf("unexpected request %s", *body)
+			}
+		})
+	}
+}
+
+func TestEmbedErrors(t *testing.T) {
+	c := &Client{Provider: Claude}
+	if _, err := c.Embed(context.Background(), []string{"x"}); err == nil {
+		t.Error("expected an error for Claude")
+	}
+
+	srv, _, _ := mockProvider(t, http.StatusOK, `{"embeddings":[[1,0]]}`)
+	c = &Client{Provider: Ollama, Model: "embedder", Endpoint: srv.URL}
+	if _, err := c.Embed(context.Background(), []string{"a", "b"}); err == nil {
+		t.Error("expected an error when embeddings are missing")
+	}
+}
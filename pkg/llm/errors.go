@@ -0,0 +1,104 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// StatusError is returned when a provider answers with an HTTP error status
+type StatusError struct {
+	Provider   Provider
+	StatusCode int
+	Body       string
+
+	// RequestID is the provider's reference for the request, from the
+	// request-id or x-request-id header, to quote to its support
+	RequestID string
+}
+
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, strings.TrimSpace(e.Body))
+	if e.RequestID != "" {
+		msg += " (request ID " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// NetworkError is returned when a provider can't be reached, or the
+// connection fails before the response is read
+type NetworkError struct {
+	Provider Provider
+	Err      error
+}
+
+func (e *NetworkError) Error() string {
+	return "failed to make request: " + e.Err.Error()
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+func (e *APIError) Error() string {
+	return "API error: " + e.Message
+}
+
+// ErrorType says what kind of failure a request had
+type ErrorType string
+
+const (
+	ErrorAuth      ErrorType = "auth"
+	ErrorRateLimit ErrorType = "rate_limit"
+	ErrorNetwork   ErrorType = "network"
+	ErrorProvider  ErrorType = "provider"
+)
+
+// ClassifyError returns the kind of failure err is, or "" if it isn't a
+// failed request. A missing API key counts as an auth failure.
+func ClassifyError(err error) ErrorType {
+	var status *StatusError
+	var apiErr *APIError
+	var netErr *NetworkError
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrNoProvider):
+		return ErrorAuth
+	case errors.As(err, &status):
+		switch status.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return ErrorAuth
+		case http.StatusTooManyRequests:
+			return ErrorRateLimit
+		}
+		return ErrorProvider
+	case errors.As(err, &apiErr):
+		switch apiErr.Type {
+		case "authentication_error", "permission_error":
+			return ErrorAuth
+		case "rate_limit_error":
+			return ErrorRateLimit
+		}
+		return ErrorProvider
+	case errors.As(err, &netErr):
+		return ErrorNetwork
+	}
+	return ""
+}
+
+// Retryable reports whether a request that failed with err may succeed if
+// tried again later: when rate limited, when the provider couldn't be
+// reached, or when it had a server error or was overloaded
+func Retryable(err error) bool {
+	var status *StatusError
+	var apiErr *APIError
+	switch {
+	case errors.As(err, &status):
+		return status.StatusCode == http.StatusTooManyRequests || status.StatusCode >= 500
+	case errors.As(err, &apiErr):
+		return apiErr.Type == "rate_limit_error" || apiErr.Type == "overloaded_error" || apiErr.Type == "api_error"
+	}
+	return ClassifyError(err) == ErrorNetwork
+}
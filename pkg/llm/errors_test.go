@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   ErrorType
+	}{
+		{"bad key", http.StatusUnauthorized, `{"error":{"message":"invalid x-api-key"}}`, ErrorAuth},
+		{"no access", http.StatusForbidden, `{}`, ErrorAuth},
+		{"rate limited", http.StatusTooManyRequests, `{}`, ErrorRateLimit},
+		{"overloaded", 529, `{}`, ErrorProvider},
+		{"server error", http.StatusInternalServerError, `{}`, ErrorProvider},
+		{"error body", http.StatusOK, `{"error":{"type":"rate_limit_error","message":"slow down"}}`, ErrorRateLimit},
+		{"auth error body", http.StatusOK, `{"error":{"type":"authentication_error","message":"bad key"}}`, ErrorAuth},
+		{"other error body", http.StatusOK, `{"error":{"type":"x","message":"overloaded"}}`, ErrorProvider},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+			client := &Client{Provider: Claude, APIKey: "k", Endpoint: server.URL}
+			_, err := client.Query(context.Background(), "q")
+			if got := ClassifyError(err); got != tt.want {
+				t.Errorf("ClassifyError(%v) = %q, want %q", err, got, tt.want)
+			}
+		})
+	}
+
+	// Nothing listens on a closed server
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	client := &Client{Provider: OpenAI, APIKey: "k", Endpoint: server.URL}
+	if _, err := client.Query(context.Background(), "q"); ClassifyError(err) != ErrorNetwork {
+		t.Errorf("ClassifyError(%v) = %q, want network", err, ClassifyError(err))
+	}
+
+	if got := ClassifyError(fmt.Errorf("setup: %w", ErrNoProvider)); got != ErrorAuth {
+		t.Errorf("missing key classified as %q, want auth", got)
+	}
+	if got := ClassifyError(errors.New("failed to read image")); got != "" {
+		t.Errorf("local error classified as %q", got)
+	}
+}
+
+func TestStatusErrorRequestID(t *testing.T) {
+	for _, header := range []string{"request-id", "x-request-id"} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(header, "req_011CKv")
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintln(w, `{"error":{"message":"internal"}}`)
+		}))
+		client := &Client{Provider: OpenAI, APIKey: "k", Endpoint: server.URL}
+		_, err := client.Query(context.Background(), "q")
+		server.Close()
+
+		var status *StatusError
+		if !errors.As(err, &status) || status.RequestID != "req_011CKv" {
+			t.Fatalf("%s: err = %#v, want a StatusError with the request ID", header, err)
+		}
+		want := `API request failed with status 500: {"error":{"message":"internal"}} (request ID req_011CKv)`
+		if err.Error() != want {
+			t.Errorf("%s: message = %q, want %q", header, err.Error(), want)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&StatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{&StatusError{StatusCode: 529}, true},
+		{&StatusError{StatusCode: http.StatusBadGateway}, true},
+		{&StatusError{StatusCode: http.StatusUnauthorized}, false},
+		{&StatusError{StatusCode: http.StatusBadRequest}, false},
+		{&APIError{Type: "overloaded_error"}, true},
+		{&APIError{Type: "invalid_request_error"}, false},
+		{&NetworkError{Err: errors.New("connection refused")}, true},
+		{ErrNoProvider, false},
+		{errors.New("failed to read image"), false},
+	}
+	for _, tt := range tests {
+		if got := Retryable(tt.err); got != tt.want {
+			t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package llm
+
+import "fmt"
+
+// CommandBreakdown explains a shell command part by part
+type CommandBreakdown struct {
+	Summary string        `json:"summary"`
+	Parts   []CommandPart `json:"parts"`
+}
+
+// CommandPart is one piece of a command, e.g. a flag and its argument
+type CommandPart struct {
+	Text        string `json:"text"`
+	Explanation string `json:"explanation"`
+}
+
+// ParseBreakdown parses an ExplainCommandMode response
+func ParseBreakdown(response string) (*CommandBreakdown, error) {
+	var b CommandBreakdown
+	if err := parseJSON(response, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse explanation: %v", err)
+	}
+	if len(b.Parts) == 0 {
+		return nil, fmt.Errorf("failed to parse explanation: no parts")
+	}
+	return &b, nil
+}
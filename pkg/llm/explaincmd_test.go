@@ -0,0 +1,19 @@
+package llm
+
+import "testing"
+
+func TestParseBreakdown(t *testing.T) {
+	b, err := ParseBreakdown(`{"summary": "Extracts foo.tgz into /tmp.", "parts": [
+		{"text": "tar", "explanation": "Archive tool."},
+		{"text": "-C /tmp", "explanation": "Extract into /tmp."}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Summary != "Extracts foo.tgz into /tmp." || len(b.Parts) != 2 || b.Parts[1].Text != "-C /tmp" {
+		t.Errorf("got %+v", b)
+	}
+
+	if _, err := ParseBreakdown(`{"summary": "x", "parts": []}`); err == nil {
+		t.Error("a breakdown without parts should fail")
+	}
+}
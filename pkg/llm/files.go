@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"regexp"
+	"strings"
+)
+
+// File is one file of a code answer that has several
+type File struct {
+	// Path is relative to the directory the files are written under, with
+	// forward slashes
+	Path    string
+	Content string
+}
+
+// fileHeaderRe matches the line that starts each file of a multi-file
+// answer, e.g. "### FILE: cmd/server/main.go". Only the exact form the
+// prompt asks for counts, so that comments such as "# FILE: config.py"
+// don't.
+var fileHeaderRe = regexp.MustCompile("^### FILE:\\s*`?([^`]+?)`?\\s*$")
+
+// ParseFiles splits a code answer with "### FILE: path" lines outside code
+// fences into its files, removing any code fence around each one. It
+// returns nil for an answer without them.
+func ParseFiles(response string) []File {
+	var files []File
+	var content []string
+	flush := func() {
+		if len(files) > 0 {
+			files[len(files)-1].Content = unfence(strings.Join(content, "\n"))
+		}
+		content = nil
+	}
+	fenced := false
+	for _, line := range strings.Split(response, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			fenced = !fenced
+		}
+		if m := fileHeaderRe.FindStringSubmatch(trimmed); m != nil && !fenced {
+			flush()
+			files = append(files, File{Path: m[1]})
+			continue
+		}
+		content = append(content, line)
+	}
+	flush()
+	return files
+}
+
+// unfence trims text and removes a code fence around it
+func unfence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") || !strings.HasSuffix(text, "```") {
+		return text
+	}
+	start := strings.Index(text, "\n")
+	end := strings.LastIndex(text, "\n")
+	if start < 0 || end <= start {
+		return text
+	}
+	return strings.TrimSpace(text[start+1 : end])
+}
@@ -0,0 +1,34 @@
+package llm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFiles(t *testing.T) {
+	tests := []struct {
+		response string
+		want     []File
+	}{
+		{"package main\n\nfunc main() {}", nil},
+		{
+			"Here's the service:\n### FILE: go.mod\nmodule example.com/svc\n\ngo 1.22\n\n### FILE: `cmd/svc/main.go`\n```go\npackage main\n\nfunc main() {}\n```\n",
+			[]File{
+				{Path: "go.mod", Content: "module example.com/svc\n\ngo 1.22"},
+				{Path: "cmd/svc/main.go", Content: "package main\n\nfunc main() {}"},
+			},
+		},
+		{"### FILE: README.md\n", []File{{Path: "README.md"}}},
+		{"import os\n\n# FILE: config.py\nprint(os.getcwd())", nil},
+		{"## FILE: README.md\n", nil},
+		{
+			"### FILE: gen.sh\n```sh\ncat > a.txt <<EOF\n### FILE: a.txt\nEOF\n```\n",
+			[]File{{Path: "gen.sh", Content: "cat > a.txt <<EOF\n### FILE: a.txt\nEOF"}},
+		},
+	}
+	for _, tt := range tests {
+		if got := ParseFiles(tt.response); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseFiles(%q) = %+v, want %+v", tt.response, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Fix diagnoses a failed command
+type Fix struct {
+	Cause string `json:"cause"`
+
+	// Command is a corrected command to run, if one would help
+	Command string `json:"command"`
+
+	// Steps describes anything else needed, such as a change to code
+	Steps string `json:"steps"`
+}
+
+// ParseFix parses a FixMode response
+func ParseFix(response string) (*Fix, error) {
+	var f Fix
+	if err := parseJSON(response, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse fix: %v", err)
+	}
+	if f.Cause == "" && f.Command == "" {
+		return nil, errors.New("failed to parse fix: no cause or command")
+	}
+	return &f, nil
+}
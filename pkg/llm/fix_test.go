@@ -0,0 +1,19 @@
+package llm
+
+import "testing"
+
+func TestParseFix(t *testing.T) {
+	f, err := ParseFix("```json\n" + `{"cause": "The make target is misspelled.", "command": "make test", "steps": ""}` + "\n```")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Cause != "The make target is misspelled." || f.Command != "make test" {
+		t.Errorf("got %+v", f)
+	}
+
+	for _, bad := range []string{"Try make test", `{"steps": "reinstall"}`} {
+		if _, err := ParseFix(bad); err == nil {
+			t.Errorf("ParseFix(%q) should fail", bad)
+		}
+	}
+}
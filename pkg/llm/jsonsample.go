@@ -0,0 +1,134 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// SampleJSON shortens JSON input for a prompt while keeping its shape:
+// arrays keep their first maxItems elements, strings their first maxString
+// characters, and object keys stay in order. Input holding a stream of
+// values, such as JSON lines, keeps its first maxItems values. It reports
+// whether anything was cut.
+func SampleJSON(input string, maxItems, maxString int) (string, bool, error) {
+	s := &sampler{dec: json.NewDecoder(strings.NewReader(input)), maxItems: maxItems, maxString: maxString}
+	s.dec.UseNumber()
+
+	var out bytes.Buffer
+	for n := 0; ; n++ {
+		if !s.dec.More() {
+			break
+		}
+		if n == maxItems {
+			s.cut = true
+			break
+		}
+		var compact strings.Builder
+		if err := s.value(&compact); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", false, fmt.Errorf("input isn't JSON: %v", err)
+		}
+		if n > 0 {
+			out.WriteByte('\n')
+		}
+		if err := json.Indent(&out, []byte(compact.String()), "", "  "); err != nil {
+			return "", false, err
+		}
+	}
+	if out.Len() == 0 {
+		return "", false, errors.New("input isn't JSON: it's empty")
+	}
+	return out.String(), s.cut, nil
+}
+
+type sampler struct {
+	dec                 *json.Decoder
+	maxItems, maxString int
+	cut                 bool
+}
+
+// value copies the next value from the decoder to b, shortened
+func (s *sampler) value(b *strings.Builder) error {
+	tok, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			return s.object(b)
+		}
+		return s.array(b)
+	case string:
+		if utf8.RuneCountInString(t) > s.maxString {
+			t = string([]rune(t)[:s.maxString]) + "..."
+			s.cut = true
+		}
+		return writeJSON(b, t)
+	default:
+		return writeJSON(b, t)
+	}
+}
+
+func (s *sampler) object(b *strings.Builder) error {
+	b.WriteByte('{')
+	for i := 0; s.dec.More(); i++ {
+		key, err := s.dec.Token()
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if err := writeJSON(b, key); err != nil {
+			return err
+		}
+		b.WriteByte(':')
+		if err := s.value(b); err != nil {
+			return err
+		}
+	}
+	b.WriteByte('}')
+	_, err := s.dec.Token()
+	return err
+}
+
+func (s *sampler) array(b *strings.Builder) error {
+	b.WriteByte('[')
+	for i := 0; s.dec.More(); i++ {
+		if i >= s.maxItems {
+			var skip json.RawMessage
+			if err := s.dec.Decode(&skip); err != nil {
+				return err
+			}
+			s.cut = true
+			continue
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if err := s.value(b); err != nil {
+			return err
+		}
+	}
+	b.WriteByte(']')
+	_, err := s.dec.Token()
+	return err
+}
+
+func writeJSON(b *strings.Builder, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	b.Write(data)
+	return nil
+}
@@ -0,0 +1,36 @@
+package llm
+
+import "testing"
+
+func TestSampleJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		cut   bool
+	}{
+		{"scalar", `42`, `42`, false},
+		{"keeps key order", `{"z": 1, "a": [true, null]}`, "{\n  \"z\": 1,\n  \"a\": [\n    true,\n    null\n  ]\n}", false},
+		{"long array", `[1, 2, 3, {"x": [4]}]`, "[\n  1,\n  2\n]", true},
+		{"long string", `{"s": "abcdefgh"}`, "{\n  \"s\": \"abcd...\"\n}", true},
+		{"json lines", "{\"a\":1}\n{\"a\":2}\n{\"a\":3}\n", "{\n  \"a\": 1\n}\n{\n  \"a\": 2\n}", true},
+		{"big numbers", `[12345678901234567890]`, "[\n  12345678901234567890\n]", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, cut, err := SampleJSON(tt.input, 2, 4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want || cut != tt.cut {
+				t.Errorf("got %q (cut %v), want %q (cut %v)", got, cut, tt.want, tt.cut)
+			}
+		})
+	}
+
+	for _, bad := range []string{"", "not json", `{"a": `, `[1, 2`} {
+		if _, _, err := SampleJSON(bad, 2, 4); err == nil {
+			t.Errorf("SampleJSON(%q) should fail", bad)
+		}
+	}
+}
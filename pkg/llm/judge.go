@@ -0,0 +1,22 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// JudgePrompt asks for the best of several candidate answers to prompt,
+// picked or merged, in the form prompt asks for
+func JudgePrompt(prompt string, candidates []string) string {
+	var b strings.Builder
+	b.WriteString("Several candidate answers were written for the request below. " +
+		"Check each one carefully for wrong flags, syntax errors and answers to the wrong question, " +
+		"then pick the most correct one, or merge them into a better one.\n\n")
+	b.WriteString("<request>\n" + prompt + "\n</request>\n")
+	for i, c := range candidates {
+		fmt.Fprintf(&b, "\n<candidate %d>\n%s\n</candidate %d>\n", i+1, c, i+1)
+	}
+	b.WriteString("\nRespond with ONLY the final answer, formatted exactly as the request's instructions say, " +
+		"without mentioning the candidates.\n")
+	return b.String()
+}
@@ -0,0 +1,20 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJudgePrompt(t *testing.T) {
+	got := JudgePrompt("User request: list files", []string{"ls -la", "ls -l"})
+	for _, want := range []string{
+		"<request>\nUser request: list files\n</request>",
+		"<candidate 1>\nls -la\n</candidate 1>",
+		"<candidate 2>\nls -l\n</candidate 2>",
+		"Respond with ONLY the final answer",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("judge prompt is missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package llm
+
+import "strings"
+
+// Language is a programming language code can be asked for in
+type Language struct {
+	// Name is how the model is told the language, e.g. "Python"
+	Name string
+
+	// ID is the language's usual short name, e.g. "python", which
+	// render.Code uses to pick highlighting
+	ID string
+
+	// Extension is the usual file extension, e.g. ".py", or "" if unknown
+	Extension string
+}
+
+// languages lists known languages with the other names they go by
+var languages = []struct {
+	Language
+	aliases []string
+}{
+	{Language{"Bash", "bash", ".sh"}, []string{"sh", "shell"}},
+	{Language{"C", "c", ".c"}, nil},
+	{Language{"C++", "cpp", ".cpp"}, []string{"c++", "cxx"}},
+	{Language{"C#", "csharp", ".cs"}, []string{"c#", "cs"}},
+	{Language{"Fish", "fish", ".fish"}, nil},
+	{Language{"Go", "go", ".go"}, []string{"golang"}},
+	{Language{"HCL", "hcl", ".tf"}, []string{"terraform", "tf"}},
+	{Language{"Java", "java", ".java"}, nil},
+	{Language{"JavaScript", "javascript", ".js"}, []string{"js", "node"}},
+	{Language{"Kotlin", "kotlin", ".kt"}, []string{"kt"}},
+	{Language{"Lua", "lua", ".lua"}, nil},
+	{Language{"Perl", "perl", ".pl"}, []string{"pl"}},
+	{Language{"PHP", "php", ".php"}, nil},
+	{Language{"PowerShell", "powershell", ".ps1"}, []string{"pwsh", "ps1"}},
+	{Language{"Python", "python", ".py"}, []string{"py", "python3"}},
+	{Language{"Ruby", "ruby", ".rb"}, []string{"rb"}},
+	{Language{"Rust", "rust", ".rs"}, []string{"rs"}},
+	{Language{"SQL", "sql", ".sql"}, nil},
+	{Language{"Swift", "swift", ".swift"}, nil},
+	{Language{"TypeScript", "typescript", ".ts"}, []string{"ts"}},
+	{Language{"Zsh", "zsh", ".zsh"}, nil},
+}
+
+// CodeLanguage returns the language called name, ignoring case. Languages
+// it doesn't know are passed on to the model as named, without an
+// extension.
+func CodeLanguage(name string) Language {
+	lower := strings.ToLower(strings.TrimSpace(name))
+	for _, l := range languages {
+		if lower == l.ID || lower == strings.ToLower(l.Name) {
+			return l.Language
+		}
+		for _, alias := range l.aliases {
+			if lower == alias {
+				return l.Language
+			}
+		}
+	}
+	return Language{Name: strings.TrimSpace(name), ID: lower}
+}
+
+// ExtensionLanguage returns the language whose files have extension ext,
+// e.g. ".py", and whether there is one
+func ExtensionLanguage(ext string) (Language, bool) {
+	ext = strings.ToLower(ext)
+	for _, l := range languages {
+		if ext != "" && ext == l.Extension {
+			return l.Language, true
+		}
+	}
+	return Language{}, false
+}
@@ -0,0 +1,37 @@
+package llm
+
+import "testing"
+
+func TestCodeLanguage(t *testing.T) {
+	tests := []struct {
+		name string
+		want Language
+	}{
+		{"python", Language{"Python", "python", ".py"}},
+		{"PY", Language{"Python", "python", ".py"}},
+		{"golang", Language{"Go", "go", ".go"}},
+		{"c++", Language{"C++", "cpp", ".cpp"}},
+		{" rs ", Language{"Rust", "rust", ".rs"}},
+		{"JavaScript", Language{"JavaScript", "javascript", ".js"}},
+		{"Haskell", Language{"Haskell", "haskell", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeLanguage(tt.name); got != tt.want {
+				t.Errorf("CodeLanguage(%q) = %+v, want %+v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtensionLanguage(t *testing.T) {
+	if l, ok := ExtensionLanguage(".PY"); !ok || l.ID != "python" {
+		t.Errorf(".PY = %+v, %v", l, ok)
+	}
+	for _, ext := range []string{"", ".txt"} {
+		if l, ok := ExtensionLanguage(ext); ok {
+			t.Errorf("%q = %+v", ext, l)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"cmp"
+	"strings"
+)
+
+// localeLanguages names the languages of common locales, by language code or
+// by language and territory where the territory changes the answer
+var localeLanguages = map[string]string{
+	"ar":    "Arabic",
+	"bg":    "Bulgarian",
+	"ca":    "Catalan",
+	"cs":    "Czech",
+	"da":    "Danish",
+	"de":    "German",
+	"el":    "Greek",
+	"en":    "English",
+	"es":    "Spanish",
+	"et":    "Estonian",
+	"fa":    "Persian",
+	"fi":    "Finnish",
+	"fr":    "French",
+	"he":    "Hebrew",
+	"hi":    "Hindi",
+	"hr":    "Croatian",
+	"hu":    "Hungarian",
+	"id":    "Indonesian",
+	"it":    "Italian",
+	"ja":    "Japanese",
+	"ko":    "Korean",
+	"lt":    "Lithuanian",
+	"lv":    "Latvian",
+	"nb":    "Norwegian",
+	"nl":    "Dutch",
+	"nn":    "Norwegian",
+	"no":    "Norwegian",
+	"pl":    "Polish",
+	"pt":    "Portuguese",
+	"pt_BR": "Brazilian Portuguese",
+	"ro":    "Romanian",
+	"ru":    "Russian",
+	"sk":    "Slovak",
+	"sl":    "Slovenian",
+	"sr":    "Serbian",
+	"sv":    "Swedish",
+	"th":    "Thai",
+	"tr":    "Turkish",
+	"uk":    "Ukrainian",
+	"vi":    "Vietnamese",
+	"zh":    "Simplified Chinese",
+	"zh_HK": "Traditional Chinese",
+	"zh_TW": "Traditional Chinese",
+}
+
+// LocaleLanguage returns the name of the language of a locale such as
+// "de_DE.UTF-8", "pt-BR" or "fr", or "" if it isn't known. The C and POSIX
+// locales have no language.
+func LocaleLanguage(locale string) string {
+	locale, _, _ = strings.Cut(locale, ".")
+	locale, _, _ = strings.Cut(locale, "@")
+	lang, territory, _ := strings.Cut(strings.ReplaceAll(locale, "-", "_"), "_")
+	lang = strings.ToLower(lang)
+	if name, ok := localeLanguages[lang+"_"+strings.ToUpper(territory)]; ok {
+		return name
+	}
+	return localeLanguages[lang]
+}
+
+// UserLanguage returns the language of the user's locale, going by LC_ALL,
+// LC_MESSAGES and LANG in the order POSIX gives them, or "" if it's English
+// or unknown
+func UserLanguage(getenv func(string) string) string {
+	locale := cmp.Or(getenv("LC_ALL"), getenv("LC_MESSAGES"), getenv("LANG"))
+	if lang := LocaleLanguage(locale); lang != "English" {
+		return lang
+	}
+	return ""
+}
+
+// OutputLanguage returns the language to answer in: name if given, as a
+// language or a locale code, and otherwise the user's locale. It returns ""
+// if answers should be in English as usual.
+func OutputLanguage(name string, getenv func(string) string) string {
+	if name == "" {
+		return UserLanguage(getenv)
+	}
+	return cmp.Or(LocaleLanguage(name), name)
+}
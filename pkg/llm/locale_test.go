@@ -0,0 +1,55 @@
+package llm
+
+import "testing"
+
+func TestLocaleLanguage(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"de_DE.UTF-8", "German"},
+		{"fr", "French"},
+		{"pt_BR.UTF-8", "Brazilian Portuguese"},
+		{"pt-BR", "Brazilian Portuguese"},
+		{"pt_PT.UTF-8", "Portuguese"},
+		{"zh_TW.UTF-8", "Traditional Chinese"},
+		{"sr_RS.UTF-8@latin", "Serbian"},
+		{"en_US.UTF-8", "English"},
+		{"C.UTF-8", ""},
+		{"POSIX", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := LocaleLanguage(tt.locale); got != tt.want {
+			t.Errorf("LocaleLanguage(%q) = %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestOutputLanguage(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+	tests := []struct {
+		name string
+		flag string
+		env  map[string]string
+		want string
+	}{
+		{"from LANG", "", map[string]string{"LANG": "es_ES.UTF-8"}, "Spanish"},
+		{"LC_ALL wins", "", map[string]string{"LC_ALL": "ja_JP.UTF-8", "LANG": "de_DE.UTF-8"}, "Japanese"},
+		{"LC_MESSAGES before LANG", "", map[string]string{"LC_MESSAGES": "it_IT.UTF-8", "LANG": "de_DE.UTF-8"}, "Italian"},
+		{"English locale", "", map[string]string{"LANG": "en_GB.UTF-8"}, ""},
+		{"no locale", "", nil, ""},
+		{"flag code", "de", map[string]string{"LANG": "fr_FR.UTF-8"}, "German"},
+		{"flag name", "Klingon", nil, "Klingon"},
+		{"flag English", "en", map[string]string{"LANG": "fr_FR.UTF-8"}, "English"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OutputLanguage(tt.flag, env(tt.env)); got != tt.want {
+				t.Errorf("OutputLanguage(%q) = %q, want %q", tt.flag, got, tt.want)
+			}
+		})
+	}
+}
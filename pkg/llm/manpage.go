@@ -0,0 +1,162 @@
+package llm
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// manSynopsisLines caps the SYNOPSIS section, which is huge for some
+// commands, e.g. git and ffmpeg
+const manSynopsisLines = 15
+
+// formattingRe matches the overstrikes and escape sequences man uses for
+// bold and underlined text
+var formattingRe = regexp.MustCompile(`.\x08|\x1b\[[0-9;]*m`)
+
+// wordRe matches words and flags in a query
+var wordRe = regexp.MustCompile(`-{0,2}[A-Za-z][A-Za-z0-9-]*`)
+
+// queryStopWords are too common in queries and man pages to say which
+// parts of a page are relevant
+var queryStopWords = []string{
+	"the", "and", "for", "with", "all", "that", "this", "from", "into", "are",
+	"how", "what", "when", "which", "show", "list", "get", "make", "use",
+	"using", "want", "need", "can", "each", "only", "than", "then", "them",
+	"there", "their", "file", "files", "directory", "directories", "dir",
+	"current", "here", "some", "any", "every", "not",
+}
+
+// manSection is a section of a man page, split into paragraphs
+type manSection struct {
+	name       string
+	paragraphs []string
+}
+
+// ManExcerpt returns the parts of the man page for command worth sending
+// with query: the NAME and SYNOPSIS sections, and the paragraphs, such as
+// option descriptions, that share the most words with query, in page order
+// and up to about maxChars in all
+func ManExcerpt(page, command, query string, maxChars int) string {
+	sections := parseManPage(formattingRe.ReplaceAllString(page, ""))
+
+	keywords := slices.DeleteFunc(queryKeywords(query), func(k string) bool {
+		return strings.HasPrefix(strings.ToLower(command), k)
+	})
+	type candidate struct {
+		section, paragraph, score int
+	}
+	var candidates []candidate
+	keep := make([][]bool, len(sections))
+	used := 0
+	for i, s := range sections {
+		keep[i] = make([]bool, len(s.paragraphs))
+		for j, p := range s.paragraphs {
+			switch s.name {
+			case "NAME", "SYNOPSIS":
+				keep[i][j] = true
+				used += len(p)
+				continue
+			}
+			if score := matchCount(p, keywords); score > 0 {
+				candidates = append(candidates, candidate{i, j, score})
+			}
+		}
+	}
+
+	// Best matches first, earlier ones breaking ties
+	slices.SortStableFunc(candidates, func(a, b candidate) int { return b.score - a.score })
+	for _, c := range candidates {
+		p := sections[c.section].paragraphs[c.paragraph]
+		if used+len(p) > maxChars {
+			continue
+		}
+		keep[c.section][c.paragraph] = true
+		used += len(p)
+	}
+
+	var b strings.Builder
+	for i, s := range sections {
+		header := false
+		for j, p := range s.paragraphs {
+			if !keep[i][j] {
+				continue
+			}
+			if !header {
+				if b.Len() > 0 {
+					b.WriteString("\n")
+				}
+				b.WriteString(s.name + "\n")
+				header = true
+			}
+			b.WriteString(p + "\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// parseManPage splits formatted man page text into sections, which start
+// at unindented lines, and paragraphs, which are separated by blank lines
+func parseManPage(text string) []manSection {
+	var sections []manSection
+	var para []string
+	flush := func() {
+		if len(para) > 0 && len(sections) > 0 {
+			s := &sections[len(sections)-1]
+			if s.name == "SYNOPSIS" && len(para) > manSynopsisLines {
+				para = append(para[:manSynopsisLines], "       ...")
+			}
+			s.paragraphs = append(s.paragraphs, strings.Join(para, "\n"))
+		}
+		para = nil
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t")
+		switch {
+		case line == "":
+			flush()
+		case line[0] != ' ' && line[0] != '\t':
+			flush()
+			sections = append(sections, manSection{name: strings.TrimSpace(line)})
+		default:
+			para = append(para, line)
+		}
+	}
+	flush()
+
+	// The first and last lines of a page are headers and footers like
+	// "FIND(1)   General Commands Manual   FIND(1)", not sections
+	return slices.DeleteFunc(sections, func(s manSection) bool { return len(s.paragraphs) == 0 })
+}
+
+// queryKeywords returns the words in query to look for in a man page.
+// Long words are cut to their first five letters so that "modified"
+// matches "modification".
+func queryKeywords(query string) []string {
+	var keywords []string
+	for _, w := range wordRe.FindAllString(strings.ToLower(query), -1) {
+		if len(w) < 3 && !strings.HasPrefix(w, "-") || slices.Contains(queryStopWords, w) {
+			continue
+		}
+		if !strings.HasPrefix(w, "-") && len(w) > 5 {
+			w = w[:5]
+		}
+		if !slices.Contains(keywords, w) {
+			keywords = append(keywords, w)
+		}
+	}
+	return keywords
+}
+
+// matchCount returns how many of keywords occur in text
+func matchCount(text string, keywords []string) int {
+	text = strings.ToLower(text)
+	n := 0
+	for _, k := range keywords {
+		if strings.Contains(text, k) {
+			n++
+		}
+	}
+	return n
+}
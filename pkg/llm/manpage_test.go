@@ -0,0 +1,59 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+const findPage = "FIND(1)                General Commands Manual                FIND(1)\n" +
+	"\n" +
+	"N\x08NA\x08AM\x08ME\x08E\n" +
+	"       find - search for files in a directory hierarchy\n" +
+	"\n" +
+	"SYNOPSIS\n" +
+	"       find [-H] [-L] [-P] [starting-point...] [expression]\n" +
+	"\n" +
+	"DESCRIPTION\n" +
+	"       This manual page documents the GNU version of find.\n" +
+	"\n" +
+	"TESTS\n" +
+	"       -mtime n\n" +
+	"              File's data was last modified less than, more than or\n" +
+	"              exactly n*24 hours ago.\n" +
+	"\n" +
+	"       -size n[cwbkMG]\n" +
+	"              File uses less than, more than or exactly n units of space.\n" +
+	"\n" +
+	"       -empty File is empty and is either a regular file or a directory.\n" +
+	"\n" +
+	"GNU findutils                  2024-01-01                         FIND(1)\n"
+
+func TestManExcerpt(t *testing.T) {
+	got := ManExcerpt(findPage, "find", "find files modified in the last week", 1000)
+	want := "NAME\n" +
+		"       find - search for files in a directory hierarchy\n" +
+		"\n" +
+		"SYNOPSIS\n" +
+		"       find [-H] [-L] [-P] [starting-point...] [expression]\n" +
+		"\n" +
+		"TESTS\n" +
+		"       -mtime n\n" +
+		"              File's data was last modified less than, more than or\n" +
+		"              exactly n*24 hours ago."
+	if got != want {
+		t.Errorf("ManExcerpt() =\n%s\nwant:\n%s", got, want)
+	}
+
+	// Better matches win when there isn't room for everything
+	got = ManExcerpt(findPage, "find", "empty files or ones using more space than 10M", 230)
+	if !strings.Contains(got, "-size") || strings.Contains(got, "-empty") {
+		t.Errorf("ManExcerpt() with a small budget =\n%s", got)
+	}
+}
+
+func TestQueryKeywords(t *testing.T) {
+	got := strings.Join(queryKeywords("Show all files modified since -newer ref in /tmp"), " ")
+	if got != "modif since -newer ref tmp" {
+		t.Errorf("queryKeywords() = %q", got)
+	}
+}
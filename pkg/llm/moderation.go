@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+const (
+	openaiModerationURL = "https://api.openai.com/v1/moderations"
+
+	// ModerationModel is OpenAI's moderation model
+	ModerationModel = "omni-moderation-latest"
+)
+
+// OpenAI moderation structs
+type OpenAIModerationRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+type OpenAIModerationResponse struct {
+	Results []struct {
+		Flagged    bool            `json:"flagged"`
+		Categories map[string]bool `json:"categories"`
+	} `json:"results"`
+	Error *APIError `json:"error,omitempty"`
+}
+
+// Moderation is the verdict of a moderation check
+type Moderation struct {
+	Flagged bool
+
+	// Categories are what the text was flagged for, e.g. "harassment"
+	Categories []string
+}
+
+// Moderate checks text with OpenAI's moderation API. The client must be an
+// OpenAI client; its model defaults to ModerationModel.
+func (c *Client) Moderate(ctx context.Context, text string) (*Moderation, error) {
+	if c.Provider != OpenAI {
+		return nil, fmt.Errorf("%v has no moderation API; use an OpenAI key", c.Provider)
+	}
+	model := c.Model
+	if model == "" {
+		model = ModerationModel
+	}
+	var resp OpenAIModerationResponse
+	err := c.postJSON(ctx, c.embedEndpoint("/chat/completions", "/moderations", openaiModerationURL), map[string]string{
+		"Authorization": "Bearer " + c.APIKey,
+	}, OpenAIModerationRequest{Model: model, Input: text}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	if len(resp.Results) == 0 {
+		return nil, errors.New("no moderation results")
+	}
+
+	m := &Moderation{}
+	for _, r := range resp.Results {
+		m.Flagged = m.Flagged || r.Flagged
+		for category, flagged := range r.Categories {
+			if flagged && !slices.Contains(m.Categories, category) {
+				m.Categories = append(m.Categories, category)
+			}
+		}
+	}
+	slices.Sort(m.Categories)
+	return m, nil
+}
@@ -0,0 +1,41 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestModerate(t *testing.T) {
+	srv, req, body := mockProvider(t, http.StatusOK,
+		`{"results":[{"flagged":true,"categories":{"violence":true,"harassment":true,"hate":false}}]}`)
+	c := &Client{Provider: OpenAI, APIKey: "key", Endpoint: srv.URL + "/v1/chat/completions"}
+	m, err := c.Moderate(context.Background(), "some text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Flagged || !slices.Equal(m.Categories, []string{"harassment", "violence"}) {
+		t.Errorf("got %+v", m)
+	}
+	if req.URL.Path != "/v1/moderations" {
+		t.Errorf("path = %s", req.URL.Path)
+	}
+	if !strings.Contains(string(*body), `"model":"omni-moderation-latest"`) || !strings.Contains(string(*body), `"input":"some text"`) {
+		t.Errorf("unexpected request %s", *body)
+	}
+}
+
+func TestModerateErrors(t *testing.T) {
+	c := &Client{Provider: Claude}
+	if _, err := c.Moderate(context.Background(), "x"); err == nil {
+		t.Error("expected an error for Claude")
+	}
+
+	srv, _, _ := mockProvider(t, http.StatusOK, `{"results":[]}`)
+	c = &Client{Provider: OpenAI, Endpoint: srv.URL}
+	if _, err := c.Moderate(context.Background(), "x"); err == nil {
+		t.Error("expected an error without results")
+	}
+}
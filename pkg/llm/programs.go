@@ -0,0 +1,179 @@
+package llm
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// commandPrefixes are words after which a command follows, such as
+// keywords and programs that run another program
+var commandPrefixes = []string{
+	"!", "{", "and", "begin", "builtin", "command", "do", "doas", "elif",
+	"else", "env", "exec", "if", "ionice", "nice", "nohup", "noglob", "not",
+	"or", "stdbuf", "sudo", "then", "time", "timeout", "until", "watch",
+	"while", "xargs",
+}
+
+// wrappers are the commandPrefixes that are programs themselves, and the
+// flags of theirs that take an argument
+var wrappers = map[string][]string{
+	"doas":    {"-u", "-C"},
+	"env":     {"-u", "-C", "-S"},
+	"ionice":  {"-c", "-n"},
+	"nice":    {"-n"},
+	"nohup":   nil,
+	"stdbuf":  {"-i", "-o", "-e"},
+	"sudo":    {"-u", "-g", "-C", "-h", "-p", "-U"},
+	"timeout": {"-s", "-k"},
+	"watch":   {"-n", "-d"},
+	"xargs":   {"-I", "-n", "-P", "-L", "-d", "-s", "-a", "-E"},
+}
+
+// shellBuiltins are commands the shell runs itself, or that every system
+// has, whose arguments aren't commands
+var shellBuiltins = []string{
+	".", ":", "[", "[[", "}", "alias", "bg", "break", "case", "cd",
+	"continue", "declare", "disown", "done", "echo", "end", "esac", "eval",
+	"exit", "export", "false", "fg", "fi", "for", "function", "hash",
+	"history", "jobs", "kill", "let", "local", "popd", "printf", "pushd",
+	"pwd", "read", "readonly", "return", "select", "set", "setopt", "shift",
+	"shopt", "source", "test", "trap", "true", "type", "typeset", "ulimit",
+	"umask", "unalias", "unset", "wait",
+}
+
+var (
+	// assignmentRe matches a variable assignment before a command
+	assignmentRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+	// redirectRe matches a redirection, and redirectOpRe one whose target
+	// is the next word
+	redirectRe   = regexp.MustCompile(`^[0-9]*[<>]`)
+	redirectOpRe = regexp.MustCompile(`^[0-9]*(?:[<>]+|>&|<&|&>)$`)
+
+	// durationRe matches timeout's duration
+	durationRe = regexp.MustCompile(`^[0-9.]+[smhd]?$`)
+
+	// programNameRe matches the names of programs looked up on the PATH
+	programNameRe = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.+-]*$`)
+)
+
+// Programs returns the programs a shell command line runs, in order and
+// without repeats: the first word of each command in it, after variable
+// assignments and wrappers such as sudo and xargs, which count too. Shell
+// builtins and paths aren't included.
+func Programs(command string) []string {
+	var programs []string
+	add := func(name string) {
+		if programNameRe.MatchString(name) && strings.ContainsFunc(name, unicode.IsLetter) && !slices.Contains(programs, name) {
+			programs = append(programs, name)
+		}
+	}
+	for _, words := range simpleCommands(command) {
+		wrapper := ""
+		for i := 0; i < len(words); i++ {
+			word := words[i]
+			switch {
+			case redirectRe.MatchString(word):
+				if redirectOpRe.MatchString(word) {
+					i++
+				}
+				continue
+			case assignmentRe.MatchString(word) && (wrapper == "" || wrapper == "env"):
+				continue
+			case wrapper != "" && strings.HasPrefix(word, "-"):
+				if slices.Contains(wrappers[wrapper], word) {
+					i++
+				}
+				continue
+			case wrapper == "timeout" && durationRe.MatchString(word):
+				continue
+			}
+			if !slices.Contains(commandPrefixes, word) {
+				if !slices.Contains(shellBuiltins, word) {
+					add(word)
+				}
+				break
+			}
+			if _, ok := wrappers[word]; ok {
+				add(word)
+				wrapper = word
+			}
+		}
+	}
+	return programs
+}
+
+// simpleCommands splits a command line into the words of each command in
+// it, breaking at operators, subshells and newlines. Quoted text stays in
+// one word, without its quotes.
+func simpleCommands(line string) [][]string {
+	var commands [][]string
+	var words []string
+	var word strings.Builder
+	inWord := false
+	endWord := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endCommand := func() {
+		endWord()
+		if len(words) > 0 {
+			commands = append(commands, words)
+			words = nil
+		}
+	}
+
+	runes := []rune(line)
+	var quote rune
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' && i+1 < len(runes) {
+				i++
+				word.WriteRune(runes[i])
+			} else {
+				word.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote, inWord = c, true
+		case c == '\\' && i+1 < len(runes):
+			i++
+			if runes[i] != '\n' {
+				word.WriteRune(runes[i])
+				inWord = true
+			}
+		case c == '#' && !inWord:
+			for i+1 < len(runes) && runes[i+1] != '\n' {
+				i++
+			}
+		case c == '&' && i > 0 && (runes[i-1] == '>' || runes[i-1] == '<'):
+			// 2>&1
+			word.WriteRune(c)
+		case c == '&' && i+1 < len(runes) && runes[i+1] == '>':
+			// &>file
+			endWord()
+			word.WriteRune(c)
+			inWord = true
+		case c == '$' && i+1 < len(runes) && runes[i+1] == '(':
+			endCommand()
+			i++
+		case strings.ContainsRune("|&;()`\n", c):
+			endCommand()
+		case unicode.IsSpace(c):
+			endWord()
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	endCommand()
+	return commands
+}
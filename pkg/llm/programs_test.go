@@ -0,0 +1,31 @@
+package llm
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPrograms(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"ls -la", []string{"ls"}},
+		{"du -sh * | sort -h | head -n 5", []string{"du", "sort", "head"}},
+		{"cd build && make -j4 2>&1 | tee log.txt", []string{"make", "tee"}},
+		{"LC_ALL=C sudo -u www apachectl graceful", []string{"sudo", "apachectl"}},
+		{"find . -name '*.go' | xargs -I{} -n 1 gofmt -l {}", []string{"find", "xargs", "gofmt"}},
+		{`grep -E "a|b; c" file.txt > out.txt`, []string{"grep"}},
+		{"echo $(date +%F) > /tmp/x; ./build.sh", []string{"date"}},
+		{"if rg -q foo; then fd bar; fi", []string{"rg", "fd"}},
+		{"timeout 5s curl -s example.com", []string{"timeout", "curl"}},
+		{"for f in *.png; do convert \"$f\" \"${f%.png}.jpg\"; done", []string{"convert"}},
+		{"# a comment\njq . data.json &>/dev/null", []string{"jq"}},
+		{"tar -czf out.tgz dir \\\n  && rsync -a out.tgz host:", []string{"tar", "rsync"}},
+	}
+	for _, tt := range tests {
+		if got := Programs(tt.command); !slices.Equal(got, tt.want) {
+			t.Errorf("Programs(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
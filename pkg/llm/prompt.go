@@ -0,0 +1,475 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Mode selects the kind of answer the model is asked for
+type Mode int
+
+const (
+	CommandMode Mode = iota
+	CodeMode
+	ExplainMode
+	CommitMode
+	PRMode
+	ReviewMode
+	ExplainCommandMode
+	FixMode
+	RegexMode
+	JQMode
+	SQLMode
+	CronMode
+	K8sMode
+	DockerMode
+	SedMode
+	AwkMode
+	TranslateMode
+	ProofreadMode
+	PatchMode
+	TLDRMode
+	PortMode
+	AgentMode
+	RecallMode
+	RepoMode
+	AliasMode
+	CommitLintMode
+	ChangelogMode
+	BugReportMode
+	AWSMode
+	GCloudMode
+	AzureMode
+	TFMode
+	SystemdMode
+)
+
+func (m Mode) String() string {
+	switch m {
+	case CodeMode:
+		return "code"
+	case ExplainMode:
+		return "explain"
+	case CommitMode:
+		return "commit"
+	case PRMode:
+		return "pr"
+	case ReviewMode:
+		return "review"
+	case ExplainCommandMode:
+		return "explain-cmd"
+	case FixMode:
+		return "fix"
+	case RegexMode:
+		return "regex"
+	case JQMode:
+		return "jq"
+	case SQLMode:
+		return "sql"
+	case CronMode:
+		return "cron"
+	case K8sMode:
+		return "k8s"
+	case DockerMode:
+		return "docker"
+	case SedMode:
+		return "sed"
+	case AwkMode:
+		return "awk"
+	case TranslateMode:
+		return "translate"
+	case ProofreadMode:
+		return "proofread"
+	case PatchMode:
+		return "patch"
+	case TLDRMode:
+		return "tldr"
+	case PortMode:
+		return "port-to"
+	case AgentMode:
+		return "agent"
+	case RecallMode:
+		return "recall"
+	case RepoMode:
+		return "repo"
+	case AliasMode:
+		return "aliases"
+	case CommitLintMode:
+		return "lint-commit"
+	case ChangelogMode:
+		return "changelog"
+	case BugReportMode:
+		return "bugreport"
+	case AWSMode:
+		return "aws"
+	case GCloudMode:
+		return "gcloud"
+	case AzureMode:
+		return "az"
+	case TFMode:
+		return "tf"
+	case SystemdMode:
+		return "systemd"
+	}
+	return "command"
+}
+
+// ParseMode returns the mode whose String is name
+func ParseMode(name string) (Mode, error) {
+	for m := CommandMode; m <= SystemdMode; m++ {
+		if m.String() == name {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown mode %q", name)
+}
+
+// Markdown reports whether answers in this mode should be rendered as
+// markdown rather than printed verbatim
+func (m Mode) Markdown() bool {
+	return modePrompts[m].markdown
+}
+
+// modePrompt is the mode-specific part of a prompt. The intro is formatted
+// with the OS and shell.
+type modePrompt struct {
+	intro        string
+	instructions string
+	markdown     bool
+
+	// text modes work on prose, so the intro isn't given the OS and shell
+	// and only the notes from the context are included
+	text bool
+}
+
+var modePrompts = map[Mode]modePrompt{
+	CommandMode: {
+		intro: "You are a command-line assistant. The user is on %s using %s shell and needs a command suggestion.",
+		instructions: `Respond with ONLY the command(s) that would accomplish this task. Do not include explanations, markdown formatting, or extra text. If multiple commands are needed, put each on a separate line.
+
+Examples:
+- For "search for foo in directory" → "grep -R foo ."
+- For "list files by size" → "ls -laSh"
+- For "find large files" → "find . -type f -size +100M"` + alternativesInstructions + questionInstructions,
+		markdown: true,
+	},
+	CodeMode: {
+		intro: "You are a code-writing assistant. The user is on %s using %s shell and needs a code snippet.",
+		instructions: `Respond with ONLY the code that would accomplish this task. Do not include explanations, code comments, markdown formatting, or extra text. Write the most concise code possible, and prefer use of standard libraries to third parties.
+
+If the request needs several files, such as a small project, give each one as a line of the form "### FILE: <path relative to the project root>" followed by the file's complete contents, and nothing else.
+`,
+	},
+	ExplainMode: {
+		intro: "You are a programming expert. The user is on %s using %s shell and needs a brief explanation of a CLI command or a programming library or concept.",
+		instructions: `Respond with ONLY a very brief, concise description of the concept or solution. The answer should not exceed 2 paragraphs.
+`,
+		markdown: true,
+	},
+	CommitMode: {
+		intro: "You are an experienced software engineer writing a git commit message. The user is on %s using %s shell.",
+		instructions: `Write a commit message for the staged changes in the Conventional Commits style. The subject line has the form "type(scope): summary", where type is one of feat, fix, docs, style, refactor, perf, test, build, ci or chore and the scope is optional. Write the summary in the imperative mood and keep the whole line under 72 characters. If the change needs explaining, add a blank line and a short body, wrapped at 72 characters, saying what changed and why.
+
+Respond with ONLY the commit message. Do not include markdown formatting, code fences, or extra text.
+`,
+	},
+	PRMode: {
+		intro: "You are an experienced software engineer opening a pull request. The user is on %s using %s shell.",
+		instructions: `Write a pull request title and description for the commits and diff above. Put the title, under 72 characters and without markdown, on the first line. After a blank line, write the description in GitHub-flavored markdown: a short summary of what the change does and why, then a bulleted list of the notable changes, then anything reviewers should pay attention to or test. Don't describe every file, and don't invent motivation that isn't evident from the commits.
+
+Respond with ONLY the title and description. Do not wrap them in code fences or add extra text.
+`,
+	},
+	ReviewMode: {
+		intro: "You are a senior software engineer reviewing a change. The user is on %s using %s shell.",
+		instructions: `Review the diff above. Look for bugs first (logic errors, unhandled errors, edge cases, races, security problems), then style issues (naming, duplication, unclear code), then note anything you would ask the author about. Refer to lines by their number in the new version of the file. A comment may suggest a fix as a unified diff in a diff code block. Only report real problems; an empty list is fine.
+
+Respond with ONLY a JSON object of this form, without code fences or extra text:
+{"summary": "one or two sentences on the change and its overall quality",
+ "bugs": [{"file": "path", "line": 12, "comment": "what is wrong and how to fix it"}],
+ "style": [{"file": "path", "line": 40, "comment": "..."}],
+ "questions": ["..."]}
+`,
+	},
+	ExplainCommandMode: {
+		intro: "You are a command-line expert. The user is on %s using %s shell and wants to understand a command.",
+		instructions: `Break the command down into its parts, in order: each program, subcommand, flag (with its argument, if it takes one), positional argument, pipe, redirection and operator. Split combined short flags like -xzvf into one part per flag. Explain each part in one short sentence, specific to how it is used here. If the command could be destructive, say so in the summary.
+
+Respond with ONLY a JSON object of this form, without code fences or extra text:
+{"summary": "one or two sentences on what the whole command does",
+ "parts": [{"text": "tar", "explanation": "..."}, {"text": "-x", "explanation": "..."}]}
+`,
+	},
+	FixMode: {
+		intro: "You are a command-line troubleshooting expert. The user is on %s using %s shell and a command has just failed.",
+		instructions: `Work out the most likely cause of the failure from the output above, quoting the line that shows it if there is one. Then give a corrected command that the user can run instead. If running a command won't fix it (for example, the code itself needs changing), leave the command empty and say what to change in the steps.
+
+Respond with ONLY a JSON object of this form, without code fences or extra text:
+{"cause": "one or two sentences on why it failed",
+ "command": "the corrected command, or empty",
+ "steps": "any other steps needed, or empty"}
+`,
+	},
+	RegexMode: {
+		intro: "You are a regular expression expert. The user is on %s using %s shell and needs a regular expression.",
+		instructions: `Write a regular expression for the user's request in the dialect given in the context, using only syntax that dialect supports. Respond in markdown with:
+1. The regular expression alone in a code block.
+2. A brief explanation of how it works, one bullet per part.
+3. Two or three example strings it matches and two or three it doesn't, as bullets under "Matches:" and "Doesn't match:".
+
+Do not include anything else.
+`,
+		markdown: true,
+	},
+	JQMode: {
+		intro: "You are a jq expert. The user is on %s using %s shell and needs a jq filter for the JSON input shown below.",
+		instructions: `Respond with ONLY the jq filter. Do not include the jq command itself, quotes around the filter, markdown formatting, or explanations. The input may be a sample of larger data, so don't rely on array lengths or particular values in it.
+`,
+	},
+	SQLMode: {
+		intro: "You are a SQL expert. The user is on %s using %s shell and needs a SQL query.",
+		instructions: `Write a query for the user's request in the SQL dialect given in the context. If a schema is included, use only the tables and columns it defines; if none is, choose sensible names. Prefer clear joins and CTEs over clever tricks, and don't modify data unless the request asks for it.
+
+Respond with ONLY the SQL. Do not include explanations, markdown formatting, or extra text.
+`,
+	},
+	CronMode: {
+		intro: "You are a cron expert. The user is on %s using %s shell and needs a crontab schedule.",
+		instructions: `Respond with ONLY a standard five-field cron expression (minute hour day-of-month month day-of-week) for the requested schedule, on one line. If the request names a command to run, put it after the five fields as a crontab line would. Do not use seconds, years, or non-standard syntax such as L, W or #, and do not include explanations or markdown formatting.
+
+Examples:
+- For "every weekday at 6:30am" → "30 6 * * 1-5"
+- For "every 15 minutes" → "*/15 * * * *"
+`,
+	},
+	K8sMode: {
+		intro: "You are a Kubernetes expert. The user is on %s using %s shell and needs a kubectl or helm command for the cluster described below.",
+		instructions: `Respond with ONLY the command(s) that would accomplish this task, using kubectl, or helm if it's installed and suits the task better. Rely on the current context and namespace unless the request names others, and only use resource types the cluster has if they're listed. Prefer read-only commands where they answer the request. Do not include explanations, markdown formatting, or extra text. If multiple commands are needed, put each on a separate line.
+`,
+		markdown: true,
+	},
+	AWSMode: {
+		intro:        "You are an AWS expert. The user is on %s using %s shell and needs an aws CLI command for the account described below.",
+		instructions: cloudInstructions("aws", "the profile and region"),
+		markdown:     true,
+	},
+	GCloudMode: {
+		intro:        "You are a Google Cloud expert. The user is on %s using %s shell and needs a gcloud command for the project described below.",
+		instructions: cloudInstructions("gcloud", "the project, region and zone"),
+		markdown:     true,
+	},
+	AzureMode: {
+		intro:        "You are an Azure expert. The user is on %s using %s shell and needs an az CLI command for the subscription described below.",
+		instructions: cloudInstructions("az", "the subscription, location and resource group"),
+		markdown:     true,
+	},
+	TFMode: {
+		intro: "You are a Terraform expert. The user is on %s using %s shell and is working on the Terraform configuration described below.",
+		instructions: `Answer the question, or write the configuration asked for, using the attached files and plan output.
+
+If asked why a resource is being replaced, changed or destroyed, answer from the plan: name the attributes marked "# forces replacement" or changed, explain why changing them does that for this resource type, and say how to avoid it if it's unintended, e.g. with lifecycle { ignore_changes }, a moved block or terraform state mv.
+
+If asked to write configuration, match the providers, versions, naming and variables of the attached files, and put the HCL in a code block marked hcl, with only a sentence or two around it. Prefer variables to hard-coded values where the files use them. Keep answers concise.
+`,
+		markdown: true,
+	},
+	SystemdMode: {
+		intro: "You are a systemd expert. The user is on %s using %s shell and needs systemd unit files.",
+		instructions: `Write the unit files that do what's described: a .service, plus a .timer if it should run on a schedule, or whatever other units it needs. Give each one as a line of the form "### FILE: <unit name>" followed by the file's complete contents, with no code fences.
+
+Write user units, installed in ~/.config/systemd/user and started with systemctl --user, unless the request needs root or names a user to run as; then write system units, with User= set. Use absolute paths in ExecStart, a Description, the Type and Restart= that suit the program, and an [Install] section, with WantedBy=default.target for user services, multi-user.target for system services and timers.target for timers. Add Persistent=true to timers that should catch up on missed runs.
+
+Respond with ONLY the files. Do not include explanations or extra text.
+`,
+	},
+	DockerMode: {
+		intro: "You are a Docker expert. The user is on %s using %s shell and needs container configuration for the project described below.",
+		instructions: `Write a Dockerfile for the project, or a compose.yaml if the request asks for compose or the project needs more than one service. Use the entrypoints, ports and manifests in the context, a multi-stage build where the language is compiled, a slim or distroless base image pinned to a major version, and a non-root user where the image allows it. Update an existing Dockerfile or compose file rather than starting over.
+
+Respond with ONLY the file content. Do not include explanations, markdown formatting, or code fences.
+`,
+	},
+	SedMode: {
+		intro:        "You are a sed expert. The user is on %s using %s shell and needs a sed one-liner.",
+		instructions: oneLinerInstructions("sed"),
+	},
+	AwkMode: {
+		intro:        "You are an awk expert. The user is on %s using %s shell and needs an awk one-liner.",
+		instructions: oneLinerInstructions("awk"),
+	},
+	TranslateMode: {
+		intro: "You are a professional translator.",
+		instructions: textInstructions + `Translate the text into the language given in the context. Keep its meaning, tone, formatting and line breaks, and leave code, commands, identifiers and URLs untranslated. Respond with ONLY the translation.
+`,
+		text: true,
+	},
+	ProofreadMode: {
+		intro: "You are a careful copy editor helping someone who may not be a native speaker.",
+		instructions: textInstructions + `Correct grammar, spelling, punctuation and unnatural phrasing in the text, in its own language. Keep its meaning, tone, formatting and line breaks, and conventions such as a commit message's short subject line. Leave code, commands, identifiers and URLs unchanged. Respond with ONLY the corrected text.
+`,
+		text: true,
+	},
+	PatchMode: {
+		intro: "You are an expert programmer. The user is on %s using %s shell and wants changes made to the attached files.",
+		instructions: `Respond with ONLY a unified diff that makes the requested changes. Use "--- a/PATH" and "+++ b/PATH" headers with the paths exactly as given above, and "--- /dev/null" for new files. Give each hunk three lines of unchanged context copied exactly from the file, including indentation and blank lines. Change only what the request needs.
+
+Do not include explanations, markdown formatting, or code fences.
+`,
+	},
+	TLDRMode: {
+		intro: "You are a command-line expert. The user is on %s using %s shell and wants a quick reference for the command below, written as a tldr-pages page.",
+		instructions: "Write the page in the exact tldr-pages format:\n\n" +
+			"# command\n\n" +
+			"> What the command does, in one or two short lines.\n" +
+			"> More information: <https://link.to/its/documentation>.\n\n" +
+			"- Description of an example, starting with a verb and ending with a colon:\n\n" +
+			"`command --option {{placeholder}}`\n\n" +
+			"Give five to eight examples, the most common and simplest first. Put values the user must fill in inside double braces, such as {{path/to/file}}, and use the options supported on the user's OS. Only include the \"More information\" line if you are sure of the link.\n\nDo not include anything else.\n",
+		markdown: true,
+	},
+	PortMode: {
+		intro: "You are a shell scripting expert. The user is on %s using %s shell and wants a command or script translated from one shell to another.",
+		instructions: `Translate the command or script from the source shell to the target shell given in the context. It is the piped or attached code if there is any, otherwise the user request itself. Keep its behavior the same, including quoting, globbing, exit status and error handling, and use the target shell's own syntax and idioms rather than calling the source shell. If something has no equivalent, do the closest thing and explain the difference in a comment in the target shell's syntax.
+
+Respond with ONLY the translated code. Do not include explanations, markdown formatting, or code fences.
+`,
+	},
+	AgentMode: {
+		intro: "You are a command-line agent. The user is on %s using %s shell and wants to reach the goal below by running commands one at a time.",
+		instructions: `Decide the single next shell command towards the goal, given the steps so far and their output, if any are shown above. Prefer commands that inspect before ones that change things, and don't repeat a command that failed without changing it. The user confirms each command before it runs, and may decline. Once the goal is met, or can't be met, stop and summarize the outcome.
+
+Respond with ONLY a JSON object of one of these forms, without code fences or extra text:
+{"done": false, "thought": "one sentence on why this is the next step", "command": "the command"}
+{"done": true, "summary": "what was done and the outcome, or why the goal can't be met"}
+`,
+	},
+	RecallMode: {
+		intro: "You are a research assistant answering a question from the user's own notes.",
+		instructions: `Answer using the excerpts from the user's notes above, which were picked as the closest matches to the question and may not all be relevant. Cite the file each fact comes from in parentheses. If the excerpts don't answer the question, say so briefly rather than guessing. Keep the answer short.
+`,
+		markdown: true,
+		text:     true,
+	},
+	RepoMode: {
+		intro: "You are an expert on the user's code repository. The user is on %s using %s shell and has a question about the repository.",
+		instructions: `Answer using the file list, key files and excerpts above, which were picked by matching words in the question and may be incomplete. Name the files, and functions or types where you can, that the answer is about. If the excerpts don't show the answer, say which files are most likely to hold it rather than guessing at their contents. Keep the answer short.
+`,
+		markdown: true,
+	},
+	AliasMode: {
+		intro: "You are a shell expert. The user is on %s using %s shell and wants aliases or functions for the long commands they type most often, listed above with how many times each was typed.",
+		instructions: `Suggest up to ten aliases or functions, for the commands that would save the most typing. Use an alias where the command is fixed and a function where part of it changes, such as a branch name or file, taking that part as an argument. Give each a short, memorable name that isn't already a common command, and prefix each definition with a comment saying what it replaces. Use the syntax of the user's shell, so that the answer can be appended to its startup file as it is.
+
+Respond with ONLY the shell code. Do not include other explanations, markdown formatting, or code fences.
+`,
+	},
+	CommitLintMode: {
+		intro: "You are an experienced software engineer fixing a git commit message. The user is on %s using %s shell.",
+		instructions: `Rewrite the commit message above in the Conventional Commits style, fixing the problems listed. The subject line has the form "type(scope): summary", where type is one of the allowed types and the scope is optional; add "!" before the colon if the message describes a breaking change. Write the summary in the imperative mood, without a trailing period, and keep the whole line under 72 characters. Keep the message's meaning and any body, after a blank line and wrapped at 72 characters. Use the staged changes, if they're shown, only to choose the type and scope.
+
+Respond with ONLY the commit message. Do not include markdown formatting, code fences, or extra text.
+`,
+	},
+	ChangelogMode: {
+		intro: "You are an experienced software engineer writing release notes. The user is on %s using %s shell.",
+		instructions: `Write the changelog entries for the commits above in the Keep a Changelog format. Group them under these headings, in this order, leaving out empty ones: "### Added", "### Changed", "### Deprecated", "### Removed", "### Fixed" and "### Security". Write one bullet per change that matters to the project's users, in the past tense and in their terms rather than the code's, merging commits that make up one change. Leave out refactoring, tests, CI and other changes users won't notice, and don't mention commit hashes.
+
+Respond with ONLY the headings and bullets, in markdown. Do not include a version heading, code fences, or extra text.
+`,
+	},
+	BugReportMode: {
+		intro: "You are an experienced software engineer writing a bug report for an issue tracker. The user is on %s using %s shell.",
+		instructions: `Write the body of a bug report about the problem described, using the failing command and output above. Use GitHub-flavored markdown with these sections: "## Summary", one or two sentences on what goes wrong; "## Steps to reproduce", a numbered list ending with the failing command in a code block; "## Expected behavior"; and "## Actual behavior", with the relevant part of the output, trimmed of noise, in a code block. Add "## Notes" only for a likely cause or workaround the output points to. Don't invent details: where something isn't known, such as what was expected, leave an HTML comment like <!-- what did you expect? --> for the reporter to fill in. An environment section is added separately, so don't write one.
+
+Respond with ONLY the report body. Do not include a title, code fences around the whole report, or extra text.
+`,
+	},
+}
+
+// powershellPrompts replace modePrompts when the shell is PowerShell, whose
+// idioms differ too much from Unix shells for the usual examples to help
+var powershellPrompts = map[Mode]modePrompt{
+	CommandMode: {
+		intro: "You are a PowerShell assistant. The user is on %s using %s shell and needs a command suggestion.",
+		instructions: `Respond with ONLY the PowerShell command(s) that would accomplish this task. Use cmdlets with their full Verb-Noun names and full parameter names rather than aliases such as ls, dir or %, and pass objects along the pipeline with Where-Object, Select-Object, Sort-Object and ForEach-Object rather than parsing text. Only call external programs when no cmdlet does the job. Do not include explanations, markdown formatting, or extra text. If multiple commands are needed, put each on a separate line.
+
+Examples:
+- For "search for foo in directory" → "Get-ChildItem -Recurse -File | Select-String -Pattern foo"
+- For "list files by size" → "Get-ChildItem -File | Sort-Object -Property Length -Descending"
+- For "find large files" → "Get-ChildItem -Recurse -File | Where-Object Length -gt 100MB"
+- For "what's using port 8080" → "Get-NetTCPConnection -LocalPort 8080 | Select-Object -Property OwningProcess, State"` + alternativesInstructions + questionInstructions,
+		markdown: true,
+	},
+}
+
+// isPowerShell reports whether shell is Windows PowerShell or PowerShell 7
+func isPowerShell(shell string) bool {
+	return shell == "pwsh" || shell == "powershell"
+}
+
+// alternativesInstructions let command mode offer a choice of commands; see
+// ParseSuggestions
+const alternativesInstructions = `
+
+If there are clearly different ways to do it that are worth choosing between, for example one using a tool that may not be installed, you may instead give up to three. Start each with a line "PREFERRED: <one-line label>" or "ALTERNATIVE: <one-line label>", saying when to use it, followed by its command(s). Mark exactly one as preferred.`
+
+// questionInstructions let command mode ask the user to clarify a request
+// rather than guess; see ParseQuestion
+const questionInstructions = `
+
+If the request is so ambiguous that a reasonable guess would likely be wrong, for example when it could mean very different commands or needs a name or value you can't work out, respond instead with ONLY a line of the form "` + questionPrefix + ` <one short question for the user>".`
+
+// textInstructions says where the text to work on is for text modes
+const textInstructions = `The text is the piped or attached text if there is any, in which case the user request, if any, says how to handle it. Otherwise the text is the user request itself.
+
+`
+
+// oneLinerInstructions asks for a single tool command that filters stdin,
+// so that it can be checked against sample input
+func oneLinerInstructions(tool string) string {
+	return fmt.Sprintf(`Respond with ONLY a single %[1]s command, on one line, that reads from stdin and writes to stdout. Don't name input files, edit files in place, or pipe into other commands. Use only features of the %[1]s version given in the context. If sample input is included, the command must work on it, but don't rely on particular values in it.
+
+Do not include explanations, markdown formatting, or extra text.
+`, tool)
+}
+
+// cloudInstructions asks for commands using a cloud provider's CLI tool,
+// relying on the defaults named by settings when they're given
+func cloudInstructions(tool, settings string) string {
+	return fmt.Sprintf(`Respond with ONLY the command(s) that would accomplish this task, using %[1]s. If %[2]s are given, rely on them rather than repeating them as flags, unless the request names others; if they aren't given, use placeholders such as <region> where the command needs them. Prefer read-only commands where they answer the request, and never add flags that skip confirmation prompts of deletions unless asked to. Do not include explanations, markdown formatting, or extra text. If multiple commands are needed, put each on a separate line.
+`, tool, settings)
+}
+
+// BuildPrompt returns the prompt asking for query to be answered in mode
+func BuildPrompt(mode Mode, sys System, query string) string {
+	p, ok := modePrompts[mode]
+	if !ok {
+		mode, p = CommandMode, modePrompts[CommandMode]
+	}
+	if ps, ok := powershellPrompts[mode]; ok && isPowerShell(sys.Shell) {
+		p = ps
+	}
+
+	var b strings.Builder
+	lines := sys.contextLines()
+	if p.text {
+		b.WriteString(p.intro)
+		lines = sys.Notes
+	} else {
+		fmt.Fprintf(&b, p.intro, sys.OS, sys.Shell)
+	}
+
+	if len(lines) > 0 {
+		b.WriteString("\n\nContext:")
+		for _, line := range lines {
+			b.WriteString("\n- " + line)
+		}
+	}
+	for _, block := range sys.contextBlocks() {
+		fmt.Fprintf(&b, "\n\n%s:\n```\n%s\n```", block.title, block.body)
+	}
+
+	fmt.Fprintf(&b, "\n\nUser request: %s\n\n", query)
+	b.WriteString(p.instructions)
+	return b.String()
+}
@@ -0,0 +1,172 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildPrompt(t *testing.T) {
+	tests := []struct {
+		mode     Mode
+		contains string
+		markdown bool
+	}{
+		{CommandMode, "needs a command suggestion", true},
+		{CodeMode, "needs a code snippet", false},
+		{ExplainMode, "needs a brief explanation", true},
+		{CommitMode, "Conventional Commits", false},
+		{PRMode, "pull request title", false},
+		{ReviewMode, "JSON object", false},
+		{ExplainCommandMode, "Split combined short flags", false},
+		{FixMode, "corrected command", false},
+		{RegexMode, "needs a regular expression", true},
+		{JQMode, "needs a jq filter", false},
+		{SQLMode, "needs a SQL query", false},
+		{CronMode, "needs a crontab schedule", false},
+		{K8sMode, "needs a kubectl or helm command", true},
+		{DockerMode, "needs container configuration", false},
+		{SedMode, "single sed command", false},
+		{AwkMode, "single awk command", false},
+		{TranslateMode, "Respond with ONLY the translation", false},
+		{ProofreadMode, "Respond with ONLY the corrected text", false},
+		{PatchMode, "unified diff", false},
+		{TLDRMode, "tldr-pages format", true},
+		{PortMode, "from the source shell to the target shell", false},
+		{AgentMode, "single next shell command", false},
+		{RecallMode, "excerpts from the user's notes", true},
+		{RepoMode, "question about the repository", true},
+		{AliasMode, "aliases or functions", false},
+		{CommitLintMode, "fixing the problems listed", false},
+		{ChangelogMode, "Keep a Changelog format", false},
+		{BugReportMode, "body of a bug report", false},
+		{AWSMode, "using aws", true},
+		{GCloudMode, "using gcloud", true},
+		{AzureMode, "using az", true},
+		{TFMode, "forces replacement", true},
+		{SystemdMode, "needs systemd unit files", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			prompt := BuildPrompt(tt.mode, System{OS: "linux", Shell: "zsh"}, "do the thing")
+			if !strings.Contains(prompt, tt.contains) {
+				t.Errorf("prompt missing %q:\n%s", tt.contains, prompt)
+			}
+			if !modePrompts[tt.mode].text && !strings.Contains(prompt, "on linux using zsh shell") {
+				t.Errorf("prompt missing system context:\n%s", prompt)
+			}
+			if !strings.Contains(prompt, "User request: do the thing") {
+				t.Errorf("prompt missing query:\n%s", prompt)
+			}
+			if tt.mode.Markdown() != tt.markdown {
+				t.Errorf("Markdown() = %v, want %v", tt.mode.Markdown(), tt.markdown)
+			}
+		})
+	}
+}
+
+func TestBuildPromptPowerShell(t *testing.T) {
+	for _, shell := range []string{"pwsh", "powershell"} {
+		prompt := BuildPrompt(CommandMode, System{OS: "windows", Shell: shell}, "list files by size")
+		if !strings.Contains(prompt, "You are a PowerShell assistant") || !strings.Contains(prompt, "Sort-Object -Property Length") {
+			t.Errorf("%s: prompt should use the PowerShell examples:\n%s", shell, prompt)
+		}
+		if strings.Contains(prompt, "ls -laSh") {
+			t.Errorf("%s: prompt contains Unix examples:\n%s", shell, prompt)
+		}
+	}
+
+	// Other modes are unchanged
+	prompt := BuildPrompt(CodeMode, System{OS: "windows", Shell: "pwsh"}, "reverse a string")
+	if !strings.Contains(prompt, "needs a code snippet") {
+		t.Errorf("code mode prompt changed for PowerShell:\n%s", prompt)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for m := CommandMode; m <= SystemdMode; m++ {
+		if got, err := ParseMode(m.String()); err != nil || got != m {
+			t.Errorf("ParseMode(%q) = %v, %v", m.String(), got, err)
+		}
+	}
+	if _, err := ParseMode("bogus"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestBuildPromptText(t *testing.T) {
+	sys := System{OS: "linux", Shell: "bash", Distro: "Ubuntu 24.04", Notes: []string{"Translate into: German"}}
+	prompt := BuildPrompt(TranslateMode, sys, "good morning")
+
+	if !strings.HasPrefix(prompt, "You are a professional translator.\n\nContext:\n- Translate into: German\n") {
+		t.Errorf("prompt should only have the notes as context:\n%s", prompt)
+	}
+	for _, unwanted := range []string{"linux", "bash", "Ubuntu", "%!"} {
+		if strings.Contains(prompt, unwanted) {
+			t.Errorf("prompt contains %q:\n%s", unwanted, prompt)
+		}
+	}
+}
+
+func TestBuildPromptWithProject(t *testing.T) {
+	sys := System{OS: "linux", Shell: "bash", Project: &Project{
+		Dir:    "llm-cli",
+		Branch: "main",
+		Status: "2 changed",
+		Types:  []string{"Go (go.mod)"},
+	}}
+	prompt := BuildPrompt(CommandMode, sys, "run the tests")
+
+	for _, want := range []string{
+		"Context:\n- Working directory: llm-cli\n- Git branch: main (2 changed)\n- Project type: Go (go.mod)\n\nUser request: run the tests",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+
+	sys = System{OS: "linux", Shell: "bash", Distro: "Fedora Linux 40", PackageManagers: []string{"dnf", "brew"}}
+	prompt = BuildPrompt(CommandMode, sys, "install ripgrep")
+	if want := "Context:\n- OS release: Fedora Linux 40\n- Package managers: dnf, brew\n"; !strings.Contains(prompt, want) {
+		t.Errorf("prompt missing %q:\n%s", want, prompt)
+	}
+
+	if plain := BuildPrompt(CommandMode, System{OS: "linux", Shell: "bash"}, "q"); strings.Contains(plain, "Context:") {
+		t.Errorf("context section without any context:\n%s", plain)
+	}
+}
+
+func TestBuildPromptWithPreviousCommand(t *testing.T) {
+	sys := System{OS: "linux", Shell: "zsh", Previous: &CommandResult{
+		Command:    "cargo build",
+		ExitStatus: 101,
+		Stderr:     "error[E0425]: cannot find value `x`",
+	}}
+	prompt := BuildPrompt(ExplainMode, sys, "why did that fail")
+
+	for _, want := range []string{
+		"- Previous command: `cargo build` (exit status 101)",
+		"Error output of the previous command:\n```\nerror[E0425]: cannot find value `x`\n```\n\nUser request: why did that fail",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+}
+
+func TestBuildPromptWithAttachments(t *testing.T) {
+	sys := System{OS: "linux", Shell: "bash", Attachments: []Attachment{
+		{Content: "panic: nil map"},
+		{Name: "main.go", Content: "package main"},
+	}}
+	prompt := BuildPrompt(ExplainMode, sys, "what's wrong")
+
+	for _, want := range []string{
+		"Piped input:\n```\npanic: nil map\n```",
+		"Contents of main.go:\n```\npackage main\n```",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package llm
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	claudeAPIURL = "https://api.anthropic.com/v1/messages"
+	openaiAPIURL = "https://api.openai.com/v1/chat/completions"
+	ollamaAPIURL = "http://localhost:11434/api/generate"
+	claudeModel  = "claude-sonnet-4-20250514"
+	openaiModel  = "gpt-4o-mini"
+)
+
+// Claude API structs
+type ClaudeRequest struct {
+	Model         string    `json:"model"`
+	MaxTokens     int       `json:"max_tokens"`
+	Temperature   float64   `json:"temperature,omitempty"`
+	StopSequences []string  `json:"stop_sequences,omitempty"`
+	System        string    `json:"system,omitempty"`
+	Messages      []Message `json:"messages"`
+}
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ClaudeResponse struct {
+	Content    []ContentBlock `json:"content"`
+	StopReason string         `json:"stop_reason,omitempty"`
+	Error      *APIError      `json:"error,omitempty"`
+}
+
+// ContentBlock is part of a message. Besides text, blocks carry images
+// (type "image"), tool calls (type "tool_use") and their results (type
+// "tool_result").
+type ContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	IsError   bool            `json:"is_error,omitempty"`
+	Source    *ImageSource    `json:"source,omitempty"`
+}
+
+// ImageSource holds the data of an image block
+type ImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+// Claude vision structs
+type ClaudeVisionRequest struct {
+	Model         string              `json:"model"`
+	MaxTokens     int                 `json:"max_tokens"`
+	Temperature   float64             `json:"temperature,omitempty"`
+	StopSequences []string            `json:"stop_sequences,omitempty"`
+	Messages      []ClaudeToolMessage `json:"messages"`
+}
+
+// Claude tool use structs
+type ClaudeToolRequest struct {
+	Model      string              `json:"model"`
+	MaxTokens  int                 `json:"max_tokens"`
+	Tools      []ClaudeTool        `json:"tools"`
+	ToolChoice *ClaudeToolChoice   `json:"tool_choice,omitempty"`
+	Messages   []ClaudeToolMessage `json:"messages"`
+}
+
+type ClaudeTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type ClaudeToolChoice struct {
+	Type string `json:"type"`
+}
+
+type ClaudeToolMessage struct {
+	Role    string         `json:"role"`
+	Content []ContentBlock `json:"content"`
+}
+
+// OpenAI API structs
+type OpenAIRequest struct {
+	Model       string          `json:"model"`
+	Messages    []OpenAIMessage `json:"messages"`
+	MaxTokens   int             `json:"max_tokens"`
+	Temperature float64         `json:"temperature"`
+	N           int             `json:"n,omitempty"`
+	Stop        []string        `json:"stop,omitempty"`
+	Seed        int             `json:"seed,omitempty"`
+	Tools       []OpenAITool    `json:"tools,omitempty"`
+	ToolChoice  string          `json:"tool_choice,omitempty"`
+}
+
+type OpenAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []OpenAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// OpenAI function calling structs
+type OpenAITool struct {
+	Type     string         `json:"type"`
+	Function OpenAIFunction `json:"function"`
+}
+
+type OpenAIFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+	Arguments   string         `json:"arguments,omitempty"`
+}
+
+type OpenAIToolCall struct {
+	ID       string         `json:"id"`
+	Type     string         `json:"type"`
+	Function OpenAIFunction `json:"function"`
+}
+
+type OpenAIResponse struct {
+	Choices []OpenAIChoice `json:"choices"`
+	Error   *APIError      `json:"error,omitempty"`
+}
+
+type OpenAIChoice struct {
+	Message OpenAIMessage `json:"message"`
+}
+
+// OpenAI vision structs
+type OpenAIVisionRequest struct {
+	Model       string                `json:"model"`
+	Messages    []OpenAIVisionMessage `json:"messages"`
+	MaxTokens   int                   `json:"max_tokens"`
+	Temperature float64               `json:"temperature"`
+	Stop        []string              `json:"stop,omitempty"`
+	Seed        int                   `json:"seed,omitempty"`
+}
+
+type OpenAIVisionMessage struct {
+	Role    string              `json:"role"`
+	Content []OpenAIContentPart `json:"content"`
+}
+
+type OpenAIContentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *OpenAIImageURL `json:"image_url,omitempty"`
+}
+
+type OpenAIImageURL struct {
+	URL string `json:"url"`
+}
+
+// Ollama API structs
+type OllamaRequest struct {
+	Model   string         `json:"model"`
+	Prompt  string         `json:"prompt"`
+	System  string         `json:"system,omitempty"`
+	Stream  bool           `json:"stream"`
+	Images  []string       `json:"images,omitempty"`
+	Options *OllamaOptions `json:"options,omitempty"`
+}
+
+type OllamaOptions struct {
+	Temperature float64  `json:"temperature,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+	Seed        int      `json:"seed,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+}
+
+type OllamaResponse struct {
+	Response string    `json:"response"`
+	Error    *APIError `json:"error,omitempty"`
+}
+
+// Common error struct
+type APIError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// Provider identifies an LLM API
+type Provider int
+
+const (
+	Claude Provider = iota
+	OpenAI
+	Ollama
+)
+
+func (p Provider) String() string {
+	switch p {
+	case Claude:
+		return "claude"
+	case OpenAI:
+		return "openai"
+	case Ollama:
+		return "ollama"
+	}
+	return "unknown"
+}
+
+// DefaultModel returns the model used for p when none is configured. Ollama
+// has no default since it depends on which models are installed locally.
+func DefaultModel(p Provider) string {
+	switch p {
+	case Claude:
+		return claudeModel
+	case OpenAI:
+		return openaiModel
+	}
+	return ""
+}
+
+// modelAliases are short names for the default models
+var modelAliases = map[string]string{
+	"claude":        claudeModel,
+	"claude-sonnet": claudeModel,
+	"gpt":           openaiModel,
+}
+
+// ResolveModel returns the provider and model that name refers to. A
+// provider can be given explicitly, as in "openai:gpt-4o"; otherwise names
+// starting with "claude" are Claude's, names like "gpt-4o", "o3-mini" and
+// "text-embedding-3-small" are OpenAI's, and anything else is taken to be an
+// Ollama model.
+func ResolveModel(name string) (Provider, string) {
+	if prefix, model, ok := strings.Cut(name, ":"); ok {
+		for _, p := range []Provider{Claude, OpenAI, Ollama} {
+			if prefix == p.String() {
+				return p, cmp.Or(modelAliases[model], model)
+			}
+		}
+	}
+	if model, ok := modelAliases[name]; ok {
+		name = model
+	}
+	switch {
+	case strings.HasPrefix(name, "claude"):
+		return Claude, name
+	case strings.HasPrefix(name, "gpt-"), strings.HasPrefix(name, "chatgpt-"), strings.HasPrefix(name, "text-embedding-"),
+		openaiReasoningRe.MatchString(name):
+		return OpenAI, name
+	}
+	return Ollama, name
+}
+
+// openaiReasoningRe matches OpenAI's o-series model names
+var openaiReasoningRe = regexp.MustCompile(`^o\d+(-|$)`)
+
+// ErrNoProvider is returned by FromEnv when no provider is configured
+var ErrNoProvider = errors.New("no API key or Ollama model found")
+
+// FromEnv returns a client for the first provider configured in the
+// environment. Priority order: Claude > OpenAI > Ollama.
+func FromEnv() (*Client, error) {
+	// Check for Claude API key first
+	if apiKey := os.Getenv("ANTHROPIC_API_KEY"); apiKey != "" {
+		return NewClient(Claude, apiKey, ""), nil
+	}
+
+	// Check for OpenAI API key
+	if apiKey := os.Getenv("OPENAI_API_KEY"); apiKey != "" {
+		return NewClient(OpenAI, apiKey, ""), nil
+	}
+
+	// Check for Ollama model
+	if model := os.Getenv("OLLAMA_MODEL"); model != "" {
+		return NewClient(Ollama, "", model), nil
+	}
+
+	return nil, ErrNoProvider
+}
@@ -0,0 +1,18 @@
+package llm
+
+import "strings"
+
+// questionPrefix starts a command-mode response that asks the user to
+// clarify the request instead of answering it
+const questionPrefix = "QUESTION:"
+
+// ParseQuestion returns the question in a command-mode response that asks
+// the user to clarify the request, if it is one
+func ParseQuestion(response string) (string, bool) {
+	response = strings.Trim(strings.TrimSpace(response), "`")
+	question, ok := strings.CutPrefix(strings.TrimSpace(response), questionPrefix)
+	if !ok || strings.Contains(strings.TrimSpace(question), "\n") {
+		return "", false
+	}
+	return strings.TrimSpace(question), true
+}
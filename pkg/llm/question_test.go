@@ -0,0 +1,38 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseQuestion(t *testing.T) {
+	tests := []struct {
+		response string
+		want     string
+		ok       bool
+	}{
+		{"QUESTION: Which container, web or db?", "Which container, web or db?", true},
+		{"  `QUESTION: Which branch?`\n", "Which branch?", true},
+		{"docker restart web", "", false},
+		{"QUESTION: first\nrm -rf build", "", false},
+		{"echo 'QUESTION: no'", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseQuestion(tt.response)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseQuestion(%q) = %q, %v, want %q, %v", tt.response, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCommandPromptAllowsQuestions(t *testing.T) {
+	for _, shell := range []string{"bash", "pwsh"} {
+		prompt := BuildPrompt(CommandMode, System{OS: "linux", Shell: shell}, "restart the container")
+		if !strings.Contains(prompt, questionPrefix) {
+			t.Errorf("%s command prompt doesn't say how to ask a question", shell)
+		}
+	}
+	if strings.Contains(BuildPrompt(CodeMode, System{}, "q"), questionPrefix) {
+		t.Error("code prompt shouldn't ask questions")
+	}
+}
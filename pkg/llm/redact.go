@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"regexp"
+	"strings"
+)
+
+// redactedMarker starts every placeholder
+const redactedMarker = "[REDACTED"
+
+// redaction replaces text matching re with replacement, which may refer to
+// submatches
+type redaction struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// redactions find things that look like credentials. Rules that keep a
+// variable name and only replace its value come last so that the specific
+// placeholders win.
+var redactions = []redaction{
+	{regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`), "[REDACTED PRIVATE KEY]"},
+	{regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_\-]{20,}`), "[REDACTED ANTHROPIC KEY]"},
+	{regexp.MustCompile(`\bsk-(proj-|svcacct-)?[A-Za-z0-9_\-]{20,}`), "[REDACTED OPENAI KEY]"},
+	{regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`), "[REDACTED AWS ACCESS KEY]"},
+	{regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`), "[REDACTED GITHUB TOKEN]"},
+	{regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20,}`), "[REDACTED GITLAB TOKEN]"},
+	{regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9\-]{10,}`), "[REDACTED SLACK TOKEN]"},
+	{regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}`), "[REDACTED GOOGLE API KEY]"},
+	{regexp.MustCompile(`\b[sr]k_(live|test)_[0-9A-Za-z]{24,}`), "[REDACTED STRIPE KEY]"},
+	{regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{10,}\.eyJ[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}`), "[REDACTED JWT]"},
+
+	// user:password@host in URLs
+	{regexp.MustCompile(`\b([a-zA-Z][a-zA-Z0-9+.\-]*://[^/\s:@]+:)[^/\s@]+@`), "${1}[REDACTED]@"},
+
+	// PASSWORD=..., "api_key": "...", aws_secret_access_key = ...
+	{regexp.MustCompile(`(?i)\b([A-Z0-9_.\-]*(?:PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|ACCESS_?KEY|PRIVATE_?KEY|CREDENTIALS?)[A-Z0-9_]*["']?\s*[=:]\s*["']?)([^\s"',;]{4,})`), "${1}[REDACTED]"},
+}
+
+// Redact replaces likely secrets in text with placeholders, returning the
+// redacted text and the number of replacements made
+func Redact(text string) (string, int) {
+	count := 0
+	for _, r := range redactions {
+		text = r.re.ReplaceAllStringFunc(text, func(match string) string {
+			if strings.Contains(match, redactedMarker) {
+				// Already replaced by a more specific rule
+				return match
+			}
+			replaced := r.re.ReplaceAllString(match, r.replacement)
+			if replaced != match {
+				count++
+			}
+			return replaced
+		})
+	}
+	return text, count
+}
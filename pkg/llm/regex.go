@@ -0,0 +1,27 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// regexDialects describes each regular expression dialect to the model, by
+// the names users give them
+var regexDialects = map[string]string{
+	"pcre": "PCRE (Perl-compatible)",
+	"re2":  "RE2, as used by Go's regexp package (no lookaround or backreferences)",
+	"go":   "RE2, as used by Go's regexp package (no lookaround or backreferences)",
+	"ere":  "POSIX extended, as used by grep -E",
+	"grep": "POSIX extended, as used by grep -E",
+	"bre":  "POSIX basic, as used by sed without -E (groups and intervals need backslashes)",
+	"sed":  "POSIX basic, as used by sed without -E (groups and intervals need backslashes)",
+}
+
+// RegexDialect returns the description of a regex dialect for the prompt
+func RegexDialect(name string) (string, error) {
+	d, ok := regexDialects[strings.ToLower(name)]
+	if !ok {
+		return "", fmt.Errorf("unknown regex dialect %q (expected pcre, re2/go, ere/grep or bre/sed)", name)
+	}
+	return d, nil
+}
@@ -0,0 +1,22 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegexDialect(t *testing.T) {
+	d, err := RegexDialect("Go")
+	if err != nil || !strings.HasPrefix(d, "RE2") {
+		t.Errorf("RegexDialect(Go) = %q, %v", d, err)
+	}
+	if _, err := RegexDialect("perl6"); err == nil {
+		t.Error("expected an error for an unknown dialect")
+	}
+
+	sys := System{OS: "linux", Shell: "bash", Notes: []string{"Regex dialect: " + d}}
+	prompt := BuildPrompt(RegexMode, sys, "ISO dates")
+	if !strings.Contains(prompt, "Context:\n- Regex dialect: RE2") {
+		t.Errorf("prompt missing dialect:\n%s", prompt)
+	}
+}
@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// idempotencyHeader carries a key that's the same for every attempt at a
+// request, so a gateway that honors it can tell a retry from a new request
+// after a failure that left it unclear whether the first attempt arrived.
+// It's only sent with Client.IdempotencyKeys, since Claude and OpenAI
+// ignore it.
+const idempotencyHeader = "Idempotency-Key"
+
+// retryDelay is the wait before the first retry, doubling for each one
+// after it
+var retryDelay = time.Second
+
+// newIdempotencyKey returns a random key for one request
+func newIdempotencyKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate idempotency key: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// statusOverloaded is the status Claude answers with when it's overloaded
+const statusOverloaded = 529
+
+// retrySafe reports whether a request that failed with err clearly wasn't
+// answered, so sending it again can't be answered and billed twice: the
+// connection to the provider was never made, or the provider refused the
+// request as rate limited or overloaded. A dropped connection or a server
+// error may come after the answer was generated, so neither is retried.
+func retrySafe(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var status *StatusError
+	return errors.As(err, &status) && (status.StatusCode == http.StatusTooManyRequests || status.StatusCode == statusOverloaded)
+}
+
+// waitToRetry waits before retrying after the given attempt, which counts
+// from 0, returning early with an error if ctx is done
+func waitToRetry(ctx context.Context, attempt int) error {
+	d := retryDelay << attempt
+	slog.DebugContext(ctx, "retrying request", "attempt", attempt+2, "wait", d)
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
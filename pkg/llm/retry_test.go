@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetries(t *testing.T) {
+	retryDelay = time.Millisecond
+	t.Cleanup(func() { retryDelay = time.Second })
+
+	tests := []struct {
+		name     string
+		provider Provider
+		retries  int
+		keys     bool
+		statuses []int
+		wantErr  bool
+		wantReqs int
+	}{
+		{"no retries", OpenAI, 0, true, []int{429, 200}, true, 1},
+		{"retried", OpenAI, 2, true, []int{429, 429, 200}, false, 3},
+		{"gives up", Claude, 1, true, []int{529, 529, 200}, true, 2},
+		{"not retryable", OpenAI, 2, true, []int{400, 200}, true, 1},
+		{"server error may have been answered", Claude, 2, true, []int{500, 200}, true, 1},
+		{"no keys unless asked for", OpenAI, 1, false, []int{429, 200}, false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				keys = append(keys, r.Header.Get(idempotencyHeader))
+				w.WriteHeader(tt.statuses[len(keys)-1])
+				io.WriteString(w, `{"content":[{"text":"ls"}],"choices":[{"message":{"content":"ls"}}],"response":"ls"}`)
+			}))
+			defer srv.Close()
+
+			c := &Client{Provider: tt.provider, Model: "m", Endpoint: srv.URL, Retries: tt.retries, IdempotencyKeys: tt.keys}
+			_, err := c.Query(context.Background(), "list files")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v", err)
+			}
+			if len(keys) != tt.wantReqs {
+				t.Fatalf("sent %d requests, want %d", len(keys), tt.wantReqs)
+			}
+			for _, key := range keys {
+				if key != keys[0] || (key != "") != tt.keys {
+					t.Errorf("keys = %q", keys)
+				}
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeysDiffer(t *testing.T) {
+	srv, req, _ := mockProvider(t, http.StatusOK, `{"choices":[{"message":{"content":"ls"}}]}`)
+	c := &Client{Provider: OpenAI, Endpoint: srv.URL, IdempotencyKeys: true}
+	seen := map[string]bool{}
+	for range 3 {
+		if _, err := c.Query(context.Background(), "list files"); err != nil {
+			t.Fatal(err)
+		}
+		seen[req.Header.Get(idempotencyHeader)] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("keys repeated across requests: %v", seen)
+	}
+}
+
+func TestRetrySafe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	_, dialErr := net.Dial("tcp", addr)
+	if dialErr == nil {
+		t.Skip("the closed port accepted a connection")
+	}
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&NetworkError{Provider: Claude, Err: dialErr}, true},
+		{&StatusError{Provider: OpenAI, StatusCode: 429}, true},
+		{&StatusError{Provider: Claude, StatusCode: 529}, true},
+		{&StatusError{Provider: OpenAI, StatusCode: 503}, false},
+		{&NetworkError{Provider: OpenAI, Err: io.ErrUnexpectedEOF}, false},
+	}
+	for _, tt := range tests {
+		if got := retrySafe(tt.err); got != tt.want {
+			t.Errorf("retrySafe(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
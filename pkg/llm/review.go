@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Review is a structured code review of a diff
+type Review struct {
+	Summary   string    `json:"summary"`
+	Bugs      []Finding `json:"bugs"`
+	Style     []Finding `json:"style"`
+	Questions []string  `json:"questions"`
+}
+
+// Finding is a comment on a particular place in a diff
+type Finding struct {
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Comment string `json:"comment"`
+}
+
+// ParseReview parses a ReviewMode response
+func ParseReview(response string) (*Review, error) {
+	var r Review
+	if err := parseJSON(response, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse review: %v", err)
+	}
+	return &r, nil
+}
+
+// parseJSON decodes a JSON object from a response into v. Models sometimes
+// wrap the JSON in a code fence or add a sentence around it, so anything
+// outside the outermost braces is ignored.
+func parseJSON(response string, v any) error {
+	start := strings.Index(response, "{")
+	end := strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return errors.New("the response isn't JSON")
+	}
+	return json.Unmarshal([]byte(response[start:end+1]), v)
+}
+
+// Markdown formats the review for reading
+func (r *Review) Markdown() string {
+	var b strings.Builder
+	if r.Summary != "" {
+		b.WriteString(r.Summary + "\n")
+	}
+	writeFindings(&b, "Potential bugs", r.Bugs)
+	writeFindings(&b, "Style", r.Style)
+	if len(r.Questions) > 0 {
+		b.WriteString("\n## Questions\n")
+		for _, q := range r.Questions {
+			b.WriteString("- " + q + "\n")
+		}
+	}
+	if len(r.Bugs) == 0 && len(r.Style) == 0 && len(r.Questions) == 0 {
+		b.WriteString("\nNo issues found.\n")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func writeFindings(b *strings.Builder, heading string, findings []Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n", heading)
+	for _, f := range findings {
+		switch {
+		case f.File != "" && f.Line > 0:
+			fmt.Fprintf(b, "- `%s:%d` %s\n", f.File, f.Line, f.Comment)
+		case f.File != "":
+			fmt.Fprintf(b, "- `%s` %s\n", f.File, f.Comment)
+		default:
+			b.WriteString("- " + f.Comment + "\n")
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseReview(t *testing.T) {
+	response := "Here is the review:\n```json\n" + `{
+  "summary": "Adds a pager.",
+  "bugs": [{"file": "pager.go", "line": 12, "comment": "The error from Wait is ignored."}],
+  "style": [{"comment": "Consider a shorter name."}],
+  "questions": ["Should LESS be overridable?"]
+}` + "\n```"
+
+	r, err := ParseReview(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Summary != "Adds a pager." || len(r.Bugs) != 1 || r.Bugs[0].Line != 12 || len(r.Questions) != 1 {
+		t.Errorf("got %+v", r)
+	}
+
+	md := r.Markdown()
+	for _, want := range []string{
+		"Adds a pager.\n\n## Potential bugs\n- `pager.go:12` The error from Wait is ignored.",
+		"## Style\n- Consider a shorter name.",
+		"## Questions\n- Should LESS be overridable?",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+
+	if md := (&Review{Summary: "Fine."}).Markdown(); md != "Fine.\n\nNo issues found." {
+		t.Errorf("empty review = %q", md)
+	}
+
+	for _, bad := range []string{"Looks good to me!", `{"bugs": "none"}`} {
+		if _, err := ParseReview(bad); err == nil {
+			t.Errorf("ParseReview(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package llm
+
+// parentProcessName is only needed on Windows, where there's no $SHELL
+func parentProcessName() string {
+	return ""
+}
@@ -0,0 +1,27 @@
+package llm
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// parentProcessName returns the executable name of the process that started
+// this one, such as "pwsh.exe", or "" if it can't be found
+func parentProcessName() string {
+	snapshot, err := syscall.CreateToolhelp32Snapshot(syscall.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return ""
+	}
+	defer syscall.CloseHandle(snapshot)
+
+	ppid := uint32(os.Getppid())
+	var entry syscall.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	for err = syscall.Process32First(snapshot, &entry); err == nil; err = syscall.Process32Next(snapshot, &entry) {
+		if entry.ProcessID == ppid {
+			return syscall.UTF16ToString(entry.ExeFile[:])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,33 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// shellNames describes each shell to the model, by the names users give
+// them
+var shellNames = map[string]string{
+	"bash":       "bash",
+	"zsh":        "zsh",
+	"fish":       "fish",
+	"sh":         "POSIX sh",
+	"posix":      "POSIX sh",
+	"dash":       "POSIX sh",
+	"powershell": "PowerShell",
+	"pwsh":       "PowerShell",
+	"ps":         "PowerShell",
+	"cmd":        "cmd.exe",
+}
+
+// ShellName returns the description of a shell for the prompt. Paths and
+// .exe suffixes are ignored, so the value of $SHELL works too.
+func ShellName(name string) (string, error) {
+	base := name[strings.LastIndexAny(name, `/\`)+1:]
+	base = strings.TrimSuffix(strings.ToLower(base), ".exe")
+	s, ok := shellNames[base]
+	if !ok {
+		return "", fmt.Errorf("unknown shell %q (expected bash, zsh, fish, sh, powershell or cmd)", name)
+	}
+	return s, nil
+}
@@ -0,0 +1,22 @@
+package llm
+
+import "testing"
+
+func TestShellName(t *testing.T) {
+	for in, want := range map[string]string{
+		"fish":                "fish",
+		"/usr/local/bin/zsh":  "zsh",
+		"pwsh":                "PowerShell",
+		`C:\Windows\pwsh.exe`: "PowerShell",
+		"PowerShell":          "PowerShell",
+		"dash":                "POSIX sh",
+		"cmd.exe":             "cmd.exe",
+	} {
+		if got, err := ShellName(in); err != nil || got != want {
+			t.Errorf("ShellName(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ShellName("tcsh"); err == nil {
+		t.Error("expected an error for an unknown shell")
+	}
+}
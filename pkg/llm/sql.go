@@ -0,0 +1,27 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sqlDialects describes each SQL dialect to the model, by the names users
+// give them
+var sqlDialects = map[string]string{
+	"postgres":   "PostgreSQL",
+	"postgresql": "PostgreSQL",
+	"pg":         "PostgreSQL",
+	"mysql":      "MySQL",
+	"mariadb":    "MariaDB",
+	"sqlite":     "SQLite",
+	"sqlite3":    "SQLite",
+}
+
+// SQLDialect returns the name of a SQL dialect for the prompt
+func SQLDialect(name string) (string, error) {
+	d, ok := sqlDialects[strings.ToLower(name)]
+	if !ok {
+		return "", fmt.Errorf("unknown SQL dialect %q (expected postgres, mysql or sqlite)", name)
+	}
+	return d, nil
+}
@@ -0,0 +1,14 @@
+package llm
+
+import "testing"
+
+func TestSQLDialect(t *testing.T) {
+	for name, want := range map[string]string{"pg": "PostgreSQL", "MySQL": "MySQL", "sqlite3": "SQLite"} {
+		if got, err := SQLDialect(name); err != nil || got != want {
+			t.Errorf("SQLDialect(%q) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := SQLDialect("oracle"); err == nil {
+		t.Error("expected an error for an unknown dialect")
+	}
+}
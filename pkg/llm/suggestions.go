@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Suggestion is one way command mode suggests to do what was asked
+type Suggestion struct {
+	Command string
+
+	// Label says in a line when to use it, if the model gave one
+	Label     string
+	Preferred bool
+}
+
+// suggestionRe matches the line that starts a labeled suggestion
+var suggestionRe = regexp.MustCompile(`^(PREFERRED|ALTERNATIVE):\s*(.*)$`)
+
+// ParseSuggestions splits a command-mode response into the preferred
+// command, which comes first, and its alternatives. A response without
+// labels is a single suggestion, which may be several commands to run in
+// turn.
+func ParseSuggestions(response string) []Suggestion {
+	var suggestions []Suggestion
+	var unlabeled []string
+	for _, line := range strings.Split(strings.TrimSpace(response), "\n") {
+		if m := suggestionRe.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			suggestions = append(suggestions, Suggestion{Label: m[2], Preferred: m[1] == "PREFERRED"})
+			continue
+		}
+		if len(suggestions) == 0 {
+			unlabeled = append(unlabeled, line)
+			continue
+		}
+		s := &suggestions[len(suggestions)-1]
+		s.Command += line + "\n"
+	}
+	if command := strings.TrimSpace(strings.Join(unlabeled, "\n")); command != "" {
+		suggestions = append([]Suggestion{{Command: command}}, suggestions...)
+	}
+
+	var preferred, alternatives []Suggestion
+	for _, s := range suggestions {
+		if s.Command = strings.TrimSpace(s.Command); s.Command == "" {
+			continue
+		}
+		if s.Preferred && len(preferred) == 0 {
+			preferred = append(preferred, s)
+			continue
+		}
+		s.Preferred = false
+		alternatives = append(alternatives, s)
+	}
+	if len(preferred) == 0 && len(alternatives) > 0 {
+		// Without a mark, the first is the one to use
+		preferred, alternatives = alternatives[:1], alternatives[1:]
+		preferred[0].Preferred = true
+	}
+	return append(preferred, alternatives...)
+}
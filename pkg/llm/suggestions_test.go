@@ -0,0 +1,37 @@
+package llm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSuggestions(t *testing.T) {
+	tests := []struct {
+		response string
+		want     []Suggestion
+	}{
+		{"du -sh * | sort -h\n", []Suggestion{{Command: "du -sh * | sort -h", Preferred: true}}},
+		{"cd build\nmake", []Suggestion{{Command: "cd build\nmake", Preferred: true}}},
+		{
+			"ALTERNATIVE: on any Unix\nfind . -size +100M\nPREFERRED: faster, if fd is installed\nfd --size +100m\nALTERNATIVE: with sizes\nfind . -size +100M -exec ls -lh {} +\n",
+			[]Suggestion{
+				{Command: "fd --size +100m", Label: "faster, if fd is installed", Preferred: true},
+				{Command: "find . -size +100M", Label: "on any Unix"},
+				{Command: "find . -size +100M -exec ls -lh {} +", Label: "with sizes"},
+			},
+		},
+		{
+			"ALTERNATIVE: one\nls\nALTERNATIVE: two\nls -la",
+			[]Suggestion{{Command: "ls", Label: "one", Preferred: true}, {Command: "ls -la", Label: "two"}},
+		},
+		{
+			"PREFERRED: a\nls\nPREFERRED: b\nls -a\nALTERNATIVE: empty\n",
+			[]Suggestion{{Command: "ls", Label: "a", Preferred: true}, {Command: "ls -a", Label: "b"}},
+		},
+	}
+	for _, tt := range tests {
+		if got := ParseSuggestions(tt.response); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseSuggestions(%q) = %+v, want %+v", tt.response, got, tt.want)
+		}
+	}
+}
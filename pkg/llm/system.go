@@ -0,0 +1,223 @@
+package llm
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// System describes the user's environment to the model
+type System struct {
+	OS    string
+	Shell string
+
+	// Distro names the OS release, e.g. "Ubuntu 22.04.4 LTS"
+	Distro string
+
+	// WSL is "WSL1" or "WSL2" when running in the Windows Subsystem for
+	// Linux
+	WSL string
+
+	// PackageManagers lists the package managers found on PATH
+	PackageManagers []string
+
+	// Project is included when the user opts in to sharing project context
+	Project *Project
+
+	// Previous is the command the user ran before asking, if known
+	Previous *CommandResult
+
+	// Listing is an ls-style listing of the working directory, if the user
+	// opted in to sharing it
+	Listing string
+
+	// Attachments are piped input or files the user supplied
+	Attachments []Attachment
+
+	// Images are sent alongside the prompt rather than written into it
+	Images []Image
+
+	// Notes are extra context lines for a particular request, e.g.
+	// "Regex dialect: RE2"
+	Notes []string
+}
+
+// Attachment is content the user supplied alongside the query
+type Attachment struct {
+	// Name describes where the content came from, e.g. a file path. Piped
+	// input has no name.
+	Name    string
+	Content string
+
+	// Title overrides how the content is introduced in the prompt, e.g.
+	// "Staged changes"
+	Title string
+}
+
+func (a Attachment) title() string {
+	if a.Title != "" {
+		return a.Title
+	}
+	if a.Name == "" {
+		return "Piped input"
+	}
+	return "Contents of " + a.Name
+}
+
+// CommandResult describes a command the user ran
+type CommandResult struct {
+	Command    string
+	ExitStatus int
+
+	// Stderr holds the command's error output, if it was captured
+	Stderr string
+}
+
+// contextBlock is a multi-line piece of context, quoted in the prompt
+type contextBlock struct {
+	title string
+	body  string
+}
+
+// packageManagers are checked for on PATH, most specific to the OS first
+var packageManagers = []string{
+	"apt", "dnf", "yum", "pacman", "zypper", "apk", "emerge", "xbps-install",
+	"nix", "brew", "port", "winget", "choco", "scoop",
+}
+
+// DetectSystem returns the System for the current process
+func DetectSystem() System {
+	sys := System{OS: runtime.GOOS, Shell: detectShell(), Distro: detectDistro()}
+	if runtime.GOOS == "linux" {
+		if data, err := os.ReadFile("/proc/version"); err == nil {
+			sys.WSL = parseWSL(string(data))
+		}
+	}
+	for _, pm := range packageManagers {
+		if _, err := exec.LookPath(pm); err == nil {
+			sys.PackageManagers = append(sys.PackageManagers, pm)
+		}
+	}
+	return sys
+}
+
+// contextLines returns extra details about the environment, one per line
+func (s System) contextLines() []string {
+	var lines []string
+	if s.Distro != "" {
+		lines = append(lines, "OS release: "+s.Distro)
+	}
+	if s.WSL != "" {
+		lines = append(lines, fmt.Sprintf("Running in %s under Windows: Windows drives are mounted at /mnt/c and so on, "+
+			"wslpath converts between Windows and Linux paths, and Windows programs such as clip.exe, "+
+			"explorer.exe, cmd.exe and powershell.exe can be run from the shell", s.WSL))
+	}
+	if len(s.PackageManagers) > 0 {
+		lines = append(lines, "Package managers: "+strings.Join(s.PackageManagers, ", "))
+	}
+	if s.Project != nil {
+		lines = append(lines, s.Project.contextLines()...)
+	}
+	if s.Previous != nil {
+		lines = append(lines, fmt.Sprintf("Previous command: `%s` (exit status %d)",
+			s.Previous.Command, s.Previous.ExitStatus))
+	}
+	return append(lines, s.Notes...)
+}
+
+// contextBlocks returns multi-line context such as captured output
+func (s System) contextBlocks() []contextBlock {
+	var blocks []contextBlock
+	if s.Previous != nil && s.Previous.Stderr != "" {
+		blocks = append(blocks, contextBlock{"Error output of the previous command", s.Previous.Stderr})
+	}
+	if s.Listing != "" {
+		blocks = append(blocks, contextBlock{"Files in the current directory (size, name)", s.Listing})
+	}
+	for _, a := range s.Attachments {
+		blocks = append(blocks, contextBlock{a.title(), a.Content})
+	}
+	return blocks
+}
+
+func detectShell() string {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		if runtime.GOOS == "windows" {
+			return windowsShell(parentProcessName(), os.Getenv("PSModulePath"))
+		}
+		return "sh"
+	}
+	// Extract just the shell name (e.g., "/bin/bash" -> "bash")
+	parts := strings.Split(shell, "/")
+	return parts[len(parts)-1]
+}
+
+// windowsShell names the shell llm was started from on Windows, going by
+// the parent process and, failing that, PSModulePath. PowerShell adds the
+// user's module directory to PSModulePath, so it has more entries than the
+// system-wide value cmd inherits.
+func windowsShell(parent, psModulePath string) string {
+	parent = strings.TrimSuffix(strings.ToLower(parent), ".exe")
+	switch parent {
+	case "pwsh", "powershell", "cmd", "bash", "zsh", "fish", "nu":
+		return parent
+	}
+	if strings.Count(psModulePath, ";") < 2 {
+		return "cmd"
+	}
+	if strings.Contains(strings.ToLower(psModulePath), `\powershell\7`) {
+		return "pwsh"
+	}
+	return "powershell"
+}
+
+// detectDistro describes the OS release, or returns "" if unknown
+func detectDistro() string {
+	switch runtime.GOOS {
+	case "linux":
+		for _, path := range []string{"/etc/os-release", "/usr/lib/os-release"} {
+			if data, err := os.ReadFile(path); err == nil {
+				return parseOSRelease(string(data))
+			}
+		}
+	case "darwin":
+		if out, err := exec.Command("sw_vers", "-productVersion").Output(); err == nil {
+			return "macOS " + strings.TrimSpace(string(out))
+		}
+	}
+	return ""
+}
+
+// parseWSL returns "WSL1" or "WSL2" if /proc/version's contents show a WSL
+// kernel, or "" otherwise. WSL2 kernels are named "...microsoft-standard-WSL2"
+// and WSL1 reports "Microsoft".
+func parseWSL(procVersion string) string {
+	lower := strings.ToLower(procVersion)
+	switch {
+	case strings.Contains(lower, "wsl2"), strings.Contains(procVersion, "microsoft-standard"):
+		return "WSL2"
+	case strings.Contains(lower, "microsoft"):
+		return "WSL1"
+	}
+	return ""
+}
+
+// parseOSRelease extracts a human-readable name from os-release(5) contents
+func parseOSRelease(data string) string {
+	fields := map[string]string{}
+	for _, line := range strings.Split(data, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		fields[key] = strings.Trim(value, `"'`)
+	}
+
+	if name := fields["PRETTY_NAME"]; name != "" {
+		return name
+	}
+	return strings.TrimSpace(fields["NAME"] + " " + fields["VERSION_ID"])
+}
@@ -0,0 +1,70 @@
+package llm
+
+import "testing"
+
+func TestGetShell(t *testing.T) {
+	t.Setenv("SHELL", "/usr/local/bin/fish")
+	if got := detectShell(); got != "fish" {
+		t.Errorf("detectShell() = %q", got)
+	}
+}
+
+func TestWindowsShell(t *testing.T) {
+	const (
+		system = `C:\Program Files\WindowsPowerShell\Modules;C:\WINDOWS\system32\WindowsPowerShell\v1.0\Modules`
+		user   = `C:\Users\me\Documents\WindowsPowerShell\Modules;` + system
+		pwsh   = `C:\Users\me\Documents\PowerShell\Modules;C:\Program Files\PowerShell\Modules;c:\program files\powershell\7\Modules;` + system
+	)
+	tests := []struct {
+		parent       string
+		psModulePath string
+		want         string
+	}{
+		{"pwsh.exe", system, "pwsh"},
+		{"PowerShell.exe", "", "powershell"},
+		{"cmd.exe", user, "cmd"},
+		{"bash.exe", "", "bash"},
+		{"", system, "cmd"},
+		{"", "", "cmd"},
+		{"explorer.exe", user, "powershell"},
+		{"", pwsh, "pwsh"},
+	}
+	for _, tt := range tests {
+		if got := windowsShell(tt.parent, tt.psModulePath); got != tt.want {
+			t.Errorf("windowsShell(%q, %q) = %q, want %q", tt.parent, tt.psModulePath, got, tt.want)
+		}
+	}
+}
+
+func TestParseWSL(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"Linux version 5.15.153.1-microsoft-standard-WSL2 (root@941d701f84f1) (gcc (GCC) 11.2.0)", "WSL2"},
+		{"Linux version 4.4.0-19041-Microsoft (Microsoft@Microsoft.com) (gcc version 5.4.0 (GCC) )", "WSL1"},
+		{"Linux version 6.8.0-45-generic (buildd@lcy02-amd64-115) (x86_64-linux-gnu-gcc-13)", ""},
+	}
+	for _, tt := range tests {
+		if got := parseWSL(tt.version); got != tt.want {
+			t.Errorf("parseWSL(%q) = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestParseOSRelease(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"NAME=\"Ubuntu\"\nVERSION_ID=\"22.04\"\nPRETTY_NAME=\"Ubuntu 22.04.4 LTS\"\n", "Ubuntu 22.04.4 LTS"},
+		{"NAME=Alpine\nVERSION_ID=3.19.1\n", "Alpine 3.19.1"},
+		{"NAME='Arch Linux'\n", "Arch Linux"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := parseOSRelease(tt.data); got != tt.want {
+			t.Errorf("parseOSRelease(%q) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultContextWindow is assumed for models not in contextWindows
+const defaultContextWindow = 8192
+
+// contextWindows maps model name prefixes to their context window in
+// tokens. Longer prefixes are listed before shorter ones they start with.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"claude-", 200000},
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"llama3.1", 131072},
+	{"llama3.2", 131072},
+	{"llama3", 8192},
+	{"mistral", 32768},
+	{"mixtral", 32768},
+	{"qwen2.5", 32768},
+	{"codellama", 16384},
+	{"gemma", 8192},
+	{"phi3", 4096},
+}
+
+// ContextWindow returns the context window of model in tokens. Unknown
+// models are assumed to have a small window.
+func ContextWindow(model string) int {
+	for _, w := range contextWindows {
+		if strings.HasPrefix(model, w.prefix) {
+			return w.tokens
+		}
+	}
+	return defaultContextWindow
+}
+
+// EstimateTokens roughly estimates how many tokens text will use. It
+// assumes about four characters per token, which is close for English
+// prose and code with the tokenizers in common use.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// FitPrompt shortens the attachments in sys, keeping the start and end of
+// each, so that the prompt for query is estimated to fit in limit tokens.
+// Each attachment gives up space in proportion to its size. It reports
+// whether anything was truncated.
+func FitPrompt(mode Mode, sys *System, query string, limit int) bool {
+	total := EstimateTokens(BuildPrompt(mode, *sys, query))
+	if total <= limit || len(sys.Attachments) == 0 {
+		return false
+	}
+
+	attached := 0
+	for _, a := range sys.Attachments {
+		attached += EstimateTokens(a.Content)
+	}
+	budget := limit - (total - attached)
+	if budget < 0 {
+		budget = 0
+	}
+
+	attachments := make([]Attachment, len(sys.Attachments))
+	for i, a := range sys.Attachments {
+		share := budget * EstimateTokens(a.Content) / attached
+		a.Content = TruncateMiddle(a.Content, share)
+		attachments[i] = a
+	}
+	sys.Attachments = attachments
+	return true
+}
+
+// TruncateMiddle shortens text to about maxTokens by dropping whole lines
+// from the middle, keeping the start and end, which is usually where the
+// interesting parts of logs and files are
+func TruncateMiddle(text string, maxTokens int) string {
+	if EstimateTokens(text) <= maxTokens {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	// Budget in characters, leaving room for the truncation marker
+	budget := max(maxTokens*4-32, 0)
+	var head, tail []string
+	used := 0
+	for i, j := 0, len(lines)-1; i <= j; {
+		// Alternate between the start and end so both are kept
+		if len(head) <= len(tail) {
+			n := utf8.RuneCountInString(lines[i]) + 1
+			if used+n > budget {
+				break
+			}
+			head = append(head, lines[i])
+			used += n
+			i++
+		} else {
+			n := utf8.RuneCountInString(lines[j]) + 1
+			if used+n > budget {
+				break
+			}
+			tail = append(tail, lines[j])
+			used += n
+			j--
+		}
+	}
+
+	if len(head) == 0 && len(tail) == 0 {
+		// Lines are too long to keep whole, e.g. minified JSON
+		runes := []rune(text)
+		half := budget / 2
+		return string(runes[:half]) + "[... truncated ...]" + string(runes[len(runes)-half:])
+	}
+
+	dropped := len(lines) - len(head) - len(tail)
+	var b strings.Builder
+	for _, line := range head {
+		b.WriteString(line + "\n")
+	}
+	fmt.Fprintf(&b, "[... %d lines truncated ...]", dropped)
+	for i := len(tail) - 1; i >= 0; i-- {
+		b.WriteString("\n" + tail[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestContextWindow(t *testing.T) {
+	tests := map[string]int{
+		"claude-sonnet-4-20250514": 200000,
+		"gpt-4o-mini":              128000,
+		"gpt-4":                    8192,
+		"llama3.1:8b":              131072,
+		"llama3":                   8192,
+		"some-local-model":         defaultContextWindow,
+	}
+	for model, want := range tests {
+		if got := ContextWindow(model); got != want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if got := EstimateTokens(""); got != 0 {
+		t.Errorf("empty: %d", got)
+	}
+	if got := EstimateTokens(strings.Repeat("abcd", 100)); got != 100 {
+		t.Errorf("400 chars: %d", got)
+	}
+}
+
+func numberedLines(n int) string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %03d", i)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestTruncateMiddle(t *testing.T) {
+	text := numberedLines(100)
+	if got := TruncateMiddle(text, 10000); got != text {
+		t.Error("text that fits shouldn't change")
+	}
+
+	got := TruncateMiddle(text, 50)
+	if EstimateTokens(got) > 60 {
+		t.Errorf("result is ~%d tokens, want about 50", EstimateTokens(got))
+	}
+	for _, want := range []string{"line 000\n", "\nline 099", "lines truncated ..."} {
+		if !strings.Contains(got, want) {
+			t.Errorf("result missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "line 050") {
+		t.Errorf("middle wasn't dropped:\n%s", got)
+	}
+}
+
+func TestTruncateMiddleLongLine(t *testing.T) {
+	got := TruncateMiddle("HEAD"+strings.Repeat("x", 1000)+"TAIL", 10)
+	if !strings.HasPrefix(got, "HEAD") || !strings.HasSuffix(got, "TAIL") || len(got) > 100 {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestFitPrompt(t *testing.T) {
+	sys := System{OS: "linux", Shell: "bash", Attachments: []Attachment{
+		{Name: "big.log", Content: numberedLines(3000)},
+		{Name: "small.txt", Content: numberedLines(1000)},
+	}}
+	if FitPrompt(CommandMode, &sys, "q", 1000000) {
+		t.Error("prompt that fits shouldn't be truncated")
+	}
+
+	original := sys.Attachments[0].Content
+	if !FitPrompt(CommandMode, &sys, "q", 2000) {
+		t.Fatal("expected truncation")
+	}
+	if got := EstimateTokens(BuildPrompt(CommandMode, sys, "q")); got > 2100 {
+		t.Errorf("prompt is still ~%d tokens", got)
+	}
+	big, small := EstimateTokens(sys.Attachments[0].Content), EstimateTokens(sys.Attachments[1].Content)
+	if big < 2*small {
+		t.Errorf("attachments should shrink proportionally: big=%d small=%d", big, small)
+	}
+	if original == sys.Attachments[0].Content {
+		t.Error("attachment unchanged")
+	}
+}
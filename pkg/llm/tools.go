@@ -0,0 +1,192 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Tool is a function the model may call to gather facts before answering
+type Tool struct {
+	Name        string
+	Description string
+
+	// Parameters is a JSON Schema object describing the arguments
+	Parameters map[string]any
+
+	// Run carries out a call given its arguments as JSON. The result, or
+	// the error's message, is sent back to the model.
+	Run func(ctx context.Context, args json.RawMessage) (string, error)
+}
+
+// ErrToolsUnsupported is returned by QueryWithTools for providers that
+// can't call tools
+var ErrToolsUnsupported = errors.New("tools aren't supported")
+
+// errTooManyToolCalls is returned if the model still calls tools after
+// being told it can't
+var errTooManyToolCalls = errors.New("the model kept calling tools after the limit")
+
+// SupportsTools reports whether the provider can call tools
+func (c *Client) SupportsTools() bool {
+	return c.Provider == Claude || c.Provider == OpenAI
+}
+
+// toolCall is a call the model asked for
+type toolCall struct {
+	id   string
+	name string
+	args json.RawMessage
+}
+
+// QueryWithTools sends prompt to the provider, letting the model call tools
+// and see the results for up to maxRounds rounds before it must answer
+func (c *Client) QueryWithTools(ctx context.Context, prompt string, tools []Tool, maxRounds int) (string, error) {
+	if c.ModelName() == "" {
+		return "", fmt.Errorf("no model configured for %v", c.Provider)
+	}
+
+	switch c.Provider {
+	case Claude:
+		return c.queryClaudeTools(ctx, prompt, tools, maxRounds)
+	case OpenAI:
+		return c.queryOpenAITools(ctx, prompt, tools, maxRounds)
+	}
+	return "", fmt.Errorf("%w with %v", ErrToolsUnsupported, c.Provider)
+}
+
+// runTool carries out call, returning the result to send back to the model
+// and whether it is an error
+func runTool(ctx context.Context, tools []Tool, call toolCall) (string, bool) {
+	for _, t := range tools {
+		if t.Name != call.name {
+			continue
+		}
+		slog.Debug("calling tool", "name", call.name, "args", string(call.args))
+		result, err := t.Run(ctx, call.args)
+		if err != nil {
+			return err.Error(), true
+		}
+		return result, false
+	}
+	return "unknown tool " + call.name, true
+}
+
+func (c *Client) queryClaudeTools(ctx context.Context, prompt string, tools []Tool, maxRounds int) (string, error) {
+	reqBody := ClaudeToolRequest{
+		Model:     c.ModelName(),
+		MaxTokens: c.maxTokens(),
+		Messages: []ClaudeToolMessage{{
+			Role:    "user",
+			Content: []ContentBlock{{Type: "text", Text: prompt}},
+		}},
+	}
+	for _, t := range tools {
+		reqBody.Tools = append(reqBody.Tools, ClaudeTool{Name: t.Name, Description: t.Description, InputSchema: t.Parameters})
+	}
+
+	for round := 0; ; round++ {
+		if round == maxRounds {
+			// Out of rounds; the model has to answer with what it has
+			reqBody.ToolChoice = &ClaudeToolChoice{Type: "none"}
+		}
+
+		var claudeResp ClaudeResponse
+		err := c.postJSON(ctx, c.endpoint(claudeAPIURL), map[string]string{
+			"x-api-key":         c.APIKey,
+			"anthropic-version": "2023-06-01",
+		}, reqBody, &claudeResp)
+		if err != nil {
+			return "", err
+		}
+		if claudeResp.Error != nil {
+			return "", claudeResp.Error
+		}
+
+		var text []string
+		var calls []toolCall
+		for _, block := range claudeResp.Content {
+			switch block.Type {
+			case "text":
+				text = append(text, block.Text)
+			case "tool_use":
+				calls = append(calls, toolCall{block.ID, block.Name, block.Input})
+			}
+		}
+		if len(calls) == 0 {
+			answer := strings.TrimSpace(strings.Join(text, "\n"))
+			if answer == "" {
+				return "", fmt.Errorf("empty response from API")
+			}
+			return answer, nil
+		}
+		if round == maxRounds {
+			return "", errTooManyToolCalls
+		}
+
+		var results []ContentBlock
+		for _, call := range calls {
+			result, isError := runTool(ctx, tools, call)
+			results = append(results, ContentBlock{Type: "tool_result", ToolUseID: call.id, Content: result, IsError: isError})
+		}
+		reqBody.Messages = append(reqBody.Messages,
+			ClaudeToolMessage{Role: "assistant", Content: claudeResp.Content},
+			ClaudeToolMessage{Role: "user", Content: results})
+	}
+}
+
+func (c *Client) queryOpenAITools(ctx context.Context, prompt string, tools []Tool, maxRounds int) (string, error) {
+	reqBody := OpenAIRequest{
+		Model:       c.ModelName(),
+		MaxTokens:   c.maxTokens(),
+		Temperature: 0.1,
+		Messages:    []OpenAIMessage{{Role: "user", Content: prompt}},
+	}
+	for _, t := range tools {
+		reqBody.Tools = append(reqBody.Tools, OpenAITool{
+			Type:     "function",
+			Function: OpenAIFunction{Name: t.Name, Description: t.Description, Parameters: t.Parameters},
+		})
+	}
+
+	for round := 0; ; round++ {
+		if round == maxRounds {
+			reqBody.ToolChoice = "none"
+		}
+
+		var openaiResp OpenAIResponse
+		err := c.postJSON(ctx, c.endpoint(openaiAPIURL), map[string]string{
+			"Authorization": "Bearer " + c.APIKey,
+		}, reqBody, &openaiResp)
+		if err != nil {
+			return "", err
+		}
+		if openaiResp.Error != nil {
+			return "", openaiResp.Error
+		}
+		if len(openaiResp.Choices) == 0 {
+			return "", fmt.Errorf("no choices in response")
+		}
+
+		msg := openaiResp.Choices[0].Message
+		if len(msg.ToolCalls) == 0 {
+			answer := strings.TrimSpace(msg.Content)
+			if answer == "" {
+				return "", fmt.Errorf("empty response from API")
+			}
+			return answer, nil
+		}
+		if round == maxRounds {
+			return "", errTooManyToolCalls
+		}
+
+		reqBody.Messages = append(reqBody.Messages, msg)
+		for _, call := range msg.ToolCalls {
+			result, _ := runTool(ctx, tools, toolCall{call.ID, call.Function.Name, json.RawMessage(call.Function.Arguments)})
+			reqBody.Messages = append(reqBody.Messages, OpenAIMessage{Role: "tool", ToolCallID: call.ID, Content: result})
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// sequenceProvider starts a server that answers requests with bodies in
+// turn and records the requests it receives
+func sequenceProvider(t *testing.T, bodies ...string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, string(body))
+		if len(requests) > len(bodies) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		io.WriteString(w, bodies[len(requests)-1])
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+// diskTool answers like df, recording the arguments it was called with
+func diskTool(calls *[]string) Tool {
+	return Tool{
+		Name:        "disk_usage",
+		Description: "Show disk usage",
+		Parameters:  map[string]any{"type": "object", "properties": map[string]any{"path": map[string]any{"type": "string"}}},
+		Run: func(ctx context.Context, args json.RawMessage) (string, error) {
+			*calls = append(*calls, string(args))
+			if strings.Contains(string(args), "/secret") {
+				return "", errors.New("declined")
+			}
+			return "/dev/sda1 95% /", nil
+		},
+	}
+}
+
+func TestQueryWithToolsClaude(t *testing.T) {
+	srv, requests := sequenceProvider(t,
+		`{"content":[{"type":"text","text":"Checking."},{"type":"tool_use","id":"t1","name":"disk_usage","input":{"path":"/"}},{"type":"tool_use","id":"t2","name":"disk_usage","input":{"path":"/secret"}}],"stop_reason":"tool_use"}`,
+		`{"content":[{"type":"text","text":"  docker system prune\n"}],"stop_reason":"end_turn"}`)
+
+	var calls []string
+	c := &Client{Provider: Claude, APIKey: "k", Endpoint: srv.URL}
+	got, err := c.QueryWithTools(context.Background(), "free up space", []Tool{diskTool(&calls)}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "docker system prune" {
+		t.Errorf("got %q", got)
+	}
+	if len(calls) != 2 || calls[0] != `{"path":"/"}` {
+		t.Errorf("calls = %q", calls)
+	}
+
+	var second ClaudeToolRequest
+	if err := json.Unmarshal([]byte((*requests)[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+	if len(second.Tools) != 1 || second.Tools[0].Name != "disk_usage" || second.ToolChoice != nil {
+		t.Errorf("tools = %+v, choice = %+v", second.Tools, second.ToolChoice)
+	}
+	if len(second.Messages) != 3 || second.Messages[1].Role != "assistant" || second.Messages[2].Role != "user" {
+		t.Fatalf("messages = %+v", second.Messages)
+	}
+	results := second.Messages[2].Content
+	if len(results) != 2 || results[0].ToolUseID != "t1" || results[0].Content != "/dev/sda1 95% /" || results[0].IsError ||
+		results[1].ToolUseID != "t2" || results[1].Content != "declined" || !results[1].IsError {
+		t.Errorf("results = %+v", results)
+	}
+}
+
+func TestQueryWithToolsOpenAI(t *testing.T) {
+	srv, requests := sequenceProvider(t,
+		`{"choices":[{"message":{"role":"assistant","content":"","tool_calls":[{"id":"c1","type":"function","function":{"name":"disk_usage","arguments":"{\"path\":\"/\"}"}}]}}]}`,
+		`{"choices":[{"message":{"role":"assistant","content":"du -sh /var/*"}}]}`)
+
+	var calls []string
+	c := &Client{Provider: OpenAI, APIKey: "k", Endpoint: srv.URL}
+	got, err := c.QueryWithTools(context.Background(), "free up space", []Tool{diskTool(&calls)}, 3)
+	if err != nil || got != "du -sh /var/*" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+
+	var second OpenAIRequest
+	if err := json.Unmarshal([]byte((*requests)[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+	msgs := second.Messages
+	if len(msgs) != 3 || len(msgs[1].ToolCalls) != 1 || msgs[2].Role != "tool" || msgs[2].ToolCallID != "c1" || msgs[2].Content != "/dev/sda1 95% /" {
+		t.Errorf("messages = %+v", msgs)
+	}
+}
+
+func TestQueryWithToolsRoundLimit(t *testing.T) {
+	toolUse := `{"content":[{"type":"tool_use","id":"t","name":"disk_usage","input":{}}]}`
+	srv, requests := sequenceProvider(t, toolUse, toolUse, `{"content":[{"type":"text","text":"df -h"}]}`)
+
+	var calls []string
+	c := &Client{Provider: Claude, APIKey: "k", Endpoint: srv.URL}
+	if _, err := c.QueryWithTools(context.Background(), "q", []Tool{diskTool(&calls)}, 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 || len(*requests) != 3 {
+		t.Errorf("%d calls in %d requests", len(calls), len(*requests))
+	}
+	if !strings.Contains((*requests)[2], `"tool_choice":{"type":"none"}`) {
+		t.Errorf("last request should forbid tools: %s", (*requests)[2])
+	}
+}
+
+func TestQueryWithToolsOllama(t *testing.T) {
+	c := &Client{Provider: Ollama, Model: "llama3"}
+	if _, err := c.QueryWithTools(context.Background(), "q", nil, 1); !errors.Is(err, ErrToolsUnsupported) {
+		t.Errorf("err = %v, want ErrToolsUnsupported", err)
+	}
+}
@@ -0,0 +1,196 @@
+package llm
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// maxImageBytes is the largest image Claude accepts, which is also within
+// OpenAI's limit
+const maxImageBytes = 5 << 20
+
+// Image is a picture sent alongside a prompt
+type Image struct {
+	// MediaType is "image/png", "image/jpeg", "image/gif" or "image/webp"
+	MediaType string
+	Data      []byte
+}
+
+// ReadImage reads an image file to send with a prompt
+func ReadImage(path string) (Image, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Image{}, fmt.Errorf("failed to read image: %v", err)
+	}
+	if len(data) > maxImageBytes {
+		return Image{}, fmt.Errorf("%s is larger than the %d MB models accept", path, maxImageBytes>>20)
+	}
+	mediaType := http.DetectContentType(data)
+	switch mediaType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+		return Image{MediaType: mediaType, Data: data}, nil
+	}
+	return Image{}, fmt.Errorf("%s isn't a PNG, JPEG, GIF or WebP image", path)
+}
+
+// visionModels are prefixes of Ollama models that can read images
+var visionModels = []string{
+	"bakllava", "gemma3", "granite3.2-vision", "llama3.2-vision", "llama4",
+	"llava", "minicpm-v", "moondream", "qwen2.5vl",
+}
+
+// SupportsImages reports whether the client's model can read images. All
+// current Claude models can, as can OpenAI's apart from GPT-3.5; Ollama
+// models are recognised by name.
+func (c *Client) SupportsImages() bool {
+	model := c.ModelName()
+	switch c.Provider {
+	case Claude:
+		return true
+	case OpenAI:
+		return !strings.HasPrefix(model, "gpt-3.5")
+	case Ollama:
+		for _, prefix := range visionModels {
+			if strings.HasPrefix(model, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// QueryImages sends prompt along with images and returns the trimmed answer
+func (c *Client) QueryImages(ctx context.Context, prompt string, images []Image) (string, error) {
+	if len(images) == 0 {
+		return c.Query(ctx, prompt)
+	}
+	if c.ModelName() == "" {
+		return "", fmt.Errorf("no model configured for %v", c.Provider)
+	}
+	if !c.SupportsImages() {
+		return "", fmt.Errorf("%s can't read images; pick a vision model with -m", c.ModelName())
+	}
+
+	switch c.Provider {
+	case Claude:
+		return c.queryClaudeImages(ctx, prompt, images)
+	case OpenAI:
+		return c.queryOpenAIImages(ctx, prompt, images)
+	case Ollama:
+		return c.queryOllamaImages(ctx, prompt, images)
+	}
+	return "", fmt.Errorf("unknown provider %v", c.Provider)
+}
+
+func (c *Client) queryClaudeImages(ctx context.Context, prompt string, images []Image) (string, error) {
+	var content []ContentBlock
+	for _, img := range images {
+		content = append(content, ContentBlock{Type: "image", Source: &ImageSource{
+			Type:      "base64",
+			MediaType: img.MediaType,
+			Data:      base64.StdEncoding.EncodeToString(img.Data),
+		}})
+	}
+	content = append(content, ContentBlock{Type: "text", Text: prompt})
+	reqBody := ClaudeVisionRequest{
+		Model:         c.ModelName(),
+		MaxTokens:     c.maxTokens(),
+		Temperature:   c.Temperature,
+		StopSequences: c.Stop,
+		Messages:      []ClaudeToolMessage{{Role: "user", Content: content}},
+	}
+
+	var claudeResp ClaudeResponse
+	err := c.postJSON(ctx, c.endpoint(claudeAPIURL), map[string]string{
+		"x-api-key":         c.APIKey,
+		"anthropic-version": "2023-06-01",
+	}, reqBody, &claudeResp)
+	if err != nil {
+		return "", err
+	}
+	if claudeResp.Error != nil {
+		return "", claudeResp.Error
+	}
+
+	var text []string
+	for _, block := range claudeResp.Content {
+		if block.Type == "text" {
+			text = append(text, block.Text)
+		}
+	}
+	answer := strings.TrimSpace(strings.Join(text, "\n"))
+	if answer == "" {
+		return "", fmt.Errorf("empty response from API")
+	}
+	return answer, nil
+}
+
+func (c *Client) queryOpenAIImages(ctx context.Context, prompt string, images []Image) (string, error) {
+	content := []OpenAIContentPart{{Type: "text", Text: prompt}}
+	for _, img := range images {
+		url := "data:" + img.MediaType + ";base64," + base64.StdEncoding.EncodeToString(img.Data)
+		content = append(content, OpenAIContentPart{Type: "image_url", ImageURL: &OpenAIImageURL{URL: url}})
+	}
+	reqBody := OpenAIVisionRequest{
+		Model:       c.ModelName(),
+		Messages:    []OpenAIVisionMessage{{Role: "user", Content: content}},
+		MaxTokens:   c.maxTokens(),
+		Temperature: cmp.Or(c.Temperature, 0.1),
+		Stop:        c.Stop,
+		Seed:        c.Seed,
+	}
+
+	var openaiResp OpenAIResponse
+	err := c.postJSON(ctx, c.endpoint(openaiAPIURL), map[string]string{
+		"Authorization": "Bearer " + c.APIKey,
+	}, reqBody, &openaiResp)
+	if err != nil {
+		return "", err
+	}
+	if openaiResp.Error != nil {
+		return "", openaiResp.Error
+	}
+	if len(openaiResp.Choices) == 0 {
+		return "", fmt.Errorf("no choices in response")
+	}
+
+	answer := strings.TrimSpace(openaiResp.Choices[0].Message.Content)
+	if answer == "" {
+		return "", fmt.Errorf("empty response from API")
+	}
+	return answer, nil
+}
+
+func (c *Client) queryOllamaImages(ctx context.Context, prompt string, images []Image) (string, error) {
+	reqBody := OllamaRequest{
+		Model:  c.ModelName(),
+		Prompt: prompt,
+		Stream: false,
+	}
+	for _, img := range images {
+		reqBody.Images = append(reqBody.Images, base64.StdEncoding.EncodeToString(img.Data))
+	}
+	if c.Temperature != 0 || len(c.Stop) > 0 || c.Seed != 0 || c.MaxTokens != 0 {
+		reqBody.Options = &OllamaOptions{Temperature: c.Temperature, Stop: c.Stop, Seed: c.Seed}
+		if c.MaxTokens != 0 {
+			reqBody.Options.NumPredict = c.maxTokens()
+		}
+	}
+
+	var ollamaResp OllamaResponse
+	if err := c.postJSON(ctx, c.endpoint(ollamaAPIURL), nil, reqBody, &ollamaResp); err != nil {
+		return "", err
+	}
+	if ollamaResp.Error != nil {
+		return "", ollamaResp.Error
+	}
+	if ollamaResp.Response == "" {
+		return "", fmt.Errorf("empty response from API")
+	}
+	return strings.TrimSpace(ollamaResp.Response), nil
+}
@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pngHeader is enough of a PNG for content sniffing
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestReadImage(t *testing.T) {
+	dir := t.TempDir()
+	png := filepath.Join(dir, "shot.png")
+	text := filepath.Join(dir, "notes.txt")
+	os.WriteFile(png, pngHeader, 0644)
+	os.WriteFile(text, []byte("not an image"), 0644)
+
+	img, err := ReadImage(png)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.MediaType != "image/png" {
+		t.Errorf("media type = %q", img.MediaType)
+	}
+	if _, err := ReadImage(text); err == nil {
+		t.Error("expected an error for a text file")
+	}
+	if _, err := ReadImage(filepath.Join(dir, "missing.png")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestSupportsImages(t *testing.T) {
+	tests := []struct {
+		client Client
+		want   bool
+	}{
+		{Client{Provider: Claude}, true},
+		{Client{Provider: OpenAI, Model: "gpt-4o"}, true},
+		{Client{Provider: OpenAI, Model: "gpt-3.5-turbo"}, false},
+		{Client{Provider: Ollama, Model: "llava:13b"}, true},
+		{Client{Provider: Ollama, Model: "llama3"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.client.SupportsImages(); got != tt.want {
+			t.Errorf("%v %s: SupportsImages() = %v, want %v", tt.client.Provider, tt.client.Model, got, tt.want)
+		}
+	}
+}
+
+func TestQueryImages(t *testing.T) {
+	img := Image{MediaType: "image/png", Data: pngHeader}
+	encoded := base64.StdEncoding.EncodeToString(pngHeader)
+	tests := []struct {
+		provider Provider
+		model    string
+		response string
+		want     string
+	}{
+		{Claude, "", `{"content":[{"type":"text","text":"A stack trace"}]}`,
+			`"source":{"type":"base64","media_type":"image/png","data":"` + encoded + `"}`},
+		{OpenAI, "", `{"choices":[{"message":{"content":"A stack trace"}}]}`,
+			`"image_url":{"url":"data:image/png;base64,` + encoded + `"}`},
+		{Ollama, "llava", `{"response":"A stack trace"}`,
+			`"images":["` + encoded + `"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider.String(), func(t *testing.T) {
+			srv, _, body := mockProvider(t, http.StatusOK, tt.response)
+			c := &Client{Provider: tt.provider, Model: tt.model, Endpoint: srv.URL}
+			got, err := c.QueryImages(context.Background(), "what is this?", []Image{img})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != "A stack trace" {
+				t.Errorf("got %q", got)
+			}
+			if !strings.Contains(string(*body), tt.want) {
+				t.Errorf("request %s doesn't contain %s", *body, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryImagesUnsupported(t *testing.T) {
+	c := &Client{Provider: Ollama, Model: "llama3", Endpoint: "http://127.0.0.1:0"}
+	_, err := c.QueryImages(context.Background(), "what is this?", []Image{{MediaType: "image/png"}})
+	if err == nil || !strings.Contains(err.Error(), "can't read images") {
+		t.Errorf("err = %v", err)
+	}
+}
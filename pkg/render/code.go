@@ -0,0 +1,168 @@
+package render
+
+import (
+	"slices"
+	"strings"
+)
+
+// syntax describes just enough of a language to highlight it a line at a
+// time: multi-line strings and comments aren't recognised
+type syntax struct {
+	comments []string // line comment markers
+	quotes   string   // characters that delimit strings
+	keywords []string
+}
+
+var cLike = syntax{comments: []string{"//"}, quotes: `"'`}
+
+var syntaxes = map[string]syntax{
+	"bash": {comments: []string{"#"}, quotes: `"'`, keywords: []string{
+		"case", "do", "done", "elif", "else", "esac", "export", "fi", "for", "function",
+		"if", "in", "local", "return", "then", "until", "while",
+	}},
+	"c": withKeywords(cLike, "break", "case", "char", "const", "continue", "default", "do",
+		"double", "else", "enum", "float", "for", "if", "int", "long", "return", "sizeof",
+		"static", "struct", "switch", "typedef", "unsigned", "void", "while"),
+	"cpp": withKeywords(cLike, "auto", "break", "case", "class", "const", "continue", "delete",
+		"else", "for", "if", "namespace", "new", "private", "public", "return", "struct",
+		"switch", "template", "using", "virtual", "void", "while"),
+	"csharp": withKeywords(cLike, "class", "else", "for", "foreach", "if", "in", "namespace",
+		"new", "private", "public", "return", "static", "using", "var", "void", "while"),
+	"go": {comments: []string{"//"}, quotes: "\"'`", keywords: []string{
+		"break", "case", "chan", "const", "continue", "default", "defer", "else", "for",
+		"func", "go", "if", "import", "interface", "map", "package", "range", "return",
+		"select", "struct", "switch", "type", "var",
+	}},
+	"hcl": {comments: []string{"#", "//"}, quotes: `"`, keywords: []string{
+		"data", "dynamic", "for", "for_each", "if", "in", "lifecycle", "locals", "module",
+		"moved", "output", "provider", "resource", "terraform", "variable",
+	}},
+	"java": withKeywords(cLike, "class", "else", "extends", "final", "for", "if", "import",
+		"new", "package", "private", "public", "return", "static", "throws", "try", "catch",
+		"void", "while"),
+	"javascript": {comments: []string{"//"}, quotes: "\"'`", keywords: []string{
+		"async", "await", "break", "case", "class", "const", "else", "export", "for",
+		"function", "if", "import", "let", "new", "of", "return", "switch", "throw", "try",
+		"catch", "var", "while",
+	}},
+	"lua": {comments: []string{"--"}, quotes: `"'`, keywords: []string{
+		"and", "do", "else", "elseif", "end", "for", "function", "if", "in", "local", "not",
+		"or", "repeat", "return", "then", "until", "while",
+	}},
+	"python": {comments: []string{"#"}, quotes: `"'`, keywords: []string{
+		"and", "as", "async", "await", "class", "def", "elif", "else", "except", "for",
+		"from", "if", "import", "in", "is", "lambda", "not", "or", "pass", "raise",
+		"return", "try", "while", "with", "yield",
+	}},
+	"ruby": {comments: []string{"#"}, quotes: `"'`, keywords: []string{
+		"begin", "class", "def", "do", "else", "elsif", "end", "if", "module", "require",
+		"rescue", "return", "unless", "while", "yield",
+	}},
+	"rust": {comments: []string{"//"}, quotes: `"`, keywords: []string{
+		"as", "else", "enum", "fn", "for", "if", "impl", "in", "let", "loop", "match",
+		"mod", "mut", "pub", "return", "self", "struct", "trait", "use", "where", "while",
+	}},
+	"sql": {comments: []string{"--"}, quotes: `'"`, keywords: []string{
+		"and", "as", "by", "create", "delete", "from", "group", "having", "insert", "into",
+		"join", "left", "limit", "not", "on", "or", "order", "select", "set", "table",
+		"update", "values", "where",
+	}},
+}
+
+func init() {
+	syntaxes["sh"] = syntaxes["bash"]
+	syntaxes["zsh"] = syntaxes["bash"]
+	syntaxes["terraform"] = syntaxes["hcl"]
+	syntaxes["tf"] = syntaxes["hcl"]
+	syntaxes["typescript"] = withKeywords(syntaxes["javascript"], "interface", "type", "enum")
+}
+
+// withKeywords returns s with keywords added
+func withKeywords(s syntax, keywords ...string) syntax {
+	s.keywords = append(append([]string(nil), s.keywords...), keywords...)
+	return s
+}
+
+// Code highlights keywords, strings and comments in code written in lang,
+// e.g. "python". Code in languages it doesn't know is returned unchanged.
+func Code(code, lang string) string {
+	s, ok := syntaxes[lang]
+	if !ok {
+		return code
+	}
+	lines := strings.Split(code, "\n")
+	for i, line := range lines {
+		lines[i] = s.highlight(line, lang == "sql")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// highlight colours one line of code
+func (s syntax) highlight(line string, foldCase bool) string {
+	var b strings.Builder
+	for i := 0; i < len(line); {
+		rest := line[i:]
+		if s.isComment(rest) {
+			b.WriteString(Cyan + rest + Reset)
+			break
+		}
+		c := line[i]
+		switch {
+		case strings.IndexByte(s.quotes, c) >= 0:
+			end := stringEnd(line, i)
+			b.WriteString(Green + line[i:end] + Reset)
+			i = end
+		case isWordByte(c):
+			end := i
+			for end < len(line) && isWordByte(line[end]) {
+				end++
+			}
+			word := line[i:end]
+			if s.isKeyword(word, foldCase) {
+				b.WriteString(Magenta + word + Reset)
+			} else {
+				b.WriteString(word)
+			}
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+func (s syntax) isComment(rest string) bool {
+	for _, marker := range s.comments {
+		if strings.HasPrefix(rest, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s syntax) isKeyword(word string, foldCase bool) bool {
+	if foldCase {
+		word = strings.ToLower(word)
+	}
+	return slices.Contains(s.keywords, word)
+}
+
+// stringEnd returns the index just past the string starting at line[start],
+// or the end of the line if it isn't closed
+func stringEnd(line string, start int) int {
+	quote := line[start]
+	for i := start + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+	return len(line)
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,31 @@
+package render
+
+import "testing"
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		lang string
+		want string
+	}{
+		{"keyword", "def f():", "python", Magenta + "def" + Reset + " f():"},
+		{"string", `print("if")`, "python", `print(` + Green + `"if"` + Reset + `)`},
+		{"escaped quote", `x = "a\"b"`, "python", `x = ` + Green + `"a\"b"` + Reset},
+		{"comment", "x := 1 // if", "go", "x := 1 " + Cyan + "// if" + Reset},
+		{"keyword inside word", "format", "go", "format"},
+		{"sql ignores case", "SELECT 1", "sql", Magenta + "SELECT" + Reset + " 1"},
+		{"alias", "fi", "sh", Magenta + "fi" + Reset},
+		{"hcl", `count = 2 # two`, "terraform", "count = 2 " + Cyan + "# two" + Reset},
+		{"unknown language", "if x", "cobol", "if x"},
+		{"lines", "if\nfor", "go", Magenta + "if" + Reset + "\n" + Magenta + "for" + Reset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Code(tt.code, tt.lang); got != tt.want {
+				t.Errorf("Code(%q, %q) = %q, want %q", tt.code, tt.lang, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package render
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// minColumnWidth is the narrowest column Columns lays out side by side
+const minColumnWidth = 20
+
+// columnSeparator goes between columns
+const columnSeparator = " │ "
+
+// Column is a titled block of text, e.g. one model's answer
+type Column struct {
+	Title string
+	Text  string
+}
+
+// Columns lays out cols side by side in width columns, breaking long lines
+// to fit. If they'd be too narrow, they're stacked instead.
+func Columns(cols []Column, width int) string {
+	sepWidth := utf8.RuneCountInString(columnSeparator)
+	colWidth := (width - sepWidth*(len(cols)-1)) / max(len(cols), 1)
+	if colWidth < minColumnWidth {
+		var blocks []string
+		for _, c := range cols {
+			blocks = append(blocks, Bold+c.Title+Reset+"\n"+c.Text)
+		}
+		return strings.Join(blocks, "\n\n")
+	}
+
+	lines := make([][]string, len(cols))
+	titleLines := make([]int, len(cols))
+	height := 0
+	for i, c := range cols {
+		lines[i] = breakLines(c.Title, colWidth)
+		titleLines[i] = len(lines[i])
+		lines[i] = append(lines[i], strings.Repeat("─", colWidth))
+		lines[i] = append(lines[i], breakLines(c.Text, colWidth)...)
+		height = max(height, len(lines[i]))
+	}
+
+	var b strings.Builder
+	for row := range height {
+		var cells []string
+		for i := range cols {
+			cell := ""
+			if row < len(lines[i]) {
+				cell = lines[i][row]
+			}
+			pad := strings.Repeat(" ", colWidth-utf8.RuneCountInString(cell))
+			if row < titleLines[i] {
+				cell = Bold + cell + Reset
+			}
+			cells = append(cells, cell+pad)
+		}
+		b.WriteString(strings.TrimRight(strings.Join(cells, columnSeparator), " ") + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// breakLines splits text into lines of at most width runes. Long lines are
+// broken at width rather than at spaces, since they're often commands.
+func breakLines(text string, width int) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\t", "    "), "\n") {
+		runes := []rune(line)
+		for len(runes) > width {
+			lines = append(lines, string(runes[:width]))
+			runes = runes[width:]
+		}
+		lines = append(lines, string(runes))
+	}
+	return lines
+}
@@ -0,0 +1,36 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestColumns(t *testing.T) {
+	cols := []Column{
+		{"gpt-4o-mini", "du -sh *"},
+		{"llama3", "du -h --max-depth=1 | sort -h"},
+	}
+	got := Columns(cols, 50)
+	want := Bold + "gpt-4o-mini" + Reset + "             │ " + Bold + "llama3" + Reset + "\n" +
+		"─────────────────────── │ ───────────────────────\n" +
+		"du -sh *                │ du -h --max-depth=1 | s\n" +
+		"                        │ ort -h"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Too narrow to fit side by side
+	got = Columns(cols, 30)
+	want = Bold + "gpt-4o-mini" + Reset + "\ndu -sh *\n\n" + Bold + "llama3" + Reset + "\ndu -h --max-depth=1 | sort -h"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBreakLines(t *testing.T) {
+	got := breakLines("abcdef\n\nxy", 4)
+	want := []string{"abcd", "ef", "", "xy"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("breakLines() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,62 @@
+package render
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTermWidth is the widest term kept in the left column; longer terms get
+// a line of their own
+const maxTermWidth = 24
+
+// Definition is a term and its description, e.g. a flag and what it does
+type Definition struct {
+	Term        string
+	Description string
+}
+
+// DefinitionList lays out defs as two columns, terms on the left and their
+// descriptions wrapped to fit in width columns on the right
+func DefinitionList(defs []Definition, width int) string {
+	termWidth := 0
+	for _, d := range defs {
+		if n := utf8.RuneCountInString(d.Term); n > termWidth && n <= maxTermWidth {
+			termWidth = n
+		}
+	}
+	indent := 2 + termWidth + 2
+	textWidth := max(width-indent, 20)
+
+	var b strings.Builder
+	for _, d := range defs {
+		lines := wrap(d.Description, textWidth)
+		pad := termWidth - utf8.RuneCountInString(d.Term)
+		b.WriteString("  " + Cyan + Bold + d.Term + Reset)
+		if pad < 0 {
+			// Too long for the column; start the description below it
+			b.WriteString("\n" + strings.Repeat(" ", indent))
+		} else {
+			b.WriteString(strings.Repeat(" ", pad+2))
+		}
+		b.WriteString(strings.Join(lines, "\n"+strings.Repeat(" ", indent)) + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// wrap breaks text into lines of at most width runes at spaces. Words longer
+// than width are left whole.
+func wrap(text string, width int) []string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		if line != "" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	return append(lines, line)
+}
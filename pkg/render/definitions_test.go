@@ -0,0 +1,41 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefinitionList(t *testing.T) {
+	defs := []Definition{
+		{"tar", "Create or extract archives"},
+		{"-xzvf", "Extract, gunzip, list files, from file"},
+		{"--this-option-is-much-too-long", "Long"},
+	}
+	got := DefinitionList(defs, 30)
+	want := "  " + Cyan + Bold + "tar" + Reset + "    Create or extract\n" +
+		"         archives\n" +
+		"  " + Cyan + Bold + "-xzvf" + Reset + "  Extract, gunzip, list\n" +
+		"         files, from file\n" +
+		"  " + Cyan + Bold + "--this-option-is-much-too-long" + Reset + "\n" +
+		"         Long"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  []string
+	}{
+		{"", 10, []string{""}},
+		{"one two three", 7, []string{"one two", "three"}},
+		{"supercalifragilistic word", 5, []string{"supercalifragilistic", "word"}},
+	}
+	for _, tt := range tests {
+		if got := wrap(tt.text, tt.width); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("wrap(%q, %d) = %q, want %q", tt.text, tt.width, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package render
+
+import (
+	"slices"
+	"strings"
+)
+
+// Diff colors a unified diff: added lines green, removed lines red, hunk
+// headers cyan and file headers bold
+func Diff(diff string) string {
+	lines := strings.Split(diff, "\n")
+	for i, line := range lines {
+		lines[i] = diffLine(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// diffLine colors one line of a unified diff
+func diffLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "diff "):
+		return Bold + line + Reset
+	case strings.HasPrefix(line, "@@"):
+		return Cyan + line + Reset
+	case strings.HasPrefix(line, "+"):
+		return Green + line + Reset
+	case strings.HasPrefix(line, "-"):
+		return Red + line + Reset
+	}
+	return line
+}
+
+// gitHeaders start the lines git puts before a file's --- and +++ lines
+var gitHeaders = []string{
+	"diff ", "index ", "new file mode ", "deleted file mode ", "old mode ", "new mode ",
+	"similarity index ", "rename from ", "rename to ",
+}
+
+// IsDiff reports whether text is a unified diff: a --- and +++ header
+// followed by a hunk, possibly after git's diff and index lines
+func IsDiff(text string) bool {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i := 0; i+2 < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "--- ") {
+			return strings.HasPrefix(lines[i+1], "+++ ") && strings.HasPrefix(lines[i+2], "@@")
+		}
+		if !slices.ContainsFunc(gitHeaders, func(h string) bool { return strings.HasPrefix(line, h) }) {
+			return false
+		}
+	}
+	return false
+}
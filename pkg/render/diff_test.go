@@ -0,0 +1,37 @@
+package render
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	got := Diff("--- a/f\n+++ b/f\n@@ -1,2 +1,2 @@\n-old\n+new\n same\n")
+	want := Bold + "--- a/f" + Reset + "\n" +
+		Bold + "+++ b/f" + Reset + "\n" +
+		Cyan + "@@ -1,2 +1,2 @@" + Reset + "\n" +
+		Red + "-old" + Reset + "\n" +
+		Green + "+new" + Reset + "\n" +
+		" same\n"
+	if got != want {
+		t.Errorf("Diff() = %q, want %q", got, want)
+	}
+}
+
+func TestIsDiff(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"--- a/f\n+++ b/f\n@@ -1 +1 @@\n-a\n+b\n", true},
+		{"diff --git a/f b/f\nindex 1234567..89abcde 100644\n--- a/f\n+++ b/f\n@@ -1 +1 @@\n-a\n+b", true},
+		{"diff --git a/f b/f\nnew file mode 100644\n--- /dev/null\n+++ b/f\n@@ -0,0 +1 @@\n+b", true},
+		{"\n--- a/f\n+++ b/f\n@@ -1 +1 @@\n", true},
+		{"Here's the diff:\n--- a/f\n+++ b/f\n@@ -1 +1 @@\n", false},
+		{"--- a/f\n+++ b/f\n", false},
+		{"- item\n- item", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsDiff(tt.text); got != tt.want {
+			t.Errorf("IsDiff(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Package render formats model output for display in a terminal
+package render
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ANSI escape codes for terminal formatting
+const (
+	Reset     = "\033[0m"
+	Bold      = "\033[1m"
+	Dim       = "\033[2m"
+	Italic    = "\033[3m"
+	Underline = "\033[4m"
+	Red       = "\033[31m"
+	Green     = "\033[32m"
+	Yellow    = "\033[33m"
+	Blue      = "\033[34m"
+	Magenta   = "\033[35m"
+	Cyan      = "\033[36m"
+)
+
+// Markdown converts basic markdown to terminal-formatted text. Code blocks
+// are highlighted as by Code for the language after their opening fence,
+// and diffs, in a diff code block or making up the whole text, are colored
+// as by Diff.
+func Markdown(markdown string) string {
+	if IsDiff(markdown) {
+		return Diff(markdown)
+	}
+	lines := strings.Split(markdown, "\n")
+	var result strings.Builder
+
+	// lang is the language of the code block the line is in, if inCode
+	inCode, lang := false, ""
+	for _, line := range lines {
+		rendered := ""
+		fence := strings.TrimSpace(line)
+		switch {
+		case inCode && strings.HasPrefix(fence, "```"):
+			inCode = false
+			rendered = renderLine(line)
+		case inCode && (lang == "diff" || lang == "patch"):
+			rendered = diffLine(line)
+		case inCode:
+			rendered = Code(line, lang)
+		default:
+			if strings.HasPrefix(fence, "```") {
+				inCode, lang = true, strings.ToLower(strings.TrimPrefix(fence, "```"))
+			}
+			rendered = renderLine(line)
+		}
+		result.WriteString(rendered + "\n")
+	}
+
+	return strings.TrimSuffix(result.String(), "\n")
+}
+
+func renderLine(line string) string {
+	// Handle headers
+	if strings.HasPrefix(line, "### ") {
+		return Yellow + Bold + strings.TrimPrefix(line, "### ") + Reset
+	}
+	if strings.HasPrefix(line, "## ") {
+		return Blue + Bold + strings.TrimPrefix(line, "## ") + Reset
+	}
+	if strings.HasPrefix(line, "# ") {
+		return Magenta + Bold + strings.TrimPrefix(line, "# ") + Reset
+	}
+
+	// Handle block quotes, which tldr pages use for descriptions
+	if strings.HasPrefix(line, "> ") {
+		return Italic + renderInlineFormatting(strings.TrimPrefix(line, "> ")) + Reset
+	}
+
+	// Handle code blocks (simple single-line detection)
+	if strings.HasPrefix(line, "```") {
+		return Cyan + line + Reset
+	}
+
+	// Handle bullet points
+	if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") {
+		return Green + "• " + Reset + renderInlineFormatting(line[2:])
+	}
+
+	// Handle numbered lists
+	if matched, _ := regexp.MatchString(`^\d+\. `, line); matched {
+		re := regexp.MustCompile(`^(\d+\. )(.*)`)
+		matches := re.FindStringSubmatch(line)
+		if len(matches) == 3 {
+			return Yellow + matches[1] + Reset + matches[2]
+		}
+	}
+
+	// Handle inline formatting
+	line = renderInlineFormatting(line)
+
+	return line
+}
+
+// codeSpanRe matches inline code
+var codeSpanRe = regexp.MustCompile("`([^`\n]*?)`")
+
+func renderInlineFormatting(text string) string {
+	// Code spans are found first so that * and _ in commands such as
+	// `ls *.go` aren't taken for emphasis
+	var b strings.Builder
+	last := 0
+	for _, m := range codeSpanRe.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(renderEmphasis(text[last:m[0]]))
+		b.WriteString(Cyan + text[m[2]:m[3]] + Reset)
+		last = m[1]
+	}
+	b.WriteString(renderEmphasis(text[last:]))
+	return b.String()
+}
+
+// renderEmphasis formats bold, italic and links in text outside code spans
+func renderEmphasis(text string) string {
+	// Process bold first (**text** and __text__) to avoid conflicts with italic
+	boldRe := regexp.MustCompile(`\*\*([^\*\n]*?)\*\*`)
+	text = boldRe.ReplaceAllString(text, Bold+"$1"+Reset)
+
+	boldRe2 := regexp.MustCompile(`__([^_\n]*?)__`)
+	text = boldRe2.ReplaceAllString(text, Bold+"$1"+Reset)
+
+	// Then process italic (*text* and _text_)
+	// Use non-greedy matching and allow whitespace
+	italicRe := regexp.MustCompile(`\*([^\*\n]*?)\*`)
+	text = italicRe.ReplaceAllString(text, Italic+"$1"+Reset)
+
+	italicRe2 := regexp.MustCompile(`_([^_\n]*?)_`)
+	text = italicRe2.ReplaceAllString(text, Italic+"$1"+Reset)
+
+	// Links [text](url) - preserve whitespace
+	linkRe := regexp.MustCompile(`\[([^\]\n]*?)\]\([^)\n]*?\)`)
+	text = linkRe.ReplaceAllString(text, Blue+Underline+"$1"+Reset)
+
+	return text
+}
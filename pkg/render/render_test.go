@@ -0,0 +1,44 @@
+package render
+
+import "testing"
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "ls -la", "ls -la"},
+		{"empty", "", ""},
+		{"h1", "# Title", Magenta + Bold + "Title" + Reset},
+		{"h2", "## Section", Blue + Bold + "Section" + Reset},
+		{"h3", "### Sub", Yellow + Bold + "Sub" + Reset},
+		{"hash without space", "#hashtag", "#hashtag"},
+		{"fence", "```bash", Cyan + "```bash" + Reset},
+		{"dash bullet", "- item", Green + "• " + Reset + "item"},
+		{"star bullet", "* item", Green + "• " + Reset + "item"},
+		{"bullet with code", "- List `*.go` files:", Green + "• " + Reset + "List " + Cyan + "*.go" + Reset + " files:"},
+		{"numbered", "12. step", Yellow + "12. " + Reset + "step"},
+		{"bold", "a **b** c", "a " + Bold + "b" + Reset + " c"},
+		{"underscore bold", "__b__", Bold + "b" + Reset},
+		{"italic", "an *em* word", "an " + Italic + "em" + Reset + " word"},
+		{"inline code", "run `ls -l` now", "run " + Cyan + "ls -l" + Reset + " now"},
+		{"code with stars", "`ls *.go *.txt` and `a_b_c`", Cyan + "ls *.go *.txt" + Reset + " and " + Cyan + "a_b_c" + Reset},
+		{"block quote", "> Archive `files`.", Italic + "Archive " + Cyan + "files" + Reset + "." + Reset},
+		{"link", "[docs](https://example.com)", Blue + Underline + "docs" + Reset},
+		{"multiline", "# T\nls", Magenta + Bold + "T" + Reset + "\nls"},
+		{"trailing newline", "ls\n", "ls\n"},
+		{"diff block", "Change it:\n```diff\n-a\n+b\n```\n- item", "Change it:\n" + Cyan + "```diff" + Reset + "\n" + Red + "-a" + Reset + "\n" + Green + "+b" + Reset + "\n" + Cyan + "```" + Reset + "\n" + Green + "• " + Reset + "item"},
+		{"hcl block", "```hcl\n# Bucket\nresource \"aws_s3_bucket\" \"logs\" {\n```", Cyan + "```hcl" + Reset + "\n" + Cyan + "# Bucket" + Reset + "\n" + Magenta + "resource" + Reset + " " + Green + `"aws_s3_bucket"` + Reset + " " + Green + `"logs"` + Reset + " {\n" + Cyan + "```" + Reset},
+		{"unknown block", "```\n# not a heading\n- not a bullet\n```", Cyan + "```" + Reset + "\n# not a heading\n- not a bullet\n" + Cyan + "```" + Reset},
+		{"whole diff", "--- a/f\n+++ b/f\n@@ -1 +1 @@\n-a", Bold + "--- a/f" + Reset + "\n" + Bold + "+++ b/f" + Reset + "\n" + Cyan + "@@ -1 +1 @@" + Reset + "\n" + Red + "-a" + Reset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Markdown(tt.in); got != tt.want {
+				t.Errorf("Markdown(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}